@@ -25,6 +25,7 @@ import (
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/managementasset"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/misc"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/store"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/tracing"
 	_ "github.com/router-for-me/CLIProxyAPI/v6/internal/translator"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/usage"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
@@ -545,6 +546,38 @@ func main() {
 			defer kiro.StopGlobalRefreshManager()
 		}
 
+		// Persist usage statistics when a usage store is configured.
+		if cfg.UsageStore.Enable {
+			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			usageStore, errUsageStore := usage.OpenStore(ctx, cfg.UsageStore, filepath.Dir(configFilePath), pgStoreInst.DB(), pgStoreInst.Schema())
+			cancel()
+			if errUsageStore != nil {
+				log.Errorf("failed to open usage store: %v", errUsageStore)
+			} else {
+				usage.SetPersistentStore(usageStore)
+				defer func() {
+					usage.SetPersistentStore(nil)
+					_ = usageStore.Close()
+				}()
+			}
+		}
+
+		// Export OpenTelemetry spans when tracing is configured.
+		if cfg.Tracing.Enable {
+			shutdownTracing, errTracing := tracing.Setup(context.Background(), cfg.Tracing, buildinfo.Version)
+			if errTracing != nil {
+				log.Errorf("failed to set up tracing: %v", errTracing)
+			} else {
+				defer func() {
+					ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+					defer cancel()
+					if errShutdown := shutdownTracing(ctx); errShutdown != nil {
+						log.Warnf("failed to flush traces: %v", errShutdown)
+					}
+				}()
+			}
+		}
+
 		cmd.StartService(cfg, configFilePath, password)
 	}
 }
@@ -13,6 +13,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
 	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
 	log "github.com/sirupsen/logrus"
 	"gopkg.in/yaml.v3"
@@ -281,15 +282,7 @@ func (h *Handler) PutForceModelPrefix(c *gin.Context) {
 }
 
 func normalizeRoutingStrategy(strategy string) (string, bool) {
-	normalized := strings.ToLower(strings.TrimSpace(strategy))
-	switch normalized {
-	case "", "round-robin", "roundrobin", "rr":
-		return "round-robin", true
-	case "fill-first", "fillfirst", "ff":
-		return "fill-first", true
-	default:
-		return "", false
-	}
+	return coreauth.NormalizeRoutingStrategy(strategy)
 }
 
 // RoutingStrategy
@@ -318,6 +311,34 @@ func (h *Handler) PutRoutingStrategy(c *gin.Context) {
 	h.persist(c)
 }
 
+// GetSessionAffinities lists conversations currently pinned to a credential.
+func (h *Handler) GetSessionAffinities(c *gin.Context) {
+	if h.authManager == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "core auth manager unavailable"})
+		return
+	}
+	entries, ok := h.authManager.SessionAffinities()
+	if entries == nil {
+		entries = []coreauth.SessionAffinity{}
+	}
+	c.JSON(http.StatusOK, gin.H{"enabled": ok, "affinities": entries})
+}
+
+// DeleteSessionAffinities clears one affinity (?key=) or all of them.
+func (h *Handler) DeleteSessionAffinities(c *gin.Context) {
+	if h.authManager == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "core auth manager unavailable"})
+		return
+	}
+	key := strings.TrimSpace(c.Query("key"))
+	removed, ok := h.authManager.ClearSessionAffinities(key)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "session affinity is not enabled"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "ok", "removed": removed})
+}
+
 // Proxy URL
 func (h *Handler) GetProxyURL(c *gin.Context) { c.JSON(200, gin.H{"proxy-url": h.cfg.ProxyURL}) }
 func (h *Handler) PutProxyURL(c *gin.Context) {
@@ -104,19 +104,149 @@ func (h *Handler) deleteFromStringList(c *gin.Context, target *[]string, after f
 	c.JSON(400, gin.H{"error": "missing index or value"})
 }
 
-// api-keys
+// api-keys: []APIKeyEntry, each either a plain key string or an object with a policy
 func (h *Handler) GetAPIKeys(c *gin.Context) { c.JSON(200, gin.H{"api-keys": h.cfg.APIKeys}) }
 func (h *Handler) PutAPIKeys(c *gin.Context) {
-	h.putStringList(c, func(v []string) {
-		h.cfg.APIKeys = append([]string(nil), v...)
-		h.cfg.Access.Providers = nil
-	}, nil)
+	data, err := c.GetRawData()
+	if err != nil {
+		c.JSON(400, gin.H{"error": "failed to read body"})
+		return
+	}
+	var arr []config.APIKeyEntry
+	if err = json.Unmarshal(data, &arr); err != nil {
+		var obj struct {
+			Items []config.APIKeyEntry `json:"items"`
+		}
+		if err2 := json.Unmarshal(data, &obj); err2 != nil || len(obj.Items) == 0 {
+			c.JSON(400, gin.H{"error": "invalid body"})
+			return
+		}
+		arr = obj.Items
+	}
+	h.cfg.APIKeys = arr
+	h.cfg.SanitizeAPIKeys()
+	h.cfg.Access.Providers = nil
+	h.persist(c)
 }
 func (h *Handler) PatchAPIKeys(c *gin.Context) {
-	h.patchStringList(c, &h.cfg.APIKeys, func() { h.cfg.Access.Providers = nil })
+	type apiKeyPatch struct {
+		APIKey               *string   `json:"api-key"`
+		Name                 *string   `json:"name"`
+		RequestsPerMinute    *int      `json:"requests-per-minute"`
+		TokensPerDay         *int64    `json:"tokens-per-day"`
+		AllowedModels        *[]string `json:"allowed-models"`
+		AllowedProviders     *[]string `json:"allowed-providers"`
+		DisableResponseCache *bool     `json:"disable-response-cache"`
+	}
+	var body struct {
+		Old   *string         `json:"old"`
+		New   *string         `json:"new"`
+		Index *int            `json:"index"`
+		Match *string         `json:"match"`
+		Value json.RawMessage `json:"value"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(400, gin.H{"error": "invalid body"})
+		return
+	}
+	if body.Old != nil && body.New != nil {
+		for i := range h.cfg.APIKeys {
+			if h.cfg.APIKeys[i].APIKey == *body.Old {
+				h.cfg.APIKeys[i].APIKey = *body.New
+				h.cfg.Access.Providers = nil
+				h.persist(c)
+				return
+			}
+		}
+		h.cfg.APIKeys = append(h.cfg.APIKeys, config.APIKeyEntry{APIKey: *body.New})
+		h.cfg.Access.Providers = nil
+		h.persist(c)
+		return
+	}
+	if len(body.Value) == 0 {
+		c.JSON(400, gin.H{"error": "missing fields"})
+		return
+	}
+	// A plain string value replaces the key and keeps its policy.
+	var patch apiKeyPatch
+	var key string
+	if err := json.Unmarshal(body.Value, &key); err == nil {
+		patch.APIKey = &key
+	} else if err = json.Unmarshal(body.Value, &patch); err != nil {
+		c.JSON(400, gin.H{"error": "invalid body"})
+		return
+	}
+	targetIndex := -1
+	if body.Index != nil && *body.Index >= 0 && *body.Index < len(h.cfg.APIKeys) {
+		targetIndex = *body.Index
+	}
+	if targetIndex == -1 && body.Match != nil {
+		match := strings.TrimSpace(*body.Match)
+		if match != "" {
+			for i := range h.cfg.APIKeys {
+				if h.cfg.APIKeys[i].APIKey == match {
+					targetIndex = i
+					break
+				}
+			}
+		}
+	}
+	if targetIndex == -1 {
+		c.JSON(404, gin.H{"error": "item not found"})
+		return
+	}
+
+	entry := h.cfg.APIKeys[targetIndex]
+	if patch.APIKey != nil {
+		entry.APIKey = *patch.APIKey
+	}
+	if patch.Name != nil {
+		entry.Name = *patch.Name
+	}
+	if patch.RequestsPerMinute != nil {
+		entry.RequestsPerMinute = *patch.RequestsPerMinute
+	}
+	if patch.TokensPerDay != nil {
+		entry.TokensPerDay = *patch.TokensPerDay
+	}
+	if patch.AllowedModels != nil {
+		entry.AllowedModels = append([]string(nil), (*patch.AllowedModels)...)
+	}
+	if patch.AllowedProviders != nil {
+		entry.AllowedProviders = append([]string(nil), (*patch.AllowedProviders)...)
+	}
+	if patch.DisableResponseCache != nil {
+		entry.DisableResponseCache = *patch.DisableResponseCache
+	}
+	h.cfg.APIKeys[targetIndex] = entry
+	h.cfg.SanitizeAPIKeys()
+	h.cfg.Access.Providers = nil
+	h.persist(c)
 }
 func (h *Handler) DeleteAPIKeys(c *gin.Context) {
-	h.deleteFromStringList(c, &h.cfg.APIKeys, func() { h.cfg.Access.Providers = nil })
+	if idxStr := c.Query("index"); idxStr != "" {
+		var idx int
+		_, err := fmt.Sscanf(idxStr, "%d", &idx)
+		if err == nil && idx >= 0 && idx < len(h.cfg.APIKeys) {
+			h.cfg.APIKeys = append(h.cfg.APIKeys[:idx], h.cfg.APIKeys[idx+1:]...)
+			h.cfg.Access.Providers = nil
+			h.persist(c)
+			return
+		}
+	}
+	if val := strings.TrimSpace(c.Query("value")); val != "" {
+		out := make([]config.APIKeyEntry, 0, len(h.cfg.APIKeys))
+		for _, v := range h.cfg.APIKeys {
+			if strings.TrimSpace(v.APIKey) != val {
+				out = append(out, v)
+			}
+		}
+		h.cfg.APIKeys = out
+		h.cfg.Access.Providers = nil
+		h.persist(c)
+		return
+	}
+	c.JSON(400, gin.H{"error": "missing index or value"})
 }
 
 // gemini-api-key: []GeminiKey
@@ -1,6 +1,13 @@
 package management
 
-import "github.com/gin-gonic/gin"
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+)
 
 // Quota exceeded toggles
 func (h *Handler) GetSwitchProject(c *gin.Context) {
@@ -16,3 +23,86 @@ func (h *Handler) GetSwitchPreviewModel(c *gin.Context) {
 func (h *Handler) PutSwitchPreviewModel(c *gin.Context) {
 	h.updateBoolField(c, func(v bool) { h.cfg.QuotaExceeded.SwitchPreviewModel = v })
 }
+
+// GetQuota lists the known quota of every credential: the remaining amount and reset time
+// reported by provider quota polling, plus any cooldown learned from 429 responses.
+//
+// Endpoint:
+//
+//	GET /v0/management/quota
+//
+// Query Parameters (optional):
+//   - refresh: when "true", polls every supporting provider before responding.
+func (h *Handler) GetQuota(c *gin.Context) {
+	if h == nil || h.authManager == nil {
+		c.JSON(503, gin.H{"error": "core auth manager unavailable"})
+		return
+	}
+	if strings.EqualFold(strings.TrimSpace(c.Query("refresh")), "true") {
+		h.authManager.PollQuotas(c.Request.Context())
+	}
+	now := time.Now()
+	auths := h.authManager.List()
+	quotas := make([]gin.H, 0, len(auths))
+	for _, auth := range auths {
+		if auth == nil {
+			continue
+		}
+		auth.EnsureIndex()
+		entry := quotaEntry(auth.Quota, now)
+		entry["auth_index"] = auth.Index
+		entry["id"] = auth.ID
+		entry["provider"] = strings.TrimSpace(auth.Provider)
+		if auth.Label != "" {
+			entry["label"] = auth.Label
+		}
+		models := gin.H{}
+		for model, state := range auth.ModelStates {
+			if state == nil || (state.Quota.Limit <= 0 && !state.Quota.Exceeded) {
+				continue
+			}
+			models[model] = quotaEntry(state.Quota, now)
+		}
+		if len(models) > 0 {
+			entry["models"] = models
+		}
+		quotas = append(quotas, entry)
+	}
+	sort.Slice(quotas, func(i, j int) bool {
+		providerI, _ := quotas[i]["provider"].(string)
+		providerJ, _ := quotas[j]["provider"].(string)
+		if providerI != providerJ {
+			return providerI < providerJ
+		}
+		idI, _ := quotas[i]["id"].(string)
+		idJ, _ := quotas[j]["id"].(string)
+		return idI < idJ
+	})
+	c.JSON(200, gin.H{"quotas": quotas})
+}
+
+func quotaEntry(quota coreauth.QuotaState, now time.Time) gin.H {
+	entry := gin.H{"exceeded": quota.Exceeded && quota.NextRecoverAt.After(now)}
+	if quota.Limit > 0 {
+		entry["remaining"] = quota.Remaining
+		entry["limit"] = quota.Limit
+		entry["remaining_percent"] = quota.Remaining / quota.Limit * 100
+		if quota.Remaining <= 0 && (quota.ResetAt.IsZero() || quota.ResetAt.After(now)) {
+			entry["exceeded"] = true
+		}
+		if !quota.CheckedAt.IsZero() {
+			entry["checked_at"] = quota.CheckedAt
+		}
+	}
+	resetAt := quota.ResetAt
+	if quota.Exceeded && quota.NextRecoverAt.After(now) {
+		resetAt = quota.NextRecoverAt
+	}
+	if !resetAt.IsZero() {
+		entry["reset_at"] = resetAt
+	}
+	if quota.Reason != "" {
+		entry["reason"] = quota.Reason
+	}
+	return entry
+}
@@ -2,7 +2,9 @@ package management
 
 import (
 	"encoding/json"
+	"fmt"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -20,11 +22,53 @@ type usageImportPayload struct {
 	Usage   usage.StatisticsSnapshot `json:"usage"`
 }
 
-// GetUsageStatistics returns the in-memory request statistics snapshot.
+// GetUsageStatistics returns the request statistics snapshot. When the persistent usage store is
+// enabled the snapshot is read from it; otherwise the in-memory statistics are used.
+// Optional query parameters: from, to (YYYY-MM-DD or RFC3339; to is exclusive for timestamps and
+// inclusive for dates), api_key, provider, model, auth_index and granularity (hour or day, persistent
+// store only) to include time-bucketed rollups.
 func (h *Handler) GetUsageStatistics(c *gin.Context) {
+	filter, err := parseUsageFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	granularity := strings.ToLower(strings.TrimSpace(c.Query("granularity")))
+	if granularity != "" && granularity != usage.GranularityHour && granularity != usage.GranularityDay {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid granularity"})
+		return
+	}
+
+	if store := usage.PersistentStore(); store != nil {
+		snapshot, errSnapshot := store.Snapshot(c.Request.Context(), filter)
+		if errSnapshot != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": errSnapshot.Error()})
+			return
+		}
+		resp := gin.H{
+			"usage":           snapshot,
+			"failed_requests": snapshot.FailureCount,
+			"persistent":      true,
+		}
+		if granularity != "" {
+			buckets, errBuckets := store.Buckets(c.Request.Context(), granularity, filter)
+			if errBuckets != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": errBuckets.Error()})
+				return
+			}
+			if buckets == nil {
+				buckets = []usage.UsageBucket{}
+			}
+			resp["granularity"] = granularity
+			resp["buckets"] = buckets
+		}
+		c.JSON(http.StatusOK, resp)
+		return
+	}
+
 	var snapshot usage.StatisticsSnapshot
 	if h != nil && h.usageStats != nil {
-		snapshot = h.usageStats.Snapshot()
+		snapshot = usage.FilterSnapshot(h.usageStats.Snapshot(), filter)
 	}
 	c.JSON(http.StatusOK, gin.H{
 		"usage":           snapshot,
@@ -32,6 +76,48 @@ func (h *Handler) GetUsageStatistics(c *gin.Context) {
 	})
 }
 
+func parseUsageFilter(c *gin.Context) (usage.QueryFilter, error) {
+	filter := usage.QueryFilter{
+		APIKey:    strings.TrimSpace(c.Query("api_key")),
+		Provider:  strings.TrimSpace(c.Query("provider")),
+		Model:     strings.TrimSpace(c.Query("model")),
+		AuthIndex: strings.TrimSpace(c.Query("auth_index")),
+	}
+	if raw := strings.TrimSpace(c.Query("from")); raw != "" {
+		from, _, err := parseUsageTime(raw)
+		if err != nil {
+			return filter, fmt.Errorf("invalid from: %w", err)
+		}
+		filter.From = from
+	}
+	if raw := strings.TrimSpace(c.Query("to")); raw != "" {
+		to, dateOnly, err := parseUsageTime(raw)
+		if err != nil {
+			return filter, fmt.Errorf("invalid to: %w", err)
+		}
+		if dateOnly {
+			to = to.AddDate(0, 0, 1)
+		}
+		filter.To = to
+	}
+	if !filter.From.IsZero() && !filter.To.IsZero() && !filter.From.Before(filter.To) {
+		return filter, fmt.Errorf("from must be before to")
+	}
+	return filter, nil
+}
+
+// parseUsageTime accepts RFC3339 timestamps or YYYY-MM-DD dates (UTC) and reports which form was used.
+func parseUsageTime(raw string) (time.Time, bool, error) {
+	if t, err := time.Parse(time.RFC3339, raw); err == nil {
+		return t, false, nil
+	}
+	t, err := time.ParseInLocation("2006-01-02", raw, time.UTC)
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("expected YYYY-MM-DD or RFC3339")
+	}
+	return t, true, nil
+}
+
 // ExportUsageStatistics returns a complete usage snapshot for backup/migration.
 func (h *Handler) ExportUsageStatistics(c *gin.Context) {
 	var snapshot usage.StatisticsSnapshot
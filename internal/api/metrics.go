@@ -0,0 +1,58 @@
+package api
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/metrics"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	log "github.com/sirupsen/logrus"
+)
+
+// serveMetrics exposes Prometheus metrics when metrics.enable is set. Access requires the
+// dedicated metrics key; without a configured key only localhost clients may scrape.
+func (s *Server) serveMetrics(c *gin.Context) {
+	cfg := s.cfg
+	if cfg == nil || !cfg.Metrics.Enable {
+		c.AbortWithStatus(http.StatusNotFound)
+		return
+	}
+
+	key := strings.TrimSpace(cfg.Metrics.Key)
+	if key == "" {
+		clientIP := c.ClientIP()
+		if clientIP != "127.0.0.1" && clientIP != "::1" {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "metrics key not set"})
+			return
+		}
+	} else {
+		var provided string
+		if ah := c.GetHeader("Authorization"); ah != "" {
+			parts := strings.SplitN(ah, " ", 2)
+			if len(parts) == 2 && strings.ToLower(parts[0]) == "bearer" {
+				provided = parts[1]
+			} else {
+				provided = ah
+			}
+		}
+		if provided == "" {
+			provided = c.GetHeader("X-Metrics-Key")
+		}
+		if subtle.ConstantTimeCompare([]byte(strings.TrimSpace(provided)), []byte(key)) != 1 {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid metrics key"})
+			return
+		}
+	}
+
+	var auths []*auth.Auth
+	if s.handlers != nil && s.handlers.AuthManager != nil {
+		auths = s.handlers.AuthManager.List()
+	}
+	c.Header("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	c.Status(http.StatusOK)
+	if err := metrics.Default().WriteText(c.Writer, auths); err != nil {
+		log.WithError(err).Warn("failed to write metrics response")
+	}
+}
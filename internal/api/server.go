@@ -25,9 +25,11 @@ import (
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/api/modules"
 	ampmodule "github.com/router-for-me/CLIProxyAPI/v6/internal/api/modules/amp"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/auth/kiro"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/batch"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/logging"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/managementasset"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/tracing"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/usage"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
 	sdkaccess "github.com/router-for-me/CLIProxyAPI/v6/sdk/access"
@@ -156,6 +158,9 @@ type Server struct {
 	// ampModule is the Amp routing module for model mapping hot-reload
 	ampModule *ampmodule.AmpModule
 
+	// batches runs batch jobs in the background; nil when no storage directory is available.
+	batches *batch.Manager
+
 	// managementRoutesRegistered tracks whether the management routes have been attached to the engine.
 	managementRoutesRegistered atomic.Bool
 	// managementRoutesEnabled controls whether management endpoints serve real handlers.
@@ -204,6 +209,7 @@ func NewServer(cfg *config.Config, authManager *auth.Manager, accessManager *sdk
 	// Add middleware
 	engine.Use(logging.GinLogrusLogger())
 	engine.Use(logging.GinLogrusRecovery())
+	engine.Use(tracing.Middleware())
 	for _, mw := range optionState.extraMiddleware {
 		engine.Use(mw)
 	}
@@ -265,8 +271,13 @@ func NewServer(cfg *config.Config, authManager *auth.Manager, accessManager *sdk
 	s.mgmt.SetLogDirectory(logDir)
 	s.localPassword = optionState.localPassword
 
+	s.batches = newBatchManager(cfg)
+
 	// Setup routes
 	s.setupRoutes()
+	if s.batches != nil {
+		s.batches.Start()
+	}
 
 	// Register Amp module using V2 interface with Context
 	s.ampModule = ampmodule.NewLegacy(accessManager, AuthMiddleware(accessManager))
@@ -310,6 +321,30 @@ func NewServer(cfg *config.Config, authManager *auth.Manager, accessManager *sdk
 	return s
 }
 
+// newBatchManager opens the batch store configured in cfg. Batch endpoints stay disabled when
+// no storage directory can be resolved.
+func newBatchManager(cfg *config.Config) *batch.Manager {
+	dir := cfg.Batch.Dir
+	if dir == "" {
+		authDir, err := util.ResolveAuthDir(cfg.AuthDir)
+		if err != nil || authDir == "" {
+			log.Debugf("batch endpoints disabled: no auth directory to store batches in (%v)", err)
+			return nil
+		}
+		dir = filepath.Join(authDir, "batches")
+	}
+	manager, err := batch.NewManager(dir, batchOptions(cfg))
+	if err != nil {
+		log.Warnf("batch endpoints disabled: %v", err)
+		return nil
+	}
+	return manager
+}
+
+func batchOptions(cfg *config.Config) batch.Options {
+	return batch.Options{Concurrency: cfg.Batch.Concurrency, MaxAttempts: cfg.Batch.MaxAttempts}
+}
+
 // setupRoutes configures the API routes for the server.
 // It defines the endpoints and associates them with their respective handlers.
 func (s *Server) setupRoutes() {
@@ -327,10 +362,33 @@ func (s *Server) setupRoutes() {
 		v1.GET("/models", s.unifiedModelsHandler(openaiHandlers, claudeCodeHandlers))
 		v1.POST("/chat/completions", openaiHandlers.ChatCompletions)
 		v1.POST("/completions", openaiHandlers.Completions)
+		v1.POST("/embeddings", openaiHandlers.Embeddings)
+		v1.POST("/images/generations", openaiHandlers.ImagesGenerations)
+		v1.POST("/images/edits", openaiHandlers.ImagesEdits)
 		v1.POST("/messages", claudeCodeHandlers.ClaudeMessages)
 		v1.POST("/messages/count_tokens", claudeCodeHandlers.ClaudeCountTokens)
 		v1.POST("/responses", openaiResponsesHandlers.Responses)
 		v1.POST("/responses/compact", openaiResponsesHandlers.Compact)
+		if s.batches != nil {
+			batchHandlers := openai.NewOpenAIBatchAPIHandler(s.handlers, s.batches, openaiHandlers)
+			v1.POST("/files", batchHandlers.UploadFile)
+			v1.GET("/files", batchHandlers.ListFiles)
+			v1.GET("/files/:file_id", batchHandlers.GetFile)
+			v1.GET("/files/:file_id/content", batchHandlers.GetFileContent)
+			v1.DELETE("/files/:file_id", batchHandlers.DeleteFile)
+			v1.POST("/batches", batchHandlers.CreateBatch)
+			v1.GET("/batches", batchHandlers.ListBatches)
+			v1.GET("/batches/:batch_id", batchHandlers.GetBatch)
+			v1.POST("/batches/:batch_id/cancel", batchHandlers.CancelBatch)
+
+			messageBatchHandlers := claude.NewClaudeBatchAPIHandler(s.handlers, s.batches, claudeCodeHandlers)
+			v1.POST("/messages/batches", messageBatchHandlers.CreateBatch)
+			v1.GET("/messages/batches", messageBatchHandlers.ListBatches)
+			v1.GET("/messages/batches/:batch_id", messageBatchHandlers.GetBatch)
+			v1.DELETE("/messages/batches/:batch_id", messageBatchHandlers.DeleteBatch)
+			v1.POST("/messages/batches/:batch_id/cancel", messageBatchHandlers.CancelBatch)
+			v1.GET("/messages/batches/:batch_id/results", messageBatchHandlers.GetResults)
+		}
 	}
 
 	// Gemini compatible API routes
@@ -342,6 +400,14 @@ func (s *Server) setupRoutes() {
 		v1beta.GET("/models/*action", geminiHandlers.GeminiGetHandler)
 	}
 
+	// Gemini Live API (BidiGenerateContent) websocket sessions
+	live := s.engine.Group("/ws")
+	live.Use(AuthMiddleware(s.accessManager))
+	{
+		live.GET("/google.ai.generativelanguage.v1beta.GenerativeService.BidiGenerateContent", geminiHandlers.GeminiLiveHandler)
+		live.GET("/google.ai.generativelanguage.v1alpha.GenerativeService.BidiGenerateContent", geminiHandlers.GeminiLiveHandler)
+	}
+
 	// Root endpoint
 	s.engine.GET("/", func(c *gin.Context) {
 		c.JSON(http.StatusOK, gin.H{
@@ -354,6 +420,9 @@ func (s *Server) setupRoutes() {
 		})
 	})
 
+	// Prometheus metrics endpoint, guarded by its own key (see metrics config)
+	s.engine.GET("/metrics", s.serveMetrics)
+
 	// Event logging endpoint - handles Claude Code telemetry requests
 	// Returns 200 OK to prevent 404 errors in logs
 	s.engine.POST("/api/event_logging/batch", func(c *gin.Context) {
@@ -536,6 +605,7 @@ func (s *Server) registerManagementRoutes() {
 
 		mgmt.POST("/api-call", s.mgmt.APICall)
 
+		mgmt.GET("/quota", s.mgmt.GetQuota)
 		mgmt.GET("/quota-exceeded/switch-project", s.mgmt.GetSwitchProject)
 		mgmt.PUT("/quota-exceeded/switch-project", s.mgmt.PutSwitchProject)
 		mgmt.PATCH("/quota-exceeded/switch-project", s.mgmt.PutSwitchProject)
@@ -604,6 +674,8 @@ func (s *Server) registerManagementRoutes() {
 		mgmt.GET("/routing/strategy", s.mgmt.GetRoutingStrategy)
 		mgmt.PUT("/routing/strategy", s.mgmt.PutRoutingStrategy)
 		mgmt.PATCH("/routing/strategy", s.mgmt.PutRoutingStrategy)
+		mgmt.GET("/routing/session-affinity", s.mgmt.GetSessionAffinities)
+		mgmt.DELETE("/routing/session-affinity", s.mgmt.DeleteSessionAffinities)
 
 		mgmt.GET("/claude-api-key", s.mgmt.GetClaudeKeys)
 		mgmt.PUT("/claude-api-key", s.mgmt.PutClaudeKeys)
@@ -848,6 +920,11 @@ func (s *Server) Stop(ctx context.Context) error {
 		return fmt.Errorf("failed to shutdown HTTP server: %v", err)
 	}
 
+	// Interrupted batch requests run again when the jobs resume on the next start.
+	if s.batches != nil {
+		s.batches.Stop()
+	}
+
 	log.Debug("API server stopped")
 	return nil
 }
@@ -917,6 +994,15 @@ func (s *Server) UpdateClients(cfg *config.Config) {
 		usage.SetStatisticsEnabled(cfg.UsageStatisticsEnabled)
 	}
 
+	if oldCfg != nil && oldCfg.UsageStore != cfg.UsageStore {
+		if store := usage.PersistentStore(); store != nil {
+			store.SetRetention(usage.RetentionFromConfig(cfg.UsageStore))
+		}
+		if oldCfg.UsageStore.Enable != cfg.UsageStore.Enable || oldCfg.UsageStore.Driver != cfg.UsageStore.Driver || oldCfg.UsageStore.Path != cfg.UsageStore.Path {
+			log.Warn("usage-store backend changes take effect after restart")
+		}
+	}
+
 	if s.requestLogger != nil && (oldCfg == nil || oldCfg.ErrorLogsMaxFiles != cfg.ErrorLogsMaxFiles) {
 		if setter, ok := s.requestLogger.(interface{ SetErrorLogsMaxFiles(int) }); ok {
 			setter.SetErrorLogsMaxFiles(cfg.ErrorLogsMaxFiles)
@@ -927,6 +1013,13 @@ func (s *Server) UpdateClients(cfg *config.Config) {
 		auth.SetQuotaCooldownDisabled(cfg.DisableCooling)
 	}
 
+	if s.batches != nil && (oldCfg == nil || oldCfg.Batch != cfg.Batch) {
+		s.batches.SetOptions(batchOptions(cfg))
+		if oldCfg != nil && oldCfg.Batch.Dir != cfg.Batch.Dir {
+			log.Warn("batch.dir changes take effect after restart")
+		}
+	}
+
 	if s.handlers != nil && s.handlers.AuthManager != nil {
 		s.handlers.AuthManager.SetRetryConfig(cfg.RequestRetry, time.Duration(cfg.MaxRetryInterval)*time.Second)
 	}
@@ -28,7 +28,7 @@ func newTestServer(t *testing.T) *Server {
 
 	cfg := &proxyconfig.Config{
 		SDKConfig: sdkconfig.SDKConfig{
-			APIKeys: []string{"test-key"},
+			APIKeys: sdkconfig.APIKeyEntries("test-key"),
 		},
 		Port:                   0,
 		AuthDir:                authDir,
@@ -109,3 +109,34 @@ func TestAmpProviderModelRoutes(t *testing.T) {
 		})
 	}
 }
+
+func TestMetricsEndpointRequiresKey(t *testing.T) {
+	server := newTestServer(t)
+
+	rr := httptest.NewRecorder()
+	server.engine.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if rr.Code != http.StatusNotFound {
+		t.Fatalf("disabled metrics: status = %d, want %d", rr.Code, http.StatusNotFound)
+	}
+
+	server.cfg.Metrics = proxyconfig.MetricsConfig{Enable: true, Key: "metrics-secret"}
+
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	req.Header.Set("Authorization", "Bearer test-key")
+	rr = httptest.NewRecorder()
+	server.engine.ServeHTTP(rr, req)
+	if rr.Code != http.StatusUnauthorized {
+		t.Fatalf("wrong key: status = %d, want %d", rr.Code, http.StatusUnauthorized)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	req.Header.Set("X-Metrics-Key", "metrics-secret")
+	rr = httptest.NewRecorder()
+	server.engine.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("valid key: status = %d, want %d", rr.Code, http.StatusOK)
+	}
+	if !strings.Contains(rr.Body.String(), "# TYPE cliproxy_upstream_requests_total counter") {
+		t.Fatalf("unexpected metrics body: %s", rr.Body.String())
+	}
+}
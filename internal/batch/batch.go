@@ -0,0 +1,192 @@
+// Package batch runs batch jobs in the background: a job is a list of requests that are
+// executed with bounded concurrency and retries, with files, job state and results kept on
+// disk so that unfinished jobs resume after a restart. The package is format agnostic; API
+// handlers register a Handler per format that executes requests and renders result lines.
+package batch
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+// Job status values follow the OpenAI batch lifecycle.
+const (
+	StatusInProgress = "in_progress"
+	StatusFinalizing = "finalizing"
+	StatusCompleted  = "completed"
+	StatusExpired    = "expired"
+	StatusCancelling = "cancelling"
+	StatusCancelled  = "cancelled"
+)
+
+// Result error codes for requests that never ran to completion.
+const (
+	ErrorCodeCancelled = "batch_cancelled"
+	ErrorCodeExpired   = "batch_expired"
+)
+
+// ownerIDPrefix marks owner IDs, which are hashes of the client API key.
+const ownerIDPrefix = "sha256:"
+
+// File kinds for generated result files.
+const (
+	FileKindOutput = "output"
+	FileKindError  = "error"
+)
+
+// File is an uploaded or generated file.
+type File struct {
+	ID string `json:"id"`
+	// Owner is the OwnerID of the client key that uploaded the file.
+	Owner     string `json:"owner,omitempty"`
+	Purpose   string `json:"purpose"`
+	Filename  string `json:"filename"`
+	Bytes     int64  `json:"bytes"`
+	CreatedAt int64  `json:"created_at"`
+	// JobID and Kind link a generated result file to its job.
+	JobID string `json:"job_id,omitempty"`
+	Kind  string `json:"kind,omitempty"`
+}
+
+// Request is one request of a job.
+type Request struct {
+	CustomID string          `json:"custom_id"`
+	Method   string          `json:"method,omitempty"`
+	URL      string          `json:"url,omitempty"`
+	Body     json.RawMessage `json:"body"`
+}
+
+// Result is the outcome of one request. Requests that ran carry the HTTP status and body of
+// the response; requests that were cancelled or expired before running carry only Error.
+type Result struct {
+	ID         string          `json:"id"`
+	CustomID   string          `json:"custom_id"`
+	StatusCode int             `json:"status_code,omitempty"`
+	Body       json.RawMessage `json:"body,omitempty"`
+	Error      *ResultError    `json:"error,omitempty"`
+}
+
+// ResultError describes why a request produced no response.
+type ResultError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// Succeeded reports whether the request completed with a 2xx response.
+func (r Result) Succeeded() bool {
+	return r.Error == nil && r.StatusCode >= 200 && r.StatusCode < 300
+}
+
+// Counts summarizes the requests of a job.
+type Counts struct {
+	Total     int `json:"total"`
+	Succeeded int `json:"succeeded"`
+	Errored   int `json:"errored"`
+	Cancelled int `json:"cancelled"`
+	Expired   int `json:"expired"`
+}
+
+// Processing returns the number of requests without a result yet.
+func (c Counts) Processing() int {
+	return c.Total - c.Succeeded - c.Errored - c.Cancelled - c.Expired
+}
+
+// Failed returns the number of requests that finished without a successful response.
+func (c Counts) Failed() int {
+	return c.Errored + c.Cancelled + c.Expired
+}
+
+// Job is a batch job. Timestamps are Unix seconds; zero means the event has not happened.
+type Job struct {
+	ID string `json:"id"`
+	// Format selects the Handler that executes the job and renders its results.
+	Format string `json:"format"`
+	// Owner is the OwnerID of the client key that created the job. Keys are never stored.
+	Owner            string            `json:"owner,omitempty"`
+	Endpoint         string            `json:"endpoint,omitempty"`
+	InputFileID      string            `json:"input_file_id,omitempty"`
+	CompletionWindow string            `json:"completion_window,omitempty"`
+	Metadata         map[string]string `json:"metadata,omitempty"`
+	Status           string            `json:"status"`
+	OutputFileID     string            `json:"output_file_id,omitempty"`
+	ErrorFileID      string            `json:"error_file_id,omitempty"`
+	Counts           Counts            `json:"counts"`
+	CreatedAt        int64             `json:"created_at"`
+	InProgressAt     int64             `json:"in_progress_at,omitempty"`
+	ExpiresAt        int64             `json:"expires_at,omitempty"`
+	FinalizingAt     int64             `json:"finalizing_at,omitempty"`
+	CompletedAt      int64             `json:"completed_at,omitempty"`
+	ExpiredAt        int64             `json:"expired_at,omitempty"`
+	CancellingAt     int64             `json:"cancelling_at,omitempty"`
+	CancelledAt      int64             `json:"cancelled_at,omitempty"`
+	// Upstream is set for jobs that run on a provider's own batch API. The manager stores
+	// them so that they can be looked up, but does not execute them.
+	Upstream *Upstream `json:"upstream,omitempty"`
+}
+
+// Upstream identifies a batch created on a provider's batch API.
+type Upstream struct {
+	// AuthID is the credential the batch was created with; it is needed to access it again.
+	AuthID string `json:"auth_id"`
+	ID     string `json:"id"`
+}
+
+// Terminal reports whether the job has finished and will not change anymore.
+func (j Job) Terminal() bool {
+	switch j.Status {
+	case StatusCompleted, StatusExpired, StatusCancelled:
+		return true
+	}
+	return false
+}
+
+// EndedAt returns when the job reached its terminal status, or zero.
+func (j Job) EndedAt() int64 {
+	switch j.Status {
+	case StatusCompleted:
+		return j.CompletedAt
+	case StatusExpired:
+		return j.ExpiredAt
+	case StatusCancelled:
+		return j.CancelledAt
+	}
+	return 0
+}
+
+// Handler executes and renders the jobs of one API format.
+type Handler struct {
+	// Execute runs one request on behalf of the job owner and returns the HTTP status,
+	// response headers and body. Statuses 408, 429 and 5xx are retried.
+	Execute func(ctx context.Context, job Job, req Request) (int, http.Header, []byte)
+
+	// RenderResult formats a result as one line of an output or error file. When nil, no
+	// result files are generated and results are only available through Manager.Results.
+	RenderResult func(job Job, result Result) []byte
+
+	// Authorize reports whether the job's owner may still run requests. Jobs whose owner was
+	// revoked, e.g. because the client key was removed, are cancelled. When nil, every job runs.
+	Authorize func(job Job) bool
+}
+
+// OwnerID returns the identifier files and jobs of a client API key are stored under, so that
+// keys are not written to disk. An empty key, used when access control is disabled, yields an
+// empty owner; IDs are returned unchanged.
+func OwnerID(apiKey string) string {
+	if apiKey == "" || strings.HasPrefix(apiKey, ownerIDPrefix) {
+		return apiKey
+	}
+	sum := sha256.Sum256([]byte(apiKey))
+	return ownerIDPrefix + hex.EncodeToString(sum[:])
+}
+
+// NewID returns a random identifier with the given prefix.
+func NewID(prefix string) string {
+	var buf [12]byte
+	_, _ = rand.Read(buf[:])
+	return prefix + hex.EncodeToString(buf[:])
+}
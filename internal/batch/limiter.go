@@ -0,0 +1,78 @@
+package batch
+
+import (
+	"context"
+	"sync"
+)
+
+// limiter is a counting semaphore whose limit can change while it is in use. Released slots
+// are handed to waiters in arrival order.
+type limiter struct {
+	mu      sync.Mutex
+	limit   int
+	active  int
+	waiters []chan struct{}
+}
+
+func newLimiter(limit int) *limiter {
+	return &limiter{limit: limit}
+}
+
+// acquire blocks until a slot is free or ctx is done.
+func (l *limiter) acquire(ctx context.Context) error {
+	l.mu.Lock()
+	if l.active < l.limit && len(l.waiters) == 0 {
+		l.active++
+		l.mu.Unlock()
+		return nil
+	}
+	ch := make(chan struct{})
+	l.waiters = append(l.waiters, ch)
+	l.mu.Unlock()
+
+	select {
+	case <-ch:
+		return nil
+	case <-ctx.Done():
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		for i, waiter := range l.waiters {
+			if waiter == ch {
+				l.waiters = append(l.waiters[:i], l.waiters[i+1:]...)
+				return ctx.Err()
+			}
+		}
+		// The slot was granted concurrently; give it back.
+		l.releaseLocked()
+		return ctx.Err()
+	}
+}
+
+// release frees a slot acquired before.
+func (l *limiter) release() {
+	l.mu.Lock()
+	l.releaseLocked()
+	l.mu.Unlock()
+}
+
+func (l *limiter) releaseLocked() {
+	l.active--
+	l.grantLocked()
+}
+
+// setLimit changes the number of slots; lowering it lets running holders finish first.
+func (l *limiter) setLimit(limit int) {
+	l.mu.Lock()
+	l.limit = limit
+	l.grantLocked()
+	l.mu.Unlock()
+}
+
+func (l *limiter) grantLocked() {
+	for l.active < l.limit && len(l.waiters) > 0 {
+		ch := l.waiters[0]
+		l.waiters = l.waiters[1:]
+		l.active++
+		close(ch)
+	}
+}
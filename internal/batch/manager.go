@@ -0,0 +1,834 @@
+package batch
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	defaultConcurrency = 4
+	defaultMaxAttempts = 3
+	// maxBackoff caps the delay between retries when the response carries no Retry-After.
+	maxBackoff = time.Minute
+	// CompletionWindow is the only supported completion window.
+	CompletionWindow = "24h"
+	completionWindow = 24 * time.Hour
+)
+
+var (
+	// ErrNotFound reports an unknown file or job, or one owned by another client.
+	ErrNotFound = errors.New("batch: not found")
+	// ErrUnsupportedFormat reports a job whose format has no registered Handler.
+	ErrUnsupportedFormat = errors.New("batch: unsupported format")
+	// ErrJobRunning reports an attempt to delete a job that has not finished.
+	ErrJobRunning = errors.New("batch: job is still running")
+)
+
+// Options tune job execution.
+type Options struct {
+	// Concurrency bounds the requests running at once across all jobs. Defaults to 4.
+	Concurrency int
+	// MaxAttempts bounds how often a request is tried when it fails with a retryable status.
+	// Rate limited responses carrying Retry-After wait for it without using up an attempt.
+	// Defaults to 3.
+	MaxAttempts int
+}
+
+func (o Options) normalized() Options {
+	if o.Concurrency <= 0 {
+		o.Concurrency = defaultConcurrency
+	}
+	if o.MaxAttempts <= 0 {
+		o.MaxAttempts = defaultMaxAttempts
+	}
+	return o
+}
+
+// Manager stores files and jobs under a directory and runs unfinished jobs in the background.
+type Manager struct {
+	dir string
+
+	mu          sync.Mutex
+	files       map[string]*File
+	jobs        map[string]*jobState
+	handlers    map[string]Handler
+	maxAttempts int
+	running     bool
+
+	slots  *limiter
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	now     func() time.Time
+	backoff func(attempt int) time.Duration
+}
+
+// jobState is a job with its requests and the custom IDs that already have a result.
+type jobState struct {
+	job      Job
+	requests []Request
+	done     map[string]bool
+	// cancel stops the job's in-flight requests while it runs.
+	cancel context.CancelFunc
+}
+
+// NewManager opens the batch store in dir, creating it if needed, and loads existing files
+// and jobs. Call Start once the format handlers are registered to run unfinished jobs.
+func NewManager(dir string, opts Options) (*Manager, error) {
+	if strings.TrimSpace(dir) == "" {
+		return nil, fmt.Errorf("batch: storage directory is required")
+	}
+	for _, sub := range []string{"files", "batches"} {
+		if err := os.MkdirAll(filepath.Join(dir, sub), 0o700); err != nil {
+			return nil, fmt.Errorf("batch: create storage directory: %w", err)
+		}
+	}
+	opts = opts.normalized()
+	ctx, cancel := context.WithCancel(context.Background())
+	m := &Manager{
+		dir:         dir,
+		files:       make(map[string]*File),
+		jobs:        make(map[string]*jobState),
+		handlers:    make(map[string]Handler),
+		maxAttempts: opts.MaxAttempts,
+		slots:       newLimiter(opts.Concurrency),
+		ctx:         ctx,
+		cancel:      cancel,
+		now:         time.Now,
+		backoff:     exponentialBackoff,
+	}
+	m.load()
+	return m, nil
+}
+
+// SetOptions applies new execution options to running and future jobs.
+func (m *Manager) SetOptions(opts Options) {
+	opts = opts.normalized()
+	m.mu.Lock()
+	m.maxAttempts = opts.MaxAttempts
+	m.mu.Unlock()
+	m.slots.setLimit(opts.Concurrency)
+}
+
+// RegisterHandler registers the handler for jobs of the given format.
+func (m *Manager) RegisterHandler(format string, handler Handler) {
+	m.mu.Lock()
+	m.handlers[format] = handler
+	m.mu.Unlock()
+}
+
+// Start runs the unfinished jobs loaded from disk. Jobs created afterwards start immediately.
+func (m *Manager) Start() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.running {
+		return
+	}
+	m.running = true
+	for _, js := range m.jobs {
+		if !js.job.Terminal() && js.job.Upstream == nil {
+			m.launchLocked(js)
+		}
+	}
+}
+
+// Stop interrupts running jobs and waits for them to return. Requests that were in flight
+// have no result recorded and run again when the jobs resume.
+func (m *Manager) Stop() {
+	m.cancel()
+	m.wg.Wait()
+}
+
+// CreateFile stores an uploaded file. Owners here and below are client API keys; they are
+// stored as their OwnerID.
+func (m *Manager) CreateFile(owner, purpose, filename string, data []byte) (File, error) {
+	file := File{
+		ID:        NewID("file-"),
+		Owner:     OwnerID(owner),
+		Purpose:   purpose,
+		Filename:  filename,
+		Bytes:     int64(len(data)),
+		CreatedAt: m.now().Unix(),
+	}
+	if err := m.storeFile(&file, data); err != nil {
+		return File{}, err
+	}
+	return file, nil
+}
+
+func (m *Manager) storeFile(file *File, data []byte) error {
+	if err := writeFileAtomic(m.fileDataPath(file.ID), data); err != nil {
+		return fmt.Errorf("batch: store file: %w", err)
+	}
+	if err := writeJSONFile(m.fileMetaPath(file.ID), file); err != nil {
+		_ = os.Remove(m.fileDataPath(file.ID))
+		return fmt.Errorf("batch: store file: %w", err)
+	}
+	m.mu.Lock()
+	m.files[file.ID] = file
+	m.mu.Unlock()
+	return nil
+}
+
+// File returns the file with id owned by owner.
+func (m *Manager) File(id, owner string) (File, error) {
+	owner = OwnerID(owner)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	file, ok := m.files[id]
+	if !ok || file.Owner != owner {
+		return File{}, ErrNotFound
+	}
+	return *file, nil
+}
+
+// Files lists the files owned by owner, newest first, optionally filtered by purpose.
+func (m *Manager) Files(owner, purpose string) []File {
+	owner = OwnerID(owner)
+	m.mu.Lock()
+	out := make([]File, 0, len(m.files))
+	for _, file := range m.files {
+		if file.Owner == owner && (purpose == "" || file.Purpose == purpose) {
+			out = append(out, *file)
+		}
+	}
+	m.mu.Unlock()
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].CreatedAt != out[j].CreatedAt {
+			return out[i].CreatedAt > out[j].CreatedAt
+		}
+		return out[i].ID > out[j].ID
+	})
+	return out
+}
+
+// FileContent returns the content of the file with id owned by owner.
+func (m *Manager) FileContent(id, owner string) ([]byte, error) {
+	if _, err := m.File(id, owner); err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(m.fileDataPath(id))
+	if err != nil {
+		return nil, fmt.Errorf("batch: read file: %w", err)
+	}
+	return data, nil
+}
+
+// DeleteFile removes the file with id owned by owner.
+func (m *Manager) DeleteFile(id, owner string) error {
+	owner = OwnerID(owner)
+	m.mu.Lock()
+	file, ok := m.files[id]
+	if !ok || file.Owner != owner {
+		m.mu.Unlock()
+		return ErrNotFound
+	}
+	delete(m.files, id)
+	m.mu.Unlock()
+	for _, path := range []string{m.fileMetaPath(id), m.fileDataPath(id)} {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("batch: delete file: %w", err)
+		}
+	}
+	return nil
+}
+
+// CreateJob stores a new job for requests and starts it. The job's ID defaults to a random
+// "batch_" identifier; status, timestamps and counts are filled in. Jobs with Upstream set
+// are only stored.
+func (m *Manager) CreateJob(job Job, requests []Request) (Job, error) {
+	m.mu.Lock()
+	_, ok := m.handlers[job.Format]
+	m.mu.Unlock()
+	if !ok {
+		return Job{}, ErrUnsupportedFormat
+	}
+	now := m.now()
+	if job.ID == "" {
+		job.ID = NewID("batch_")
+	}
+	job.Owner = OwnerID(job.Owner)
+	job.Status = StatusInProgress
+	job.CreatedAt = now.Unix()
+	job.InProgressAt = now.Unix()
+	job.ExpiresAt = now.Add(completionWindow).Unix()
+	job.Counts = Counts{Total: len(requests)}
+
+	var buf bytes.Buffer
+	for _, req := range requests {
+		line, err := json.Marshal(req)
+		if err != nil {
+			return Job{}, fmt.Errorf("batch: encode request %s: %w", req.CustomID, err)
+		}
+		buf.Write(line)
+		buf.WriteByte('\n')
+	}
+	if err := writeFileAtomic(m.requestsPath(job.ID), buf.Bytes()); err != nil {
+		return Job{}, fmt.Errorf("batch: store requests: %w", err)
+	}
+	if err := writeJSONFile(m.jobPath(job.ID), job); err != nil {
+		_ = os.Remove(m.requestsPath(job.ID))
+		return Job{}, fmt.Errorf("batch: store job: %w", err)
+	}
+
+	js := &jobState{job: job, requests: requests, done: make(map[string]bool, len(requests))}
+	m.mu.Lock()
+	m.jobs[job.ID] = js
+	if m.running && job.Upstream == nil {
+		m.launchLocked(js)
+	}
+	m.mu.Unlock()
+	return job, nil
+}
+
+// Job returns the job with id owned by owner.
+func (m *Manager) Job(id, owner string) (Job, error) {
+	owner = OwnerID(owner)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	js, ok := m.jobs[id]
+	if !ok || js.job.Owner != owner {
+		return Job{}, ErrNotFound
+	}
+	return js.job, nil
+}
+
+// Jobs lists the jobs of format owned by owner, newest first.
+func (m *Manager) Jobs(owner, format string) []Job {
+	owner = OwnerID(owner)
+	m.mu.Lock()
+	out := make([]Job, 0, len(m.jobs))
+	for _, js := range m.jobs {
+		if js.job.Owner == owner && js.job.Format == format {
+			out = append(out, js.job)
+		}
+	}
+	m.mu.Unlock()
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].CreatedAt != out[j].CreatedAt {
+			return out[i].CreatedAt > out[j].CreatedAt
+		}
+		return out[i].ID > out[j].ID
+	})
+	return out
+}
+
+// CancelJob stops a running job. Requests already in flight are abandoned and, like the
+// ones that never started, recorded as cancelled. Finished jobs are returned unchanged.
+func (m *Manager) CancelJob(id, owner string) (Job, error) {
+	owner = OwnerID(owner)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	js, ok := m.jobs[id]
+	if !ok || js.job.Owner != owner {
+		return Job{}, ErrNotFound
+	}
+	if js.job.Status != StatusInProgress || js.job.Upstream != nil {
+		return js.job, nil
+	}
+	js.job.Status = StatusCancelling
+	js.job.CancellingAt = m.now().Unix()
+	m.saveJobLocked(js)
+	if js.cancel != nil {
+		js.cancel()
+	}
+	return js.job, nil
+}
+
+// DeleteJob removes a finished job and its results. Jobs with Upstream set can be deleted at
+// any time; generated result files are kept until deleted on their own.
+func (m *Manager) DeleteJob(id, owner string) error {
+	owner = OwnerID(owner)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	js, ok := m.jobs[id]
+	if !ok || js.job.Owner != owner {
+		return ErrNotFound
+	}
+	if !js.job.Terminal() && js.job.Upstream == nil {
+		return ErrJobRunning
+	}
+	for _, path := range []string{m.jobPath(id), m.requestsPath(id), m.resultsPath(id)} {
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("batch: delete job: %w", err)
+		}
+	}
+	delete(m.jobs, id)
+	return nil
+}
+
+// Results returns the results recorded for the job with id owned by owner, in completion order.
+func (m *Manager) Results(id, owner string) ([]Result, error) {
+	if _, err := m.Job(id, owner); err != nil {
+		return nil, err
+	}
+	return m.readResults(id)
+}
+
+// launchLocked starts running js. The job is bounded by its expiry; m.mu must be held.
+func (m *Manager) launchLocked(js *jobState) {
+	handler := m.handlers[js.job.Format]
+	if handler.Execute == nil {
+		log.Warnf("batch: no handler for format %q, job %s stays %s", js.job.Format, js.job.ID, js.job.Status)
+		return
+	}
+	ctx, cancel := context.WithDeadline(m.ctx, time.Unix(js.job.ExpiresAt, 0))
+	js.cancel = cancel
+	pending := make([]Request, 0, len(js.requests)-len(js.done))
+	if js.job.Status == StatusInProgress {
+		for _, req := range js.requests {
+			if !js.done[req.CustomID] {
+				pending = append(pending, req)
+			}
+		}
+	}
+	m.wg.Add(1)
+	go func() {
+		defer m.wg.Done()
+		defer cancel()
+		m.run(ctx, js, handler, pending)
+	}()
+}
+
+// run executes the pending requests of a job and finalizes it unless the manager stopped.
+func (m *Manager) run(ctx context.Context, js *jobState, handler Handler, pending []Request) {
+	var wg sync.WaitGroup
+	for _, req := range pending {
+		if err := m.slots.acquire(ctx); err != nil {
+			break
+		}
+		wg.Add(1)
+		go func(req Request) {
+			defer wg.Done()
+			m.execute(ctx, js, handler, req)
+		}(req)
+	}
+	wg.Wait()
+	if m.ctx.Err() != nil {
+		return
+	}
+	m.finalize(js, handler)
+}
+
+// execute runs one request, holding a concurrency slot while it is in flight, and records
+// its result. Retryable failures are retried after Retry-After or an exponential backoff.
+func (m *Manager) execute(ctx context.Context, js *jobState, handler Handler, req Request) {
+	job := m.snapshot(js)
+	attempt := 0
+	for {
+		if handler.Authorize != nil && !handler.Authorize(job) {
+			m.slots.release()
+			m.revoke(js)
+			return
+		}
+		status, header, body := handler.Execute(ctx, job, req)
+		m.slots.release()
+		if ctx.Err() != nil {
+			return
+		}
+		wait, hasRetryAfter := retryAfter(header, m.now())
+		if status != http.StatusTooManyRequests || !hasRetryAfter {
+			attempt++
+		}
+		m.mu.Lock()
+		maxAttempts := m.maxAttempts
+		m.mu.Unlock()
+		if !retryableStatus(status) || attempt >= maxAttempts {
+			m.record(js, newResult(req, status, body))
+			return
+		}
+		if !hasRetryAfter {
+			wait = m.backoff(attempt)
+		}
+		log.Debugf("batch: job %s request %s got status %d, retrying in %s", job.ID, req.CustomID, status, wait)
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+		if err := m.slots.acquire(ctx); err != nil {
+			return
+		}
+	}
+}
+
+// revoke cancels a running job whose owner may no longer run requests.
+func (m *Manager) revoke(js *jobState) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if js.job.Status != StatusInProgress {
+		return
+	}
+	log.Warnf("batch: owner of job %s was revoked, cancelling it", js.job.ID)
+	js.job.Status = StatusCancelling
+	js.job.CancellingAt = m.now().Unix()
+	m.saveJobLocked(js)
+	if js.cancel != nil {
+		js.cancel()
+	}
+}
+
+func (m *Manager) snapshot(js *jobState) Job {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return js.job
+}
+
+// newResult converts a response into a result. Bodies that are not JSON are kept as a string.
+func newResult(req Request, status int, body []byte) Result {
+	result := Result{ID: NewID("batch_req_"), CustomID: req.CustomID, StatusCode: status}
+	if status == 0 {
+		result.Error = &ResultError{Code: "request_failed", Message: strings.TrimSpace(string(body))}
+		return result
+	}
+	trimmed := bytes.TrimSpace(body)
+	if json.Valid(trimmed) {
+		result.Body = append(json.RawMessage(nil), trimmed...)
+	} else {
+		result.Body, _ = json.Marshal(string(body))
+	}
+	return result
+}
+
+// record stores the result of a request unless one was recorded already.
+func (m *Manager) record(js *jobState, result Result) {
+	line, err := json.Marshal(result)
+	if err != nil {
+		log.Warnf("batch: encode result %s: %v", result.CustomID, err)
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if js.done[result.CustomID] {
+		return
+	}
+	if err = appendLine(m.resultsPath(js.job.ID), line); err != nil {
+		log.Warnf("batch: store result for job %s: %v", js.job.ID, err)
+		return
+	}
+	js.done[result.CustomID] = true
+	countResult(&js.job.Counts, result)
+}
+
+func countResult(counts *Counts, result Result) {
+	switch {
+	case result.Succeeded():
+		counts.Succeeded++
+	case result.Error != nil && result.Error.Code == ErrorCodeCancelled:
+		counts.Cancelled++
+	case result.Error != nil && result.Error.Code == ErrorCodeExpired:
+		counts.Expired++
+	default:
+		counts.Errored++
+	}
+}
+
+// finalize records the requests that never ran, generates the result files and moves the
+// job to its terminal status.
+func (m *Manager) finalize(js *jobState, handler Handler) {
+	m.mu.Lock()
+	now := m.now()
+	final, code, message := StatusCompleted, "", ""
+	switch {
+	case js.job.Status == StatusCancelling:
+		final, code, message = StatusCancelled, ErrorCodeCancelled, "the batch was cancelled before this request ran"
+	case now.Unix() >= js.job.ExpiresAt:
+		final, code, message = StatusExpired, ErrorCodeExpired, "the batch expired before this request ran"
+	}
+	var unfinished []Request
+	for _, req := range js.requests {
+		if !js.done[req.CustomID] {
+			unfinished = append(unfinished, req)
+		}
+	}
+	if code == "" && len(unfinished) > 0 {
+		// Only an interrupted run leaves requests behind; keep them for the next start.
+		js.cancel = nil
+		m.mu.Unlock()
+		return
+	}
+	js.job.Status = StatusFinalizing
+	js.job.FinalizingAt = now.Unix()
+	m.mu.Unlock()
+
+	for _, req := range unfinished {
+		m.record(js, Result{ID: NewID("batch_req_"), CustomID: req.CustomID, Error: &ResultError{Code: code, Message: message}})
+	}
+
+	var outputID, errorID string
+	if handler.RenderResult != nil {
+		outputID, errorID = m.writeResultFiles(js, handler)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	js.job.OutputFileID = outputID
+	js.job.ErrorFileID = errorID
+	js.job.Status = final
+	switch final {
+	case StatusCompleted:
+		js.job.CompletedAt = m.now().Unix()
+	case StatusCancelled:
+		js.job.CancelledAt = m.now().Unix()
+	case StatusExpired:
+		js.job.ExpiredAt = m.now().Unix()
+	}
+	js.cancel = nil
+	m.saveJobLocked(js)
+}
+
+// writeResultFiles renders the results into an output file for successful requests and an
+// error file for the others, returning the IDs of the files that are not empty.
+func (m *Manager) writeResultFiles(js *jobState, handler Handler) (string, string) {
+	job := m.snapshot(js)
+	results, err := m.readResults(job.ID)
+	if err != nil {
+		log.Warnf("batch: read results of job %s: %v", job.ID, err)
+		return "", ""
+	}
+	var output, failures bytes.Buffer
+	for _, result := range results {
+		target := &failures
+		if result.Succeeded() {
+			target = &output
+		}
+		target.Write(handler.RenderResult(job, result))
+		target.WriteByte('\n')
+	}
+	store := func(kind string, data []byte) string {
+		if len(data) == 0 {
+			return ""
+		}
+		file := File{
+			ID:        NewID("file-"),
+			Owner:     job.Owner,
+			Purpose:   "batch_output",
+			Filename:  fmt.Sprintf("%s_%s.jsonl", job.ID, kind),
+			Bytes:     int64(len(data)),
+			CreatedAt: m.now().Unix(),
+			JobID:     job.ID,
+			Kind:      kind,
+		}
+		if errStore := m.storeFile(&file, data); errStore != nil {
+			log.Warnf("batch: %v", errStore)
+			return ""
+		}
+		return file.ID
+	}
+	return store(FileKindOutput, output.Bytes()), store(FileKindError, failures.Bytes())
+}
+
+func (m *Manager) saveJobLocked(js *jobState) {
+	if err := writeJSONFile(m.jobPath(js.job.ID), js.job); err != nil {
+		log.Warnf("batch: store job %s: %v", js.job.ID, err)
+	}
+}
+
+// load reads the files and jobs stored in the directory. Counts are rebuilt from the results
+// so that results recorded right before a crash are not lost.
+func (m *Manager) load() {
+	metas, _ := filepath.Glob(filepath.Join(m.dir, "files", "*.json"))
+	for _, path := range metas {
+		var file File
+		if err := readJSONFile(path, &file); err != nil || file.ID == "" {
+			log.Warnf("batch: skip unreadable file metadata %s: %v", path, err)
+			continue
+		}
+		if owner := OwnerID(file.Owner); owner != file.Owner {
+			// Stores written before owners were hashed hold the plain key.
+			file.Owner = owner
+			if errWrite := writeJSONFile(path, &file); errWrite != nil {
+				log.Warnf("batch: store file metadata %s: %v", path, errWrite)
+			}
+		}
+		m.files[file.ID] = &file
+	}
+
+	jobs, _ := filepath.Glob(filepath.Join(m.dir, "batches", "*.json"))
+	for _, path := range jobs {
+		var job Job
+		if err := readJSONFile(path, &job); err != nil || job.ID == "" {
+			log.Warnf("batch: skip unreadable job %s: %v", path, err)
+			continue
+		}
+		requests, err := m.readRequests(job.ID)
+		if err != nil {
+			log.Warnf("batch: skip job %s: %v", job.ID, err)
+			continue
+		}
+		results, err := m.readResults(job.ID)
+		if err != nil {
+			log.Warnf("batch: skip job %s: %v", job.ID, err)
+			continue
+		}
+		js := &jobState{job: job, requests: requests, done: make(map[string]bool, len(results))}
+		js.job.Counts = Counts{Total: len(requests)}
+		for _, result := range results {
+			if !js.done[result.CustomID] {
+				js.done[result.CustomID] = true
+				countResult(&js.job.Counts, result)
+			}
+		}
+		if js.job.Status == StatusFinalizing {
+			js.job.Status = StatusInProgress
+		}
+		if owner := OwnerID(js.job.Owner); owner != js.job.Owner {
+			js.job.Owner = owner
+			m.saveJobLocked(js)
+		}
+		m.jobs[job.ID] = js
+	}
+}
+
+func (m *Manager) readRequests(id string) ([]Request, error) {
+	var requests []Request
+	err := readLines(m.requestsPath(id), func(line []byte) error {
+		var req Request
+		if err := json.Unmarshal(line, &req); err != nil {
+			return err
+		}
+		requests = append(requests, req)
+		return nil
+	})
+	return requests, err
+}
+
+func (m *Manager) readResults(id string) ([]Result, error) {
+	var results []Result
+	err := readLines(m.resultsPath(id), func(line []byte) error {
+		var result Result
+		if err := json.Unmarshal(line, &result); err != nil {
+			// A torn last line from a crash is dropped; the request runs again.
+			return nil
+		}
+		results = append(results, result)
+		return nil
+	})
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	return results, err
+}
+
+func (m *Manager) fileMetaPath(id string) string { return filepath.Join(m.dir, "files", id+".json") }
+func (m *Manager) fileDataPath(id string) string { return filepath.Join(m.dir, "files", id+".data") }
+func (m *Manager) jobPath(id string) string      { return filepath.Join(m.dir, "batches", id+".json") }
+func (m *Manager) requestsPath(id string) string {
+	return filepath.Join(m.dir, "batches", id+".requests.jsonl")
+}
+func (m *Manager) resultsPath(id string) string {
+	return filepath.Join(m.dir, "batches", id+".results.jsonl")
+}
+
+// retryableStatus reports whether a response status is worth another attempt.
+func retryableStatus(status int) bool {
+	return status == 0 || status == http.StatusRequestTimeout || status == http.StatusTooManyRequests || status >= http.StatusInternalServerError
+}
+
+// retryAfter parses a Retry-After header given in seconds or as an HTTP date.
+func retryAfter(header http.Header, now time.Time) (time.Duration, bool) {
+	value := strings.TrimSpace(header.Get("Retry-After"))
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		if wait := at.Sub(now); wait > 0 {
+			return wait, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
+
+func exponentialBackoff(attempt int) time.Duration {
+	wait := time.Second << (attempt - 1)
+	if wait <= 0 || wait > maxBackoff {
+		return maxBackoff
+	}
+	return wait
+}
+
+func writeJSONFile(path string, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(path, data)
+}
+
+func readJSONFile(path string, v any) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+func writeFileAtomic(path string, data []byte) error {
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	return nil
+}
+
+func appendLine(path string, line []byte) error {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err = f.Write(append(line, '\n')); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// readLines calls fn for every non-empty line of a JSONL file.
+func readLines(path string, fn func([]byte) error) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = f.Close() }()
+	reader := bufio.NewReader(f)
+	for {
+		line, errRead := reader.ReadBytes('\n')
+		if trimmed := bytes.TrimSpace(line); len(trimmed) > 0 {
+			if err = fn(trimmed); err != nil {
+				return err
+			}
+		}
+		if errRead != nil {
+			if errors.Is(errRead, io.EOF) {
+				return nil
+			}
+			return errRead
+		}
+	}
+}
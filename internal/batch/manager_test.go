@@ -0,0 +1,255 @@
+package batch
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func newTestManager(t *testing.T, dir string, handler Handler) *Manager {
+	t.Helper()
+	m, err := NewManager(dir, Options{Concurrency: 2, MaxAttempts: 2})
+	if err != nil {
+		t.Fatalf("NewManager() error = %v", err)
+	}
+	m.backoff = func(int) time.Duration { return time.Millisecond }
+	m.RegisterHandler("test", handler)
+	t.Cleanup(m.Stop)
+	return m
+}
+
+func testRequests(ids ...string) []Request {
+	requests := make([]Request, 0, len(ids))
+	for _, id := range ids {
+		requests = append(requests, Request{CustomID: id, Body: json.RawMessage(`{"model":"m"}`)})
+	}
+	return requests
+}
+
+func waitForJob(t *testing.T, m *Manager, id string) Job {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		job, err := m.Job(id, "owner")
+		if err != nil {
+			t.Fatalf("Job() error = %v", err)
+		}
+		if job.Terminal() {
+			return job
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("job %s did not finish", id)
+	return Job{}
+}
+
+func renderTestResult(_ Job, result Result) []byte {
+	data, _ := json.Marshal(result)
+	return data
+}
+
+func TestManager_RunsJobWithRetriesAndWritesResultFiles(t *testing.T) {
+	var mu sync.Mutex
+	attempts := make(map[string]int)
+	m := newTestManager(t, t.TempDir(), Handler{
+		Execute: func(_ context.Context, job Job, req Request) (int, http.Header, []byte) {
+			mu.Lock()
+			attempts[req.CustomID]++
+			n := attempts[req.CustomID]
+			mu.Unlock()
+			switch req.CustomID {
+			case "flaky":
+				if n == 1 {
+					return http.StatusServiceUnavailable, nil, []byte(`{"error":"busy"}`)
+				}
+			case "cooling":
+				// Rate limits with Retry-After do not use up attempts.
+				if n <= 3 {
+					return http.StatusTooManyRequests, http.Header{"Retry-After": []string{"0"}}, nil
+				}
+			case "bad":
+				return http.StatusBadRequest, nil, []byte(`{"error":"bad"}`)
+			}
+			return http.StatusOK, nil, []byte(`{"ok":"` + req.CustomID + `"}`)
+		},
+		RenderResult: renderTestResult,
+	})
+	m.Start()
+
+	job, err := m.CreateJob(Job{Format: "test", Owner: "owner"}, testRequests("ok", "flaky", "cooling", "bad"))
+	if err != nil {
+		t.Fatalf("CreateJob() error = %v", err)
+	}
+	job = waitForJob(t, m, job.ID)
+
+	if job.Status != StatusCompleted || job.Counts.Succeeded != 3 || job.Counts.Errored != 1 {
+		t.Fatalf("unexpected job %+v", job)
+	}
+	if attempts["flaky"] != 2 || attempts["cooling"] != 4 || attempts["bad"] != 1 {
+		t.Fatalf("unexpected attempts %v", attempts)
+	}
+	output, err := m.FileContent(job.OutputFileID, "owner")
+	if err != nil {
+		t.Fatalf("FileContent(output) error = %v", err)
+	}
+	if lines := strings.Count(string(output), "\n"); lines != 3 {
+		t.Fatalf("expected 3 output lines, got %d:\n%s", lines, output)
+	}
+	errorsFile, err := m.FileContent(job.ErrorFileID, "owner")
+	if err != nil {
+		t.Fatalf("FileContent(error) error = %v", err)
+	}
+	if !strings.Contains(string(errorsFile), `"custom_id":"bad"`) {
+		t.Fatalf("expected the failed request in the error file, got %s", errorsFile)
+	}
+	if _, err = m.Job(job.ID, "someone-else"); err != ErrNotFound {
+		t.Fatalf("expected jobs to be scoped to their owner, got %v", err)
+	}
+}
+
+func TestManager_ResumesUnfinishedJobAfterRestart(t *testing.T) {
+	dir := t.TempDir()
+	release := make(chan struct{})
+	first, err := NewManager(dir, Options{Concurrency: 1})
+	if err != nil {
+		t.Fatalf("NewManager() error = %v", err)
+	}
+	first.RegisterHandler("test", Handler{Execute: func(ctx context.Context, _ Job, req Request) (int, http.Header, []byte) {
+		if req.CustomID == "a" {
+			return http.StatusOK, nil, []byte(`{}`)
+		}
+		select {
+		case <-ctx.Done():
+		case <-release:
+		}
+		return 0, nil, nil
+	}})
+	first.Start()
+	job, err := first.CreateJob(Job{Format: "test", Owner: "owner"}, testRequests("a", "b"))
+	if err != nil {
+		t.Fatalf("CreateJob() error = %v", err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		current, _ := first.Job(job.ID, "owner")
+		if current.Counts.Succeeded == 1 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("first request did not complete")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	first.Stop()
+	close(release)
+
+	var executed []string
+	second := newTestManager(t, dir, Handler{Execute: func(_ context.Context, _ Job, req Request) (int, http.Header, []byte) {
+		executed = append(executed, req.CustomID)
+		return http.StatusOK, nil, []byte(`{}`)
+	}})
+	second.Start()
+	job = waitForJob(t, second, job.ID)
+
+	if job.Status != StatusCompleted || job.Counts.Succeeded != 2 {
+		t.Fatalf("unexpected resumed job %+v", job)
+	}
+	if len(executed) != 1 || executed[0] != "b" {
+		t.Fatalf("expected only the unfinished request to run again, got %v", executed)
+	}
+}
+
+func TestManager_CancelJobRecordsUnfinishedRequests(t *testing.T) {
+	started := make(chan struct{}, 1)
+	m := newTestManager(t, t.TempDir(), Handler{Execute: func(ctx context.Context, _ Job, _ Request) (int, http.Header, []byte) {
+		started <- struct{}{}
+		<-ctx.Done()
+		return 0, nil, nil
+	}})
+	m.SetOptions(Options{Concurrency: 1})
+	m.Start()
+	job, err := m.CreateJob(Job{Format: "test", Owner: "owner"}, testRequests("a", "b"))
+	if err != nil {
+		t.Fatalf("CreateJob() error = %v", err)
+	}
+	<-started
+	if job, err = m.CancelJob(job.ID, "owner"); err != nil || job.Status != StatusCancelling {
+		t.Fatalf("CancelJob() = %+v, %v", job, err)
+	}
+	job = waitForJob(t, m, job.ID)
+
+	if job.Status != StatusCancelled || job.Counts.Cancelled != 2 || job.CancelledAt == 0 {
+		t.Fatalf("unexpected cancelled job %+v", job)
+	}
+	results, err := m.Results(job.ID, "owner")
+	if err != nil || len(results) != 2 || results[0].Error == nil || results[0].Error.Code != ErrorCodeCancelled {
+		t.Fatalf("unexpected results %+v, %v", results, err)
+	}
+}
+
+func TestManager_StoresOwnerIDInsteadOfKey(t *testing.T) {
+	dir := t.TempDir()
+	m := newTestManager(t, dir, Handler{Execute: func(context.Context, Job, Request) (int, http.Header, []byte) {
+		return http.StatusOK, nil, []byte(`{}`)
+	}})
+	file, err := m.CreateFile("secret-key", "batch", "in.jsonl", []byte("{}"))
+	if err != nil {
+		t.Fatalf("CreateFile() error = %v", err)
+	}
+	job, err := m.CreateJob(Job{Format: "test", Owner: "secret-key"}, testRequests("a"))
+	if err != nil {
+		t.Fatalf("CreateJob() error = %v", err)
+	}
+	if job.Owner != OwnerID("secret-key") || file.Owner != job.Owner {
+		t.Fatalf("expected hashed owners, got job %q file %q", job.Owner, file.Owner)
+	}
+	for _, path := range []string{m.jobPath(job.ID), m.fileMetaPath(file.ID)} {
+		data, errRead := os.ReadFile(path)
+		if errRead != nil || strings.Contains(string(data), "secret-key") {
+			t.Fatalf("expected %s not to contain the key: %s, %v", path, data, errRead)
+		}
+	}
+
+	// Stores written before owners were hashed are migrated on load.
+	job.Owner = "secret-key"
+	if err = writeJSONFile(m.jobPath(job.ID), job); err != nil {
+		t.Fatalf("writeJSONFile() error = %v", err)
+	}
+	reopened := newTestManager(t, dir, Handler{})
+	if _, err = reopened.Job(job.ID, "secret-key"); err != nil {
+		t.Fatalf("Job() after migration error = %v", err)
+	}
+	if data, _ := os.ReadFile(reopened.jobPath(job.ID)); strings.Contains(string(data), "secret-key") {
+		t.Fatalf("expected the migrated job to hold the owner ID, got %s", data)
+	}
+}
+
+func TestManager_CancelsJobOfRevokedOwner(t *testing.T) {
+	var executed int
+	m := newTestManager(t, t.TempDir(), Handler{
+		Execute: func(context.Context, Job, Request) (int, http.Header, []byte) {
+			executed++
+			return http.StatusOK, nil, []byte(`{}`)
+		},
+		Authorize: func(job Job) bool { return job.Owner != OwnerID("removed") },
+	})
+	m.Start()
+	job, err := m.CreateJob(Job{Format: "test", Owner: "removed"}, testRequests("a", "b"))
+	if err != nil {
+		t.Fatalf("CreateJob() error = %v", err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for !job.Terminal() && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+		job, _ = m.Job(job.ID, "removed")
+	}
+
+	if job.Status != StatusCancelled || job.Counts.Cancelled != 2 || executed != 0 {
+		t.Fatalf("expected the job to be cancelled without running, got %+v after %d requests", job, executed)
+	}
+}
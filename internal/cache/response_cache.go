@@ -0,0 +1,260 @@
+package cache
+
+import (
+	"container/list"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// ResponseEntry is a cached upstream response together with the request it originally cost.
+type ResponseEntry struct {
+	Payload   []byte        `json:"payload"`
+	Provider  string        `json:"provider,omitempty"`
+	Model     string        `json:"model,omitempty"`
+	AuthID    string        `json:"auth_id,omitempty"`
+	AuthIndex string        `json:"auth_index,omitempty"`
+	Source    string        `json:"source,omitempty"`
+	Usage     ResponseUsage `json:"usage"`
+	StoredAt  time.Time     `json:"stored_at"`
+	ExpiresAt time.Time     `json:"expires_at"`
+}
+
+// ResponseUsage is the token usage reported when the cached response was produced.
+type ResponseUsage struct {
+	InputTokens     int64 `json:"input_tokens,omitempty"`
+	OutputTokens    int64 `json:"output_tokens,omitempty"`
+	ReasoningTokens int64 `json:"reasoning_tokens,omitempty"`
+	CachedTokens    int64 `json:"cached_tokens,omitempty"`
+	TotalTokens     int64 `json:"total_tokens,omitempty"`
+}
+
+// ResponseStore keeps cached responses under opaque keys.
+type ResponseStore interface {
+	// Get returns the entry stored under key unless it is missing or expired at now.
+	Get(key string, now time.Time) (*ResponseEntry, bool)
+	// Put stores entry under key, evicting least recently used entries beyond the size limit.
+	Put(key string, entry *ResponseEntry)
+}
+
+// responseIndex tracks entry sizes, expiry and recency for LRU eviction.
+type responseIndex struct {
+	maxBytes int64
+	total    int64
+	order    *list.List
+	items    map[string]*list.Element
+}
+
+type responseIndexItem struct {
+	key       string
+	size      int64
+	expiresAt time.Time
+}
+
+func newResponseIndex(maxBytes int64) *responseIndex {
+	return &responseIndex{maxBytes: maxBytes, order: list.New(), items: make(map[string]*list.Element)}
+}
+
+// touch marks key as recently used and reports whether it is present and unexpired.
+func (idx *responseIndex) touch(key string, now time.Time) bool {
+	elem, ok := idx.items[key]
+	if !ok {
+		return false
+	}
+	// A zero expiry means unknown (entries indexed from disk); the reader checks it.
+	if expiresAt := elem.Value.(*responseIndexItem).expiresAt; !expiresAt.IsZero() && !expiresAt.After(now) {
+		return false
+	}
+	idx.order.MoveToFront(elem)
+	return true
+}
+
+// add records key and returns the keys evicted to stay within the size limit. Entries larger
+// than the whole limit are not admitted and are returned as evicted themselves.
+func (idx *responseIndex) add(key string, size int64, expiresAt time.Time) []string {
+	var evicted []string
+	if elem, ok := idx.items[key]; ok {
+		idx.total -= elem.Value.(*responseIndexItem).size
+		idx.order.Remove(elem)
+		delete(idx.items, key)
+	}
+	if idx.maxBytes > 0 && size > idx.maxBytes {
+		return append(evicted, key)
+	}
+	idx.items[key] = idx.order.PushFront(&responseIndexItem{key: key, size: size, expiresAt: expiresAt})
+	idx.total += size
+	for idx.maxBytes > 0 && idx.total > idx.maxBytes {
+		oldest := idx.order.Back()
+		item := oldest.Value.(*responseIndexItem)
+		idx.order.Remove(oldest)
+		delete(idx.items, item.key)
+		idx.total -= item.size
+		evicted = append(evicted, item.key)
+	}
+	return evicted
+}
+
+func (idx *responseIndex) remove(key string) {
+	if elem, ok := idx.items[key]; ok {
+		idx.total -= elem.Value.(*responseIndexItem).size
+		idx.order.Remove(elem)
+		delete(idx.items, key)
+	}
+}
+
+func entrySize(entry *ResponseEntry) int64 {
+	return int64(len(entry.Payload)) + 256
+}
+
+// MemoryResponseStore keeps cached responses in process memory.
+type MemoryResponseStore struct {
+	mu      sync.Mutex
+	index   *responseIndex
+	entries map[string]*ResponseEntry
+}
+
+// NewMemoryResponseStore returns an in-memory store bounded to maxBytes (<= 0 means unbounded).
+func NewMemoryResponseStore(maxBytes int64) *MemoryResponseStore {
+	return &MemoryResponseStore{index: newResponseIndex(maxBytes), entries: make(map[string]*ResponseEntry)}
+}
+
+// Get implements ResponseStore.
+func (s *MemoryResponseStore) Get(key string, now time.Time) (*ResponseEntry, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.index.touch(key, now) {
+		s.index.remove(key)
+		delete(s.entries, key)
+		return nil, false
+	}
+	return s.entries[key], true
+}
+
+// Put implements ResponseStore.
+func (s *MemoryResponseStore) Put(key string, entry *ResponseEntry) {
+	if entry == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries[key] = entry
+	for _, evicted := range s.index.add(key, entrySize(entry), entry.ExpiresAt) {
+		delete(s.entries, evicted)
+	}
+}
+
+// DiskResponseStore keeps cached responses as JSON files in a directory so they survive
+// restarts. The LRU index lives in memory and is rebuilt from the directory on startup.
+type DiskResponseStore struct {
+	mu    sync.Mutex
+	dir   string
+	index *responseIndex
+}
+
+// NewDiskResponseStore opens (creating if needed) a disk store in dir bounded to maxBytes.
+func NewDiskResponseStore(dir string, maxBytes int64) (*DiskResponseStore, error) {
+	if strings.TrimSpace(dir) == "" {
+		return nil, fmt.Errorf("response cache: disk backend requires a directory")
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("response cache: create directory: %w", err)
+	}
+	s := &DiskResponseStore{dir: dir, index: newResponseIndex(maxBytes)}
+	s.load()
+	return s, nil
+}
+
+// load indexes the entries already on disk, oldest first so recency follows file age.
+func (s *DiskResponseStore) load() {
+	files, err := filepath.Glob(filepath.Join(s.dir, "*.json"))
+	if err != nil {
+		return
+	}
+	type existing struct {
+		key     string
+		size    int64
+		modTime time.Time
+	}
+	found := make([]existing, 0, len(files))
+	for _, file := range files {
+		info, errStat := os.Stat(file)
+		if errStat != nil {
+			continue
+		}
+		found = append(found, existing{key: strings.TrimSuffix(filepath.Base(file), ".json"), size: info.Size(), modTime: info.ModTime()})
+	}
+	sort.Slice(found, func(i, j int) bool { return found[i].modTime.Before(found[j].modTime) })
+	for _, item := range found {
+		for _, evicted := range s.index.add(item.key, item.size, time.Time{}) {
+			s.removeFile(evicted)
+		}
+	}
+}
+
+func (s *DiskResponseStore) path(key string) string {
+	return filepath.Join(s.dir, key+".json")
+}
+
+func (s *DiskResponseStore) removeFile(key string) {
+	if err := os.Remove(s.path(key)); err != nil && !os.IsNotExist(err) {
+		log.Debugf("response cache: remove %s: %v", key, err)
+	}
+}
+
+// Get implements ResponseStore.
+func (s *DiskResponseStore) Get(key string, now time.Time) (*ResponseEntry, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.index.touch(key, now) {
+		if _, ok := s.index.items[key]; ok {
+			s.index.remove(key)
+			s.removeFile(key)
+		}
+		return nil, false
+	}
+	data, err := os.ReadFile(s.path(key))
+	if err != nil {
+		s.index.remove(key)
+		return nil, false
+	}
+	var entry ResponseEntry
+	if err = json.Unmarshal(data, &entry); err != nil || !entry.ExpiresAt.After(now) {
+		s.index.remove(key)
+		s.removeFile(key)
+		return nil, false
+	}
+	return &entry, true
+}
+
+// Put implements ResponseStore.
+func (s *DiskResponseStore) Put(key string, entry *ResponseEntry) {
+	if entry == nil {
+		return
+	}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	tmp := s.path(key) + ".tmp"
+	if err = os.WriteFile(tmp, data, 0o600); err != nil {
+		log.Warnf("response cache: write entry: %v", err)
+		return
+	}
+	if err = os.Rename(tmp, s.path(key)); err != nil {
+		_ = os.Remove(tmp)
+		log.Warnf("response cache: store entry: %v", err)
+		return
+	}
+	for _, evicted := range s.index.add(key, int64(len(data)), entry.ExpiresAt) {
+		s.removeFile(evicted)
+	}
+}
@@ -0,0 +1,95 @@
+package cache
+
+import (
+	"testing"
+	"time"
+)
+
+func testResponseEntry(payload string, now time.Time) *ResponseEntry {
+	return &ResponseEntry{Payload: []byte(payload), StoredAt: now, ExpiresAt: now.Add(time.Hour)}
+}
+
+func TestMemoryResponseStore_EvictsLeastRecentlyUsed(t *testing.T) {
+	now := time.Now()
+	// Each entry accounts for len(payload)+256 bytes; room for two.
+	store := NewMemoryResponseStore(2 * (256 + 1))
+	store.Put("a", testResponseEntry("a", now))
+	store.Put("b", testResponseEntry("b", now))
+	if _, ok := store.Get("a", now); !ok {
+		t.Fatal("expected a to be cached")
+	}
+	store.Put("c", testResponseEntry("c", now))
+
+	if _, ok := store.Get("b", now); ok {
+		t.Fatal("expected least recently used entry b to be evicted")
+	}
+	for _, key := range []string{"a", "c"} {
+		if _, ok := store.Get(key, now); !ok {
+			t.Fatalf("expected %s to remain cached", key)
+		}
+	}
+}
+
+func TestMemoryResponseStore_ExpiresEntries(t *testing.T) {
+	now := time.Now()
+	store := NewMemoryResponseStore(0)
+	store.Put("a", testResponseEntry("a", now))
+	if _, ok := store.Get("a", now.Add(2*time.Hour)); ok {
+		t.Fatal("expected expired entry to be missing")
+	}
+	if _, ok := store.Get("a", now); ok {
+		t.Fatal("expected expired entry to be dropped")
+	}
+}
+
+func TestDiskResponseStore_PersistsAcrossReopen(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+	store, err := NewDiskResponseStore(dir, 0)
+	if err != nil {
+		t.Fatalf("NewDiskResponseStore() error = %v", err)
+	}
+	entry := testResponseEntry(`{"ok":true}`, now)
+	entry.Usage.TotalTokens = 7
+	store.Put("k", entry)
+
+	reopened, err := NewDiskResponseStore(dir, 0)
+	if err != nil {
+		t.Fatalf("NewDiskResponseStore() reopen error = %v", err)
+	}
+	got, ok := reopened.Get("k", now)
+	if !ok {
+		t.Fatal("expected entry to survive reopening the store")
+	}
+	if string(got.Payload) != `{"ok":true}` || got.Usage.TotalTokens != 7 {
+		t.Fatalf("unexpected entry %+v", got)
+	}
+	if _, ok = reopened.Get("k", now.Add(2*time.Hour)); ok {
+		t.Fatal("expected expired disk entry to be missing")
+	}
+}
+
+func TestDiskResponseStore_EvictsOverLimit(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+	probe, err := NewDiskResponseStore(t.TempDir(), 0)
+	if err != nil {
+		t.Fatalf("NewDiskResponseStore() error = %v", err)
+	}
+	probe.Put("a", testResponseEntry("a", now))
+	size := probe.index.total
+
+	store, err := NewDiskResponseStore(dir, 2*size)
+	if err != nil {
+		t.Fatalf("NewDiskResponseStore() error = %v", err)
+	}
+	store.Put("a", testResponseEntry("a", now))
+	store.Put("b", testResponseEntry("b", now))
+	store.Put("c", testResponseEntry("c", now))
+	if _, ok := store.Get("a", now); ok {
+		t.Fatal("expected oldest entry a to be evicted")
+	}
+	if _, ok := store.Get("c", now); !ok {
+		t.Fatal("expected newest entry c to be cached")
+	}
+}
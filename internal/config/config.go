@@ -47,6 +47,12 @@ type Config struct {
 	// Pprof config controls the optional pprof HTTP debug server.
 	Pprof PprofConfig `yaml:"pprof" json:"pprof"`
 
+	// Metrics config controls the optional Prometheus metrics endpoint.
+	Metrics MetricsConfig `yaml:"metrics,omitempty" json:"metrics,omitempty"`
+
+	// Tracing config controls OpenTelemetry span export for the request pipeline.
+	Tracing TracingConfig `yaml:"tracing,omitempty" json:"tracing,omitempty"`
+
 	// CommercialMode disables high-overhead HTTP middleware features to minimize per-request memory usage.
 	CommercialMode bool `yaml:"commercial-mode" json:"commercial-mode"`
 
@@ -64,6 +70,15 @@ type Config struct {
 	// UsageStatisticsEnabled toggles in-memory usage aggregation; when false, usage data is discarded.
 	UsageStatisticsEnabled bool `yaml:"usage-statistics-enabled" json:"usage-statistics-enabled"`
 
+	// UsageStore configures the persistent usage statistics backend.
+	UsageStore UsageStoreConfig `yaml:"usage-store,omitempty" json:"usage-store,omitempty"`
+
+	// ResponseCache configures the exact-match cache for deterministic non-streaming requests.
+	ResponseCache ResponseCacheConfig `yaml:"response-cache,omitempty" json:"response-cache,omitempty"`
+
+	// Batch configures the background runner behind the batch endpoints.
+	Batch BatchConfig `yaml:"batch,omitempty" json:"batch,omitempty"`
+
 	// DisableCooling disables quota cooldown scheduling when true.
 	DisableCooling bool `yaml:"disable-cooling" json:"disable-cooling"`
 
@@ -75,6 +90,9 @@ type Config struct {
 	// QuotaExceeded defines the behavior when a quota is exceeded.
 	QuotaExceeded QuotaExceeded `yaml:"quota-exceeded" json:"quota-exceeded"`
 
+	// QuotaPolling configures background polling of provider quota endpoints.
+	QuotaPolling QuotaPollingConfig `yaml:"quota-polling" json:"quota-polling"`
+
 	// Routing controls credential selection behavior.
 	Routing RoutingConfig `yaml:"routing" json:"routing"`
 
@@ -119,6 +137,14 @@ type Config struct {
 	// gemini-api-key, codex-api-key, claude-api-key, openai-compatibility, vertex-api-key, and ampcode.
 	OAuthModelAlias map[string][]OAuthModelAlias `yaml:"oauth-model-alias,omitempty" json:"oauth-model-alias,omitempty"`
 
+	// ModelFallbacks defines ordered fallback chains tried by the conductor when every
+	// credential for the requested model is cooling down, out of quota or failing with 5xx.
+	ModelFallbacks []ModelFallback `yaml:"model-fallbacks,omitempty" json:"model-fallbacks,omitempty"`
+
+	// Hedging enables hedged non-streaming requests for matching models: when the first
+	// attempt is slow, the request is raced on a second credential.
+	Hedging []HedgingRule `yaml:"hedging,omitempty" json:"hedging,omitempty"`
+
 	// Payload defines default and override rules for provider payload parameters.
 	Payload PayloadConfig `yaml:"payload" json:"payload"`
 
@@ -148,6 +174,32 @@ type PprofConfig struct {
 	Addr string `yaml:"addr" json:"addr"`
 }
 
+// MetricsConfig holds Prometheus metrics endpoint settings.
+type MetricsConfig struct {
+	// Enable exposes GET /metrics on the API server.
+	Enable bool `yaml:"enable" json:"enable"`
+	// Key guards the endpoint (Authorization: Bearer <key> or X-Metrics-Key). It is separate from the
+	// management secret. When empty, the endpoint only answers localhost clients.
+	Key string `yaml:"key,omitempty" json:"key,omitempty"`
+}
+
+// TracingConfig holds OpenTelemetry tracing settings. Spans are exported over OTLP/HTTP.
+type TracingConfig struct {
+	// Enable turns on span creation and export.
+	Enable bool `yaml:"enable" json:"enable"`
+	// Endpoint is the OTLP/HTTP collector endpoint (host:port or full URL). Empty uses the
+	// OTEL_EXPORTER_OTLP_* environment variables or the exporter default.
+	Endpoint string `yaml:"endpoint,omitempty" json:"endpoint,omitempty"`
+	// Insecure sends spans over plain HTTP instead of HTTPS.
+	Insecure bool `yaml:"insecure,omitempty" json:"insecure,omitempty"`
+	// Headers are extra HTTP headers sent to the collector (e.g. authentication).
+	Headers map[string]string `yaml:"headers,omitempty" json:"headers,omitempty"`
+	// ServiceName overrides the reported service.name resource attribute.
+	ServiceName string `yaml:"service-name,omitempty" json:"service-name,omitempty"`
+	// SampleRatio is the fraction of root spans to sample (0 < ratio <= 1). Zero means sample everything.
+	SampleRatio float64 `yaml:"sample-ratio,omitempty" json:"sample-ratio,omitempty"`
+}
+
 // RemoteManagement holds management API configuration under 'remote-management'.
 type RemoteManagement struct {
 	// AllowRemote toggles remote (non-localhost) access to management API.
@@ -171,11 +223,117 @@ type QuotaExceeded struct {
 	SwitchPreviewModel bool `yaml:"switch-preview-model" json:"switch-preview-model"`
 }
 
+// QuotaPollingConfig configures the background poller that fetches remaining quota for
+// credentials whose provider exposes it (Kiro, GitHub Copilot, Gemini CLI, Antigravity, Codex).
+type QuotaPollingConfig struct {
+	// Enabled turns on background quota polling.
+	Enabled bool `yaml:"enabled" json:"enabled"`
+
+	// IntervalSeconds is the delay between polls of the same credential. Defaults to 300.
+	IntervalSeconds int `yaml:"interval-seconds,omitempty" json:"interval-seconds,omitempty"`
+}
+
 // RoutingConfig configures how credentials are selected for requests.
 type RoutingConfig struct {
 	// Strategy selects the credential selection strategy.
-	// Supported values: "round-robin" (default), "fill-first".
+	// Supported values: "round-robin" (default), "fill-first", "least-outstanding", "latency".
 	Strategy string `yaml:"strategy,omitempty" json:"strategy,omitempty"`
+
+	// SessionAffinity pins consecutive turns of a conversation to the same credential
+	// so upstream prompt caches can be reused.
+	SessionAffinity SessionAffinityConfig `yaml:"session-affinity,omitempty" json:"session-affinity,omitempty"`
+
+	// CredentialPolicies configures per-provider credential health policies: request pacing,
+	// daily caps, suspension detection and score-based selection.
+	CredentialPolicies []CredentialPolicy `yaml:"credential-policies,omitempty" json:"credential-policies,omitempty"`
+}
+
+// CredentialPolicy configures how the credentials of one provider are paced and selected.
+type CredentialPolicy struct {
+	// Provider is the provider key the policy applies to (e.g. "codex", "claude"), or "*" for all.
+	Provider string `yaml:"provider" json:"provider"`
+	// MinIntervalMS is the minimum gap between two requests sent with the same credential.
+	MinIntervalMS int `yaml:"min-interval-ms,omitempty" json:"min-interval-ms,omitempty"`
+	// MaxIntervalMS, when greater than MinIntervalMS, randomizes the gap within [min, max].
+	MaxIntervalMS int `yaml:"max-interval-ms,omitempty" json:"max-interval-ms,omitempty"`
+	// DailyMaxRequests caps the requests sent with one credential per UTC day. 0 disables the cap.
+	DailyMaxRequests int `yaml:"daily-max-requests,omitempty" json:"daily-max-requests,omitempty"`
+	// ScoreSelection picks the credential with the best health score (success rate, latency,
+	// remaining quota and idle time) instead of using the routing strategy.
+	ScoreSelection bool `yaml:"score-selection,omitempty" json:"score-selection,omitempty"`
+	// SuspendCooldownSeconds is how long a credential is skipped after the upstream reports it
+	// suspended or banned. Defaults to 3600 when <= 0.
+	SuspendCooldownSeconds int `yaml:"suspend-cooldown-seconds,omitempty" json:"suspend-cooldown-seconds,omitempty"`
+}
+
+// SessionAffinityConfig configures sticky credential routing.
+type SessionAffinityConfig struct {
+	// Enable toggles sticky routing on top of the configured strategy.
+	Enable bool `yaml:"enable" json:"enable"`
+	// TTLSeconds controls how long an idle affinity is kept. Defaults to 3600 when <= 0.
+	TTLSeconds int `yaml:"ttl-seconds,omitempty" json:"ttl-seconds,omitempty"`
+	// Header optionally names a client request header whose value is used as the affinity key.
+	Header string `yaml:"header,omitempty" json:"header,omitempty"`
+}
+
+// ResponseCacheConfig configures the exact-match response cache. Entries are keyed on the
+// handler type, resolved model and canonicalized request payload together with the payload
+// rules in effect, so editing those rules invalidates earlier entries.
+type ResponseCacheConfig struct {
+	// Enable turns on caching of successful non-streaming responses.
+	Enable bool `yaml:"enable" json:"enable"`
+
+	// Backend selects where entries are kept: "memory" (default) or "disk".
+	Backend string `yaml:"backend,omitempty" json:"backend,omitempty"`
+
+	// Dir is the disk backend directory. Defaults to "response-cache" under the auth directory.
+	Dir string `yaml:"dir,omitempty" json:"dir,omitempty"`
+
+	// TTLSeconds bounds how long an entry is served. Defaults to 3600.
+	TTLSeconds int `yaml:"ttl-seconds,omitempty" json:"ttl-seconds,omitempty"`
+
+	// MaxSizeMB bounds the total size of cached responses; least recently used entries are
+	// evicted first. Defaults to 256.
+	MaxSizeMB int `yaml:"max-size-mb,omitempty" json:"max-size-mb,omitempty"`
+
+	// IncludeNonDeterministic also caches requests that do not set temperature to 0.
+	IncludeNonDeterministic bool `yaml:"include-non-deterministic,omitempty" json:"include-non-deterministic,omitempty"`
+}
+
+// BatchConfig configures how batch jobs are stored and executed.
+type BatchConfig struct {
+	// Dir stores uploaded files, job state and results. Defaults to "batches" under the auth directory.
+	Dir string `yaml:"dir,omitempty" json:"dir,omitempty"`
+
+	// Concurrency bounds how many batch requests run at once across all jobs. Defaults to 4.
+	Concurrency int `yaml:"concurrency,omitempty" json:"concurrency,omitempty"`
+
+	// MaxAttempts bounds how often a request is tried when it fails with a retryable status
+	// (408, 429 or 5xx). Defaults to 3.
+	MaxAttempts int `yaml:"max-attempts,omitempty" json:"max-attempts,omitempty"`
+}
+
+// UsageStoreConfig configures the persistent usage statistics sink.
+type UsageStoreConfig struct {
+	// Enable turns on persistence of usage records.
+	Enable bool `yaml:"enable" json:"enable"`
+
+	// Driver selects the backend: "sqlite" (default) or "postgres".
+	// The postgres driver reuses the connection of the Postgres-backed token store (PGSTORE_DSN).
+	Driver string `yaml:"driver,omitempty" json:"driver,omitempty"`
+
+	// Path is the SQLite database file. Relative paths resolve against the config directory.
+	// Defaults to "usage.db" next to the config file.
+	Path string `yaml:"path,omitempty" json:"path,omitempty"`
+
+	// RawRetentionDays keeps individual request records for this many days. Defaults to 30.
+	RawRetentionDays int `yaml:"raw-retention-days,omitempty" json:"raw-retention-days,omitempty"`
+
+	// HourlyRetentionDays keeps hourly rollups for this many days. Defaults to 90.
+	HourlyRetentionDays int `yaml:"hourly-retention-days,omitempty" json:"hourly-retention-days,omitempty"`
+
+	// DailyRetentionDays keeps daily rollups for this many days. 0 keeps them forever.
+	DailyRetentionDays int `yaml:"daily-retention-days,omitempty" json:"daily-retention-days,omitempty"`
 }
 
 // OAuthModelAlias defines a model ID alias for a specific channel.
@@ -188,6 +346,21 @@ type OAuthModelAlias struct {
 	Fork  bool   `yaml:"fork,omitempty" json:"fork,omitempty"`
 }
 
+// ModelFallback maps a primary model to the models tried, in order, when it is unavailable.
+// Model supports '*' wildcards (e.g. "claude-opus-*"); fallbacks may target any provider.
+type ModelFallback struct {
+	Model     string   `yaml:"model" json:"model"`
+	Fallbacks []string `yaml:"fallbacks" json:"fallbacks"`
+}
+
+// HedgingRule opts the models matching Model ('*' wildcards allowed) into request hedging.
+type HedgingRule struct {
+	Model string `yaml:"model" json:"model"`
+
+	// DelayMS is how long the first attempt may run before the hedge starts. Defaults to 1000.
+	DelayMS int `yaml:"delay-ms,omitempty" json:"delay-ms,omitempty"`
+}
+
 // AmpModelMapping defines a model name mapping for Amp CLI requests.
 // When Amp requests a model that isn't available locally, this mapping
 // allows routing to an alternative model that IS available.
@@ -346,6 +519,9 @@ type ClaudeModel struct {
 
 	// Alias is the client-facing model name that maps to Name.
 	Alias string `yaml:"alias" json:"alias"`
+
+	// ModelCapabilityConfig optionally declares the limits and features of the model.
+	ModelCapabilityConfig `yaml:",inline"`
 }
 
 func (m ClaudeModel) GetName() string  { return m.Name }
@@ -391,6 +567,9 @@ type CodexModel struct {
 
 	// Alias is the client-facing model name that maps to Name.
 	Alias string `yaml:"alias" json:"alias"`
+
+	// ModelCapabilityConfig optionally declares the limits and features of the model.
+	ModelCapabilityConfig `yaml:",inline"`
 }
 
 func (m CodexModel) GetName() string  { return m.Name }
@@ -435,6 +614,9 @@ type GeminiModel struct {
 
 	// Alias is the client-facing model name that maps to Name.
 	Alias string `yaml:"alias" json:"alias"`
+
+	// ModelCapabilityConfig optionally declares the limits and features of the model.
+	ModelCapabilityConfig `yaml:",inline"`
 }
 
 func (m GeminiModel) GetName() string  { return m.Name }
@@ -512,6 +694,9 @@ type OpenAICompatibilityModel struct {
 
 	// Alias is the model name alias that clients will use to reference this model.
 	Alias string `yaml:"alias" json:"alias"`
+
+	// ModelCapabilityConfig optionally declares the limits and features of the model.
+	ModelCapabilityConfig `yaml:",inline"`
 }
 
 func (m OpenAICompatibilityModel) GetName() string  { return m.Name }
@@ -635,6 +820,9 @@ func LoadConfigOptional(configFile string, optional bool) (*Config, error) {
 	// Sync request authentication providers with inline API keys for backwards compatibility.
 	syncInlineAccessProvider(&cfg)
 
+	// Sanitize per-key client policies (limits and allow-lists).
+	cfg.SanitizeAPIKeys()
+
 	// Sanitize Gemini API key configuration and migrate legacy entries.
 	cfg.SanitizeGeminiKeys()
 
@@ -662,6 +850,21 @@ func LoadConfigOptional(configFile string, optional bool) (*Config, error) {
 	// Validate raw payload rules and drop invalid entries.
 	cfg.SanitizePayloadRules()
 
+	// Normalize model fallback chains.
+	cfg.SanitizeModelFallbacks()
+
+	// Normalize credential policies.
+	cfg.SanitizeCredentialPolicies()
+
+	// Normalize hedging rules.
+	cfg.SanitizeHedging()
+
+	// Normalize response cache settings.
+	cfg.SanitizeResponseCache()
+
+	// Normalize batch runner settings
+	cfg.SanitizeBatch()
+
 	// NOTE: Legacy migration persistence is intentionally disabled together with
 	// startup legacy migration to keep startup read-only for config.yaml.
 	// Re-enable the block below if automatic startup migration is needed again.
@@ -774,6 +977,119 @@ func (cfg *Config) SanitizeOAuthModelAlias() {
 	cfg.OAuthModelAlias = out
 }
 
+// SanitizeHedging trims model patterns, drops rules without one and clamps negative delays
+// to the default.
+func (cfg *Config) SanitizeHedging() {
+	if cfg == nil || len(cfg.Hedging) == 0 {
+		return
+	}
+	out := make([]HedgingRule, 0, len(cfg.Hedging))
+	for _, rule := range cfg.Hedging {
+		rule.Model = strings.TrimSpace(rule.Model)
+		if rule.Model == "" {
+			continue
+		}
+		if rule.DelayMS < 0 {
+			rule.DelayMS = 0
+		}
+		out = append(out, rule)
+	}
+	cfg.Hedging = out
+}
+
+// SanitizeResponseCache normalizes the backend name, falling back to memory for unknown
+// values, and clears negative limits so the defaults apply.
+func (cfg *Config) SanitizeResponseCache() {
+	if cfg == nil {
+		return
+	}
+	rc := &cfg.ResponseCache
+	rc.Backend = strings.ToLower(strings.TrimSpace(rc.Backend))
+	if rc.Backend != "disk" {
+		rc.Backend = "memory"
+	}
+	rc.Dir = strings.TrimSpace(rc.Dir)
+	if rc.TTLSeconds < 0 {
+		rc.TTLSeconds = 0
+	}
+	if rc.MaxSizeMB < 0 {
+		rc.MaxSizeMB = 0
+	}
+}
+
+// SanitizeBatch trims the storage directory and clears negative limits so the defaults apply.
+func (cfg *Config) SanitizeBatch() {
+	if cfg == nil {
+		return
+	}
+	cfg.Batch.Dir = strings.TrimSpace(cfg.Batch.Dir)
+	if cfg.Batch.Concurrency < 0 {
+		cfg.Batch.Concurrency = 0
+	}
+	if cfg.Batch.MaxAttempts < 0 {
+		cfg.Batch.MaxAttempts = 0
+	}
+}
+
+// SanitizeModelFallbacks trims model names, drops empty chains and removes fallbacks that
+// repeat the primary model or an earlier fallback.
+func (cfg *Config) SanitizeModelFallbacks() {
+	if cfg == nil || len(cfg.ModelFallbacks) == 0 {
+		return
+	}
+	out := make([]ModelFallback, 0, len(cfg.ModelFallbacks))
+	for _, entry := range cfg.ModelFallbacks {
+		model := strings.TrimSpace(entry.Model)
+		if model == "" {
+			continue
+		}
+		seen := map[string]struct{}{strings.ToLower(model): {}}
+		fallbacks := make([]string, 0, len(entry.Fallbacks))
+		for _, fallback := range entry.Fallbacks {
+			fallback = strings.TrimSpace(fallback)
+			key := strings.ToLower(fallback)
+			if fallback == "" || strings.Contains(fallback, "*") {
+				continue
+			}
+			if _, ok := seen[key]; ok {
+				continue
+			}
+			seen[key] = struct{}{}
+			fallbacks = append(fallbacks, fallback)
+		}
+		if len(fallbacks) > 0 {
+			out = append(out, ModelFallback{Model: model, Fallbacks: fallbacks})
+		}
+	}
+	cfg.ModelFallbacks = out
+}
+
+// SanitizeCredentialPolicies normalizes provider keys, drops entries without a provider and
+// clamps negative limits. Later entries for an already configured provider are ignored.
+func (cfg *Config) SanitizeCredentialPolicies() {
+	if cfg == nil || len(cfg.Routing.CredentialPolicies) == 0 {
+		return
+	}
+	out := make([]CredentialPolicy, 0, len(cfg.Routing.CredentialPolicies))
+	seen := make(map[string]struct{}, len(cfg.Routing.CredentialPolicies))
+	for _, entry := range cfg.Routing.CredentialPolicies {
+		entry.Provider = strings.ToLower(strings.TrimSpace(entry.Provider))
+		if entry.Provider == "" {
+			continue
+		}
+		if _, ok := seen[entry.Provider]; ok {
+			continue
+		}
+		seen[entry.Provider] = struct{}{}
+		entry.MinIntervalMS = max(entry.MinIntervalMS, 0)
+		entry.MaxIntervalMS = max(entry.MaxIntervalMS, entry.MinIntervalMS)
+		entry.DailyMaxRequests = max(entry.DailyMaxRequests, 0)
+		entry.SuspendCooldownSeconds = max(entry.SuspendCooldownSeconds, 0)
+		out = append(out, entry)
+	}
+	cfg.Routing.CredentialPolicies = out
+}
+
 // SanitizeOpenAICompatibility removes OpenAI-compatibility provider entries that are
 // not actionable, specifically those missing a BaseURL. It trims whitespace before
 // evaluation and preserves the relative order of remaining entries.
@@ -894,7 +1210,7 @@ func syncInlineAccessProvider(cfg *Config) {
 	}
 	if len(cfg.APIKeys) == 0 {
 		if provider := cfg.ConfigAPIKeyProvider(); provider != nil && len(provider.APIKeys) > 0 {
-			cfg.APIKeys = append([]string(nil), provider.APIKeys...)
+			cfg.APIKeys = APIKeyEntries(provider.APIKeys...)
 		}
 	}
 	cfg.Access.Providers = nil
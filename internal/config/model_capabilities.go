@@ -0,0 +1,69 @@
+package config
+
+import (
+	"strconv"
+	"strings"
+)
+
+// ModelCapabilityConfig declares the limits and features of a configured model.
+// Unset fields fall back to the static catalog entry of the upstream model name, when one
+// exists. Features that are neither declared nor known from the catalog are assumed to be
+// supported, and models without any known capabilities are not validated before dispatch.
+type ModelCapabilityConfig struct {
+	// ContextLength is the maximum number of prompt tokens the model accepts.
+	ContextLength int `yaml:"context-length,omitempty" json:"context-length,omitempty"`
+
+	// MaxOutputTokens is the maximum number of tokens the model generates per request.
+	MaxOutputTokens int `yaml:"max-output-tokens,omitempty" json:"max-output-tokens,omitempty"`
+
+	// Vision declares whether the model accepts image inputs.
+	Vision *bool `yaml:"vision,omitempty" json:"vision,omitempty"`
+
+	// Audio declares whether the model accepts audio inputs.
+	Audio *bool `yaml:"audio,omitempty" json:"audio,omitempty"`
+
+	// ToolUse declares whether the model accepts tool declarations and tool calls.
+	ToolUse *bool `yaml:"tool-use,omitempty" json:"tool-use,omitempty"`
+
+	// JSONSchema declares whether the model accepts schema-constrained JSON output requests.
+	JSONSchema *bool `yaml:"json-schema,omitempty" json:"json-schema,omitempty"`
+
+	// Tokenizer selects the local tokenizer used to count tokens for the model:
+	// "o200k_base", "cl100k_base", "claude" or "gemini". Empty selects one by model family.
+	Tokenizer string `yaml:"tokenizer,omitempty" json:"tokenizer,omitempty"`
+}
+
+// GetCapabilities returns the capability declaration of a configured model.
+func (c ModelCapabilityConfig) GetCapabilities() ModelCapabilityConfig { return c }
+
+// isEmpty reports whether no capability or limit is declared.
+func (c ModelCapabilityConfig) isEmpty() bool {
+	return c.ContextLength <= 0 && c.MaxOutputTokens <= 0 &&
+		c.Vision == nil && c.Audio == nil && c.ToolUse == nil && c.JSONSchema == nil &&
+		strings.TrimSpace(c.Tokenizer) == ""
+}
+
+// Key returns a stable representation of the declaration for change detection.
+// It is empty when nothing is declared, so model hashes of plain aliases are unchanged.
+func (c ModelCapabilityConfig) Key() string {
+	if c.isEmpty() {
+		return ""
+	}
+	parts := []string{
+		"ctx=" + strconv.Itoa(c.ContextLength),
+		"out=" + strconv.Itoa(c.MaxOutputTokens),
+		"vision=" + boolKey(c.Vision),
+		"audio=" + boolKey(c.Audio),
+		"tools=" + boolKey(c.ToolUse),
+		"schema=" + boolKey(c.JSONSchema),
+		"tokenizer=" + strings.ToLower(strings.TrimSpace(c.Tokenizer)),
+	}
+	return "|" + strings.Join(parts, ",")
+}
+
+func boolKey(v *bool) string {
+	if v == nil {
+		return "-"
+	}
+	return strconv.FormatBool(*v)
+}
@@ -4,6 +4,13 @@
 // debug settings, proxy configuration, and API keys.
 package config
 
+import (
+	"encoding/json"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
 // SDKConfig represents the application's configuration, loaded from a YAML file.
 type SDKConfig struct {
 	// ProxyURL is the URL of an optional proxy server to use for outbound requests.
@@ -17,18 +24,200 @@ type SDKConfig struct {
 	// RequestLog enables or disables detailed request logging functionality.
 	RequestLog bool `yaml:"request-log" json:"request-log"`
 
-	// APIKeys is a list of keys for authenticating clients to this proxy server.
-	APIKeys []string `yaml:"api-keys" json:"api-keys"`
+	// APIKeys is a list of keys for authenticating clients to this proxy server. Entries are
+	// plain key strings, or mappings that attach a name, rate limits and allow-lists to the key.
+	APIKeys []APIKeyEntry `yaml:"api-keys" json:"api-keys"`
 
 	// Access holds request authentication provider configuration.
 	Access AccessConfig `yaml:"auth,omitempty" json:"auth,omitempty"`
 
-	// Streaming configures server-side streaming behavior (keep-alives and safe bootstrap retries).
+	// Streaming configures server-side streaming behavior (keep-alives, safe bootstrap retries and mid-stream continuation).
 	Streaming StreamingConfig `yaml:"streaming" json:"streaming"`
 
 	// NonStreamKeepAliveInterval controls how often blank lines are emitted for non-streaming responses.
 	// <= 0 disables keep-alives. Value is in seconds.
 	NonStreamKeepAliveInterval int `yaml:"nonstream-keepalive-interval,omitempty" json:"nonstream-keepalive-interval,omitempty"`
+
+	// RequestDedup coalesces concurrent identical requests into a single upstream call whose
+	// result (including stream chunks) is fanned out to every waiting client.
+	RequestDedup bool `yaml:"request-dedup,omitempty" json:"request-dedup,omitempty"`
+
+	// TokenCounting selects how count_tokens requests are answered: "upstream" (default) asks
+	// the provider, "local" counts with the offline tokenizer without using a credential, and
+	// "fallback" asks the provider and counts locally when that fails.
+	TokenCounting string `yaml:"token-counting,omitempty" json:"token-counting,omitempty"`
+}
+
+// Token counting modes accepted by SDKConfig.TokenCounting.
+const (
+	TokenCountingUpstream = "upstream"
+	TokenCountingLocal    = "local"
+	TokenCountingFallback = "fallback"
+)
+
+// TokenCountingMode returns the normalized token counting mode, defaulting to upstream.
+func (c *SDKConfig) TokenCountingMode() string {
+	if c == nil {
+		return TokenCountingUpstream
+	}
+	switch mode := strings.ToLower(strings.TrimSpace(c.TokenCounting)); mode {
+	case TokenCountingLocal, TokenCountingFallback:
+		return mode
+	default:
+		return TokenCountingUpstream
+	}
+}
+
+// APIKeyEntry is a client API key together with the limits enforced for it.
+// It is written as a plain string when no limit or allow-list is set.
+type APIKeyEntry struct {
+	// APIKey is the client key.
+	APIKey string `yaml:"api-key" json:"api-key"`
+
+	// Name is a human readable label for the key owner.
+	Name string `yaml:"name,omitempty" json:"name,omitempty"`
+
+	// RequestsPerMinute caps requests in any rolling 60 second window. <= 0 disables the limit.
+	RequestsPerMinute int `yaml:"requests-per-minute,omitempty" json:"requests-per-minute,omitempty"`
+
+	// TokensPerDay caps total tokens consumed per UTC day. <= 0 disables the limit.
+	TokensPerDay int64 `yaml:"tokens-per-day,omitempty" json:"tokens-per-day,omitempty"`
+
+	// AllowedModels lists model name globs ("*" wildcard) the key may use. Empty allows all models.
+	AllowedModels []string `yaml:"allowed-models,omitempty" json:"allowed-models,omitempty"`
+
+	// AllowedProviders lists provider keys (e.g. "claude", "gemini") the key may be routed to.
+	// Empty allows all providers.
+	AllowedProviders []string `yaml:"allowed-providers,omitempty" json:"allowed-providers,omitempty"`
+
+	// DisableResponseCache keeps the key's requests out of the response cache.
+	DisableResponseCache bool `yaml:"disable-response-cache,omitempty" json:"disable-response-cache,omitempty"`
+}
+
+// apiKeyEntryFields is APIKeyEntry without its custom (un)marshalers.
+type apiKeyEntryFields APIKeyEntry
+
+// HasPolicy reports whether the entry sets a name, a limit, an allow-list or a cache opt-out.
+func (e APIKeyEntry) HasPolicy() bool {
+	return e.Name != "" || e.RequestsPerMinute > 0 || e.TokensPerDay > 0 ||
+		len(e.AllowedModels) > 0 || len(e.AllowedProviders) > 0 || e.DisableResponseCache
+}
+
+// UnmarshalYAML accepts a plain key string or a mapping.
+func (e *APIKeyEntry) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		*e = APIKeyEntry{APIKey: node.Value}
+		return nil
+	}
+	var fields apiKeyEntryFields
+	if err := node.Decode(&fields); err != nil {
+		return err
+	}
+	*e = APIKeyEntry(fields)
+	return nil
+}
+
+// MarshalYAML writes entries without a policy as plain strings.
+func (e APIKeyEntry) MarshalYAML() (any, error) {
+	if !e.HasPolicy() {
+		return e.APIKey, nil
+	}
+	return apiKeyEntryFields(e), nil
+}
+
+// UnmarshalJSON accepts a plain key string or an object.
+func (e *APIKeyEntry) UnmarshalJSON(data []byte) error {
+	var key string
+	if err := json.Unmarshal(data, &key); err == nil {
+		*e = APIKeyEntry{APIKey: key}
+		return nil
+	}
+	var fields apiKeyEntryFields
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+	*e = APIKeyEntry(fields)
+	return nil
+}
+
+// MarshalJSON writes entries without a policy as plain strings.
+func (e APIKeyEntry) MarshalJSON() ([]byte, error) {
+	if !e.HasPolicy() {
+		return json.Marshal(e.APIKey)
+	}
+	return json.Marshal(apiKeyEntryFields(e))
+}
+
+// APIKeyEntries wraps plain client keys into entries without a policy.
+func APIKeyEntries(keys ...string) []APIKeyEntry {
+	if len(keys) == 0 {
+		return nil
+	}
+	entries := make([]APIKeyEntry, len(keys))
+	for i, key := range keys {
+		entries[i] = APIKeyEntry{APIKey: key}
+	}
+	return entries
+}
+
+// APIKeyValues returns the client keys listed in APIKeys.
+func (c *SDKConfig) APIKeyValues() []string {
+	if c == nil || len(c.APIKeys) == 0 {
+		return nil
+	}
+	keys := make([]string, len(c.APIKeys))
+	for i := range c.APIKeys {
+		keys[i] = c.APIKeys[i].APIKey
+	}
+	return keys
+}
+
+// APIKeyPolicyFor returns the entry of the given client key when it carries a policy, or nil.
+func (c *SDKConfig) APIKeyPolicyFor(apiKey string) *APIKeyEntry {
+	if c == nil || apiKey == "" {
+		return nil
+	}
+	for i := range c.APIKeys {
+		if c.APIKeys[i].APIKey == apiKey {
+			if c.APIKeys[i].HasPolicy() {
+				return &c.APIKeys[i]
+			}
+			return nil
+		}
+	}
+	return nil
+}
+
+// SanitizeAPIKeys trims the policy fields of the client key entries.
+func (c *SDKConfig) SanitizeAPIKeys() {
+	if c == nil {
+		return
+	}
+	for i := range c.APIKeys {
+		entry := &c.APIKeys[i]
+		entry.Name = strings.TrimSpace(entry.Name)
+		entry.AllowedModels = trimNonEmpty(entry.AllowedModels)
+		entry.AllowedProviders = trimNonEmpty(entry.AllowedProviders)
+		for j := range entry.AllowedProviders {
+			entry.AllowedProviders[j] = strings.ToLower(entry.AllowedProviders[j])
+		}
+	}
+}
+
+func trimNonEmpty(values []string) []string {
+	if len(values) == 0 {
+		return nil
+	}
+	out := make([]string, 0, len(values))
+	for _, value := range values {
+		if trimmed := strings.TrimSpace(value); trimmed != "" {
+			out = append(out, trimmed)
+		}
+	}
+	if len(out) == 0 {
+		return nil
+	}
+	return out
 }
 
 // StreamingConfig holds server streaming behavior configuration.
@@ -41,6 +230,12 @@ type StreamingConfig struct {
 	// to allow auth rotation / transient recovery.
 	// <= 0 disables bootstrap retries. Default is 0.
 	BootstrapRetries int `yaml:"bootstrap-retries,omitempty" json:"bootstrap-retries,omitempty"`
+
+	// ContinuationRetries controls how many times a stream that fails after bytes were sent may be resumed
+	// on another credential, with the partial assistant output sent back as a prefill.
+	// Supported for OpenAI chat completions, Claude messages and Gemini SSE streams.
+	// <= 0 disables mid-stream continuation. Default is 0.
+	ContinuationRetries int `yaml:"continuation-retries,omitempty" json:"continuation-retries,omitempty"`
 }
 
 // AccessConfig groups request authentication providers.
@@ -0,0 +1,76 @@
+package config
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestAPIKeyEntries_YAMLAcceptsStringsAndMappings(t *testing.T) {
+	src := `
+api-keys:
+  - "plain-key"
+  - api-key: "team-key"
+    name: " team "
+    requests-per-minute: 5
+    allowed-providers: [" Gemini "]
+`
+	var cfg SDKConfig
+	if err := yaml.Unmarshal([]byte(src), &cfg); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	cfg.SanitizeAPIKeys()
+
+	want := []APIKeyEntry{
+		{APIKey: "plain-key"},
+		{APIKey: "team-key", Name: "team", RequestsPerMinute: 5, AllowedProviders: []string{"gemini"}},
+	}
+	if !reflect.DeepEqual(cfg.APIKeys, want) {
+		t.Fatalf("api-keys = %+v, want %+v", cfg.APIKeys, want)
+	}
+	if got := cfg.APIKeyValues(); !reflect.DeepEqual(got, []string{"plain-key", "team-key"}) {
+		t.Fatalf("APIKeyValues() = %v", got)
+	}
+	if cfg.APIKeyPolicyFor("plain-key") != nil {
+		t.Fatal("plain key should carry no policy")
+	}
+	if policy := cfg.APIKeyPolicyFor("team-key"); policy == nil || policy.RequestsPerMinute != 5 {
+		t.Fatalf("APIKeyPolicyFor(team-key) = %+v", policy)
+	}
+
+	out, err := yaml.Marshal(&cfg)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	if !strings.Contains(string(out), "- plain-key\n") {
+		t.Fatalf("plain key should be written as a string, got:\n%s", out)
+	}
+	var again SDKConfig
+	if err = yaml.Unmarshal(out, &again); err != nil {
+		t.Fatalf("unmarshal round trip: %v", err)
+	}
+	if !reflect.DeepEqual(again.APIKeys, want) {
+		t.Fatalf("round trip api-keys = %+v, want %+v", again.APIKeys, want)
+	}
+}
+
+func TestAPIKeyEntries_JSONAcceptsStringsAndObjects(t *testing.T) {
+	var entries []APIKeyEntry
+	if err := json.Unmarshal([]byte(`["plain-key",{"api-key":"team-key","tokens-per-day":100}]`), &entries); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	want := []APIKeyEntry{{APIKey: "plain-key"}, {APIKey: "team-key", TokensPerDay: 100}}
+	if !reflect.DeepEqual(entries, want) {
+		t.Fatalf("entries = %+v, want %+v", entries, want)
+	}
+	out, err := json.Marshal(entries)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	if string(out) != `["plain-key",{"api-key":"team-key","tokens-per-day":100}]` {
+		t.Fatalf("marshal = %s", out)
+	}
+}
@@ -47,6 +47,9 @@ type VertexCompatModel struct {
 
 	// Alias is the model name alias that clients will use to reference this model.
 	Alias string `yaml:"alias" json:"alias"`
+
+	// ModelCapabilityConfig optionally declares the limits and features of the model.
+	ModelCapabilityConfig `yaml:",inline"`
 }
 
 func (m VertexCompatModel) GetName() string  { return m.Name }
@@ -14,12 +14,15 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
 	log "github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // aiAPIPrefixes defines path prefixes for AI API requests that should have request ID tracking.
 var aiAPIPrefixes = []string{
 	"/v1/chat/completions",
 	"/v1/completions",
+	"/v1/embeddings",
+	"/v1/images/",
 	"/v1/messages",
 	"/v1/responses",
 	"/v1beta/models/",
@@ -83,6 +86,9 @@ func GinLogrusLogger() gin.HandlerFunc {
 		}
 
 		entry := log.WithField("request_id", requestID)
+		if spanCtx := trace.SpanContextFromContext(c.Request.Context()); spanCtx.HasTraceID() {
+			entry = entry.WithField("trace_id", spanCtx.TraceID().String())
+		}
 
 		switch {
 		case statusCode >= http.StatusInternalServerError:
@@ -0,0 +1,427 @@
+// Package metrics collects proxy runtime metrics and renders them in the Prometheus
+// text exposition format. The collector observes executor attempts through the auth
+// manager's execution hooks and token usage through the usage plugin pipeline.
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	coreusage "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
+)
+
+var (
+	latencyBuckets = []float64{0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60, 120, 300}
+	ttfcBuckets    = []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30}
+)
+
+var defaultCollector = NewCollector()
+
+func init() {
+	coreusage.RegisterPlugin(defaultCollector)
+}
+
+// Default returns the process wide collector.
+func Default() *Collector { return defaultCollector }
+
+type requestKey struct {
+	handler  string
+	provider string
+	model    string
+	status   string
+}
+
+type streamKey struct {
+	handler  string
+	provider string
+	model    string
+}
+
+type tokenKey struct {
+	provider string
+	model    string
+	kind     string
+}
+
+type histogram struct {
+	counts []uint64
+	sum    float64
+	count  uint64
+}
+
+func (h *histogram) observe(bounds []float64, value float64) {
+	if h.counts == nil {
+		h.counts = make([]uint64, len(bounds))
+	}
+	for i, bound := range bounds {
+		if value <= bound {
+			h.counts[i]++
+		}
+	}
+	h.sum += value
+	h.count++
+}
+
+type attemptState struct {
+	started    time.Time
+	firstChunk bool
+}
+
+// Collector aggregates request, token, retry and stream metrics.
+// It implements coreauth.ExecutionHook, coreauth.RetryObserver and coreusage.Plugin.
+type Collector struct {
+	mu        sync.Mutex
+	requests  map[requestKey]uint64
+	latency   map[requestKey]*histogram
+	ttfc      map[streamKey]*histogram
+	tokens    map[tokenKey]int64
+	retries   map[string]uint64
+	inflight  map[string]int64
+	attempts  map[*coreauth.ExecutionAttempt]*attemptState
+	startedAt time.Time
+	now       func() time.Time
+}
+
+// NewCollector constructs an empty collector.
+func NewCollector() *Collector {
+	return &Collector{
+		requests:  make(map[requestKey]uint64),
+		latency:   make(map[requestKey]*histogram),
+		ttfc:      make(map[streamKey]*histogram),
+		tokens:    make(map[tokenKey]int64),
+		retries:   make(map[string]uint64),
+		inflight:  make(map[string]int64),
+		attempts:  make(map[*coreauth.ExecutionAttempt]*attemptState),
+		startedAt: time.Now(),
+		now:       time.Now,
+	}
+}
+
+// BeforeAttempt implements coreauth.ExecutionHook.
+func (c *Collector) BeforeAttempt(_ context.Context, attempt *coreauth.ExecutionAttempt) {
+	if c == nil || attempt == nil {
+		return
+	}
+	c.mu.Lock()
+	c.attempts[attempt] = &attemptState{started: c.now()}
+	c.inflight[attempt.Provider]++
+	c.mu.Unlock()
+}
+
+// OnAttemptChunk implements coreauth.ExecutionHook. The first chunk of a stream records time-to-first-chunk.
+func (c *Collector) OnAttemptChunk(_ context.Context, attempt *coreauth.ExecutionAttempt, chunk cliproxyexecutor.StreamChunk) {
+	if c == nil || attempt == nil || chunk.Err != nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	state := c.attempts[attempt]
+	if state == nil || state.firstChunk {
+		return
+	}
+	state.firstChunk = true
+	key := streamKey{handler: handlerLabel(attempt), provider: attempt.Provider, model: attempt.Model}
+	h := c.ttfc[key]
+	if h == nil {
+		h = &histogram{}
+		c.ttfc[key] = h
+	}
+	h.observe(ttfcBuckets, c.now().Sub(state.started).Seconds())
+}
+
+// AfterAttempt implements coreauth.ExecutionHook.
+func (c *Collector) AfterAttempt(_ context.Context, attempt *coreauth.ExecutionAttempt, _ cliproxyexecutor.Response, err error) {
+	if c == nil || attempt == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	state := c.attempts[attempt]
+	delete(c.attempts, attempt)
+	if state == nil {
+		return
+	}
+	if c.inflight[attempt.Provider] > 1 {
+		c.inflight[attempt.Provider]--
+	} else {
+		delete(c.inflight, attempt.Provider)
+	}
+	key := requestKey{handler: handlerLabel(attempt), provider: attempt.Provider, model: attempt.Model, status: statusLabel(err)}
+	c.requests[key]++
+	h := c.latency[key]
+	if h == nil {
+		h = &histogram{}
+		c.latency[key] = h
+	}
+	h.observe(latencyBuckets, c.now().Sub(state.started).Seconds())
+}
+
+// OnRetry implements coreauth.RetryObserver.
+func (c *Collector) OnRetry(_ context.Context, model string, _ int, _ time.Duration, _ error) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	c.retries[model]++
+	c.mu.Unlock()
+}
+
+// HandleUsage implements coreusage.Plugin.
+func (c *Collector) HandleUsage(_ context.Context, record coreusage.Record) {
+	if c == nil {
+		return
+	}
+	detail := record.Detail
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for kind, value := range map[string]int64{
+		"input":     detail.InputTokens,
+		"output":    detail.OutputTokens,
+		"reasoning": detail.ReasoningTokens,
+		"cached":    detail.CachedTokens,
+	} {
+		if value <= 0 {
+			continue
+		}
+		c.tokens[tokenKey{provider: record.Provider, model: record.Model, kind: kind}] += value
+	}
+}
+
+func handlerLabel(attempt *coreauth.ExecutionAttempt) string {
+	if handler := attempt.Options.SourceFormat.String(); handler != "" {
+		return handler
+	}
+	return "unknown"
+}
+
+func statusLabel(err error) string {
+	if err == nil {
+		return "200"
+	}
+	if se, ok := err.(interface{ StatusCode() int }); ok && se != nil {
+		if code := se.StatusCode(); code > 0 {
+			return strconv.Itoa(code)
+		}
+	}
+	return "error"
+}
+
+// WriteText renders all metrics in the Prometheus text exposition format. auths supplies
+// the credentials whose state gauges are reported.
+func (c *Collector) WriteText(w io.Writer, auths []*coreauth.Auth) error {
+	if c == nil {
+		return nil
+	}
+	b := &strings.Builder{}
+	now := c.now()
+
+	c.mu.Lock()
+	writeHeader(b, "cliproxy_upstream_requests_total", "counter", "Executor attempts by inbound handler, provider, model and status.")
+	for _, key := range sortedRequestKeys(c.requests) {
+		writeSample(b, "cliproxy_upstream_requests_total", requestLabels(key), float64(c.requests[key]))
+	}
+	writeHeader(b, "cliproxy_upstream_request_duration_seconds", "histogram", "Executor attempt latency in seconds.")
+	for _, key := range sortedRequestKeys(c.latency) {
+		writeHistogram(b, "cliproxy_upstream_request_duration_seconds", requestLabels(key), latencyBuckets, c.latency[key])
+	}
+	writeHeader(b, "cliproxy_stream_first_chunk_seconds", "histogram", "Time from dispatch to the first streamed chunk in seconds.")
+	streamKeys := make([]streamKey, 0, len(c.ttfc))
+	for key := range c.ttfc {
+		streamKeys = append(streamKeys, key)
+	}
+	sort.Slice(streamKeys, func(i, j int) bool {
+		return fmt.Sprint(streamKeys[i]) < fmt.Sprint(streamKeys[j])
+	})
+	for _, key := range streamKeys {
+		labels := [][2]string{{"handler", key.handler}, {"provider", key.provider}, {"model", key.model}}
+		writeHistogram(b, "cliproxy_stream_first_chunk_seconds", labels, ttfcBuckets, c.ttfc[key])
+	}
+	writeHeader(b, "cliproxy_tokens_total", "counter", "Tokens reported by upstream usage by provider, model and kind.")
+	tokenKeys := make([]tokenKey, 0, len(c.tokens))
+	for key := range c.tokens {
+		tokenKeys = append(tokenKeys, key)
+	}
+	sort.Slice(tokenKeys, func(i, j int) bool {
+		return fmt.Sprint(tokenKeys[i]) < fmt.Sprint(tokenKeys[j])
+	})
+	for _, key := range tokenKeys {
+		labels := [][2]string{{"provider", key.provider}, {"model", key.model}, {"kind", key.kind}}
+		writeSample(b, "cliproxy_tokens_total", labels, float64(c.tokens[key]))
+	}
+	writeHeader(b, "cliproxy_retries_total", "counter", "Cooldown retry rounds scheduled by the auth manager.")
+	for _, model := range sortedKeys(c.retries) {
+		writeSample(b, "cliproxy_retries_total", [][2]string{{"model", model}}, float64(c.retries[model]))
+	}
+	writeHeader(b, "cliproxy_inflight_requests", "gauge", "Executor attempts currently in flight by provider.")
+	for _, provider := range sortedKeys(c.inflight) {
+		writeSample(b, "cliproxy_inflight_requests", [][2]string{{"provider", provider}}, float64(c.inflight[provider]))
+	}
+	uptime := now.Sub(c.startedAt).Seconds()
+	c.mu.Unlock()
+
+	writeAuthMetrics(b, auths, now)
+	writeHeader(b, "cliproxy_uptime_seconds", "gauge", "Seconds since the metrics collector started.")
+	writeSample(b, "cliproxy_uptime_seconds", nil, uptime)
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func writeAuthMetrics(b *strings.Builder, auths []*coreauth.Auth, now time.Time) {
+	sorted := make([]*coreauth.Auth, 0, len(auths))
+	for _, auth := range auths {
+		if auth != nil {
+			sorted = append(sorted, auth)
+		}
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].ID < sorted[j].ID })
+
+	writeHeader(b, "cliproxy_auth_state", "gauge", "Credential state (1 for the current state) by provider and auth index.")
+	for _, auth := range sorted {
+		current := authState(auth, now)
+		for _, state := range []string{"active", "disabled", "unavailable"} {
+			value := 0.0
+			if state == current {
+				value = 1
+			}
+			writeSample(b, "cliproxy_auth_state", [][2]string{{"provider", auth.Provider}, {"auth_index", auth.EnsureIndex()}, {"state", state}}, value)
+		}
+	}
+	writeHeader(b, "cliproxy_auth_cooldown_seconds", "gauge", "Seconds until a cooling down credential recovers (QuotaState.NextRecoverAt).")
+	for _, auth := range sorted {
+		writeSample(b, "cliproxy_auth_cooldown_seconds", [][2]string{{"provider", auth.Provider}, {"auth_index", auth.EnsureIndex()}}, remaining(auth.Quota.NextRecoverAt, now))
+	}
+	writeHeader(b, "cliproxy_auth_model_cooldown_seconds", "gauge", "Seconds until a credential recovers for a specific model.")
+	for _, auth := range sorted {
+		models := make([]string, 0, len(auth.ModelStates))
+		for model := range auth.ModelStates {
+			models = append(models, model)
+		}
+		sort.Strings(models)
+		for _, model := range models {
+			state := auth.ModelStates[model]
+			if state == nil {
+				continue
+			}
+			wait := remaining(state.Quota.NextRecoverAt, now)
+			if retry := remaining(state.NextRetryAfter, now); retry > wait {
+				wait = retry
+			}
+			if wait <= 0 {
+				continue
+			}
+			writeSample(b, "cliproxy_auth_model_cooldown_seconds", [][2]string{{"provider", auth.Provider}, {"auth_index", auth.EnsureIndex()}, {"model", model}}, wait)
+		}
+	}
+}
+
+func authState(auth *coreauth.Auth, now time.Time) string {
+	if auth.Disabled || auth.Status == coreauth.StatusDisabled {
+		return "disabled"
+	}
+	if auth.Unavailable && (auth.NextRetryAfter.IsZero() || auth.NextRetryAfter.After(now)) {
+		return "unavailable"
+	}
+	if auth.Quota.Exceeded && auth.Quota.NextRecoverAt.After(now) {
+		return "unavailable"
+	}
+	return "active"
+}
+
+func remaining(until, now time.Time) float64 {
+	if until.IsZero() || !until.After(now) {
+		return 0
+	}
+	return math.Ceil(until.Sub(now).Seconds())
+}
+
+func requestLabels(key requestKey) [][2]string {
+	return [][2]string{{"handler", key.handler}, {"provider", key.provider}, {"model", key.model}, {"status", key.status}}
+}
+
+func sortedRequestKeys[V any](m map[requestKey]V) []requestKey {
+	keys := make([]requestKey, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		a, b := keys[i], keys[j]
+		if a.handler != b.handler {
+			return a.handler < b.handler
+		}
+		if a.provider != b.provider {
+			return a.provider < b.provider
+		}
+		if a.model != b.model {
+			return a.model < b.model
+		}
+		return a.status < b.status
+	})
+	return keys
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func writeHeader(b *strings.Builder, name, kind, help string) {
+	fmt.Fprintf(b, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+}
+
+func writeSample(b *strings.Builder, name string, labels [][2]string, value float64) {
+	b.WriteString(name)
+	writeLabels(b, labels)
+	b.WriteByte(' ')
+	b.WriteString(strconv.FormatFloat(value, 'g', -1, 64))
+	b.WriteByte('\n')
+}
+
+func writeHistogram(b *strings.Builder, name string, labels [][2]string, bounds []float64, h *histogram) {
+	if h == nil {
+		return
+	}
+	for i, bound := range bounds {
+		var count uint64
+		if i < len(h.counts) {
+			count = h.counts[i]
+		}
+		withLE := append(append([][2]string(nil), labels...), [2]string{"le", strconv.FormatFloat(bound, 'g', -1, 64)})
+		writeSample(b, name+"_bucket", withLE, float64(count))
+	}
+	writeSample(b, name+"_bucket", append(append([][2]string(nil), labels...), [2]string{"le", "+Inf"}), float64(h.count))
+	writeSample(b, name+"_sum", labels, h.sum)
+	writeSample(b, name+"_count", labels, float64(h.count))
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func writeLabels(b *strings.Builder, labels [][2]string) {
+	if len(labels) == 0 {
+		return
+	}
+	b.WriteByte('{')
+	for i, label := range labels {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(label[0])
+		b.WriteString(`="`)
+		b.WriteString(labelEscaper.Replace(label[1]))
+		b.WriteByte('"')
+	}
+	b.WriteByte('}')
+}
@@ -0,0 +1,71 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	coreusage "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
+)
+
+type statusErr struct{ code int }
+
+func (e statusErr) Error() string   { return "status error" }
+func (e statusErr) StatusCode() int { return e.code }
+
+func TestCollectorWriteText(t *testing.T) {
+	now := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	c := NewCollector()
+	c.now = func() time.Time { return now }
+	c.startedAt = now
+	ctx := context.Background()
+	auth := &coreauth.Auth{ID: "a1", Provider: "claude"}
+
+	stream := &coreauth.ExecutionAttempt{Auth: auth, Provider: "claude", Model: "claude-sonnet-4-5", Options: cliproxyexecutor.Options{SourceFormat: "openai"}}
+	c.BeforeAttempt(ctx, stream)
+	now = now.Add(300 * time.Millisecond)
+	c.OnAttemptChunk(ctx, stream, cliproxyexecutor.StreamChunk{Payload: []byte("a")})
+	now = now.Add(time.Second)
+	c.OnAttemptChunk(ctx, stream, cliproxyexecutor.StreamChunk{Payload: []byte("b")})
+	c.AfterAttempt(ctx, stream, cliproxyexecutor.Response{}, nil)
+
+	failed := &coreauth.ExecutionAttempt{Auth: auth, Provider: "claude", Model: "claude-sonnet-4-5", Options: cliproxyexecutor.Options{SourceFormat: "claude"}}
+	c.BeforeAttempt(ctx, failed)
+	c.AfterAttempt(ctx, failed, cliproxyexecutor.Response{}, statusErr{code: 429})
+	c.OnRetry(ctx, "claude-sonnet-4-5", 0, time.Second, errors.New("boom"))
+	c.HandleUsage(ctx, coreusage.Record{Provider: "claude", Model: "claude-sonnet-4-5", Detail: coreusage.Detail{InputTokens: 12, OutputTokens: 3}})
+
+	cooling := &coreauth.Auth{ID: "a2", Provider: "gemini", Quota: coreauth.QuotaState{Exceeded: true, NextRecoverAt: now.Add(90 * time.Second)}}
+	disabled := &coreauth.Auth{ID: "a3", Provider: "codex", Disabled: true}
+	idx2, idx3 := cooling.EnsureIndex(), disabled.EnsureIndex()
+
+	var out strings.Builder
+	if err := c.WriteText(&out, []*coreauth.Auth{cooling, disabled}); err != nil {
+		t.Fatalf("WriteText: %v", err)
+	}
+	text := out.String()
+	for _, want := range []string{
+		`cliproxy_upstream_requests_total{handler="openai",provider="claude",model="claude-sonnet-4-5",status="200"} 1`,
+		`cliproxy_upstream_requests_total{handler="claude",provider="claude",model="claude-sonnet-4-5",status="429"} 1`,
+		`cliproxy_upstream_request_duration_seconds_bucket{handler="openai",provider="claude",model="claude-sonnet-4-5",status="200",le="2.5"} 1`,
+		`cliproxy_stream_first_chunk_seconds_bucket{handler="openai",provider="claude",model="claude-sonnet-4-5",le="0.25"} 0`,
+		`cliproxy_stream_first_chunk_seconds_bucket{handler="openai",provider="claude",model="claude-sonnet-4-5",le="0.5"} 1`,
+		`cliproxy_stream_first_chunk_seconds_count{handler="openai",provider="claude",model="claude-sonnet-4-5"} 1`,
+		`cliproxy_tokens_total{provider="claude",model="claude-sonnet-4-5",kind="input"} 12`,
+		`cliproxy_retries_total{model="claude-sonnet-4-5"} 1`,
+		`cliproxy_auth_state{provider="gemini",auth_index="` + idx2 + `",state="unavailable"} 1`,
+		`cliproxy_auth_state{provider="codex",auth_index="` + idx3 + `",state="disabled"} 1`,
+		`cliproxy_auth_cooldown_seconds{provider="gemini",auth_index="` + idx2 + `"} 90`,
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("missing %q in output:\n%s", want, text)
+		}
+	}
+	if strings.Contains(text, "cliproxy_inflight_requests{") {
+		t.Errorf("finished attempts should not be reported in flight:\n%s", text)
+	}
+}
@@ -0,0 +1,53 @@
+package registry
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestConvertModelToMapListsCapabilities(t *testing.T) {
+	r := newTestModelRegistry()
+	model := &ModelInfo{
+		ID:               "caps-model",
+		InputTokenLimit:  1048576,
+		OutputTokenLimit: 65536,
+		Thinking:         &ThinkingSupport{Levels: []string{"low", "high"}},
+		Capabilities:     &ModelCapabilities{Vision: true, ToolUse: true},
+	}
+	want := map[string]any{
+		"vision":            true,
+		"audio":             false,
+		"tool_use":          true,
+		"json_schema":       false,
+		"thinking":          true,
+		"context_window":    1048576,
+		"max_output_tokens": 65536,
+		"thinking_levels":   []string{"low", "high"},
+	}
+	for _, handlerType := range []string{"openai", "claude"} {
+		got := r.convertModelToMap(model, handlerType)["capabilities"]
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("%s capabilities = %#v, want %#v", handlerType, got, want)
+		}
+	}
+
+	model.Capabilities = nil
+	if _, ok := r.convertModelToMap(model, "openai")["capabilities"]; ok {
+		t.Fatal("models without capabilities must not list them")
+	}
+}
+
+func TestStaticModelsDeclareCapabilities(t *testing.T) {
+	for _, id := range []string{"claude-sonnet-4-5-20250929", "gemini-2.5-pro", "gpt-5", "vision-model"} {
+		info := LookupStaticModelInfo(id)
+		if info == nil || info.Capabilities == nil {
+			t.Fatalf("static model %s has no capabilities", id)
+		}
+		if info.ContextWindow() <= 0 || info.MaxOutputTokens() <= 0 {
+			t.Fatalf("static model %s has no token limits", id)
+		}
+	}
+	if info := LookupStaticModelInfo("imagen-4.0-generate-001"); info == nil || info.Capabilities == nil || info.Capabilities.ToolUse {
+		t.Fatal("imagen models must not accept tools")
+	}
+}
@@ -2,6 +2,18 @@
 // This file stores the static model metadata catalog.
 package registry
 
+// Capability sets shared by the static catalog. Requests are checked against them before
+// dispatch; entries without capabilities, such as embedding models, are not checked.
+var (
+	claudeCapabilities      = &ModelCapabilities{Vision: true, ToolUse: true, JSONSchema: true}
+	geminiCapabilities      = &ModelCapabilities{Vision: true, Audio: true, ToolUse: true, JSONSchema: true}
+	geminiImageCapabilities = &ModelCapabilities{Vision: true}
+	imagenCapabilities      = &ModelCapabilities{}
+	openAICapabilities      = &ModelCapabilities{Vision: true, ToolUse: true, JSONSchema: true}
+	textCapabilities        = &ModelCapabilities{ToolUse: true, JSONSchema: true}
+	visionCapabilities      = &ModelCapabilities{Vision: true, ToolUse: true, JSONSchema: true}
+)
+
 // GetClaudeModels returns the standard Claude model definitions
 func GetClaudeModels() []*ModelInfo {
 	return []*ModelInfo{
@@ -16,6 +28,7 @@ func GetClaudeModels() []*ModelInfo {
 			ContextLength:       200000,
 			MaxCompletionTokens: 64000,
 			Thinking:            &ThinkingSupport{Min: 1024, Max: 128000, ZeroAllowed: true, DynamicAllowed: false},
+			Capabilities:        claudeCapabilities,
 		},
 		{
 			ID:                  "claude-sonnet-4-5-20250929",
@@ -27,6 +40,7 @@ func GetClaudeModels() []*ModelInfo {
 			ContextLength:       200000,
 			MaxCompletionTokens: 64000,
 			Thinking:            &ThinkingSupport{Min: 1024, Max: 128000, ZeroAllowed: true, DynamicAllowed: false},
+			Capabilities:        claudeCapabilities,
 		},
 		{
 			ID:                  "claude-opus-4-6",
@@ -39,6 +53,7 @@ func GetClaudeModels() []*ModelInfo {
 			ContextLength:       1000000,
 			MaxCompletionTokens: 128000,
 			Thinking:            &ThinkingSupport{Min: 1024, Max: 128000, ZeroAllowed: true, DynamicAllowed: false},
+			Capabilities:        claudeCapabilities,
 		},
 		{
 			ID:                  "claude-opus-4-5-20251101",
@@ -51,6 +66,7 @@ func GetClaudeModels() []*ModelInfo {
 			ContextLength:       200000,
 			MaxCompletionTokens: 64000,
 			Thinking:            &ThinkingSupport{Min: 1024, Max: 128000, ZeroAllowed: true, DynamicAllowed: false},
+			Capabilities:        claudeCapabilities,
 		},
 		{
 			ID:                  "claude-opus-4-1-20250805",
@@ -62,6 +78,7 @@ func GetClaudeModels() []*ModelInfo {
 			ContextLength:       200000,
 			MaxCompletionTokens: 32000,
 			Thinking:            &ThinkingSupport{Min: 1024, Max: 128000, ZeroAllowed: false, DynamicAllowed: false},
+			Capabilities:        claudeCapabilities,
 		},
 		{
 			ID:                  "claude-opus-4-20250514",
@@ -73,6 +90,7 @@ func GetClaudeModels() []*ModelInfo {
 			ContextLength:       200000,
 			MaxCompletionTokens: 32000,
 			Thinking:            &ThinkingSupport{Min: 1024, Max: 128000, ZeroAllowed: false, DynamicAllowed: false},
+			Capabilities:        claudeCapabilities,
 		},
 		{
 			ID:                  "claude-sonnet-4-20250514",
@@ -84,6 +102,7 @@ func GetClaudeModels() []*ModelInfo {
 			ContextLength:       200000,
 			MaxCompletionTokens: 64000,
 			Thinking:            &ThinkingSupport{Min: 1024, Max: 128000, ZeroAllowed: false, DynamicAllowed: false},
+			Capabilities:        claudeCapabilities,
 		},
 		{
 			ID:                  "claude-3-7-sonnet-20250219",
@@ -92,9 +111,10 @@ func GetClaudeModels() []*ModelInfo {
 			OwnedBy:             "anthropic",
 			Type:                "claude",
 			DisplayName:         "Claude 3.7 Sonnet",
-			ContextLength:       128000,
-			MaxCompletionTokens: 8192,
+			ContextLength:       200000,
+			MaxCompletionTokens: 64000,
 			Thinking:            &ThinkingSupport{Min: 1024, Max: 128000, ZeroAllowed: false, DynamicAllowed: false},
+			Capabilities:        claudeCapabilities,
 		},
 		{
 			ID:                  "claude-3-5-haiku-20241022",
@@ -103,8 +123,9 @@ func GetClaudeModels() []*ModelInfo {
 			OwnedBy:             "anthropic",
 			Type:                "claude",
 			DisplayName:         "Claude 3.5 Haiku",
-			ContextLength:       128000,
+			ContextLength:       200000,
 			MaxCompletionTokens: 8192,
+			Capabilities:        claudeCapabilities,
 			// Thinking: not supported for Haiku models
 		},
 	}
@@ -127,6 +148,7 @@ func GetGeminiModels() []*ModelInfo {
 			OutputTokenLimit:           65536,
 			SupportedGenerationMethods: []string{"generateContent", "countTokens", "createCachedContent", "batchGenerateContent"},
 			Thinking:                   &ThinkingSupport{Min: 128, Max: 32768, ZeroAllowed: false, DynamicAllowed: true},
+			Capabilities:               geminiCapabilities,
 		},
 		{
 			ID:                         "gemini-2.5-flash",
@@ -142,6 +164,7 @@ func GetGeminiModels() []*ModelInfo {
 			OutputTokenLimit:           65536,
 			SupportedGenerationMethods: []string{"generateContent", "countTokens", "createCachedContent", "batchGenerateContent"},
 			Thinking:                   &ThinkingSupport{Min: 0, Max: 24576, ZeroAllowed: true, DynamicAllowed: true},
+			Capabilities:               geminiCapabilities,
 		},
 		{
 			ID:                         "gemini-2.5-flash-lite",
@@ -157,6 +180,7 @@ func GetGeminiModels() []*ModelInfo {
 			OutputTokenLimit:           65536,
 			SupportedGenerationMethods: []string{"generateContent", "countTokens", "createCachedContent", "batchGenerateContent"},
 			Thinking:                   &ThinkingSupport{Min: 0, Max: 24576, ZeroAllowed: true, DynamicAllowed: true},
+			Capabilities:               geminiCapabilities,
 		},
 		{
 			ID:                         "gemini-3-pro-preview",
@@ -172,6 +196,7 @@ func GetGeminiModels() []*ModelInfo {
 			OutputTokenLimit:           65536,
 			SupportedGenerationMethods: []string{"generateContent", "countTokens", "createCachedContent", "batchGenerateContent"},
 			Thinking:                   &ThinkingSupport{Min: 128, Max: 32768, ZeroAllowed: false, DynamicAllowed: true, Levels: []string{"low", "high"}},
+			Capabilities:               geminiCapabilities,
 		},
 		{
 			ID:                         "gemini-3-flash-preview",
@@ -187,6 +212,7 @@ func GetGeminiModels() []*ModelInfo {
 			OutputTokenLimit:           65536,
 			SupportedGenerationMethods: []string{"generateContent", "countTokens", "createCachedContent", "batchGenerateContent"},
 			Thinking:                   &ThinkingSupport{Min: 128, Max: 32768, ZeroAllowed: false, DynamicAllowed: true, Levels: []string{"minimal", "low", "medium", "high"}},
+			Capabilities:               geminiCapabilities,
 		},
 		{
 			ID:                         "gemini-3-pro-image-preview",
@@ -202,6 +228,35 @@ func GetGeminiModels() []*ModelInfo {
 			OutputTokenLimit:           65536,
 			SupportedGenerationMethods: []string{"generateContent", "countTokens", "createCachedContent", "batchGenerateContent"},
 			Thinking:                   &ThinkingSupport{Min: 128, Max: 32768, ZeroAllowed: false, DynamicAllowed: true, Levels: []string{"low", "high"}},
+			Capabilities:               geminiImageCapabilities,
+		},
+		{
+			ID:                         "gemini-embedding-001",
+			Object:                     "model",
+			Created:                    1752537600,
+			OwnedBy:                    "google",
+			Type:                       "gemini",
+			Name:                       "models/gemini-embedding-001",
+			Version:                    "001",
+			DisplayName:                "Gemini Embedding 001",
+			Description:                "Gemini text embedding model",
+			InputTokenLimit:            2048,
+			SupportedGenerationMethods: []string{"embedContent", "batchEmbedContents"},
+			SupportedEndpoints:         []string{"/embeddings"},
+		},
+		{
+			ID:                         "text-embedding-004",
+			Object:                     "model",
+			Created:                    1715644800,
+			OwnedBy:                    "google",
+			Type:                       "gemini",
+			Name:                       "models/text-embedding-004",
+			Version:                    "004",
+			DisplayName:                "Text Embedding 004",
+			Description:                "Obtain a distributed representation of a text.",
+			InputTokenLimit:            2048,
+			SupportedGenerationMethods: []string{"embedContent", "batchEmbedContents"},
+			SupportedEndpoints:         []string{"/embeddings"},
 		},
 	}
 }
@@ -222,6 +277,7 @@ func GetGeminiVertexModels() []*ModelInfo {
 			OutputTokenLimit:           65536,
 			SupportedGenerationMethods: []string{"generateContent", "countTokens", "createCachedContent", "batchGenerateContent"},
 			Thinking:                   &ThinkingSupport{Min: 128, Max: 32768, ZeroAllowed: false, DynamicAllowed: true},
+			Capabilities:               geminiCapabilities,
 		},
 		{
 			ID:                         "gemini-2.5-flash",
@@ -237,6 +293,7 @@ func GetGeminiVertexModels() []*ModelInfo {
 			OutputTokenLimit:           65536,
 			SupportedGenerationMethods: []string{"generateContent", "countTokens", "createCachedContent", "batchGenerateContent"},
 			Thinking:                   &ThinkingSupport{Min: 0, Max: 24576, ZeroAllowed: true, DynamicAllowed: true},
+			Capabilities:               geminiCapabilities,
 		},
 		{
 			ID:                         "gemini-2.5-flash-lite",
@@ -252,6 +309,7 @@ func GetGeminiVertexModels() []*ModelInfo {
 			OutputTokenLimit:           65536,
 			SupportedGenerationMethods: []string{"generateContent", "countTokens", "createCachedContent", "batchGenerateContent"},
 			Thinking:                   &ThinkingSupport{Min: 0, Max: 24576, ZeroAllowed: true, DynamicAllowed: true},
+			Capabilities:               geminiCapabilities,
 		},
 		{
 			ID:                         "gemini-3-pro-preview",
@@ -267,6 +325,7 @@ func GetGeminiVertexModels() []*ModelInfo {
 			OutputTokenLimit:           65536,
 			SupportedGenerationMethods: []string{"generateContent", "countTokens", "createCachedContent", "batchGenerateContent"},
 			Thinking:                   &ThinkingSupport{Min: 128, Max: 32768, ZeroAllowed: false, DynamicAllowed: true, Levels: []string{"low", "high"}},
+			Capabilities:               geminiCapabilities,
 		},
 		{
 			ID:                         "gemini-3-flash-preview",
@@ -282,6 +341,7 @@ func GetGeminiVertexModels() []*ModelInfo {
 			OutputTokenLimit:           65536,
 			SupportedGenerationMethods: []string{"generateContent", "countTokens", "createCachedContent", "batchGenerateContent"},
 			Thinking:                   &ThinkingSupport{Min: 128, Max: 32768, ZeroAllowed: false, DynamicAllowed: true, Levels: []string{"minimal", "low", "medium", "high"}},
+			Capabilities:               geminiCapabilities,
 		},
 		{
 			ID:                         "gemini-3-pro-image-preview",
@@ -297,6 +357,7 @@ func GetGeminiVertexModels() []*ModelInfo {
 			OutputTokenLimit:           65536,
 			SupportedGenerationMethods: []string{"generateContent", "countTokens", "createCachedContent", "batchGenerateContent"},
 			Thinking:                   &ThinkingSupport{Min: 128, Max: 32768, ZeroAllowed: false, DynamicAllowed: true, Levels: []string{"low", "high"}},
+			Capabilities:               geminiImageCapabilities,
 		},
 		// Imagen image generation models - use :predict action
 		{
@@ -310,6 +371,7 @@ func GetGeminiVertexModels() []*ModelInfo {
 			DisplayName:                "Imagen 4.0 Generate",
 			Description:                "Imagen 4.0 image generation model",
 			SupportedGenerationMethods: []string{"predict"},
+			Capabilities:               imagenCapabilities,
 		},
 		{
 			ID:                         "imagen-4.0-ultra-generate-001",
@@ -322,6 +384,7 @@ func GetGeminiVertexModels() []*ModelInfo {
 			DisplayName:                "Imagen 4.0 Ultra Generate",
 			Description:                "Imagen 4.0 Ultra high-quality image generation model",
 			SupportedGenerationMethods: []string{"predict"},
+			Capabilities:               imagenCapabilities,
 		},
 		{
 			ID:                         "imagen-3.0-generate-002",
@@ -334,6 +397,7 @@ func GetGeminiVertexModels() []*ModelInfo {
 			DisplayName:                "Imagen 3.0 Generate",
 			Description:                "Imagen 3.0 image generation model",
 			SupportedGenerationMethods: []string{"predict"},
+			Capabilities:               imagenCapabilities,
 		},
 		{
 			ID:                         "imagen-3.0-fast-generate-001",
@@ -346,6 +410,7 @@ func GetGeminiVertexModels() []*ModelInfo {
 			DisplayName:                "Imagen 3.0 Fast Generate",
 			Description:                "Imagen 3.0 fast image generation model",
 			SupportedGenerationMethods: []string{"predict"},
+			Capabilities:               imagenCapabilities,
 		},
 		{
 			ID:                         "imagen-4.0-fast-generate-001",
@@ -358,6 +423,49 @@ func GetGeminiVertexModels() []*ModelInfo {
 			DisplayName:                "Imagen 4.0 Fast Generate",
 			Description:                "Imagen 4.0 fast image generation model",
 			SupportedGenerationMethods: []string{"predict"},
+			Capabilities:               imagenCapabilities,
+		},
+		{
+			ID:                         "gemini-embedding-001",
+			Object:                     "model",
+			Created:                    1752537600,
+			OwnedBy:                    "google",
+			Type:                       "gemini",
+			Name:                       "models/gemini-embedding-001",
+			Version:                    "001",
+			DisplayName:                "Gemini Embedding 001",
+			Description:                "Gemini text embedding model",
+			InputTokenLimit:            2048,
+			SupportedGenerationMethods: []string{"predict"},
+			SupportedEndpoints:         []string{"/embeddings"},
+		},
+		{
+			ID:                         "text-embedding-005",
+			Object:                     "model",
+			Created:                    1731974400,
+			OwnedBy:                    "google",
+			Type:                       "gemini",
+			Name:                       "models/text-embedding-005",
+			Version:                    "005",
+			DisplayName:                "Text Embedding 005",
+			Description:                "English and code text embedding model",
+			InputTokenLimit:            2048,
+			SupportedGenerationMethods: []string{"predict"},
+			SupportedEndpoints:         []string{"/embeddings"},
+		},
+		{
+			ID:                         "text-multilingual-embedding-002",
+			Object:                     "model",
+			Created:                    1715644800,
+			OwnedBy:                    "google",
+			Type:                       "gemini",
+			Name:                       "models/text-multilingual-embedding-002",
+			Version:                    "002",
+			DisplayName:                "Text Multilingual Embedding 002",
+			Description:                "Multilingual text embedding model",
+			InputTokenLimit:            2048,
+			SupportedGenerationMethods: []string{"predict"},
+			SupportedEndpoints:         []string{"/embeddings"},
 		},
 	}
 }
@@ -379,6 +487,7 @@ func GetGeminiCLIModels() []*ModelInfo {
 			OutputTokenLimit:           65536,
 			SupportedGenerationMethods: []string{"generateContent", "countTokens", "createCachedContent", "batchGenerateContent"},
 			Thinking:                   &ThinkingSupport{Min: 128, Max: 32768, ZeroAllowed: false, DynamicAllowed: true},
+			Capabilities:               geminiCapabilities,
 		},
 		{
 			ID:                         "gemini-2.5-flash",
@@ -394,6 +503,7 @@ func GetGeminiCLIModels() []*ModelInfo {
 			OutputTokenLimit:           65536,
 			SupportedGenerationMethods: []string{"generateContent", "countTokens", "createCachedContent", "batchGenerateContent"},
 			Thinking:                   &ThinkingSupport{Min: 0, Max: 24576, ZeroAllowed: true, DynamicAllowed: true},
+			Capabilities:               geminiCapabilities,
 		},
 		{
 			ID:                         "gemini-2.5-flash-lite",
@@ -409,6 +519,7 @@ func GetGeminiCLIModels() []*ModelInfo {
 			OutputTokenLimit:           65536,
 			SupportedGenerationMethods: []string{"generateContent", "countTokens", "createCachedContent", "batchGenerateContent"},
 			Thinking:                   &ThinkingSupport{Min: 0, Max: 24576, ZeroAllowed: true, DynamicAllowed: true},
+			Capabilities:               geminiCapabilities,
 		},
 		{
 			ID:                         "gemini-3-pro-preview",
@@ -424,6 +535,7 @@ func GetGeminiCLIModels() []*ModelInfo {
 			OutputTokenLimit:           65536,
 			SupportedGenerationMethods: []string{"generateContent", "countTokens", "createCachedContent", "batchGenerateContent"},
 			Thinking:                   &ThinkingSupport{Min: 128, Max: 32768, ZeroAllowed: false, DynamicAllowed: true, Levels: []string{"low", "high"}},
+			Capabilities:               geminiCapabilities,
 		},
 		{
 			ID:                         "gemini-3-flash-preview",
@@ -439,6 +551,7 @@ func GetGeminiCLIModels() []*ModelInfo {
 			OutputTokenLimit:           65536,
 			SupportedGenerationMethods: []string{"generateContent", "countTokens", "createCachedContent", "batchGenerateContent"},
 			Thinking:                   &ThinkingSupport{Min: 128, Max: 32768, ZeroAllowed: false, DynamicAllowed: true, Levels: []string{"minimal", "low", "medium", "high"}},
+			Capabilities:               geminiCapabilities,
 		},
 	}
 }
@@ -460,6 +573,7 @@ func GetAIStudioModels() []*ModelInfo {
 			OutputTokenLimit:           65536,
 			SupportedGenerationMethods: []string{"generateContent", "countTokens", "createCachedContent", "batchGenerateContent"},
 			Thinking:                   &ThinkingSupport{Min: 128, Max: 32768, ZeroAllowed: false, DynamicAllowed: true},
+			Capabilities:               geminiCapabilities,
 		},
 		{
 			ID:                         "gemini-2.5-flash",
@@ -475,6 +589,7 @@ func GetAIStudioModels() []*ModelInfo {
 			OutputTokenLimit:           65536,
 			SupportedGenerationMethods: []string{"generateContent", "countTokens", "createCachedContent", "batchGenerateContent"},
 			Thinking:                   &ThinkingSupport{Min: 0, Max: 24576, ZeroAllowed: true, DynamicAllowed: true},
+			Capabilities:               geminiCapabilities,
 		},
 		{
 			ID:                         "gemini-2.5-flash-lite",
@@ -490,6 +605,7 @@ func GetAIStudioModels() []*ModelInfo {
 			OutputTokenLimit:           65536,
 			SupportedGenerationMethods: []string{"generateContent", "countTokens", "createCachedContent", "batchGenerateContent"},
 			Thinking:                   &ThinkingSupport{Min: 0, Max: 24576, ZeroAllowed: true, DynamicAllowed: true},
+			Capabilities:               geminiCapabilities,
 		},
 		{
 			ID:                         "gemini-3-pro-preview",
@@ -505,6 +621,7 @@ func GetAIStudioModels() []*ModelInfo {
 			OutputTokenLimit:           65536,
 			SupportedGenerationMethods: []string{"generateContent", "countTokens", "createCachedContent", "batchGenerateContent"},
 			Thinking:                   &ThinkingSupport{Min: 128, Max: 32768, ZeroAllowed: false, DynamicAllowed: true},
+			Capabilities:               geminiCapabilities,
 		},
 		{
 			ID:                         "gemini-3-flash-preview",
@@ -520,6 +637,7 @@ func GetAIStudioModels() []*ModelInfo {
 			OutputTokenLimit:           65536,
 			SupportedGenerationMethods: []string{"generateContent", "countTokens", "createCachedContent", "batchGenerateContent"},
 			Thinking:                   &ThinkingSupport{Min: 128, Max: 32768, ZeroAllowed: false, DynamicAllowed: true},
+			Capabilities:               geminiCapabilities,
 		},
 		{
 			ID:                         "gemini-pro-latest",
@@ -535,6 +653,7 @@ func GetAIStudioModels() []*ModelInfo {
 			OutputTokenLimit:           65536,
 			SupportedGenerationMethods: []string{"generateContent", "countTokens", "createCachedContent", "batchGenerateContent"},
 			Thinking:                   &ThinkingSupport{Min: 128, Max: 32768, ZeroAllowed: false, DynamicAllowed: true},
+			Capabilities:               geminiCapabilities,
 		},
 		{
 			ID:                         "gemini-flash-latest",
@@ -550,6 +669,7 @@ func GetAIStudioModels() []*ModelInfo {
 			OutputTokenLimit:           65536,
 			SupportedGenerationMethods: []string{"generateContent", "countTokens", "createCachedContent", "batchGenerateContent"},
 			Thinking:                   &ThinkingSupport{Min: 0, Max: 24576, ZeroAllowed: true, DynamicAllowed: true},
+			Capabilities:               geminiCapabilities,
 		},
 		{
 			ID:                         "gemini-flash-lite-latest",
@@ -565,6 +685,7 @@ func GetAIStudioModels() []*ModelInfo {
 			OutputTokenLimit:           65536,
 			SupportedGenerationMethods: []string{"generateContent", "countTokens", "createCachedContent", "batchGenerateContent"},
 			Thinking:                   &ThinkingSupport{Min: 512, Max: 24576, ZeroAllowed: true, DynamicAllowed: true},
+			Capabilities:               geminiCapabilities,
 		},
 		// {
 		// 	ID:                         "gemini-2.5-flash-image-preview",
@@ -594,6 +715,7 @@ func GetAIStudioModels() []*ModelInfo {
 			InputTokenLimit:            1048576,
 			OutputTokenLimit:           8192,
 			SupportedGenerationMethods: []string{"generateContent", "countTokens", "createCachedContent", "batchGenerateContent"},
+			Capabilities:               geminiImageCapabilities,
 			// image models don't support thinkingConfig; leave Thinking nil
 		},
 	}
@@ -615,6 +737,7 @@ func GetOpenAIModels() []*ModelInfo {
 			MaxCompletionTokens: 128000,
 			SupportedParameters: []string{"tools"},
 			Thinking:            &ThinkingSupport{Levels: []string{"minimal", "low", "medium", "high"}},
+			Capabilities:        openAICapabilities,
 		},
 		{
 			ID:                  "gpt-5-codex",
@@ -629,6 +752,7 @@ func GetOpenAIModels() []*ModelInfo {
 			MaxCompletionTokens: 128000,
 			SupportedParameters: []string{"tools"},
 			Thinking:            &ThinkingSupport{Levels: []string{"low", "medium", "high"}},
+			Capabilities:        openAICapabilities,
 		},
 		{
 			ID:                  "gpt-5-codex-mini",
@@ -643,6 +767,7 @@ func GetOpenAIModels() []*ModelInfo {
 			MaxCompletionTokens: 128000,
 			SupportedParameters: []string{"tools"},
 			Thinking:            &ThinkingSupport{Levels: []string{"low", "medium", "high"}},
+			Capabilities:        openAICapabilities,
 		},
 		{
 			ID:                  "gpt-5.1",
@@ -657,6 +782,7 @@ func GetOpenAIModels() []*ModelInfo {
 			MaxCompletionTokens: 128000,
 			SupportedParameters: []string{"tools"},
 			Thinking:            &ThinkingSupport{Levels: []string{"none", "low", "medium", "high"}},
+			Capabilities:        openAICapabilities,
 		},
 		{
 			ID:                  "gpt-5.1-codex",
@@ -671,6 +797,7 @@ func GetOpenAIModels() []*ModelInfo {
 			MaxCompletionTokens: 128000,
 			SupportedParameters: []string{"tools"},
 			Thinking:            &ThinkingSupport{Levels: []string{"low", "medium", "high"}},
+			Capabilities:        openAICapabilities,
 		},
 		{
 			ID:                  "gpt-5.1-codex-mini",
@@ -685,6 +812,7 @@ func GetOpenAIModels() []*ModelInfo {
 			MaxCompletionTokens: 128000,
 			SupportedParameters: []string{"tools"},
 			Thinking:            &ThinkingSupport{Levels: []string{"low", "medium", "high"}},
+			Capabilities:        openAICapabilities,
 		},
 		{
 			ID:                  "gpt-5.1-codex-max",
@@ -699,6 +827,7 @@ func GetOpenAIModels() []*ModelInfo {
 			MaxCompletionTokens: 128000,
 			SupportedParameters: []string{"tools"},
 			Thinking:            &ThinkingSupport{Levels: []string{"low", "medium", "high", "xhigh"}},
+			Capabilities:        openAICapabilities,
 		},
 		{
 			ID:                  "gpt-5.2",
@@ -713,6 +842,7 @@ func GetOpenAIModels() []*ModelInfo {
 			MaxCompletionTokens: 128000,
 			SupportedParameters: []string{"tools"},
 			Thinking:            &ThinkingSupport{Levels: []string{"none", "low", "medium", "high", "xhigh"}},
+			Capabilities:        openAICapabilities,
 		},
 		{
 			ID:                  "gpt-5.2-codex",
@@ -727,6 +857,7 @@ func GetOpenAIModels() []*ModelInfo {
 			MaxCompletionTokens: 128000,
 			SupportedParameters: []string{"tools"},
 			Thinking:            &ThinkingSupport{Levels: []string{"low", "medium", "high", "xhigh"}},
+			Capabilities:        openAICapabilities,
 		},
 		{
 			ID:                  "gpt-5.3-codex",
@@ -741,6 +872,7 @@ func GetOpenAIModels() []*ModelInfo {
 			MaxCompletionTokens: 128000,
 			SupportedParameters: []string{"tools"},
 			Thinking:            &ThinkingSupport{Levels: []string{"low", "medium", "high", "xhigh"}},
+			Capabilities:        openAICapabilities,
 		},
 	}
 }
@@ -757,9 +889,10 @@ func GetQwenModels() []*ModelInfo {
 			Version:             "3.0",
 			DisplayName:         "Qwen3 Coder Plus",
 			Description:         "Advanced code generation and understanding model",
-			ContextLength:       32768,
-			MaxCompletionTokens: 8192,
+			ContextLength:       1000000,
+			MaxCompletionTokens: 65536,
 			SupportedParameters: []string{"temperature", "top_p", "max_tokens", "stream", "stop"},
+			Capabilities:        textCapabilities,
 		},
 		{
 			ID:                  "qwen3-coder-flash",
@@ -770,9 +903,10 @@ func GetQwenModels() []*ModelInfo {
 			Version:             "3.0",
 			DisplayName:         "Qwen3 Coder Flash",
 			Description:         "Fast code generation model",
-			ContextLength:       8192,
-			MaxCompletionTokens: 2048,
+			ContextLength:       1000000,
+			MaxCompletionTokens: 65536,
 			SupportedParameters: []string{"temperature", "top_p", "max_tokens", "stream", "stop"},
+			Capabilities:        textCapabilities,
 		},
 		{
 			ID:                  "vision-model",
@@ -783,9 +917,10 @@ func GetQwenModels() []*ModelInfo {
 			Version:             "3.0",
 			DisplayName:         "Qwen3 Vision Model",
 			Description:         "Vision model model",
-			ContextLength:       32768,
-			MaxCompletionTokens: 2048,
+			ContextLength:       262144,
+			MaxCompletionTokens: 32768,
 			SupportedParameters: []string{"temperature", "top_p", "max_tokens", "stream", "stop"},
+			Capabilities:        visionCapabilities,
 		},
 	}
 }
@@ -805,11 +940,12 @@ func GetIFlowModels() []*ModelInfo {
 		Description string
 		Created     int64
 		Thinking    *ThinkingSupport
+		Vision      bool
 	}{
-		{ID: "tstars2.0", DisplayName: "TStars-2.0", Description: "iFlow TStars-2.0 multimodal assistant", Created: 1746489600},
+		{ID: "tstars2.0", DisplayName: "TStars-2.0", Description: "iFlow TStars-2.0 multimodal assistant", Created: 1746489600, Vision: true},
 		{ID: "qwen3-coder-plus", DisplayName: "Qwen3-Coder-Plus", Description: "Qwen3 Coder Plus code generation", Created: 1753228800},
 		{ID: "qwen3-max", DisplayName: "Qwen3-Max", Description: "Qwen3 flagship model", Created: 1758672000},
-		{ID: "qwen3-vl-plus", DisplayName: "Qwen3-VL-Plus", Description: "Qwen3 multimodal vision-language", Created: 1758672000},
+		{ID: "qwen3-vl-plus", DisplayName: "Qwen3-VL-Plus", Description: "Qwen3 multimodal vision-language", Created: 1758672000, Vision: true},
 		{ID: "qwen3-max-preview", DisplayName: "Qwen3-Max-Preview", Description: "Qwen3 Max preview build", Created: 1757030400, Thinking: iFlowThinkingSupport},
 		{ID: "kimi-k2-0905", DisplayName: "Kimi-K2-Instruct-0905", Description: "Moonshot Kimi K2 instruct 0905", Created: 1757030400},
 		{ID: "glm-4.6", DisplayName: "GLM-4.6", Description: "Zhipu GLM 4.6 general model", Created: 1759190400, Thinking: iFlowThinkingSupport},
@@ -829,19 +965,24 @@ func GetIFlowModels() []*ModelInfo {
 		{ID: "minimax-m2", DisplayName: "MiniMax-M2", Description: "MiniMax M2", Created: 1758672000, Thinking: iFlowThinkingSupport},
 		{ID: "minimax-m2.1", DisplayName: "MiniMax-M2.1", Description: "MiniMax M2.1", Created: 1766448000, Thinking: iFlowThinkingSupport},
 		{ID: "iflow-rome-30ba3b", DisplayName: "iFlow-ROME", Description: "iFlow Rome 30BA3B model", Created: 1736899200},
-		{ID: "kimi-k2.5", DisplayName: "Kimi-K2.5", Description: "Moonshot Kimi K2.5", Created: 1769443200, Thinking: iFlowThinkingSupport},
+		{ID: "kimi-k2.5", DisplayName: "Kimi-K2.5", Description: "Moonshot Kimi K2.5", Created: 1769443200, Thinking: iFlowThinkingSupport, Vision: true},
 	}
 	models := make([]*ModelInfo, 0, len(entries))
 	for _, entry := range entries {
+		capabilities := textCapabilities
+		if entry.Vision {
+			capabilities = visionCapabilities
+		}
 		models = append(models, &ModelInfo{
-			ID:          entry.ID,
-			Object:      "model",
-			Created:     entry.Created,
-			OwnedBy:     "iflow",
-			Type:        "iflow",
-			DisplayName: entry.DisplayName,
-			Description: entry.Description,
-			Thinking:    entry.Thinking,
+			ID:           entry.ID,
+			Object:       "model",
+			Created:      entry.Created,
+			OwnedBy:      "iflow",
+			Type:         "iflow",
+			DisplayName:  entry.DisplayName,
+			Description:  entry.Description,
+			Thinking:     entry.Thinking,
+			Capabilities: capabilities,
 		})
 	}
 	return models
@@ -884,8 +1025,9 @@ func GetKimiModels() []*ModelInfo {
 			Type:                "kimi",
 			DisplayName:         "Kimi K2",
 			Description:         "Kimi K2 - Moonshot AI's flagship coding model",
-			ContextLength:       131072,
+			ContextLength:       262144,
 			MaxCompletionTokens: 32768,
+			Capabilities:        textCapabilities,
 		},
 		{
 			ID:                  "kimi-k2-thinking",
@@ -895,9 +1037,10 @@ func GetKimiModels() []*ModelInfo {
 			Type:                "kimi",
 			DisplayName:         "Kimi K2 Thinking",
 			Description:         "Kimi K2 Thinking - Extended reasoning model",
-			ContextLength:       131072,
+			ContextLength:       262144,
 			MaxCompletionTokens: 32768,
 			Thinking:            &ThinkingSupport{Min: 1024, Max: 32000, ZeroAllowed: true, DynamicAllowed: true},
+			Capabilities:        textCapabilities,
 		},
 		{
 			ID:                  "kimi-k2.5",
@@ -907,9 +1050,10 @@ func GetKimiModels() []*ModelInfo {
 			Type:                "kimi",
 			DisplayName:         "Kimi K2.5",
 			Description:         "Kimi K2.5 - Latest Moonshot AI coding model with improved capabilities",
-			ContextLength:       131072,
+			ContextLength:       262144,
 			MaxCompletionTokens: 32768,
 			Thinking:            &ThinkingSupport{Min: 1024, Max: 32000, ZeroAllowed: true, DynamicAllowed: true},
+			Capabilities:        visionCapabilities,
 		},
 	}
 }
@@ -54,6 +54,14 @@ type ModelInfo struct {
 	// This is optional and currently used for Gemini thinking budget normalization.
 	Thinking *ThinkingSupport `json:"thinking,omitempty"`
 
+	// Capabilities lists the inputs and features the model accepts. Requests are checked
+	// against it before dispatch; nil means unknown and disables the check.
+	Capabilities *ModelCapabilities `json:"capabilities,omitempty"`
+
+	// Tokenizer names the local tokenizer used to count tokens for the model (e.g. "o200k_base",
+	// "claude"). Empty selects one by model family.
+	Tokenizer string `json:"tokenizer,omitempty"`
+
 	// UserDefined indicates this model was defined through config file's models[]
 	// array (e.g., openai-compatibility.*.models[], *-api-key.models[]).
 	// UserDefined models have thinking configuration passed through without validation.
@@ -76,6 +84,64 @@ type ThinkingSupport struct {
 	Levels []string `json:"levels,omitempty"`
 }
 
+// ModelCapabilities describes the request features a model accepts beyond plain text.
+type ModelCapabilities struct {
+	// Vision indicates whether image inputs are accepted.
+	Vision bool `json:"vision"`
+	// Audio indicates whether audio inputs are accepted.
+	Audio bool `json:"audio"`
+	// ToolUse indicates whether tool declarations and tool calls are accepted.
+	ToolUse bool `json:"tool_use"`
+	// JSONSchema indicates whether schema-constrained JSON output is accepted.
+	JSONSchema bool `json:"json_schema"`
+}
+
+// ContextWindow returns the maximum number of prompt tokens the model accepts, or 0 when unknown.
+func (m *ModelInfo) ContextWindow() int {
+	if m == nil {
+		return 0
+	}
+	if m.ContextLength > 0 {
+		return m.ContextLength
+	}
+	return m.InputTokenLimit
+}
+
+// MaxOutputTokens returns the maximum number of tokens the model generates, or 0 when unknown.
+func (m *ModelInfo) MaxOutputTokens() int {
+	if m == nil {
+		return 0
+	}
+	if m.MaxCompletionTokens > 0 {
+		return m.MaxCompletionTokens
+	}
+	return m.OutputTokenLimit
+}
+
+// capabilitiesMap renders the capabilities and limits of the model for model listings.
+func (m *ModelInfo) capabilitiesMap() map[string]any {
+	if m == nil || m.Capabilities == nil {
+		return nil
+	}
+	result := map[string]any{
+		"vision":      m.Capabilities.Vision,
+		"audio":       m.Capabilities.Audio,
+		"tool_use":    m.Capabilities.ToolUse,
+		"json_schema": m.Capabilities.JSONSchema,
+		"thinking":    m.Thinking != nil,
+	}
+	if window := m.ContextWindow(); window > 0 {
+		result["context_window"] = window
+	}
+	if maxOutput := m.MaxOutputTokens(); maxOutput > 0 {
+		result["max_output_tokens"] = maxOutput
+	}
+	if m.Thinking != nil && len(m.Thinking.Levels) > 0 {
+		result["thinking_levels"] = m.Thinking.Levels
+	}
+	return result
+}
+
 // ModelRegistration tracks a model's availability
 type ModelRegistration struct {
 	// Info contains the model metadata
@@ -1032,6 +1098,9 @@ func (r *ModelRegistry) convertModelToMap(model *ModelInfo, handlerType string)
 		if len(model.SupportedEndpoints) > 0 {
 			result["supported_endpoints"] = model.SupportedEndpoints
 		}
+		if capabilities := model.capabilitiesMap(); capabilities != nil {
+			result["capabilities"] = capabilities
+		}
 		return result
 
 	case "claude", "kiro", "antigravity":
@@ -1063,6 +1132,9 @@ func (r *ModelRegistry) convertModelToMap(model *ModelInfo, handlerType string)
 				"dynamic_allowed": model.Thinking.DynamicAllowed,
 			}
 		}
+		if capabilities := model.capabilitiesMap(); capabilities != nil {
+			result["capabilities"] = capabilities
+		}
 		return result
 
 	case "gemini":
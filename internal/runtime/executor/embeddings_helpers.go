@@ -0,0 +1,232 @@
+package executor
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"io"
+	"math"
+	"net/http"
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
+	log "github.com/sirupsen/logrus"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// embeddingsAlt is the Options.Alt value used by the OpenAI /v1/embeddings handler.
+const embeddingsAlt = "embeddings"
+
+// embeddingsRequest is the provider-neutral view of an OpenAI embeddings request.
+type embeddingsRequest struct {
+	inputs         []string
+	dimensions     int64
+	encodingFormat string
+}
+
+// parseOpenAIEmbeddingsRequest extracts the text inputs and options of an OpenAI embeddings request.
+// Pre-tokenized inputs (arrays of integers) are rejected because Gemini only accepts text.
+func parseOpenAIEmbeddingsRequest(payload []byte) (embeddingsRequest, error) {
+	root := gjson.ParseBytes(payload)
+	req := embeddingsRequest{
+		dimensions:     root.Get("dimensions").Int(),
+		encodingFormat: strings.ToLower(strings.TrimSpace(root.Get("encoding_format").String())),
+	}
+	input := root.Get("input")
+	switch {
+	case input.Type == gjson.String:
+		req.inputs = append(req.inputs, input.String())
+	case input.IsArray():
+		for _, item := range input.Array() {
+			if item.Type != gjson.String {
+				return req, statusErr{code: http.StatusBadRequest, msg: "embeddings: only string inputs are supported by this provider"}
+			}
+			req.inputs = append(req.inputs, item.String())
+		}
+	}
+	if len(req.inputs) == 0 {
+		return req, statusErr{code: http.StatusBadRequest, msg: "embeddings: input is required"}
+	}
+	if req.encodingFormat != "" && req.encodingFormat != "float" && req.encodingFormat != "base64" {
+		return req, statusErr{code: http.StatusBadRequest, msg: "embeddings: encoding_format must be float or base64"}
+	}
+	return req, nil
+}
+
+// buildGeminiEmbeddingsRequest converts the request into a Gemini embedContent body, or a
+// batchEmbedContents body when several inputs are supplied. It returns the API action to call.
+func buildGeminiEmbeddingsRequest(model string, req embeddingsRequest) ([]byte, string) {
+	single := func(text string) []byte {
+		body := []byte(`{}`)
+		body, _ = sjson.SetBytes(body, "model", "models/"+model)
+		body, _ = sjson.SetBytes(body, "content.parts.0.text", text)
+		if req.dimensions > 0 {
+			body, _ = sjson.SetBytes(body, "outputDimensionality", req.dimensions)
+		}
+		return body
+	}
+	if len(req.inputs) == 1 {
+		return single(req.inputs[0]), "embedContent"
+	}
+	body := []byte(`{"requests":[]}`)
+	for _, text := range req.inputs {
+		body, _ = sjson.SetRawBytes(body, "requests.-1", single(text))
+	}
+	return body, "batchEmbedContents"
+}
+
+// geminiEmbeddingVectors returns the vectors of an embedContent or batchEmbedContents response.
+func geminiEmbeddingVectors(data []byte) []gjson.Result {
+	root := gjson.ParseBytes(data)
+	if embeddings := root.Get("embeddings"); embeddings.IsArray() {
+		vectors := make([]gjson.Result, 0, len(embeddings.Array()))
+		for _, item := range embeddings.Array() {
+			vectors = append(vectors, item.Get("values"))
+		}
+		return vectors
+	}
+	if values := root.Get("embedding.values"); values.Exists() {
+		return []gjson.Result{values}
+	}
+	return nil
+}
+
+// buildVertexEmbeddingsRequest converts the request into a Vertex AI predict body.
+func buildVertexEmbeddingsRequest(req embeddingsRequest) []byte {
+	body := []byte(`{"instances":[]}`)
+	for _, text := range req.inputs {
+		instance, _ := sjson.SetBytes([]byte(`{}`), "content", text)
+		body, _ = sjson.SetRawBytes(body, "instances.-1", instance)
+	}
+	if req.dimensions > 0 {
+		body, _ = sjson.SetBytes(body, "parameters.outputDimensionality", req.dimensions)
+	}
+	return body
+}
+
+// vertexEmbeddingVectors returns the vectors and the summed token count of a predict response.
+func vertexEmbeddingVectors(data []byte) ([]gjson.Result, int64) {
+	predictions := gjson.GetBytes(data, "predictions").Array()
+	vectors := make([]gjson.Result, 0, len(predictions))
+	var tokens int64
+	for _, prediction := range predictions {
+		vectors = append(vectors, prediction.Get("embeddings.values"))
+		tokens += prediction.Get("embeddings.statistics.token_count").Int()
+	}
+	return vectors, tokens
+}
+
+// estimateEmbeddingTokens approximates the prompt tokens of inputs for upstreams that do not
+// report usage for embedding calls.
+func estimateEmbeddingTokens(model string, inputs []string) int64 {
+	enc, err := getTokenizer(model)
+	if err != nil {
+		return 0
+	}
+	var total int64
+	for _, text := range inputs {
+		count, errCount := enc.Count(text)
+		if errCount != nil {
+			return 0
+		}
+		total += int64(count)
+	}
+	return total
+}
+
+// buildOpenAIEmbeddingsResponse renders vectors as an OpenAI embeddings list response.
+func buildOpenAIEmbeddingsResponse(model string, vectors []gjson.Result, promptTokens int64, encodingFormat string) []byte {
+	out := []byte(`{"object":"list","data":[]}`)
+	for i, vector := range vectors {
+		item := []byte(`{"object":"embedding"}`)
+		item, _ = sjson.SetBytes(item, "index", i)
+		if encodingFormat == "base64" {
+			item, _ = sjson.SetBytes(item, "embedding", encodeEmbeddingBase64(vector))
+		} else {
+			raw := vector.Raw
+			if raw == "" {
+				raw = "[]"
+			}
+			item, _ = sjson.SetRawBytes(item, "embedding", []byte(raw))
+		}
+		out, _ = sjson.SetRawBytes(out, "data.-1", item)
+	}
+	out, _ = sjson.SetBytes(out, "model", model)
+	out, _ = sjson.SetBytes(out, "usage.prompt_tokens", promptTokens)
+	out, _ = sjson.SetBytes(out, "usage.total_tokens", promptTokens)
+	return out
+}
+
+// encodeEmbeddingBase64 packs the vector as little-endian float32 values, matching OpenAI's base64 encoding.
+func encodeEmbeddingBase64(vector gjson.Result) string {
+	values := vector.Array()
+	buf := make([]byte, 4*len(values))
+	for i, value := range values {
+		binary.LittleEndian.PutUint32(buf[i*4:], math.Float32bits(float32(value.Float())))
+	}
+	return base64.StdEncoding.EncodeToString(buf)
+}
+
+func embeddingsUsage(promptTokens int64) usage.Detail {
+	return usage.Detail{InputTokens: promptTokens, TotalTokens: promptTokens}
+}
+
+// postUpstream sends a non-streaming JSON request upstream with request logging and returns
+// the raw response body. prepare sets the provider credentials and headers and may override
+// the content type.
+func postUpstream(ctx context.Context, cfg *config.Config, auth *cliproxyauth.Auth, provider, url string, body []byte, prepare func(*http.Request)) ([]byte, error) {
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	if prepare != nil {
+		prepare(httpReq)
+	}
+	var authID, authLabel, authType, authValue string
+	if auth != nil {
+		authID = auth.ID
+		authLabel = auth.Label
+		authType, authValue = auth.AccountInfo()
+	}
+	recordAPIRequest(ctx, cfg, upstreamRequestLog{
+		URL:       url,
+		Method:    http.MethodPost,
+		Headers:   httpReq.Header.Clone(),
+		Body:      body,
+		Provider:  provider,
+		AuthID:    authID,
+		AuthLabel: authLabel,
+		AuthType:  authType,
+		AuthValue: authValue,
+	})
+
+	httpClient := newProxyAwareHTTPClient(ctx, cfg, auth, 0)
+	httpResp, err := httpClient.Do(httpReq)
+	if err != nil {
+		recordAPIResponseError(ctx, cfg, err)
+		return nil, err
+	}
+	defer func() {
+		if errClose := httpResp.Body.Close(); errClose != nil {
+			log.Errorf("%s executor: close response body error: %v", provider, errClose)
+		}
+	}()
+	recordAPIResponseMetadata(ctx, cfg, httpResp.StatusCode, httpResp.Header.Clone())
+	reportRateLimitHeaders(ctx, httpResp.Header)
+	data, err := io.ReadAll(httpResp.Body)
+	if err != nil {
+		recordAPIResponseError(ctx, cfg, err)
+		return nil, err
+	}
+	appendAPIResponseChunk(ctx, cfg, data)
+	if httpResp.StatusCode < 200 || httpResp.StatusCode >= 300 {
+		logWithRequestID(ctx).Debugf("request error, error status: %d, error message: %s", httpResp.StatusCode, summarizeErrorBody(httpResp.Header.Get("Content-Type"), data))
+		return nil, statusErr{code: httpResp.StatusCode, msg: string(data)}
+	}
+	return data, nil
+}
@@ -0,0 +1,95 @@
+package executor
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+	"github.com/tidwall/gjson"
+)
+
+func TestParseOpenAIEmbeddingsRequestRejectsTokenInput(t *testing.T) {
+	if _, err := parseOpenAIEmbeddingsRequest([]byte(`{"model":"m","input":[[1,2,3]]}`)); err == nil {
+		t.Fatal("expected error for pre-tokenized input")
+	}
+	if _, err := parseOpenAIEmbeddingsRequest([]byte(`{"model":"m","input":"hi","encoding_format":"int8"}`)); err == nil {
+		t.Fatal("expected error for unsupported encoding_format")
+	}
+}
+
+func TestGeminiExecutorEmbeddingsBatch(t *testing.T) {
+	var gotPath, gotKey string
+	var gotBody []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		gotKey = r.Header.Get("x-goog-api-key")
+		gotBody, _ = io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"embeddings":[{"values":[0.1,0.2]},{"values":[0.3,0.4]}]}`))
+	}))
+	defer server.Close()
+
+	executor := NewGeminiExecutor(&config.Config{})
+	auth := &cliproxyauth.Auth{Attributes: map[string]string{"base_url": server.URL, "api_key": "key"}}
+	resp, err := executor.Execute(context.Background(), auth, cliproxyexecutor.Request{
+		Model:   "gemini-embedding-001",
+		Payload: []byte(`{"model":"gemini-embedding-001","input":["hello","world"],"dimensions":2}`),
+	}, cliproxyexecutor.Options{SourceFormat: sdktranslator.FromString("openai"), Alt: embeddingsAlt})
+	if err != nil {
+		t.Fatalf("Execute error: %v", err)
+	}
+	if gotPath != "/v1beta/models/gemini-embedding-001:batchEmbedContents" {
+		t.Fatalf("path = %q", gotPath)
+	}
+	if gotKey != "key" {
+		t.Fatalf("api key header = %q", gotKey)
+	}
+	if got := gjson.GetBytes(gotBody, "requests.1.content.parts.0.text").String(); got != "world" {
+		t.Fatalf("second request text = %q, body %s", got, gotBody)
+	}
+	if got := gjson.GetBytes(gotBody, "requests.0.outputDimensionality").Int(); got != 2 {
+		t.Fatalf("outputDimensionality = %d", got)
+	}
+	if got := gjson.GetBytes(resp.Payload, "object").String(); got != "list" {
+		t.Fatalf("object = %q", got)
+	}
+	if got := gjson.GetBytes(resp.Payload, "data.1.embedding.1").Float(); got != 0.4 {
+		t.Fatalf("data[1].embedding[1] = %v", got)
+	}
+	if got := gjson.GetBytes(resp.Payload, "data.1.index").Int(); got != 1 {
+		t.Fatalf("data[1].index = %d", got)
+	}
+	if gjson.GetBytes(resp.Payload, "usage.prompt_tokens").Int() <= 0 {
+		t.Fatalf("expected estimated prompt tokens, got %s", resp.Payload)
+	}
+}
+
+func TestVertexEmbeddingsResponseBase64(t *testing.T) {
+	vectors, tokens := vertexEmbeddingVectors([]byte(`{"predictions":[{"embeddings":{"values":[1.5,-2],"statistics":{"token_count":3}}}]}`))
+	if tokens != 3 {
+		t.Fatalf("tokens = %d, want 3", tokens)
+	}
+	out := buildOpenAIEmbeddingsResponse("text-embedding-005", vectors, tokens, "base64")
+	raw, err := base64.StdEncoding.DecodeString(gjson.GetBytes(out, "data.0.embedding").String())
+	if err != nil {
+		t.Fatalf("decode base64: %v", err)
+	}
+	if len(raw) != 8 {
+		t.Fatalf("decoded length = %d, want 8", len(raw))
+	}
+	if got := math.Float32frombits(binary.LittleEndian.Uint32(raw[4:])); got != -2 {
+		t.Fatalf("second value = %v, want -2", got)
+	}
+	if got := gjson.GetBytes(out, "usage.total_tokens").Int(); got != 3 {
+		t.Fatalf("total_tokens = %d, want 3", got)
+	}
+}
@@ -106,6 +106,9 @@ func (e *GeminiCLIExecutor) Execute(ctx context.Context, auth *cliproxyauth.Auth
 	if opts.Alt == "responses/compact" {
 		return resp, statusErr{code: http.StatusNotImplemented, msg: "/responses/compact not supported"}
 	}
+	if opts.Alt == embeddingsAlt || isImagesAlt(opts.Alt) {
+		return resp, statusErr{code: http.StatusNotImplemented, msg: "/" + opts.Alt + " not supported"}
+	}
 	baseModel := thinking.ParseSuffix(req.Model).ModelName
 
 	tokenSource, baseTokenData, err := prepareGeminiCLITokenSource(ctx, e.cfg, auth)
@@ -124,8 +127,8 @@ func (e *GeminiCLIExecutor) Execute(ctx context.Context, auth *cliproxyauth.Auth
 		originalPayloadSource = opts.OriginalRequest
 	}
 	originalPayload := originalPayloadSource
-	originalTranslated := sdktranslator.TranslateRequest(from, to, baseModel, originalPayload, false)
-	basePayload := sdktranslator.TranslateRequest(from, to, baseModel, req.Payload, false)
+	originalTranslated := sdktranslator.TranslateRequestWithContext(ctx, from, to, baseModel, originalPayload, false)
+	basePayload := sdktranslator.TranslateRequestWithContext(ctx, from, to, baseModel, req.Payload, false)
 
 	basePayload, err = thinking.ApplyThinking(basePayload, req.Model, from.String(), to.String(), e.Identifier())
 	if err != nil {
@@ -142,6 +145,9 @@ func (e *GeminiCLIExecutor) Execute(ctx context.Context, auth *cliproxyauth.Auth
 			action = "countTokens"
 		}
 	}
+	if action != "countTokens" {
+		reporter.setPrompt(to.String(), basePayload)
+	}
 
 	projectID := resolveGeminiProjectID(auth)
 	models := cliPreviewFallbackOrder(baseModel)
@@ -223,6 +229,9 @@ func (e *GeminiCLIExecutor) Execute(ctx context.Context, auth *cliproxyauth.Auth
 		appendAPIResponseChunk(ctx, e.cfg, data)
 		if httpResp.StatusCode >= 200 && httpResp.StatusCode < 300 {
 			reporter.publish(ctx, parseGeminiCLIUsage(data))
+			if action != "countTokens" {
+				reporter.ensurePublished(ctx)
+			}
 			var param any
 			out := sdktranslator.TranslateNonStream(respCtx, to, from, attemptModel, opts.OriginalRequest, payload, data, &param)
 			resp = cliproxyexecutor.Response{Payload: []byte(out)}
@@ -278,8 +287,8 @@ func (e *GeminiCLIExecutor) ExecuteStream(ctx context.Context, auth *cliproxyaut
 		originalPayloadSource = opts.OriginalRequest
 	}
 	originalPayload := originalPayloadSource
-	originalTranslated := sdktranslator.TranslateRequest(from, to, baseModel, originalPayload, true)
-	basePayload := sdktranslator.TranslateRequest(from, to, baseModel, req.Payload, true)
+	originalTranslated := sdktranslator.TranslateRequestWithContext(ctx, from, to, baseModel, originalPayload, true)
+	basePayload := sdktranslator.TranslateRequestWithContext(ctx, from, to, baseModel, req.Payload, true)
 
 	basePayload, err = thinking.ApplyThinking(basePayload, req.Model, from.String(), to.String(), e.Identifier())
 	if err != nil {
@@ -289,6 +298,7 @@ func (e *GeminiCLIExecutor) ExecuteStream(ctx context.Context, auth *cliproxyaut
 	basePayload = fixGeminiCLIImageAspectRatio(baseModel, basePayload)
 	requestedModel := payloadRequestedModel(opts, req.Model)
 	basePayload = applyPayloadConfigWithRoot(e.cfg, baseModel, "gemini", "request", basePayload, originalTranslated, requestedModel)
+	reporter.setPrompt(to.String(), basePayload)
 
 	projectID := resolveGeminiProjectID(auth)
 
@@ -416,6 +426,8 @@ func (e *GeminiCLIExecutor) ExecuteStream(ctx context.Context, auth *cliproxyaut
 					recordAPIResponseError(ctx, e.cfg, errScan)
 					reporter.publishFailure(ctx)
 					out <- cliproxyexecutor.StreamChunk{Err: errScan}
+				} else {
+					reporter.ensurePublished(ctx)
 				}
 				return
 			}
@@ -429,6 +441,7 @@ func (e *GeminiCLIExecutor) ExecuteStream(ctx context.Context, auth *cliproxyaut
 			}
 			appendAPIResponseChunk(ctx, e.cfg, data)
 			reporter.publish(ctx, parseGeminiCLIUsage(data))
+			reporter.ensurePublished(ctx)
 			var param any
 			segments := sdktranslator.TranslateStream(respCtx, to, from, attemptModel, opts.OriginalRequest, reqBody, data, &param)
 			for i := range segments {
@@ -487,7 +500,7 @@ func (e *GeminiCLIExecutor) CountTokens(ctx context.Context, auth *cliproxyauth.
 	// The loop variable attemptModel is only used as the concrete model id sent to the upstream
 	// Gemini CLI endpoint when iterating fallback variants.
 	for range models {
-		payload := sdktranslator.TranslateRequest(from, to, baseModel, req.Payload, false)
+		payload := sdktranslator.TranslateRequestWithContext(ctx, from, to, baseModel, req.Payload, false)
 
 		payload, err = thinking.ApplyThinking(payload, req.Model, from.String(), to.String(), e.Identifier())
 		if err != nil {
@@ -568,6 +581,42 @@ func (e *GeminiCLIExecutor) Refresh(_ context.Context, auth *cliproxyauth.Auth)
 	return auth, nil
 }
 
+// PollQuota implements cliproxyauth.QuotaPoller using the Code Assist retrieveUserQuota API.
+// Quota is reported per model; the bucket with the smallest remaining fraction wins when a
+// model has several.
+func (e *GeminiCLIExecutor) PollQuota(ctx context.Context, auth *cliproxyauth.Auth) (*cliproxyauth.QuotaSnapshot, error) {
+	tokenSource, _, err := prepareGeminiCLITokenSource(ctx, e.cfg, auth)
+	if err != nil {
+		return nil, err
+	}
+	tok, err := tokenSource.Token()
+	if err != nil {
+		return nil, err
+	}
+	body, _ := sjson.SetBytes([]byte(`{}`), "project", resolveGeminiProjectID(auth))
+	url := fmt.Sprintf("%s/%s:%s", codeAssistEndpoint, codeAssistVersion, "retrieveUserQuota")
+	data, err := fetchQuotaJSON(ctx, e.cfg, auth, http.MethodPost, url, body, func(r *http.Request) {
+		r.Header.Set("Authorization", "Bearer "+tok.AccessToken)
+		applyGeminiCLIHeaders(r)
+	})
+	if err != nil {
+		return nil, err
+	}
+	snapshot := &cliproxyauth.QuotaSnapshot{Models: make(map[string]cliproxyauth.QuotaSnapshot)}
+	for _, bucket := range gjson.GetBytes(data, "buckets").Array() {
+		model := bucket.Get("modelId").String()
+		fraction := bucket.Get("remainingFraction")
+		if model == "" || !fraction.Exists() {
+			continue
+		}
+		if current, ok := snapshot.Models[model]; ok && current.Remaining <= fraction.Float()*100 {
+			continue
+		}
+		snapshot.Models[model] = fractionQuota(fraction.Float(), parseQuotaResetTime(bucket.Get("resetTime").String()))
+	}
+	return snapshot, nil
+}
+
 func prepareGeminiCLITokenSource(ctx context.Context, cfg *config.Config, auth *cliproxyauth.Auth) (oauth2.TokenSource, map[string]any, error) {
 	metadata := geminiOAuthMetadata(auth)
 	if auth == nil || metadata == nil {
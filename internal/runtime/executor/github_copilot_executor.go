@@ -40,6 +40,9 @@ const (
 	copilotIntegrationID = "vscode-chat"
 	copilotOpenAIIntent  = "conversation-panel"
 	copilotAPIVersion    = "2025-04-01"
+
+	// githubCopilotUserURL reports the quota snapshots of the signed-in Copilot user.
+	githubCopilotUserURL = "https://api.github.com/copilot_internal/user"
 )
 
 // GitHubCopilotExecutor handles requests to the GitHub Copilot API.
@@ -101,6 +104,9 @@ func (e *GitHubCopilotExecutor) HttpRequest(ctx context.Context, auth *cliproxya
 
 // Execute handles non-streaming requests to GitHub Copilot.
 func (e *GitHubCopilotExecutor) Execute(ctx context.Context, auth *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (resp cliproxyexecutor.Response, err error) {
+	if opts.Alt == embeddingsAlt || isImagesAlt(opts.Alt) {
+		return resp, statusErr{code: http.StatusNotImplemented, msg: "/" + opts.Alt + " not supported"}
+	}
 	apiToken, errToken := e.ensureAPIToken(ctx, auth)
 	if errToken != nil {
 		return resp, errToken
@@ -119,13 +125,14 @@ func (e *GitHubCopilotExecutor) Execute(ctx context.Context, auth *cliproxyauth.
 	if len(opts.OriginalRequest) > 0 {
 		originalPayload = bytes.Clone(opts.OriginalRequest)
 	}
-	originalTranslated := sdktranslator.TranslateRequest(from, to, req.Model, originalPayload, false)
-	body := sdktranslator.TranslateRequest(from, to, req.Model, bytes.Clone(req.Payload), false)
+	originalTranslated := sdktranslator.TranslateRequestWithContext(ctx, from, to, req.Model, originalPayload, false)
+	body := sdktranslator.TranslateRequestWithContext(ctx, from, to, req.Model, bytes.Clone(req.Payload), false)
 	body = e.normalizeModel(req.Model, body)
 	requestedModel := payloadRequestedModel(opts, req.Model)
 	body = applyPayloadConfigWithRoot(e.cfg, req.Model, to.String(), "", body, originalTranslated, requestedModel)
 	body, _ = sjson.SetBytes(body, "stream", false)
 
+	reporter.setPrompt(to.String(), body)
 	path := githubCopilotChatPath
 	if useResponses {
 		path = githubCopilotResponsesPath
@@ -224,8 +231,8 @@ func (e *GitHubCopilotExecutor) ExecuteStream(ctx context.Context, auth *cliprox
 	if len(opts.OriginalRequest) > 0 {
 		originalPayload = bytes.Clone(opts.OriginalRequest)
 	}
-	originalTranslated := sdktranslator.TranslateRequest(from, to, req.Model, originalPayload, false)
-	body := sdktranslator.TranslateRequest(from, to, req.Model, bytes.Clone(req.Payload), true)
+	originalTranslated := sdktranslator.TranslateRequestWithContext(ctx, from, to, req.Model, originalPayload, false)
+	body := sdktranslator.TranslateRequestWithContext(ctx, from, to, req.Model, bytes.Clone(req.Payload), true)
 	body = e.normalizeModel(req.Model, body)
 	requestedModel := payloadRequestedModel(opts, req.Model)
 	body = applyPayloadConfigWithRoot(e.cfg, req.Model, to.String(), "", body, originalTranslated, requestedModel)
@@ -235,6 +242,7 @@ func (e *GitHubCopilotExecutor) ExecuteStream(ctx context.Context, auth *cliprox
 		body, _ = sjson.SetBytes(body, "stream_options.include_usage", true)
 	}
 
+	reporter.setPrompt(to.String(), body)
 	path := githubCopilotChatPath
 	if useResponses {
 		path = githubCopilotResponsesPath
@@ -373,6 +381,46 @@ func (e *GitHubCopilotExecutor) Refresh(ctx context.Context, auth *cliproxyauth.
 	return auth, nil
 }
 
+// PollQuota implements cliproxyauth.QuotaPoller using the Copilot user endpoint. The metered
+// quota snapshot (chat, completions or premium interactions) with the lowest share left is
+// reported; unlimited plans report no quota.
+func (e *GitHubCopilotExecutor) PollQuota(ctx context.Context, auth *cliproxyauth.Auth) (*cliproxyauth.QuotaSnapshot, error) {
+	if auth == nil {
+		return nil, statusErr{code: http.StatusUnauthorized, msg: "missing auth"}
+	}
+	accessToken := metaStringValue(auth.Metadata, "access_token")
+	if accessToken == "" {
+		return nil, statusErr{code: http.StatusUnauthorized, msg: "missing github access token"}
+	}
+	data, err := fetchQuotaJSON(ctx, e.cfg, auth, http.MethodGet, githubCopilotUserURL, nil, func(r *http.Request) {
+		r.Header.Set("Authorization", "Bearer "+accessToken)
+		r.Header.Set("User-Agent", copilotUserAgent)
+	})
+	if err != nil {
+		return nil, err
+	}
+	root := gjson.ParseBytes(data)
+	resetAt := parseQuotaResetTime(root.Get("quota_reset_date_utc").String())
+	if resetAt.IsZero() {
+		resetAt = parseQuotaResetTime(root.Get("quota_reset_date").String())
+	}
+	var snapshot *cliproxyauth.QuotaSnapshot
+	lowest := 0.0
+	root.Get("quota_snapshots").ForEach(func(_, quota gjson.Result) bool {
+		entitlement := quota.Get("entitlement").Float()
+		if quota.Get("unlimited").Bool() || entitlement <= 0 {
+			return true
+		}
+		remaining := quota.Get("remaining").Float()
+		if share := remaining / entitlement; snapshot == nil || share < lowest {
+			lowest = share
+			snapshot = &cliproxyauth.QuotaSnapshot{Remaining: remaining, Limit: entitlement, ResetAt: resetAt}
+		}
+		return true
+	})
+	return snapshot, nil
+}
+
 // ensureAPIToken gets or refreshes the Copilot API token.
 func (e *GitHubCopilotExecutor) ensureAPIToken(ctx context.Context, auth *cliproxyauth.Auth) (string, error) {
 	if auth == nil {
@@ -0,0 +1,283 @@
+package executor
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"strings"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// Options.Alt values used by the OpenAI /v1/images handlers.
+const (
+	imagesGenerationsAlt = "images/generations"
+	imagesEditsAlt       = "images/edits"
+)
+
+// maxImagesPerRequest mirrors the upper bound OpenAI enforces on the n parameter.
+const maxImagesPerRequest = 10
+
+func isImagesAlt(alt string) bool {
+	return alt == imagesGenerationsAlt || alt == imagesEditsAlt
+}
+
+// imagesRequest is the provider-neutral view of an OpenAI images request. Input images and
+// the mask are carried as data URLs; the edits handler converts multipart uploads to this form.
+type imagesRequest struct {
+	prompt         string
+	n              int
+	size           string
+	responseFormat string
+	images         []string
+	mask           string
+}
+
+// parseOpenAIImagesRequest extracts the prompt, options and input images of an images request.
+func parseOpenAIImagesRequest(payload []byte, alt string) (imagesRequest, error) {
+	root := gjson.ParseBytes(payload)
+	req := imagesRequest{
+		prompt:         strings.TrimSpace(root.Get("prompt").String()),
+		n:              int(root.Get("n").Int()),
+		size:           root.Get("size").String(),
+		responseFormat: strings.ToLower(strings.TrimSpace(root.Get("response_format").String())),
+		mask:           root.Get("mask").String(),
+	}
+	if req.prompt == "" {
+		return req, statusErr{code: http.StatusBadRequest, msg: "images: prompt is required"}
+	}
+	if req.n <= 0 {
+		req.n = 1
+	}
+	if req.n > maxImagesPerRequest {
+		return req, statusErr{code: http.StatusBadRequest, msg: fmt.Sprintf("images: n must be at most %d", maxImagesPerRequest)}
+	}
+	if req.responseFormat != "" && req.responseFormat != "b64_json" && req.responseFormat != "url" {
+		return req, statusErr{code: http.StatusBadRequest, msg: "images: response_format must be b64_json or url"}
+	}
+	if image := root.Get("image"); image.IsArray() {
+		for _, item := range image.Array() {
+			req.images = append(req.images, item.String())
+		}
+	} else if image.Type == gjson.String {
+		req.images = append(req.images, image.String())
+	}
+	for _, item := range root.Get("images").Array() {
+		url := item.Get("image_url").String()
+		if item.Get("image_url.url").Exists() {
+			url = item.Get("image_url.url").String()
+		}
+		if url != "" {
+			req.images = append(req.images, url)
+		}
+	}
+	if alt == imagesEditsAlt && len(req.images) == 0 {
+		return req, statusErr{code: http.StatusBadRequest, msg: "images: image is required for edits"}
+	}
+	return req, nil
+}
+
+// buildImagesChatRequest renders the images request as an OpenAI chat completion asking for
+// image output, so the regular OpenAI → Gemini translators can serve it.
+func buildImagesChatRequest(model string, req imagesRequest) []byte {
+	content := []byte(`[]`)
+	text, _ := sjson.SetBytes([]byte(`{"type":"text"}`), "text", req.prompt)
+	content, _ = sjson.SetRawBytes(content, "-1", text)
+	for _, image := range req.images {
+		part, _ := sjson.SetBytes([]byte(`{"type":"image_url"}`), "image_url.url", image)
+		content, _ = sjson.SetRawBytes(content, "-1", part)
+	}
+	if req.mask != "" {
+		hint, _ := sjson.SetBytes([]byte(`{"type":"text"}`), "text", "The next image is a mask: only change the areas that are transparent in it.")
+		content, _ = sjson.SetRawBytes(content, "-1", hint)
+		part, _ := sjson.SetBytes([]byte(`{"type":"image_url"}`), "image_url.url", req.mask)
+		content, _ = sjson.SetRawBytes(content, "-1", part)
+	}
+
+	out := []byte(`{"messages":[{"role":"user"}],"modalities":["image","text"]}`)
+	out, _ = sjson.SetBytes(out, "model", model)
+	out, _ = sjson.SetRawBytes(out, "messages.0.content", content)
+	if ratio := util.AspectRatioFromSize(req.size); ratio != "" {
+		out, _ = sjson.SetBytes(out, "image_config.aspect_ratio", ratio)
+	}
+	return out
+}
+
+// executeImagesViaChat serves an images request through execute, the executor's regular
+// OpenAI chat path, issuing one upstream call per requested image and collecting the inline
+// image parts of every reply into an OpenAI images response.
+func executeImagesViaChat(ctx context.Context, req cliproxyexecutor.Request, opts cliproxyexecutor.Options, execute func(context.Context, cliproxyexecutor.Request, cliproxyexecutor.Options) (cliproxyexecutor.Response, error)) (cliproxyexecutor.Response, error) {
+	imagesReq, err := parseOpenAIImagesRequest(req.Payload, opts.Alt)
+	if err != nil {
+		return cliproxyexecutor.Response{}, err
+	}
+	chatPayload := buildImagesChatRequest(req.Model, imagesReq)
+	chatReq := req
+	chatReq.Payload = chatPayload
+	chatOpts := opts
+	chatOpts.Alt = ""
+	chatOpts.Stream = false
+	chatOpts.SourceFormat = sdktranslator.FromString("openai")
+	chatOpts.OriginalRequest = chatPayload
+
+	out := []byte(`{"data":[]}`)
+	out, _ = sjson.SetBytes(out, "created", time.Now().Unix())
+	var inputTokens, outputTokens int64
+	var refusal string
+	for i := 0; i < imagesReq.n; i++ {
+		resp, errExec := execute(ctx, chatReq, chatOpts)
+		if errExec != nil {
+			return cliproxyexecutor.Response{}, errExec
+		}
+		chat := gjson.ParseBytes(resp.Payload)
+		inputTokens += chat.Get("usage.prompt_tokens").Int()
+		outputTokens += chat.Get("usage.completion_tokens").Int()
+		for _, choice := range chat.Get("choices").Array() {
+			if text := strings.TrimSpace(choice.Get("message.content").String()); text != "" {
+				refusal = text
+			}
+			for _, image := range choice.Get("message.images").Array() {
+				item := imagesResponseItem(image.Get("image_url.url").String(), imagesReq.responseFormat)
+				if item != nil {
+					out, _ = sjson.SetRawBytes(out, "data.-1", item)
+				}
+			}
+		}
+	}
+	if len(gjson.GetBytes(out, "data").Array()) == 0 {
+		msg := "images: upstream returned no image"
+		if refusal != "" {
+			msg += ": " + refusal
+		}
+		return cliproxyexecutor.Response{}, statusErr{code: http.StatusBadGateway, msg: msg}
+	}
+	out, _ = sjson.SetBytes(out, "usage.input_tokens", inputTokens)
+	out, _ = sjson.SetBytes(out, "usage.output_tokens", outputTokens)
+	out, _ = sjson.SetBytes(out, "usage.total_tokens", inputTokens+outputTokens)
+	return cliproxyexecutor.Response{Payload: out}, nil
+}
+
+// imagesResponseItem renders one generated image. Since no hosting is available, the "url"
+// response format returns the image as a data URL.
+func imagesResponseItem(dataURL, responseFormat string) []byte {
+	_, data, ok := parseDataURL(dataURL)
+	if !ok {
+		return nil
+	}
+	item := []byte(`{}`)
+	if responseFormat == "url" {
+		item, _ = sjson.SetBytes(item, "url", dataURL)
+	} else {
+		item, _ = sjson.SetBytes(item, "b64_json", data)
+	}
+	return item
+}
+
+// parseDataURL splits a base64 data URL into its mime type and payload.
+func parseDataURL(dataURL string) (mimeType, data string, ok bool) {
+	rest, found := strings.CutPrefix(dataURL, "data:")
+	if !found {
+		return "", "", false
+	}
+	header, data, found := strings.Cut(rest, ",")
+	if !found || !strings.HasSuffix(header, ";base64") {
+		return "", "", false
+	}
+	return strings.TrimSuffix(header, ";base64"), data, true
+}
+
+func imageExtension(mimeType string) string {
+	_, subtype, ok := strings.Cut(strings.ToLower(mimeType), "/")
+	if !ok {
+		return ""
+	}
+	if subtype == "jpg" {
+		return "jpeg"
+	}
+	return subtype
+}
+
+// buildImagesEditsMultipart converts a JSON edits request whose images are data URLs back
+// into the multipart form expected by OpenAI-compatible upstreams.
+func buildImagesEditsMultipart(model string, payload []byte) ([]byte, string, error) {
+	var buf bytes.Buffer
+	writer := multipart.NewWriter(&buf)
+	if err := writer.WriteField("model", model); err != nil {
+		return nil, "", err
+	}
+	var errWrite error
+	gjson.ParseBytes(payload).ForEach(func(key, value gjson.Result) bool {
+		switch key.String() {
+		case "model", "image", "mask":
+			return true
+		}
+		if value.IsObject() || value.IsArray() {
+			return true
+		}
+		errWrite = writer.WriteField(key.String(), value.String())
+		return errWrite == nil
+	})
+	if errWrite != nil {
+		return nil, "", errWrite
+	}
+
+	images := gjson.GetBytes(payload, "image")
+	field := "image"
+	if images.IsArray() && len(images.Array()) > 1 {
+		field = "image[]"
+	}
+	var files []string
+	if images.IsArray() {
+		for _, item := range images.Array() {
+			files = append(files, item.String())
+		}
+	} else if images.Type == gjson.String {
+		files = append(files, images.String())
+	}
+	for i, file := range files {
+		if err := writeDataURLPart(writer, field, fmt.Sprintf("image-%d", i), file); err != nil {
+			return nil, "", err
+		}
+	}
+	if mask := gjson.GetBytes(payload, "mask").String(); mask != "" {
+		if err := writeDataURLPart(writer, "mask", "mask", mask); err != nil {
+			return nil, "", err
+		}
+	}
+	if err := writer.Close(); err != nil {
+		return nil, "", err
+	}
+	return buf.Bytes(), writer.FormDataContentType(), nil
+}
+
+func writeDataURLPart(writer *multipart.Writer, field, name, dataURL string) error {
+	mimeType, data, ok := parseDataURL(dataURL)
+	if !ok {
+		return statusErr{code: http.StatusBadRequest, msg: "images: " + field + " must be a base64 data URL"}
+	}
+	decoded, err := base64.StdEncoding.DecodeString(data)
+	if err != nil {
+		return statusErr{code: http.StatusBadRequest, msg: "images: invalid base64 in " + field}
+	}
+	if ext := imageExtension(mimeType); ext != "" {
+		name += "." + ext
+	}
+	header := make(textproto.MIMEHeader)
+	header.Set("Content-Disposition", fmt.Sprintf(`form-data; name=%q; filename=%q`, field, name))
+	header.Set("Content-Type", mimeType)
+	part, err := writer.CreatePart(header)
+	if err != nil {
+		return err
+	}
+	_, err = part.Write(decoded)
+	return err
+}
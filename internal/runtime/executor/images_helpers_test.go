@@ -0,0 +1,103 @@
+package executor
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	"github.com/tidwall/gjson"
+)
+
+func TestExecuteImagesViaChatCollectsInlineImages(t *testing.T) {
+	var calls int
+	var gotPayload []byte
+	execute := func(_ context.Context, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+		calls++
+		gotPayload = req.Payload
+		if opts.Alt != "" || opts.SourceFormat.String() != "openai" {
+			t.Fatalf("unexpected chat options alt=%q source=%q", opts.Alt, opts.SourceFormat)
+		}
+		return cliproxyexecutor.Response{Payload: []byte(`{"choices":[{"message":{"role":"assistant","images":[{"type":"image_url","image_url":{"url":"data:image/png;base64,QUJD"}}]}}],"usage":{"prompt_tokens":4,"completion_tokens":10}}`)}, nil
+	}
+
+	resp, err := executeImagesViaChat(context.Background(), cliproxyexecutor.Request{
+		Model:   "gemini-2.5-flash-image",
+		Payload: []byte(`{"model":"gemini-2.5-flash-image","prompt":"a red fox","n":2,"size":"1792x1024"}`),
+	}, cliproxyexecutor.Options{Alt: imagesGenerationsAlt}, execute)
+	if err != nil {
+		t.Fatalf("executeImagesViaChat error: %v", err)
+	}
+	if calls != 2 {
+		t.Fatalf("upstream calls = %d, want 2", calls)
+	}
+	if got := gjson.GetBytes(gotPayload, "image_config.aspect_ratio").String(); got != "16:9" {
+		t.Fatalf("aspect ratio = %q, want 16:9", got)
+	}
+	if got := gjson.GetBytes(gotPayload, "messages.0.content.0.text").String(); got != "a red fox" {
+		t.Fatalf("prompt = %q", got)
+	}
+	data := gjson.GetBytes(resp.Payload, "data").Array()
+	if len(data) != 2 || data[0].Get("b64_json").String() != "QUJD" {
+		t.Fatalf("unexpected images response %s", resp.Payload)
+	}
+	if got := gjson.GetBytes(resp.Payload, "usage.total_tokens").Int(); got != 28 {
+		t.Fatalf("total tokens = %d, want 28", got)
+	}
+}
+
+func TestExecuteImagesViaChatWithoutImageFails(t *testing.T) {
+	execute := func(context.Context, cliproxyexecutor.Request, cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+		return cliproxyexecutor.Response{Payload: []byte(`{"choices":[{"message":{"role":"assistant","content":"I can't draw that."}}]}`)}, nil
+	}
+	_, err := executeImagesViaChat(context.Background(), cliproxyexecutor.Request{
+		Payload: []byte(`{"prompt":"x"}`),
+	}, cliproxyexecutor.Options{Alt: imagesGenerationsAlt}, execute)
+	se, ok := err.(statusErr)
+	if !ok || se.code != http.StatusBadGateway || !strings.Contains(se.msg, "I can't draw that.") {
+		t.Fatalf("expected 502 with refusal text, got %v", err)
+	}
+}
+
+func TestOpenAICompatExecutorImagesEditsMultipart(t *testing.T) {
+	var gotPath, gotModel, gotPrompt, gotFile string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		if err := r.ParseMultipartForm(1 << 20); err != nil {
+			t.Errorf("parse multipart: %v", err)
+		}
+		gotModel = r.FormValue("model")
+		gotPrompt = r.FormValue("prompt")
+		if file, _, err := r.FormFile("image"); err == nil {
+			data, _ := io.ReadAll(file)
+			gotFile = string(data)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"created":1,"data":[{"b64_json":"QUJD"}]}`))
+	}))
+	defer server.Close()
+
+	executor := NewOpenAICompatExecutor("openai-compatibility", &config.Config{})
+	auth := &cliproxyauth.Auth{Attributes: map[string]string{"base_url": server.URL + "/v1", "api_key": "test"}}
+	resp, err := executor.Execute(context.Background(), auth, cliproxyexecutor.Request{
+		Model:   "gpt-image-1",
+		Payload: []byte(`{"model":"gpt-image-1","prompt":"add a hat","image":["data:image/png;base64,QUJD"]}`),
+	}, cliproxyexecutor.Options{Alt: imagesEditsAlt})
+	if err != nil {
+		t.Fatalf("Execute error: %v", err)
+	}
+	if gotPath != "/v1/images/edits" {
+		t.Fatalf("path = %q", gotPath)
+	}
+	if gotModel != "gpt-image-1" || gotPrompt != "add a hat" || gotFile != "ABC" {
+		t.Fatalf("multipart fields model=%q prompt=%q file=%q", gotModel, gotPrompt, gotFile)
+	}
+	if string(resp.Payload) != `{"created":1,"data":[{"b64_json":"QUJD"}]}` {
+		t.Fatalf("payload = %s", resp.Payload)
+	}
+}
@@ -312,13 +312,13 @@ func newKiroHTTPClientWithPooling(ctx context.Context, cfg *config.Config, auth
 
 	// If timeout is specified, we need to wrap the pooled transport with timeout
 	if timeout > 0 {
-		return &http.Client{
+		return withTracingTransport(&http.Client{
 			Transport: pooledClient.Transport,
 			Timeout:   timeout,
-		}
+		})
 	}
 
-	return pooledClient
+	return withTracingTransport(pooledClient)
 }
 
 // kiroEndpointConfig bundles endpoint URL with its compatible Origin and AmzTarget values.
@@ -515,17 +515,35 @@ func isIDCAuth(auth *cliproxyauth.Auth) bool {
 // headers parameter allows checking Anthropic-Beta header for thinking mode detection.
 // Returns the serialized JSON payload and a boolean indicating whether thinking mode was injected.
 func buildKiroPayloadForFormat(body []byte, modelID, profileArn, origin string, isAgentic, isChatOnly bool, sourceFormat sdktranslator.Format, headers http.Header) ([]byte, bool) {
+	// Sources without a Kiro translator are chained through a hub; the body then has the
+	// shape of the hub format.
+	if route, ok := sdktranslator.ResolveRoute(sourceFormat, sdktranslator.FromString("kiro")); ok && route.Chained() {
+		sourceFormat = route.Via()
+	}
 	switch sourceFormat.String() {
 	case "openai":
 		log.Debugf("kiro: using OpenAI payload builder for source format: %s", sourceFormat.String())
 		return kiroopenai.BuildKiroPayloadFromOpenAI(body, modelID, profileArn, origin, isAgentic, isChatOnly, headers, nil)
 	default:
-		// Default to Claude format (also handles "claude", "kiro", etc.)
+		// Default to Claude format (also handles "claude", "kiro", etc.). Gemini and Gemini CLI
+		// requests are translated to a Claude body by the kiro/gemini translators.
 		log.Debugf("kiro: using Claude payload builder for source format: %s", sourceFormat.String())
 		return kiroclaude.BuildKiroPayload(body, modelID, profileArn, origin, isAgentic, isChatOnly, headers, nil)
 	}
 }
 
+// kiroPromptFormat returns the wire format of the translated request body, which is what
+// buildKiroPayloadForFormat builds the Kiro payload from.
+func kiroPromptFormat(sourceFormat sdktranslator.Format) string {
+	if route, ok := sdktranslator.ResolveRoute(sourceFormat, sdktranslator.FromString("kiro")); ok && route.Chained() {
+		sourceFormat = route.Via()
+	}
+	if sourceFormat.String() == "openai" {
+		return "openai"
+	}
+	return "claude"
+}
+
 // NewKiroExecutor creates a new Kiro executor instance.
 func NewKiroExecutor(cfg *config.Config) *KiroExecutor {
 	return &KiroExecutor{cfg: cfg}
@@ -613,6 +631,9 @@ func getTokenKey(auth *cliproxyauth.Auth) string {
 // Execute sends the request to Kiro API and returns the response.
 // Supports automatic token refresh on 401/403 errors.
 func (e *KiroExecutor) Execute(ctx context.Context, auth *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (resp cliproxyexecutor.Response, err error) {
+	if opts.Alt == embeddingsAlt || isImagesAlt(opts.Alt) {
+		return resp, statusErr{code: http.StatusNotImplemented, msg: "/" + opts.Alt + " not supported"}
+	}
 	accessToken, profileArn := kiroCredentials(auth)
 	if accessToken == "" {
 		return resp, fmt.Errorf("kiro: access token not found in auth")
@@ -670,7 +691,11 @@ func (e *KiroExecutor) Execute(ctx context.Context, auth *cliproxyauth.Auth, req
 
 	from := opts.SourceFormat
 	to := sdktranslator.FromString("kiro")
-	body := sdktranslator.TranslateRequest(from, to, req.Model, bytes.Clone(req.Payload), true)
+	if err = sdktranslator.ValidateRequest(from, to, req.Payload); err != nil {
+		return resp, err
+	}
+	body := sdktranslator.TranslateRequestWithContext(ctx, from, to, req.Model, bytes.Clone(req.Payload), true)
+	reporter.setPrompt(kiroPromptFormat(from), body)
 
 	kiroModelID := e.mapModelToKiro(req.Model)
 
@@ -1007,6 +1032,7 @@ func (e *KiroExecutor) executeWithRetry(ctx context.Context, auth *cliproxyauth.
 
 			appendAPIResponseChunk(ctx, e.cfg, []byte(content))
 			reporter.publish(ctx, usageInfo)
+			reporter.ensurePublished(ctx)
 
 			// Record success for rate limiting
 			rateLimiter.MarkTokenSuccess(tokenKey)
@@ -1091,7 +1117,11 @@ func (e *KiroExecutor) ExecuteStream(ctx context.Context, auth *cliproxyauth.Aut
 
 	from := opts.SourceFormat
 	to := sdktranslator.FromString("kiro")
-	body := sdktranslator.TranslateRequest(from, to, req.Model, bytes.Clone(req.Payload), true)
+	if err = sdktranslator.ValidateRequest(from, to, req.Payload); err != nil {
+		return nil, err
+	}
+	body := sdktranslator.TranslateRequestWithContext(ctx, from, to, req.Model, bytes.Clone(req.Payload), true)
+	reporter.setPrompt(kiroPromptFormat(from), body)
 
 	kiroModelID := e.mapModelToKiro(req.Model)
 
@@ -1457,6 +1487,31 @@ func kiroCredentials(auth *cliproxyauth.Auth) (accessToken, profileArn string) {
 	return accessToken, profileArn
 }
 
+// PollQuota implements cliproxyauth.QuotaPoller using the CodeWhisperer usage limits API.
+// Limits of all resource types, including free-trial allowances, are summed.
+func (e *KiroExecutor) PollQuota(ctx context.Context, auth *cliproxyauth.Auth) (*cliproxyauth.QuotaSnapshot, error) {
+	accessToken, profileArn := kiroCredentials(auth)
+	if accessToken == "" {
+		return nil, fmt.Errorf("kiro: access token not found in auth")
+	}
+	checker := kiroauth.NewUsageCheckerWithClient(newProxyAwareHTTPClient(ctx, e.cfg, auth, 0))
+	usageResp, err := checker.CheckUsageByAccessToken(ctx, accessToken, getEffectiveProfileArn(auth, profileArn))
+	if err != nil {
+		return nil, err
+	}
+	snapshot := &cliproxyauth.QuotaSnapshot{Remaining: kiroauth.GetRemainingQuota(usageResp)}
+	for _, breakdown := range usageResp.UsageBreakdownList {
+		snapshot.Limit += breakdown.UsageLimitWithPrecision
+		if breakdown.FreeTrialInfo != nil {
+			snapshot.Limit += breakdown.FreeTrialInfo.UsageLimitWithPrecision
+		}
+	}
+	if usageResp.NextDateReset > 0 {
+		snapshot.ResetAt = time.UnixMilli(int64(usageResp.NextDateReset))
+	}
+	return snapshot, nil
+}
+
 // findRealThinkingEndTag finds the real </thinking> end tag, skipping false positives.
 // Returns -1 if no real end tag is found.
 //
@@ -2526,6 +2581,7 @@ func (e *KiroExecutor) streamToChannel(ctx context.Context, body io.Reader, out
 	// Ensure usage is published even on early return
 	defer func() {
 		reporter.publish(ctx, totalUsage)
+		reporter.ensurePublished(ctx)
 	}()
 
 	for {
@@ -3236,6 +3292,13 @@ func (e *KiroExecutor) streamToChannel(ctx context.Context, body io.Reader, out
 			for _, tu := range completedToolUses {
 				// Check if this tool was truncated - emit with SOFT_LIMIT_REACHED marker
 				if tu.IsTruncated {
+					// When the handler can resume the stream elsewhere and no earlier tool call was
+					// sent, end it here so the call is regenerated in full instead of a marker.
+					if !hasToolUses && cliproxyexecutor.StreamContinuation(ctx) {
+						log.Infof("kiro: streamToChannel ending stream at truncated tool %s (ID: %s) for continuation", tu.Name, tu.ToolUseID)
+						out <- cliproxyexecutor.StreamChunk{Err: fmt.Errorf("kiro: tool use %s truncated at output limit", tu.Name)}
+						return
+					}
 					hasTruncatedTools = true
 					log.Infof("kiro: streamToChannel emitting truncated tool with SOFT_LIMIT_REACHED: %s (ID: %s)", tu.Name, tu.ToolUseID)
 
@@ -0,0 +1,108 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/websocket"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// maxLiveMessageBytes bounds a single upstream Live API message.
+const maxLiveMessageBytes = 16 << 20
+
+// geminiLiveSession relays a Gemini Live BidiGenerateContent websocket. It rewrites the model
+// of the client's setup message to the upstream model name and publishes the usage reported
+// in usageMetadata messages, one record per report.
+type geminiLiveSession struct {
+	ctx           context.Context
+	conn          *websocket.Conn
+	provider      string
+	model         string
+	upstreamModel string
+	auth          *cliproxyauth.Auth
+}
+
+// dialGeminiLive opens the upstream Live API websocket at target.
+func dialGeminiLive(ctx context.Context, cfg *config.Config, auth *cliproxyauth.Auth, provider, model, upstreamModel, target string, header http.Header) (cliproxyexecutor.LiveSession, error) {
+	conn, resp, err := newProxyAwareWebsocketDialer(cfg, auth).DialContext(ctx, target, header)
+	if err != nil {
+		if resp != nil {
+			body, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+			_ = resp.Body.Close()
+			msg := strings.TrimSpace(string(body))
+			if msg == "" {
+				msg = fmt.Sprintf("%s live handshake failed: %v", provider, err)
+			}
+			return nil, statusErr{code: resp.StatusCode, msg: msg}
+		}
+		return nil, fmt.Errorf("%s live dial failed: %w", provider, err)
+	}
+	conn.SetReadLimit(maxLiveMessageBytes)
+	return &geminiLiveSession{
+		ctx:           ctx,
+		conn:          conn,
+		provider:      provider,
+		model:         model,
+		upstreamModel: upstreamModel,
+		auth:          auth,
+	}, nil
+}
+
+// Send implements cliproxyexecutor.LiveSession.
+func (s *geminiLiveSession) Send(messageType int, data []byte) error {
+	if gjson.GetBytes(data, "setup").IsObject() {
+		if rewritten, err := sjson.SetBytes(data, "setup.model", s.upstreamModel); err == nil {
+			data = rewritten
+		}
+	}
+	return s.conn.WriteMessage(messageType, data)
+}
+
+// Receive implements cliproxyexecutor.LiveSession.
+func (s *geminiLiveSession) Receive() (int, []byte, error) {
+	messageType, data, err := s.conn.ReadMessage()
+	if err != nil {
+		return messageType, data, err
+	}
+	detail := parseGeminiUsage(data)
+	if detail.OutputTokens == 0 {
+		// Live API usage names the output tokens responseTokenCount.
+		detail.OutputTokens = gjson.GetBytes(data, "usageMetadata.responseTokenCount").Int()
+	}
+	if detail.TotalTokens > 0 {
+		newUsageReporter(s.ctx, s.provider, s.model, s.auth).publish(s.ctx, detail)
+	}
+	return messageType, data, nil
+}
+
+// Close implements cliproxyexecutor.LiveSession.
+func (s *geminiLiveSession) Close() error {
+	return s.conn.Close()
+}
+
+// websocketURL converts an http(s) base URL into its ws(s) equivalent.
+func websocketURL(base string) string {
+	switch {
+	case strings.HasPrefix(base, "https://"):
+		return "wss://" + strings.TrimPrefix(base, "https://")
+	case strings.HasPrefix(base, "http://"):
+		return "ws://" + strings.TrimPrefix(base, "http://")
+	}
+	return base
+}
+
+// liveHeader returns the handshake headers for a Live API session, including the custom
+// headers configured on auth.
+func liveHeader(auth *cliproxyauth.Auth) http.Header {
+	req := &http.Request{Header: make(http.Header)}
+	applyGeminiHeaders(req, auth)
+	return req.Header
+}
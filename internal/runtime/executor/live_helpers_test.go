@@ -0,0 +1,80 @@
+package executor
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/gorilla/websocket"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
+	"github.com/tidwall/gjson"
+)
+
+func TestGeminiExecutorExecuteLive_RelaysSessionAndReportsUsage(t *testing.T) {
+	var gotPath, gotKey, gotSetupModel string
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath, gotKey = r.URL.Path, r.Header.Get("x-goog-api-key")
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer func() { _ = conn.Close() }()
+		_, setup, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		gotSetupModel = gjson.GetBytes(setup, "setup.model").String()
+		_ = conn.WriteMessage(websocket.BinaryMessage, []byte(`{"setupComplete":{}}`))
+		_ = conn.WriteMessage(websocket.BinaryMessage, []byte(`{"serverContent":{"turnComplete":true},"usageMetadata":{"promptTokenCount":7,"responseTokenCount":3,"totalTokenCount":10}}`))
+		_ = conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, "done"))
+	}))
+	defer server.Close()
+
+	var mu sync.Mutex
+	var records []usage.Record
+	ctx := usage.WithRecordObserver(context.Background(), func(record usage.Record) {
+		mu.Lock()
+		records = append(records, record)
+		mu.Unlock()
+	})
+	auth := &cliproxyauth.Auth{ID: "gemini-live", Provider: "gemini", Attributes: map[string]string{"api_key": "live-key", "base_url": server.URL}}
+	session, err := NewGeminiExecutor(nil).ExecuteLive(ctx, auth, cliproxyexecutor.Request{Model: "gemini-live-model"}, cliproxyexecutor.Options{})
+	if err != nil {
+		t.Fatalf("ExecuteLive() error = %v", err)
+	}
+	defer func() { _ = session.Close() }()
+	if err = session.Send(websocket.TextMessage, []byte(`{"setup":{"model":"models/client-alias"}}`)); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	var messages int
+	for {
+		_, _, errRecv := session.Receive()
+		if errRecv != nil {
+			if !websocket.IsCloseError(errRecv, websocket.CloseNormalClosure) {
+				t.Fatalf("expected a normal close, got %v", errRecv)
+			}
+			break
+		}
+		messages++
+	}
+
+	if gotPath != "/ws/google.ai.generativelanguage.v1beta.GenerativeService.BidiGenerateContent" || gotKey != "live-key" {
+		t.Fatalf("unexpected handshake path %q key %q", gotPath, gotKey)
+	}
+	if gotSetupModel != "models/gemini-live-model" {
+		t.Fatalf("expected the setup model to be rewritten, got %q", gotSetupModel)
+	}
+	if messages != 2 {
+		t.Fatalf("expected 2 relayed messages, got %d", messages)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(records) != 1 || records[0].Detail.InputTokens != 7 || records[0].Detail.OutputTokens != 3 || records[0].Detail.TotalTokens != 10 || records[0].AuthID != "gemini-live" {
+		t.Fatalf("unexpected usage records %+v", records)
+	}
+}
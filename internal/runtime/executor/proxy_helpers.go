@@ -9,7 +9,9 @@ import (
 	"sync"
 	"time"
 
+	"github.com/gorilla/websocket"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/tracing"
 	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
 	log "github.com/sirupsen/logrus"
 	"golang.org/x/net/proxy"
@@ -37,6 +39,22 @@ var (
 // Returns:
 //   - *http.Client: An HTTP client with configured proxy or transport
 func newProxyAwareHTTPClient(ctx context.Context, cfg *config.Config, auth *cliproxyauth.Auth, timeout time.Duration) *http.Client {
+	return withTracingTransport(buildProxyAwareHTTPClient(ctx, cfg, auth, timeout))
+}
+
+// withTracingTransport wraps the client transport with upstream HTTP spans when tracing is enabled.
+// The shared cached client is left untouched; a shallow copy carries the wrapped transport.
+func withTracingTransport(client *http.Client) *http.Client {
+	if client == nil || !tracing.Enabled() {
+		return client
+	}
+	return &http.Client{
+		Transport: tracing.WrapTransport(client.Transport),
+		Timeout:   client.Timeout,
+	}
+}
+
+func buildProxyAwareHTTPClient(ctx context.Context, cfg *config.Config, auth *cliproxyauth.Auth, timeout time.Duration) *http.Client {
 	// Priority 1: Use auth.ProxyURL if configured
 	var proxyURL string
 	if auth != nil {
@@ -153,3 +171,51 @@ func buildProxyTransport(proxyURL string) *http.Transport {
 
 	return transport
 }
+
+// newProxyAwareWebsocketDialer creates a websocket dialer that follows the same proxy priority
+// as newProxyAwareHTTPClient: auth.ProxyURL, then cfg.ProxyURL, then the environment.
+func newProxyAwareWebsocketDialer(cfg *config.Config, auth *cliproxyauth.Auth) *websocket.Dialer {
+	dialer := &websocket.Dialer{
+		Proxy:            http.ProxyFromEnvironment,
+		HandshakeTimeout: 45 * time.Second,
+	}
+	var proxyURL string
+	if auth != nil {
+		proxyURL = strings.TrimSpace(auth.ProxyURL)
+	}
+	if proxyURL == "" && cfg != nil {
+		proxyURL = strings.TrimSpace(cfg.ProxyURL)
+	}
+	if proxyURL == "" {
+		return dialer
+	}
+
+	parsedURL, errParse := url.Parse(proxyURL)
+	if errParse != nil {
+		log.Errorf("parse proxy URL failed: %v", errParse)
+		return dialer
+	}
+	switch parsedURL.Scheme {
+	case "socks5":
+		var proxyAuth *proxy.Auth
+		if parsedURL.User != nil {
+			username := parsedURL.User.Username()
+			password, _ := parsedURL.User.Password()
+			proxyAuth = &proxy.Auth{User: username, Password: password}
+		}
+		socksDialer, errSOCKS5 := proxy.SOCKS5("tcp", parsedURL.Host, proxyAuth, proxy.Direct)
+		if errSOCKS5 != nil {
+			log.Errorf("create SOCKS5 dialer failed: %v", errSOCKS5)
+			return dialer
+		}
+		dialer.Proxy = nil
+		dialer.NetDialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+			return socksDialer.Dial(network, addr)
+		}
+	case "http", "https":
+		dialer.Proxy = http.ProxyURL(parsedURL)
+	default:
+		log.Errorf("unsupported proxy scheme: %s", parsedURL.Scheme)
+	}
+	return dialer
+}
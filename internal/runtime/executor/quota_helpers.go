@@ -0,0 +1,199 @@
+package executor
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	log "github.com/sirupsen/logrus"
+)
+
+// fetchQuotaJSON issues a quota lookup for auth and returns the response body. Polls run in
+// the background outside any client request, so unlike postUpstream they are not written to
+// the request log. prepare sets the provider credentials and headers.
+func fetchQuotaJSON(ctx context.Context, cfg *config.Config, auth *cliproxyauth.Auth, method, url string, body []byte, prepare func(*http.Request)) ([]byte, error) {
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, method, url, reader)
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Accept", "application/json")
+	if body != nil {
+		httpReq.Header.Set("Content-Type", "application/json")
+	}
+	if prepare != nil {
+		prepare(httpReq)
+	}
+	httpResp, err := newProxyAwareHTTPClient(ctx, cfg, auth, 0).Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if errClose := httpResp.Body.Close(); errClose != nil {
+			log.Errorf("quota poll: close response body error: %v", errClose)
+		}
+	}()
+	data, err := io.ReadAll(httpResp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if httpResp.StatusCode < 200 || httpResp.StatusCode >= 300 {
+		return nil, statusErr{code: httpResp.StatusCode, msg: string(data)}
+	}
+	return data, nil
+}
+
+// fractionQuota expresses a remaining fraction as a percent-based snapshot.
+func fractionQuota(fraction float64, resetAt time.Time) cliproxyauth.QuotaSnapshot {
+	return cliproxyauth.QuotaSnapshot{Remaining: fraction * 100, Limit: 100, ResetAt: resetAt}
+}
+
+// parseQuotaResetTime accepts the RFC 3339 timestamps and plain dates used by quota endpoints.
+func parseQuotaResetTime(value string) time.Time {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return time.Time{}
+	}
+	if ts, err := time.Parse(time.RFC3339, value); err == nil {
+		return ts
+	}
+	if ts, err := time.Parse("2006-01-02", value); err == nil {
+		return ts
+	}
+	return time.Time{}
+}
+
+// codexRateLimits keeps the latest rate-limit window reported by Codex responses per auth ID.
+var codexRateLimits sync.Map
+
+// rememberCodexRateLimits records the x-codex-primary-* and x-codex-secondary-* headers of a
+// Codex response. The more depleted of the two windows is kept.
+func rememberCodexRateLimits(auth *cliproxyauth.Auth, header http.Header) {
+	if auth == nil || auth.ID == "" {
+		return
+	}
+	now := time.Now()
+	var snapshot *cliproxyauth.QuotaSnapshot
+	for _, window := range []string{"primary", "secondary"} {
+		used, errParse := strconv.ParseFloat(header.Get("x-codex-"+window+"-used-percent"), 64)
+		if errParse != nil {
+			continue
+		}
+		remaining := 100 - used
+		if remaining < 0 {
+			remaining = 0
+		}
+		if snapshot != nil && snapshot.Remaining <= remaining {
+			continue
+		}
+		current := cliproxyauth.QuotaSnapshot{Remaining: remaining, Limit: 100}
+		if seconds, errReset := strconv.ParseInt(header.Get("x-codex-"+window+"-reset-after-seconds"), 10, 64); errReset == nil && seconds > 0 {
+			current.ResetAt = now.Add(time.Duration(seconds) * time.Second)
+		}
+		snapshot = &current
+	}
+	if snapshot != nil {
+		codexRateLimits.Store(auth.ID, *snapshot)
+	}
+}
+
+// rateLimitWindows lists the remaining/limit/reset header triplets of the request and token
+// windows reported by Anthropic (RFC 3339 resets) and OpenAI-style upstreams (duration resets).
+var rateLimitWindows = []struct {
+	remaining, limit, reset string
+	resetIsDuration         bool
+}{
+	{"anthropic-ratelimit-requests-remaining", "anthropic-ratelimit-requests-limit", "anthropic-ratelimit-requests-reset", false},
+	{"anthropic-ratelimit-tokens-remaining", "anthropic-ratelimit-tokens-limit", "anthropic-ratelimit-tokens-reset", false},
+	{"anthropic-ratelimit-input-tokens-remaining", "anthropic-ratelimit-input-tokens-limit", "anthropic-ratelimit-input-tokens-reset", false},
+	{"anthropic-ratelimit-output-tokens-remaining", "anthropic-ratelimit-output-tokens-limit", "anthropic-ratelimit-output-tokens-reset", false},
+	{"x-ratelimit-remaining-requests", "x-ratelimit-limit-requests", "x-ratelimit-reset-requests", true},
+	{"x-ratelimit-remaining-tokens", "x-ratelimit-limit-tokens", "x-ratelimit-reset-tokens", true},
+}
+
+// parseRateLimitHeaders extracts the most depleted rate-limit window from an upstream
+// response. Besides the request/token windows above it understands the percent-based Codex
+// (x-codex-primary-*, x-codex-secondary-*) and Claude subscription
+// (anthropic-ratelimit-unified-5h-*, -7d-*) windows.
+func parseRateLimitHeaders(header http.Header, now time.Time) (cliproxyexecutor.RateLimit, bool) {
+	var best cliproxyexecutor.RateLimit
+	found := false
+	consider := func(remaining, limit float64, resetAt time.Time) {
+		if limit <= 0 {
+			return
+		}
+		if remaining < 0 {
+			remaining = 0
+		}
+		if found && remaining/limit >= best.Remaining/best.Limit {
+			return
+		}
+		best = cliproxyexecutor.RateLimit{Remaining: remaining, Limit: limit, ResetAt: resetAt}
+		found = true
+	}
+	headerFloat := func(key string) (float64, bool) {
+		value, err := strconv.ParseFloat(strings.TrimSpace(header.Get(key)), 64)
+		return value, err == nil
+	}
+
+	for _, window := range rateLimitWindows {
+		remaining, okRemaining := headerFloat(window.remaining)
+		limit, okLimit := headerFloat(window.limit)
+		if !okRemaining || !okLimit {
+			continue
+		}
+		var resetAt time.Time
+		if reset := strings.TrimSpace(header.Get(window.reset)); reset != "" {
+			if window.resetIsDuration {
+				if d, err := time.ParseDuration(reset); err == nil {
+					resetAt = now.Add(d)
+				}
+			} else {
+				resetAt = parseQuotaResetTime(reset)
+			}
+		}
+		consider(remaining, limit, resetAt)
+	}
+	for _, window := range []string{"primary", "secondary"} {
+		used, ok := headerFloat("x-codex-" + window + "-used-percent")
+		if !ok {
+			continue
+		}
+		var resetAt time.Time
+		if seconds, okReset := headerFloat("x-codex-" + window + "-reset-after-seconds"); okReset && seconds > 0 {
+			resetAt = now.Add(time.Duration(seconds * float64(time.Second)))
+		}
+		consider(100-used, 100, resetAt)
+	}
+	for _, window := range []string{"5h", "7d"} {
+		utilization, ok := headerFloat("anthropic-ratelimit-unified-" + window + "-utilization")
+		if !ok {
+			continue
+		}
+		var resetAt time.Time
+		if unix, okReset := headerFloat("anthropic-ratelimit-unified-" + window + "-reset"); okReset && unix > 0 {
+			resetAt = time.Unix(int64(unix), 0)
+		}
+		consider((1-utilization)*100, 100, resetAt)
+	}
+	return best, found
+}
+
+// reportRateLimitHeaders forwards the rate limits found in an upstream response to the auth
+// manager so nearly exhausted credentials are deprioritized before they start failing.
+func reportRateLimitHeaders(ctx context.Context, header http.Header) {
+	if limit, ok := parseRateLimitHeaders(header, time.Now()); ok {
+		cliproxyexecutor.ReportRateLimit(ctx, limit)
+	}
+}
@@ -0,0 +1,102 @@
+package executor
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+)
+
+func TestCodexPollQuotaReportsMostDepletedWindow(t *testing.T) {
+	auth := &cliproxyauth.Auth{ID: "codex-quota-test"}
+	t.Cleanup(func() { codexRateLimits.Delete(auth.ID) })
+	exec := NewCodexExecutor(nil)
+
+	if snapshot, err := exec.PollQuota(context.Background(), auth); err != nil || snapshot != nil {
+		t.Fatalf("PollQuota() before any response = %+v, %v; want nil", snapshot, err)
+	}
+
+	header := http.Header{}
+	header.Set("x-codex-primary-used-percent", "25")
+	header.Set("x-codex-primary-reset-after-seconds", "600")
+	header.Set("x-codex-secondary-used-percent", "90.5")
+	header.Set("x-codex-secondary-reset-after-seconds", "86400")
+	rememberCodexRateLimits(auth, header)
+
+	snapshot, err := exec.PollQuota(context.Background(), auth)
+	if err != nil || snapshot == nil {
+		t.Fatalf("PollQuota() = %+v, %v", snapshot, err)
+	}
+	if snapshot.Limit != 100 || snapshot.Remaining != 9.5 {
+		t.Fatalf("snapshot = %+v, want secondary window with 9.5 remaining", snapshot)
+	}
+	if until := time.Until(snapshot.ResetAt); until < 23*time.Hour || until > 24*time.Hour {
+		t.Fatalf("reset in %v, want about a day", until)
+	}
+}
+
+func TestParseRateLimitHeadersPicksMostDepletedWindow(t *testing.T) {
+	now := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+	header := http.Header{}
+	header.Set("anthropic-ratelimit-requests-remaining", "40")
+	header.Set("anthropic-ratelimit-requests-limit", "50")
+	header.Set("anthropic-ratelimit-tokens-remaining", "1000")
+	header.Set("anthropic-ratelimit-tokens-limit", "80000")
+	header.Set("anthropic-ratelimit-tokens-reset", "2026-10-17T12:01:00Z")
+
+	limit, ok := parseRateLimitHeaders(header, now)
+	if !ok || limit.Remaining != 1000 || limit.Limit != 80000 || !limit.ResetAt.Equal(now.Add(time.Minute)) {
+		t.Fatalf("anthropic limit = %+v, %v; want token window", limit, ok)
+	}
+
+	header = http.Header{}
+	header.Set("x-ratelimit-remaining-requests", "2")
+	header.Set("x-ratelimit-limit-requests", "100")
+	header.Set("x-ratelimit-reset-requests", "6m0s")
+	header.Set("x-codex-primary-used-percent", "25")
+	header.Set("x-codex-secondary-used-percent", "90.5")
+	header.Set("x-codex-secondary-reset-after-seconds", "86400")
+	limit, ok = parseRateLimitHeaders(header, now)
+	if !ok || limit.Remaining != 2 || !limit.ResetAt.Equal(now.Add(6*time.Minute)) {
+		t.Fatalf("openai limit = %+v, %v; want request window", limit, ok)
+	}
+
+	header.Del("x-ratelimit-remaining-requests")
+	limit, _ = parseRateLimitHeaders(header, now)
+	if limit.Limit != 100 || limit.Remaining != 9.5 || !limit.ResetAt.Equal(now.Add(24*time.Hour)) {
+		t.Fatalf("codex limit = %+v, want secondary window", limit)
+	}
+
+	if _, ok = parseRateLimitHeaders(http.Header{}, now); ok {
+		t.Fatal("expected no limit without rate-limit headers")
+	}
+}
+
+func TestReportRateLimitHeadersUsesContextReporter(t *testing.T) {
+	var got []cliproxyexecutor.RateLimit
+	ctx := cliproxyexecutor.WithRateLimitReporter(context.Background(), func(limit cliproxyexecutor.RateLimit) {
+		got = append(got, limit)
+	})
+	header := http.Header{}
+	header.Set("anthropic-ratelimit-unified-5h-utilization", "0.97")
+	reportRateLimitHeaders(ctx, header)
+	reportRateLimitHeaders(context.Background(), header)
+	if len(got) != 1 || got[0].Limit != 100 || got[0].Remaining < 2.9 || got[0].Remaining > 3.1 {
+		t.Fatalf("reported = %+v, want one 3%% window", got)
+	}
+}
+
+func TestParseQuotaResetTime(t *testing.T) {
+	if got := parseQuotaResetTime("2026-11-01"); !got.Equal(time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("date = %v", got)
+	}
+	if got := parseQuotaResetTime("2026-10-18T07:00:00Z"); !got.Equal(time.Date(2026, 10, 18, 7, 0, 0, 0, time.UTC)) {
+		t.Fatalf("timestamp = %v", got)
+	}
+	if got := parseQuotaResetTime("soon"); !got.IsZero() {
+		t.Fatalf("invalid = %v, want zero", got)
+	}
+}
@@ -9,7 +9,10 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/tokencount"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/tracing"
 	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
 	"github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
 	"github.com/tidwall/gjson"
 	"github.com/tidwall/sjson"
@@ -23,7 +26,12 @@ type usageReporter struct {
 	apiKey      string
 	source      string
 	requestedAt time.Time
-	once        sync.Once
+	fallback    string
+	// promptFormat and prompt hold the upstream request, counted locally when the
+	// response carries no usage.
+	promptFormat string
+	prompt       []byte
+	once         sync.Once
 }
 
 func newUsageReporter(ctx context.Context, provider, model string, auth *cliproxyauth.Auth) *usageReporter {
@@ -34,6 +42,7 @@ func newUsageReporter(ctx context.Context, provider, model string, auth *cliprox
 		requestedAt: time.Now(),
 		apiKey:      apiKey,
 		source:      resolveUsageSource(auth, apiKey),
+		fallback:    cliproxyexecutor.FallbackFrom(ctx),
 	}
 	if auth != nil {
 		reporter.authID = auth.ID
@@ -42,6 +51,16 @@ func newUsageReporter(ctx context.Context, provider, model string, auth *cliprox
 	return reporter
 }
 
+// setPrompt records the upstream request payload and its wire format so ensurePublished can
+// estimate input tokens when the upstream response omits usage.
+func (r *usageReporter) setPrompt(format string, payload []byte) {
+	if r == nil {
+		return
+	}
+	r.promptFormat = format
+	r.prompt = payload
+}
+
 func (r *usageReporter) publish(ctx context.Context, detail usage.Detail) {
 	r.publishWithOutcome(ctx, detail, false)
 }
@@ -73,16 +92,20 @@ func (r *usageReporter) publishWithOutcome(ctx context.Context, detail usage.Det
 		return
 	}
 	r.once.Do(func() {
-		usage.PublishRecord(ctx, usage.Record{
-			Provider:    r.provider,
-			Model:       r.model,
-			Source:      r.source,
-			APIKey:      r.apiKey,
-			AuthID:      r.authID,
-			AuthIndex:   r.authIndex,
-			RequestedAt: r.requestedAt,
-			Failed:      failed,
-			Detail:      detail,
+		cliproxyexecutor.PublishUsage(ctx, func() {
+			tracing.RecordUsage(ctx, detail.InputTokens, detail.OutputTokens, detail.ReasoningTokens, detail.CachedTokens, detail.TotalTokens)
+			usage.PublishRecord(ctx, usage.Record{
+				Provider:     r.provider,
+				Model:        r.model,
+				Source:       r.source,
+				APIKey:       r.apiKey,
+				AuthID:       r.authID,
+				AuthIndex:    r.authIndex,
+				RequestedAt:  r.requestedAt,
+				Failed:       failed,
+				Detail:       detail,
+				FallbackFrom: r.fallback,
+			})
 		})
 	})
 }
@@ -90,26 +113,48 @@ func (r *usageReporter) publishWithOutcome(ctx context.Context, detail usage.Det
 // ensurePublished guarantees that a usage record is emitted exactly once.
 // It is safe to call multiple times; only the first call wins due to once.Do.
 // This is used to ensure request counting even when upstream responses do not
-// include any usage fields (tokens), especially for streaming paths.
+// include any usage fields (tokens), especially for streaming paths. When the
+// request payload was recorded with setPrompt, its input tokens are counted locally.
 func (r *usageReporter) ensurePublished(ctx context.Context) {
 	if r == nil {
 		return
 	}
 	r.once.Do(func() {
-		usage.PublishRecord(ctx, usage.Record{
-			Provider:    r.provider,
-			Model:       r.model,
-			Source:      r.source,
-			APIKey:      r.apiKey,
-			AuthID:      r.authID,
-			AuthIndex:   r.authIndex,
-			RequestedAt: r.requestedAt,
-			Failed:      false,
-			Detail:      usage.Detail{},
+		detail := r.estimatePromptUsage()
+		cliproxyexecutor.PublishUsage(ctx, func() {
+			usage.PublishRecord(ctx, usage.Record{
+				Provider:     r.provider,
+				Model:        r.model,
+				Source:       r.source,
+				APIKey:       r.apiKey,
+				AuthID:       r.authID,
+				AuthIndex:    r.authIndex,
+				RequestedAt:  r.requestedAt,
+				Failed:       false,
+				Detail:       detail,
+				FallbackFrom: r.fallback,
+				Estimated:    detail.InputTokens > 0,
+			})
 		})
 	})
 }
 
+// estimatePromptUsage counts the recorded prompt with the local tokenizer of the model.
+func (r *usageReporter) estimatePromptUsage() usage.Detail {
+	if len(r.prompt) == 0 {
+		return usage.Detail{}
+	}
+	enc, err := tokencount.ForModel(r.model)
+	if err != nil {
+		return usage.Detail{}
+	}
+	count, err := tokencount.CountRequest(enc, r.promptFormat, r.prompt)
+	if err != nil || count <= 0 {
+		return usage.Detail{}
+	}
+	return usage.Detail{InputTokens: count, TotalTokens: count}
+}
+
 func apiKeyFromContext(ctx context.Context) string {
 	if ctx == nil {
 		return ""
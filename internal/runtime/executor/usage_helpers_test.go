@@ -1,6 +1,17 @@
 package executor
 
-import "testing"
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+)
 
 func TestParseOpenAIUsageChatCompletions(t *testing.T) {
 	data := []byte(`{"usage":{"prompt_tokens":1,"completion_tokens":2,"total_tokens":3,"prompt_tokens_details":{"cached_tokens":4},"completion_tokens_details":{"reasoning_tokens":5}}}`)
@@ -41,3 +52,88 @@ func TestParseOpenAIUsageResponses(t *testing.T) {
 		t.Fatalf("reasoning tokens = %d, want %d", detail.ReasoningTokens, 9)
 	}
 }
+
+func TestEnsurePublishedEstimatesMissingUsage(t *testing.T) {
+	var records []usage.Record
+	ctx := usage.WithRecordObserver(context.Background(), func(record usage.Record) {
+		records = append(records, record)
+	})
+	reporter := newUsageReporter(ctx, "openai-compatibility", "gpt-4o", nil)
+	reporter.setPrompt("openai", []byte(`{"messages":[{"role":"user","content":"count these words please"}]}`))
+	reporter.ensurePublished(ctx)
+
+	if len(records) != 1 {
+		t.Fatalf("expected one usage record, got %d", len(records))
+	}
+	if !records[0].Estimated || records[0].Detail.InputTokens <= 0 || records[0].Detail.TotalTokens != records[0].Detail.InputTokens {
+		t.Fatalf("expected estimated input tokens, got %+v", records[0])
+	}
+
+	records = nil
+	bare := newUsageReporter(ctx, "openai-compatibility", "gpt-4o", nil)
+	bare.ensurePublished(ctx)
+	if len(records) != 1 || records[0].Estimated || records[0].Detail.InputTokens != 0 {
+		t.Fatalf("expected an empty record without a prompt, got %+v", records)
+	}
+}
+
+func TestGeminiExecutorEstimatesUsageWithoutUsageMetadata(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"candidates":[{"content":{"role":"model","parts":[{"text":"hi"}]},"finishReason":"STOP"}]}`))
+	}))
+	defer server.Close()
+
+	var records []usage.Record
+	ctx := usage.WithRecordObserver(context.Background(), func(record usage.Record) {
+		records = append(records, record)
+	})
+	executor := NewGeminiExecutor(&config.Config{})
+	auth := &cliproxyauth.Auth{Attributes: map[string]string{"base_url": server.URL, "api_key": "key"}}
+	_, err := executor.Execute(ctx, auth, cliproxyexecutor.Request{
+		Model:   "gemini-2.5-flash",
+		Payload: []byte(`{"contents":[{"role":"user","parts":[{"text":"count these words please"}]}]}`),
+	}, cliproxyexecutor.Options{SourceFormat: sdktranslator.FromString("gemini")})
+	if err != nil {
+		t.Fatalf("Execute error: %v", err)
+	}
+	if len(records) != 1 {
+		t.Fatalf("expected one usage record, got %d", len(records))
+	}
+	if !records[0].Estimated || records[0].Detail.InputTokens <= 0 {
+		t.Fatalf("expected estimated input tokens, got %+v", records[0])
+	}
+}
+
+func TestClaudeExecutorStreamEstimatesUsageWithoutUsage(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = w.Write([]byte("event: content_block_delta\ndata: {\"type\":\"content_block_delta\",\"index\":0,\"delta\":{\"type\":\"text_delta\",\"text\":\"hi\"}}\n\nevent: message_stop\ndata: {\"type\":\"message_stop\"}\n\n"))
+	}))
+	defer server.Close()
+
+	var records []usage.Record
+	ctx := usage.WithRecordObserver(context.Background(), func(record usage.Record) {
+		records = append(records, record)
+	})
+	executor := NewClaudeExecutor(&config.Config{})
+	auth := &cliproxyauth.Auth{Attributes: map[string]string{"base_url": server.URL, "api_key": "key"}}
+	stream, err := executor.ExecuteStream(ctx, auth, cliproxyexecutor.Request{
+		Model:   "claude-sonnet-4-5",
+		Payload: []byte(`{"model":"claude-sonnet-4-5","max_tokens":16,"stream":true,"messages":[{"role":"user","content":"count these words please"}]}`),
+	}, cliproxyexecutor.Options{SourceFormat: sdktranslator.FromString("claude"), Stream: true})
+	if err != nil {
+		t.Fatalf("ExecuteStream error: %v", err)
+	}
+	for chunk := range stream {
+		if chunk.Err != nil {
+			t.Fatalf("stream error: %v", chunk.Err)
+		}
+	}
+	if len(records) != 1 {
+		t.Fatalf("expected one usage record, got %d", len(records))
+	}
+	if !records[0].Estimated || records[0].Detail.InputTokens <= 0 {
+		t.Fatalf("expected estimated input tokens, got %+v", records[0])
+	}
+}
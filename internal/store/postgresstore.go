@@ -107,6 +107,22 @@ func (s *PostgresStore) Close() error {
 	return s.db.Close()
 }
 
+// DB exposes the underlying connection pool so other subsystems can share it.
+func (s *PostgresStore) DB() *sql.DB {
+	if s == nil {
+		return nil
+	}
+	return s.db
+}
+
+// Schema returns the configured schema name (empty means the default search path).
+func (s *PostgresStore) Schema() string {
+	if s == nil {
+		return ""
+	}
+	return strings.TrimSpace(s.cfg.Schema)
+}
+
 // EnsureSchema creates the required tables (and schema when provided).
 func (s *PostgresStore) EnsureSchema(ctx context.Context) error {
 	if s == nil || s.db == nil {
@@ -0,0 +1,137 @@
+// Package tokencount counts prompt tokens offline. It pairs tiktoken-compatible BPE encodings
+// with per-model tokenizer selection so that count_tokens requests, usage missing from upstream
+// responses and context-window checks can be answered without calling a provider.
+package tokencount
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/thinking"
+	log "github.com/sirupsen/logrus"
+	"github.com/tiktoken-go/tokenizer"
+)
+
+// Tokenizer names accepted by Get and by the tokenizer setting of configured models.
+const (
+	// O200kBase is the BPE encoding of GPT-4o, GPT-4.1, o-series and GPT-5 models.
+	O200kBase = "o200k_base"
+	// Cl100kBase is the BPE encoding of GPT-4 and GPT-3.5 models.
+	Cl100kBase = "cl100k_base"
+	// Claude approximates Anthropic's tokenizer with cl100k_base scaled by 1.1, since
+	// cl100k_base undercounts Claude prompts.
+	Claude = "claude"
+	// Gemini approximates Gemini's SentencePiece tokenizer with o200k_base.
+	Gemini = "gemini"
+)
+
+// Counter counts the tokens of plain text.
+type Counter interface {
+	// Name returns the tokenizer name the counter was resolved from.
+	Name() string
+	// Count returns the number of tokens in text.
+	Count(text string) (int, error)
+}
+
+type bpeCounter struct {
+	name   string
+	codec  tokenizer.Codec
+	factor float64
+}
+
+func (c *bpeCounter) Name() string { return c.name }
+
+func (c *bpeCounter) Count(text string) (int, error) {
+	count, err := c.codec.Count(text)
+	if err != nil {
+		return 0, err
+	}
+	if c.factor > 0 && c.factor != 1.0 {
+		return int(float64(count) * c.factor), nil
+	}
+	return count, nil
+}
+
+// counters caches counters by tokenizer name; codecs are expensive to build.
+var counters sync.Map
+
+// Names returns the tokenizer names accepted by Get.
+func Names() []string {
+	return []string{O200kBase, Cl100kBase, Claude, Gemini}
+}
+
+// Get returns the counter for a tokenizer name.
+func Get(name string) (Counter, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if cached, ok := counters.Load(name); ok {
+		return cached.(Counter), nil
+	}
+	encoding := tokenizer.O200kBase
+	factor := 1.0
+	switch name {
+	case O200kBase, Gemini:
+	case Cl100kBase:
+		encoding = tokenizer.Cl100kBase
+	case Claude:
+		encoding = tokenizer.Cl100kBase
+		factor = 1.1
+	default:
+		return nil, fmt.Errorf("tokencount: unknown tokenizer %q", name)
+	}
+	codec, err := tokenizer.Get(encoding)
+	if err != nil {
+		return nil, err
+	}
+	actual, _ := counters.LoadOrStore(name, &bpeCounter{name: name, codec: codec, factor: factor})
+	return actual.(Counter), nil
+}
+
+// ForModel returns the counter selected for a model: the tokenizer configured for it in the
+// model registry, or else the one matching its model family.
+func ForModel(model string) (Counter, error) {
+	base := strings.TrimSpace(thinking.ParseSuffix(model).ModelName)
+	if info := registry.LookupModelInfo(base); info != nil {
+		return ForModelInfo(info)
+	}
+	return Get(FamilyTokenizer(base))
+}
+
+// ForModelInfo returns the counter selected for a registered model. An unknown configured
+// tokenizer is logged and replaced by the model family tokenizer.
+func ForModelInfo(info *registry.ModelInfo) (Counter, error) {
+	if info == nil {
+		return Get(FamilyTokenizer(""))
+	}
+	if info.Tokenizer != "" {
+		counter, err := Get(info.Tokenizer)
+		if err == nil {
+			return counter, nil
+		}
+		log.Warnf("tokencount: model %s: %v, using the model family tokenizer", info.ID, err)
+	}
+	return Get(FamilyTokenizer(info.ID))
+}
+
+// FamilyTokenizer returns the tokenizer name matching a model ID by family.
+func FamilyTokenizer(model string) string {
+	sanitized := strings.ToLower(strings.TrimSpace(model))
+	if idx := strings.LastIndex(sanitized, "/"); idx >= 0 {
+		sanitized = sanitized[idx+1:]
+	}
+	switch {
+	case sanitized == "":
+		return Cl100kBase
+	case strings.Contains(sanitized, "claude"), strings.HasPrefix(sanitized, "kiro-"), strings.HasPrefix(sanitized, "amazonq-"):
+		return Claude
+	case strings.Contains(sanitized, "gemini"), strings.Contains(sanitized, "gemma"):
+		return Gemini
+	case strings.HasPrefix(sanitized, "gpt-4o"), strings.HasPrefix(sanitized, "gpt-4.1"):
+		return O200kBase
+	case strings.HasPrefix(sanitized, "gpt-4"), strings.HasPrefix(sanitized, "gpt-3"):
+		return Cl100kBase
+	default:
+		return O200kBase
+	}
+}
@@ -0,0 +1,102 @@
+package tokencount
+
+import (
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+)
+
+func TestFamilyTokenizer(t *testing.T) {
+	tests := map[string]string{
+		"":                           Cl100kBase,
+		"claude-sonnet-4-5-20250929": Claude,
+		"kiro-claude-sonnet-4-5":     Claude,
+		"gemini-2.5-pro":             Gemini,
+		"models/gemma-3-27b-it":      Gemini,
+		"gpt-4o-mini":                O200kBase,
+		"gpt-4.1":                    O200kBase,
+		"gpt-4-turbo":                Cl100kBase,
+		"gpt-3.5-turbo":              Cl100kBase,
+		"gpt-5-codex":                O200kBase,
+		"qwen3-coder-plus":           O200kBase,
+	}
+	for model, want := range tests {
+		if got := FamilyTokenizer(model); got != want {
+			t.Errorf("FamilyTokenizer(%q) = %q, want %q", model, got, want)
+		}
+	}
+}
+
+func TestGet(t *testing.T) {
+	for _, name := range Names() {
+		counter, err := Get(name)
+		if err != nil {
+			t.Fatalf("Get(%q) error = %v", name, err)
+		}
+		if counter.Name() != name {
+			t.Fatalf("Get(%q).Name() = %q", name, counter.Name())
+		}
+		if count, errCount := counter.Count("hello world"); errCount != nil || count <= 0 {
+			t.Fatalf("Get(%q).Count() = %d, %v", name, count, errCount)
+		}
+	}
+	if _, err := Get("unknown"); err == nil {
+		t.Fatal("expected an error for an unknown tokenizer")
+	}
+}
+
+func TestForModelUsesRegisteredTokenizer(t *testing.T) {
+	modelRegistry := registry.GetGlobalRegistry()
+	modelRegistry.RegisterClient("test-tokencount", "openai-compatibility", []*registry.ModelInfo{
+		{ID: "test-tokencount-model", Tokenizer: Claude},
+		{ID: "test-tokencount-bad", Tokenizer: "unknown"},
+	})
+	t.Cleanup(func() { modelRegistry.UnregisterClient("test-tokencount") })
+
+	tests := map[string]string{
+		"test-tokencount-model":        Claude,
+		"test-tokencount-model(high)":  Claude,
+		"test-tokencount-bad":          O200kBase,
+		"gemini-unregistered-tokenize": Gemini,
+	}
+	for model, want := range tests {
+		counter, err := ForModel(model)
+		if err != nil {
+			t.Fatalf("ForModel(%q) error = %v", model, err)
+		}
+		if counter.Name() != want {
+			t.Errorf("ForModel(%q) = %q, want %q", model, counter.Name(), want)
+		}
+	}
+}
+
+func TestCountRequest(t *testing.T) {
+	enc, err := Get(O200kBase)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	tests := []struct {
+		format  string
+		payload string
+	}{
+		{format: "openai", payload: `{"messages":[{"role":"user","content":"The quick brown fox"}]}`},
+		{format: "claude", payload: `{"system":"Be brief.","messages":[{"role":"user","content":[{"type":"text","text":"The quick brown fox"}]}]}`},
+		{format: "gemini", payload: `{"contents":[{"role":"user","parts":[{"text":"The quick brown fox"}]}]}`},
+		{format: "gemini-cli", payload: `{"request":{"contents":[{"role":"user","parts":[{"text":"The quick brown fox"}]}]}}`},
+		{format: "codex", payload: `{"instructions":"Be brief.","input":[{"type":"message","role":"user","content":[{"type":"input_text","text":"The quick brown fox"}]}]}`},
+	}
+	for _, tt := range tests {
+		count, errCount := CountRequest(enc, tt.format, []byte(tt.payload))
+		if errCount != nil {
+			t.Fatalf("%s: CountRequest() error = %v", tt.format, errCount)
+		}
+		if count < 4 || count > 20 {
+			t.Errorf("%s: CountRequest() = %d, want a count close to the prompt size", tt.format, count)
+		}
+	}
+
+	withImage, _ := CountRequest(enc, "openai", []byte(`{"messages":[{"role":"user","content":[{"type":"image_url","image_url":{"url":"data:image/png;base64,AAAA"}}]}]}`))
+	if withImage < 1000 {
+		t.Errorf("image inputs must count as a placeholder estimate, got %d", withImage)
+	}
+}
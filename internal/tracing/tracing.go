@@ -0,0 +1,192 @@
+// Package tracing wires OpenTelemetry span export for the proxy request pipeline.
+// A root span is opened per inbound HTTP request by Middleware; translation, credential
+// selection, executor attempts and upstream round trips open child spans through Start
+// and WrapTransport. When tracing is disabled every helper degrades to a no-op.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync/atomic"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+const (
+	instrumentationName = "github.com/router-for-me/CLIProxyAPI/v6"
+	defaultServiceName  = "cli-proxy-api"
+)
+
+// Attribute keys shared by the spans emitted across the pipeline.
+const (
+	AttrRequestID    = attribute.Key("cliproxy.request_id")
+	AttrProvider     = attribute.Key("cliproxy.provider")
+	AttrModel        = attribute.Key("cliproxy.model")
+	AttrAuthIndex    = attribute.Key("cliproxy.auth_index")
+	AttrAttempt      = attribute.Key("cliproxy.attempt")
+	AttrStream       = attribute.Key("cliproxy.stream")
+	AttrSourceFormat = attribute.Key("cliproxy.translator.from")
+	AttrTargetFormat = attribute.Key("cliproxy.translator.to")
+	AttrInputTokens  = attribute.Key("cliproxy.usage.input_tokens")
+	AttrOutputTokens = attribute.Key("cliproxy.usage.output_tokens")
+	AttrReasoning    = attribute.Key("cliproxy.usage.reasoning_tokens")
+	AttrCachedTokens = attribute.Key("cliproxy.usage.cached_tokens")
+	AttrTotalTokens  = attribute.Key("cliproxy.usage.total_tokens")
+)
+
+var (
+	enabled atomic.Bool
+	tracer  atomic.Value // tracerHolder
+)
+
+// tracerHolder keeps atomic.Value stores consistently typed across tracer implementations.
+type tracerHolder struct {
+	tracer trace.Tracer
+}
+
+// Setup installs a global tracer provider exporting spans over OTLP/HTTP according to cfg.
+// The returned function flushes pending spans and must be called on shutdown. When tracing
+// is disabled Setup is a no-op and returns a shutdown function that does nothing.
+func Setup(ctx context.Context, cfg config.TracingConfig, version string) (func(context.Context) error, error) {
+	if !cfg.Enable {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	opts := make([]otlptracehttp.Option, 0, 3)
+	if endpoint := strings.TrimSpace(cfg.Endpoint); endpoint != "" {
+		if strings.Contains(endpoint, "://") {
+			opts = append(opts, otlptracehttp.WithEndpointURL(endpoint))
+		} else {
+			opts = append(opts, otlptracehttp.WithEndpoint(endpoint))
+		}
+	}
+	if cfg.Insecure {
+		opts = append(opts, otlptracehttp.WithInsecure())
+	}
+	if len(cfg.Headers) > 0 {
+		opts = append(opts, otlptracehttp.WithHeaders(cfg.Headers))
+	}
+	exporter, err := otlptracehttp.New(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("tracing: create otlp exporter: %w", err)
+	}
+
+	serviceName := strings.TrimSpace(cfg.ServiceName)
+	if serviceName == "" {
+		serviceName = defaultServiceName
+	}
+	res, err := resource.Merge(resource.Default(), resource.NewSchemaless(
+		semconv.ServiceName(serviceName),
+		semconv.ServiceVersion(version),
+	))
+	if err != nil {
+		res = resource.Default()
+	}
+
+	sampler := sdktrace.AlwaysSample()
+	if cfg.SampleRatio > 0 && cfg.SampleRatio < 1 {
+		sampler = sdktrace.TraceIDRatioBased(cfg.SampleRatio)
+	}
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sampler)),
+	)
+	SetTracerProvider(provider)
+
+	return func(shutdownCtx context.Context) error {
+		enabled.Store(false)
+		return provider.Shutdown(shutdownCtx)
+	}, nil
+}
+
+// SetTracerProvider installs provider as the global tracer provider and enables the helpers
+// in this package. Passing nil disables tracing.
+func SetTracerProvider(provider trace.TracerProvider) {
+	if provider == nil {
+		enabled.Store(false)
+		tracer.Store(tracerHolder{tracer: noop.NewTracerProvider().Tracer(instrumentationName)})
+		return
+	}
+	otel.SetTracerProvider(provider)
+	tracer.Store(tracerHolder{tracer: provider.Tracer(instrumentationName)})
+	enabled.Store(true)
+}
+
+// Enabled reports whether spans are currently recorded.
+func Enabled() bool {
+	return enabled.Load()
+}
+
+// Start opens a span named name as a child of the span stored in ctx.
+// When tracing is disabled it returns ctx unchanged together with a no-op span.
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return startSpan(ctx, name, trace.SpanKindInternal, attrs)
+}
+
+func startSpan(ctx context.Context, name string, kind trace.SpanKind, attrs []attribute.KeyValue) (context.Context, trace.Span) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if !enabled.Load() {
+		return ctx, trace.SpanFromContext(context.Background())
+	}
+	holder, _ := tracer.Load().(tracerHolder)
+	t := holder.tracer
+	if t == nil {
+		return ctx, trace.SpanFromContext(context.Background())
+	}
+	return t.Start(ctx, name, trace.WithSpanKind(kind), trace.WithAttributes(attrs...))
+}
+
+// End records err on span (if any) and ends it.
+func End(span trace.Span, err error) {
+	if span == nil {
+		return
+	}
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// TraceID returns the hex trace ID of the span stored in ctx, or an empty string.
+func TraceID(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	spanCtx := trace.SpanContextFromContext(ctx)
+	if !spanCtx.HasTraceID() {
+		return ""
+	}
+	return spanCtx.TraceID().String()
+}
+
+// RecordUsage attaches token usage counters to the span stored in ctx.
+func RecordUsage(ctx context.Context, input, output, reasoning, cached, total int64) {
+	if !enabled.Load() || ctx == nil {
+		return
+	}
+	span := trace.SpanFromContext(ctx)
+	if !span.IsRecording() {
+		return
+	}
+	span.SetAttributes(
+		AttrInputTokens.Int64(input),
+		AttrOutputTokens.Int64(output),
+		AttrReasoning.Int64(reasoning),
+		AttrCachedTokens.Int64(cached),
+		AttrTotalTokens.Int64(total),
+	)
+}
@@ -0,0 +1,85 @@
+package tracing
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/logging"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestMiddlewareAndTransportSpans(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	SetTracerProvider(provider)
+	t.Cleanup(func() { SetTracerProvider(nil) })
+
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer upstream.Close()
+
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.Use(func(c *gin.Context) {
+		logging.SetGinRequestID(c, "abcd1234")
+		c.Next()
+	})
+	engine.Use(Middleware())
+	engine.POST("/v1/chat/completions", func(c *gin.Context) {
+		client := &http.Client{Transport: WrapTransport(nil)}
+		req, _ := http.NewRequestWithContext(c.Request.Context(), http.MethodPost, upstream.URL+"/v1/messages?key=secret", nil)
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Errorf("upstream call: %v", err)
+		} else {
+			_ = resp.Body.Close()
+		}
+		RecordUsage(c.Request.Context(), 10, 5, 0, 0, 15)
+		c.Status(http.StatusOK)
+	})
+
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/v1/chat/completions", nil))
+
+	spans := recorder.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("expected 2 spans, got %d", len(spans))
+	}
+	client, root := spans[0], spans[1]
+	if client.Parent().SpanID() != root.SpanContext().SpanID() {
+		t.Fatal("upstream span should be a child of the handler span")
+	}
+	if client.Status().Code.String() != "Error" {
+		t.Fatalf("upstream 429 should mark the span as error, got %v", client.Status())
+	}
+	attrs := map[string]string{}
+	for _, kv := range append(root.Attributes(), client.Attributes()...) {
+		attrs[string(kv.Key)] = kv.Value.Emit()
+	}
+	if attrs[string(AttrRequestID)] != "abcd1234" {
+		t.Fatalf("request id not linked: %v", attrs)
+	}
+	if attrs[string(AttrTotalTokens)] != "15" {
+		t.Fatalf("usage not recorded: %v", attrs)
+	}
+	for _, kv := range client.Attributes() {
+		if kv.Key == "url.path" && kv.Value.Emit() != "/v1/messages" {
+			t.Fatalf("unexpected upstream url.path %q", kv.Value.Emit())
+		}
+		if kv.Value.Emit() == "secret" {
+			t.Fatal("query string must not be recorded")
+		}
+	}
+}
+
+func TestStartDisabledIsNoop(t *testing.T) {
+	SetTracerProvider(nil)
+	ctx, span := Start(t.Context(), "noop")
+	if span.IsRecording() || TraceID(ctx) != "" {
+		t.Fatal("disabled tracing should not record spans")
+	}
+}
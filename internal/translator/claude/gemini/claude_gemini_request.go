@@ -163,7 +163,11 @@ func ConvertGeminiRequestToClaude(modelName string, inputRawJSON []byte, stream
 	}
 
 	// System instruction conversion to Claude Code format
-	if sysInstr := root.Get("system_instruction"); sysInstr.Exists() {
+	sysInstr := root.Get("system_instruction")
+	if !sysInstr.Exists() {
+		sysInstr = root.Get("systemInstruction")
+	}
+	if sysInstr.Exists() {
 		if parts := sysInstr.Get("parts"); parts.Exists() && parts.IsArray() {
 			var systemText strings.Builder
 			parts.ForEach(func(_, part gjson.Result) bool {
@@ -262,10 +266,18 @@ func ConvertGeminiRequestToClaude(modelName string, inputRawJSON []byte, stream
 						return true
 					}
 
-					// Image content (inline_data) conversion to Claude Code format
-					if inlineData := part.Get("inline_data"); inlineData.Exists() {
+					// Image content (inline_data or inlineData) conversion to Claude Code format
+					inlineData := part.Get("inline_data")
+					if !inlineData.Exists() {
+						inlineData = part.Get("inlineData")
+					}
+					if inlineData.Exists() {
 						imageContent := `{"type":"image","source":{"type":"base64","media_type":"","data":""}}`
-						if mimeType := inlineData.Get("mime_type"); mimeType.Exists() {
+						mimeType := inlineData.Get("mime_type")
+						if !mimeType.Exists() {
+							mimeType = inlineData.Get("mimeType")
+						}
+						if mimeType.Exists() {
 							imageContent, _ = sjson.Set(imageContent, "source.media_type", mimeType.String())
 						}
 						if data := inlineData.Get("data"); data.Exists() {
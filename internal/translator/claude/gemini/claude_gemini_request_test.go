@@ -0,0 +1,33 @@
+package gemini
+
+import (
+	"testing"
+
+	"github.com/tidwall/gjson"
+)
+
+func TestConvertGeminiRequestToClaude_CamelCaseFields(t *testing.T) {
+	inputJSON := []byte(`{
+		"systemInstruction": {"parts": [{"text": "Be brief."}]},
+		"contents": [
+			{
+				"role": "user",
+				"parts": [
+					{"text": "What is this?"},
+					{"inlineData": {"mimeType": "image/png", "data": "aGVsbG8="}}
+				]
+			}
+		]
+	}`)
+
+	output := ConvertGeminiRequestToClaude("claude-sonnet-4-5", inputJSON, false)
+
+	// The system instruction is sent as a leading user message in Claude Code format.
+	if got := gjson.GetBytes(output, "messages.0.content.0.text").String(); got != "Be brief." {
+		t.Fatalf("expected systemInstruction as the first message, got %s", gjson.GetBytes(output, "messages").Raw)
+	}
+	image := gjson.GetBytes(output, `messages.1.content.#(type=="image")`)
+	if image.Get("source.media_type").String() != "image/png" || image.Get("source.data").String() != "aGVsbG8=" {
+		t.Fatalf("expected inlineData to become an image block, got %s", gjson.GetBytes(output, "messages.1.content").Raw)
+	}
+}
@@ -0,0 +1,33 @@
+package chat_completions
+
+import (
+	"context"
+	"testing"
+
+	"github.com/tidwall/gjson"
+)
+
+func TestConvertClaudeResponseToOpenAINonStream_ReasoningContent(t *testing.T) {
+	rawJSON := []byte("data: {\"type\":\"message_start\",\"message\":{\"id\":\"msg_1\",\"model\":\"claude-sonnet-4-5\",\"usage\":{\"input_tokens\":3,\"output_tokens\":0}}}\n" +
+		"data: {\"type\":\"content_block_start\",\"index\":0,\"content_block\":{\"type\":\"thinking\",\"thinking\":\"\"}}\n" +
+		"data: {\"type\":\"content_block_delta\",\"index\":0,\"delta\":{\"type\":\"thinking_delta\",\"thinking\":\"Let me think.\"}}\n" +
+		"data: {\"type\":\"content_block_stop\",\"index\":0}\n" +
+		"data: {\"type\":\"content_block_start\",\"index\":1,\"content_block\":{\"type\":\"text\",\"text\":\"\"}}\n" +
+		"data: {\"type\":\"content_block_delta\",\"index\":1,\"delta\":{\"type\":\"text_delta\",\"text\":\"Done.\"}}\n" +
+		"data: {\"type\":\"content_block_stop\",\"index\":1}\n" +
+		"data: {\"type\":\"message_delta\",\"delta\":{\"stop_reason\":\"end_turn\"},\"usage\":{\"output_tokens\":5}}\n" +
+		"data: {\"type\":\"message_stop\"}\n")
+
+	out := ConvertClaudeResponseToOpenAINonStream(context.Background(), "claude-sonnet-4-5", nil, nil, rawJSON, nil)
+
+	// Streaming deltas already use reasoning_content; the non-stream message must match.
+	if got := gjson.Get(out, "choices.0.message.reasoning_content").String(); got != "Let me think." {
+		t.Fatalf("expected reasoning_content %q, got %q in %s", "Let me think.", got, out)
+	}
+	if gjson.Get(out, "choices.0.message.reasoning").Exists() {
+		t.Fatalf("expected no legacy reasoning field, got %s", out)
+	}
+	if got := gjson.Get(out, "choices.0.message.content").String(); got != "Done." {
+		t.Fatalf("expected content %q, got %q", "Done.", got)
+	}
+}
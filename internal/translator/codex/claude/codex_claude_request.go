@@ -55,6 +55,10 @@ func ConvertClaudeRequestToCodex(modelName string, inputRawJSON []byte, _ bool)
 			}
 		}
 		template, _ = sjson.SetRaw(template, "input.-1", message)
+	} else if systemsResult.Type == gjson.String && systemsResult.String() != "" {
+		message := `{"type":"message","role":"developer","content":[{"type":"input_text","text":""}]}`
+		message, _ = sjson.Set(message, "content.0.text", systemsResult.String())
+		template, _ = sjson.SetRaw(template, "input.-1", message)
 	}
 
 	// Process messages and transform their contents to appropriate formats.
@@ -0,0 +1,22 @@
+package claude
+
+import (
+	"testing"
+
+	"github.com/tidwall/gjson"
+)
+
+func TestConvertClaudeRequestToCodex_StringSystemPrompt(t *testing.T) {
+	inputJSON := []byte(`{
+		"model": "gpt-5",
+		"system": "Be brief.",
+		"messages": [{"role": "user", "content": "hi"}]
+	}`)
+
+	output := ConvertClaudeRequestToCodex("gpt-5", inputJSON, false)
+
+	developer := gjson.GetBytes(output, `input.#(role=="developer")`)
+	if got := developer.Get("content.0.text").String(); got != "Be brief." {
+		t.Fatalf("expected the string system prompt as a developer message, got %s", gjson.GetBytes(output, "input").Raw)
+	}
+}
@@ -86,6 +86,9 @@ func ConvertGeminiRequestToCodex(modelName string, inputRawJSON []byte, _ bool)
 
 	// System instruction -> as a user message with input_text parts
 	sysParts := root.Get("system_instruction.parts")
+	if !sysParts.Exists() {
+		sysParts = root.Get("systemInstruction.parts")
+	}
 	if sysParts.IsArray() {
 		msg := `{"type":"message","role":"developer","content":[]}`
 		arr := sysParts.Array()
@@ -137,6 +140,24 @@ func ConvertGeminiRequestToCodex(modelName string, inputRawJSON []byte, _ bool)
 					continue
 				}
 
+				// inline image part
+				inlineData := p.Get("inlineData")
+				if !inlineData.Exists() {
+					inlineData = p.Get("inline_data")
+				}
+				if inlineData.Exists() && role == "user" {
+					mimeType := inlineData.Get("mimeType").String()
+					if mimeType == "" {
+						mimeType = inlineData.Get("mime_type").String()
+					}
+					if data := inlineData.Get("data").String(); data != "" {
+						msg := `{"type":"message","role":"user","content":[{"type":"input_image","image_url":""}]}`
+						msg, _ = sjson.Set(msg, "content.0.image_url", fmt.Sprintf("data:%s;base64,%s", mimeType, data))
+						out, _ = sjson.SetRaw(out, "input.-1", msg)
+					}
+					continue
+				}
+
 				// function call from model
 				if fc := p.Get("functionCall"); fc.Exists() {
 					fn := `{"type":"function_call"}`
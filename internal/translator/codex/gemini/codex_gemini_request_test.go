@@ -0,0 +1,33 @@
+package gemini
+
+import (
+	"testing"
+
+	"github.com/tidwall/gjson"
+)
+
+func TestConvertGeminiRequestToCodex_CamelCaseFields(t *testing.T) {
+	inputJSON := []byte(`{
+		"systemInstruction": {"parts": [{"text": "Be brief."}]},
+		"contents": [
+			{
+				"role": "user",
+				"parts": [
+					{"text": "What is this?"},
+					{"inlineData": {"mimeType": "image/png", "data": "aGVsbG8="}}
+				]
+			}
+		]
+	}`)
+
+	output := ConvertGeminiRequestToCodex("gpt-5", inputJSON, false)
+
+	developer := gjson.GetBytes(output, `input.#(role=="developer")`)
+	if got := developer.Get("content.0.text").String(); got != "Be brief." {
+		t.Fatalf("expected systemInstruction as a developer message, got %s", gjson.GetBytes(output, "input").Raw)
+	}
+	image := gjson.GetBytes(output, `input.#(content.0.type=="input_image")`)
+	if got := image.Get("content.0.image_url").String(); got != "data:image/png;base64,aGVsbG8=" {
+		t.Fatalf("expected inlineData as an input_image data URL, got %s", gjson.GetBytes(output, "input").Raw)
+	}
+}
@@ -213,8 +213,17 @@ func ConvertCodexResponseToGeminiNonStream(_ context.Context, modelName string,
 					// Flush any pending function calls before adding non-function content
 					flushPendingFunctionCalls()
 
-					// Add thinking content
-					if content := value.Get("content"); content.Exists() {
+					// Add thinking content from the reasoning summary
+					if summary := value.Get("summary"); summary.IsArray() {
+						summary.ForEach(func(_, summaryItem gjson.Result) bool {
+							if summaryItem.Get("type").String() == "summary_text" && summaryItem.Get("text").String() != "" {
+								part := `{"text":"","thought":true}`
+								part, _ = sjson.Set(part, "text", summaryItem.Get("text").String())
+								template, _ = sjson.SetRaw(template, "candidates.0.content.parts.-1", part)
+							}
+							return true
+						})
+					} else if content := value.Get("content"); content.Exists() {
 						part := `{"text":"","thought":true}`
 						part, _ = sjson.Set(part, "text", content.String())
 						template, _ = sjson.SetRaw(template, "candidates.0.content.parts.-1", part)
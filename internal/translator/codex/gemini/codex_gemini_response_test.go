@@ -0,0 +1,28 @@
+package gemini
+
+import (
+	"context"
+	"testing"
+
+	"github.com/tidwall/gjson"
+)
+
+func TestConvertCodexResponseToGeminiNonStream_ReasoningSummary(t *testing.T) {
+	rawJSON := []byte(`{"type":"response.completed","response":{"id":"resp_1","created_at":1700000000,"output":[
+		{"type":"reasoning","summary":[{"type":"summary_text","text":"Thinking it over."}]},
+		{"type":"message","content":[{"type":"output_text","text":"Done."}]}
+	],"usage":{"input_tokens":3,"output_tokens":5}}}`)
+
+	out := ConvertCodexResponseToGeminiNonStream(context.Background(), "gpt-5", nil, nil, rawJSON, nil)
+
+	parts := gjson.Get(out, "candidates.0.content.parts").Array()
+	if len(parts) != 2 {
+		t.Fatalf("expected a thought part and a text part, got %s", gjson.Get(out, "candidates.0.content.parts").Raw)
+	}
+	if !parts[0].Get("thought").Bool() || parts[0].Get("text").String() != "Thinking it over." {
+		t.Fatalf("expected the reasoning summary as a thought part, got %s", parts[0].Raw)
+	}
+	if parts[1].Get("text").String() != "Done." {
+		t.Fatalf("expected the message text, got %s", parts[1].Raw)
+	}
+}
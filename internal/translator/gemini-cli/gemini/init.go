@@ -4,6 +4,7 @@ import (
 	. "github.com/router-for-me/CLIProxyAPI/v6/internal/constant"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/translator/translator"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
 )
 
 func init() {
@@ -17,4 +18,7 @@ func init() {
 			TokenCount: GeminiTokenCount,
 		},
 	)
+
+	// Both formats share the Gemini content schema, so every feature survives.
+	translator.SetCapabilities(Gemini, GeminiCLI, sdktranslator.AllCapabilities)
 }
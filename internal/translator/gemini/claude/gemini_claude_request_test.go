@@ -0,0 +1,29 @@
+package claude
+
+import (
+	"testing"
+
+	"github.com/tidwall/gjson"
+)
+
+func TestConvertClaudeRequestToGemini_ImageBlock(t *testing.T) {
+	inputJSON := []byte(`{
+		"model": "gemini-2.5-pro",
+		"messages": [
+			{
+				"role": "user",
+				"content": [
+					{"type": "text", "text": "What is this?"},
+					{"type": "image", "source": {"type": "base64", "media_type": "image/png", "data": "aGVsbG8="}}
+				]
+			}
+		]
+	}`)
+
+	output := ConvertClaudeRequestToGemini("gemini-2.5-pro", inputJSON, false)
+
+	image := gjson.GetBytes(output, "contents.0.parts.#(inlineData).inlineData")
+	if image.Get("mime_type").String() != "image/png" || image.Get("data").String() != "aGVsbG8=" {
+		t.Fatalf("expected the image block as inlineData, got %s", gjson.GetBytes(output, "contents.0.parts").Raw)
+	}
+}
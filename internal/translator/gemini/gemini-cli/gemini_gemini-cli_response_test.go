@@ -0,0 +1,24 @@
+package geminiCLI
+
+import (
+	"context"
+	"testing"
+
+	"github.com/tidwall/gjson"
+)
+
+func TestConvertGeminiResponseToGeminiCLI_BareJSON(t *testing.T) {
+	chunk := `{"candidates":[{"content":{"role":"model","parts":[{"text":"Hi"}]}}]}`
+	for _, raw := range []string{chunk, "data: " + chunk} {
+		out := ConvertGeminiResponseToGeminiCLI(context.Background(), "gemini-2.5-pro", nil, nil, []byte(raw), nil)
+		if len(out) != 1 {
+			t.Fatalf("expected one chunk for %q, got %v", raw, out)
+		}
+		if got := gjson.Get(out[0], "response.candidates.0.content.parts.0.text").String(); got != "Hi" {
+			t.Fatalf("expected the chunk wrapped in response, got %s", out[0])
+		}
+	}
+	if out := ConvertGeminiResponseToGeminiCLI(context.Background(), "gemini-2.5-pro", nil, nil, []byte("data: [DONE]"), nil); len(out) != 0 {
+		t.Fatalf("expected [DONE] to produce nothing, got %v", out)
+	}
+}
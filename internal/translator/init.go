@@ -35,5 +35,7 @@ import (
 	_ "github.com/router-for-me/CLIProxyAPI/v6/internal/translator/antigravity/openai/responses"
 
 	_ "github.com/router-for-me/CLIProxyAPI/v6/internal/translator/kiro/claude"
+	_ "github.com/router-for-me/CLIProxyAPI/v6/internal/translator/kiro/gemini"
+	_ "github.com/router-for-me/CLIProxyAPI/v6/internal/translator/kiro/gemini-cli"
 	_ "github.com/router-for-me/CLIProxyAPI/v6/internal/translator/kiro/openai"
 )
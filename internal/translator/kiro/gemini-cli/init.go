@@ -0,0 +1,21 @@
+package geminiCLI
+
+import (
+	. "github.com/router-for-me/CLIProxyAPI/v6/internal/constant"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
+	claudegeminicli "github.com/router-for-me/CLIProxyAPI/v6/internal/translator/claude/gemini-cli"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/translator/translator"
+)
+
+func init() {
+	translator.Register(
+		GeminiCLI,
+		Kiro,
+		ConvertGeminiCLIRequestToKiro,
+		interfaces.TranslateResponse{
+			Stream:     ConvertKiroStreamToGeminiCLI,
+			NonStream:  ConvertKiroNonStreamToGeminiCLI,
+			TokenCount: claudegeminicli.GeminiCLITokenCount,
+		},
+	)
+}
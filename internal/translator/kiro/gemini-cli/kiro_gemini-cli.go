@@ -0,0 +1,42 @@
+// Package geminiCLI provides translation between Gemini CLI requests and Kiro. The Gemini CLI
+// envelope ({"model":...,"request":{...}} and {"response":{...}}) is unwrapped and rewrapped
+// around the Gemini-to-Kiro translators.
+package geminiCLI
+
+import (
+	"context"
+
+	. "github.com/router-for-me/CLIProxyAPI/v6/internal/translator/kiro/gemini"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// ConvertGeminiCLIRequestToKiro unwraps a Gemini CLI request and converts the inner Gemini
+// request for Kiro.
+func ConvertGeminiCLIRequestToKiro(modelName string, inputRawJSON []byte, stream bool) []byte {
+	rawJSON := []byte(gjson.GetBytes(inputRawJSON, "request").Raw)
+	if len(rawJSON) == 0 {
+		rawJSON = []byte(`{}`)
+	}
+	return ConvertGeminiRequestToKiro(modelName, rawJSON, stream)
+}
+
+// ConvertKiroStreamToGeminiCLI converts Kiro streaming events to Gemini chunks wrapped in the
+// Gemini CLI "response" envelope.
+func ConvertKiroStreamToGeminiCLI(ctx context.Context, model string, originalRequest, request, rawResponse []byte, param *any) []string {
+	outputs := ConvertKiroStreamToGemini(ctx, model, originalRequest, request, rawResponse, param)
+	wrapped := make([]string, 0, len(outputs))
+	for _, output := range outputs {
+		envelope, _ := sjson.SetRaw(`{"response":{}}`, "response", output)
+		wrapped = append(wrapped, envelope)
+	}
+	return wrapped
+}
+
+// ConvertKiroNonStreamToGeminiCLI converts a Kiro non-streaming response to a Gemini response
+// wrapped in the Gemini CLI "response" envelope.
+func ConvertKiroNonStreamToGeminiCLI(ctx context.Context, model string, originalRequest, request, rawResponse []byte, param *any) string {
+	output := ConvertKiroNonStreamToGemini(ctx, model, originalRequest, request, rawResponse, param)
+	envelope, _ := sjson.SetRaw(`{"response":{}}`, "response", output)
+	return envelope
+}
@@ -0,0 +1,331 @@
+// Package gemini provides translation between Gemini generateContent requests and Kiro.
+// Gemini requests are rewritten into the Claude Messages shape that the Kiro payload
+// builder consumes, so message merging and tool compression are shared with the Claude
+// source path. Kiro responses (Claude-compatible SSE and JSON) are converted back to
+// Gemini candidates.
+package gemini
+
+import (
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/thinking"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// ConvertGeminiRequestToKiro converts a Gemini generateContent request into the Claude
+// Messages body used to build the Kiro payload. The Kiro executor builds the final
+// conversationState from this body with kiroclaude.BuildKiroPayload.
+//
+// Parameters:
+//   - modelName: The name of the model to use for the request
+//   - inputRawJSON: The raw JSON request data from the Gemini API
+//   - stream: A boolean indicating if the request is for a streaming response
+//
+// Returns:
+//   - []byte: The request body in Claude Messages format
+func ConvertGeminiRequestToKiro(modelName string, inputRawJSON []byte, stream bool) []byte {
+	root := gjson.ParseBytes(inputRawJSON)
+
+	out := `{"model":"","max_tokens":32000,"messages":[]}`
+	out, _ = sjson.Set(out, "model", modelName)
+	if stream {
+		out, _ = sjson.Set(out, "stream", true)
+	}
+
+	if genConfig := root.Get("generationConfig"); genConfig.Exists() {
+		if maxTokens := genConfig.Get("maxOutputTokens"); maxTokens.Exists() {
+			out, _ = sjson.Set(out, "max_tokens", maxTokens.Int())
+		}
+		if temp := genConfig.Get("temperature"); temp.Exists() {
+			out, _ = sjson.Set(out, "temperature", temp.Float())
+		}
+		if topP := genConfig.Get("topP"); topP.Exists() {
+			out, _ = sjson.Set(out, "top_p", topP.Float())
+		}
+		if stopSeqs := genConfig.Get("stopSequences"); stopSeqs.IsArray() && len(stopSeqs.Array()) > 0 {
+			out, _ = sjson.SetRaw(out, "stop_sequences", stopSeqs.Raw)
+		}
+		out = applyThinkingConfig(out, genConfig.Get("thinkingConfig"))
+	}
+
+	systemInstruction := root.Get("systemInstruction")
+	if !systemInstruction.Exists() {
+		systemInstruction = root.Get("system_instruction")
+	}
+	if system := joinTextParts(systemInstruction.Get("parts")); system != "" {
+		out, _ = sjson.Set(out, "system", system)
+	}
+
+	// Gemini pairs functionResponse parts with earlier functionCall parts by name (and by id
+	// when the client echoes one), while Claude requires explicit tool_use ids.
+	var pending []pendingToolCall
+	root.Get("contents").ForEach(func(_, content gjson.Result) bool {
+		role := "user"
+		if content.Get("role").String() == "model" {
+			role = "assistant"
+		}
+		msg := `{"role":"","content":[]}`
+		msg, _ = sjson.Set(msg, "role", role)
+
+		content.Get("parts").ForEach(func(_, part gjson.Result) bool {
+			switch {
+			case part.Get("thought").Bool():
+				// Prior reasoning is not replayed to Kiro.
+			case part.Get("text").Exists():
+				block := `{"type":"text","text":""}`
+				block, _ = sjson.Set(block, "text", part.Get("text").String())
+				msg, _ = sjson.SetRaw(msg, "content.-1", block)
+			case part.Get("functionCall").Exists():
+				fc := part.Get("functionCall")
+				id := fc.Get("id").String()
+				if id == "" {
+					id = "toolu_" + strings.ReplaceAll(uuid.New().String(), "-", "")[:24]
+				}
+				pending = append(pending, pendingToolCall{id: id, name: fc.Get("name").String()})
+				block := `{"type":"tool_use","id":"","name":"","input":{}}`
+				block, _ = sjson.Set(block, "id", id)
+				block, _ = sjson.Set(block, "name", fc.Get("name").String())
+				if args := fc.Get("args"); args.IsObject() {
+					block, _ = sjson.SetRaw(block, "input", args.Raw)
+				}
+				msg, _ = sjson.SetRaw(msg, "content.-1", block)
+			case part.Get("functionResponse").Exists():
+				fr := part.Get("functionResponse")
+				var id string
+				id, pending = takeToolCall(pending, fr.Get("id").String(), fr.Get("name").String())
+				block := `{"type":"tool_result","tool_use_id":"","content":""}`
+				block, _ = sjson.Set(block, "tool_use_id", id)
+				block, _ = sjson.Set(block, "content", functionResponseContent(fr.Get("response")))
+				msg, _ = sjson.SetRaw(msg, "content.-1", block)
+			case part.Get("inlineData").Exists() || part.Get("inline_data").Exists():
+				inline := part.Get("inlineData")
+				if !inline.Exists() {
+					inline = part.Get("inline_data")
+				}
+				mimeType := inline.Get("mimeType").String()
+				if mimeType == "" {
+					mimeType = inline.Get("mime_type").String()
+				}
+				if !strings.HasPrefix(mimeType, "image/") {
+					// Kiro only accepts image attachments.
+					return true
+				}
+				block := `{"type":"image","source":{"type":"base64","media_type":"","data":""}}`
+				block, _ = sjson.Set(block, "source.media_type", mimeType)
+				block, _ = sjson.Set(block, "source.data", inline.Get("data").String())
+				msg, _ = sjson.SetRaw(msg, "content.-1", block)
+			case part.Get("fileData").Exists() || part.Get("file_data").Exists():
+				file := part.Get("fileData")
+				if !file.Exists() {
+					file = part.Get("file_data")
+				}
+				uri := file.Get("fileUri").String()
+				if uri == "" {
+					uri = file.Get("file_uri").String()
+				}
+				block := `{"type":"text","text":""}`
+				block, _ = sjson.Set(block, "text", "File: "+uri)
+				msg, _ = sjson.SetRaw(msg, "content.-1", block)
+			}
+			return true
+		})
+
+		if len(gjson.Get(msg, "content").Array()) > 0 {
+			out, _ = sjson.SetRaw(out, "messages.-1", msg)
+		}
+		return true
+	})
+
+	if tools := convertFunctionDeclarations(root.Get("tools")); len(tools) > 0 {
+		out, _ = sjson.Set(out, "tools", tools)
+	}
+
+	toolConfig := root.Get("toolConfig.functionCallingConfig")
+	if !toolConfig.Exists() {
+		toolConfig = root.Get("tool_config.function_calling_config")
+	}
+	switch strings.ToUpper(toolConfig.Get("mode").String()) {
+	case "AUTO":
+		out, _ = sjson.SetRaw(out, "tool_choice", `{"type":"auto"}`)
+	case "ANY":
+		out, _ = sjson.SetRaw(out, "tool_choice", `{"type":"any"}`)
+	case "NONE":
+		out, _ = sjson.SetRaw(out, "tool_choice", `{"type":"none"}`)
+	}
+
+	return []byte(out)
+}
+
+// pendingToolCall is a functionCall still waiting for its functionResponse.
+type pendingToolCall struct {
+	id   string
+	name string
+}
+
+// takeToolCall removes and returns the id of the pending call answered by a functionResponse.
+// It matches by id first, then by name, then falls back to the oldest pending call.
+func takeToolCall(pending []pendingToolCall, id, name string) (string, []pendingToolCall) {
+	match := -1
+	for i, call := range pending {
+		if id != "" && call.id == id {
+			match = i
+			break
+		}
+	}
+	if match < 0 && id == "" {
+		for i, call := range pending {
+			if call.name == name {
+				match = i
+				break
+			}
+		}
+		if match < 0 && len(pending) > 0 {
+			match = 0
+		}
+	}
+	if match < 0 {
+		if id == "" {
+			id = "toolu_" + strings.ReplaceAll(uuid.New().String(), "-", "")[:24]
+		}
+		return id, pending
+	}
+	found := pending[match].id
+	return found, append(pending[:match], pending[match+1:]...)
+}
+
+// functionResponseContent flattens a Gemini functionResponse.response object into tool
+// result text. The conventional "result" or "output" field is used when present.
+func functionResponseContent(response gjson.Result) string {
+	for _, key := range []string{"result", "output", "content"} {
+		if value := response.Get(key); value.Exists() {
+			if value.Type == gjson.String {
+				return value.String()
+			}
+			return value.Raw
+		}
+	}
+	if response.Type == gjson.String {
+		return response.String()
+	}
+	return response.Raw
+}
+
+// applyThinkingConfig maps Gemini thinkingConfig onto the Claude thinking field that the
+// Kiro payload builder uses to enable reasoning.
+func applyThinkingConfig(out string, thinkingConfig gjson.Result) string {
+	if !thinkingConfig.IsObject() {
+		return out
+	}
+	level := thinkingConfig.Get("thinkingLevel")
+	if !level.Exists() {
+		level = thinkingConfig.Get("thinking_level")
+	}
+	budget := thinkingConfig.Get("thinkingBudget")
+	if !budget.Exists() {
+		budget = thinkingConfig.Get("thinking_budget")
+	}
+	includeThoughts := thinkingConfig.Get("includeThoughts")
+	if !includeThoughts.Exists() {
+		includeThoughts = thinkingConfig.Get("include_thoughts")
+	}
+
+	switch {
+	case level.Exists():
+		switch value := strings.ToLower(strings.TrimSpace(level.String())); value {
+		case "":
+		case "none":
+			out, _ = sjson.Set(out, "thinking.type", "disabled")
+		case "auto":
+			out, _ = sjson.Set(out, "thinking.type", "enabled")
+		default:
+			if tokens, ok := thinking.ConvertLevelToBudget(value); ok {
+				out, _ = sjson.Set(out, "thinking.type", "enabled")
+				out, _ = sjson.Set(out, "thinking.budget_tokens", tokens)
+			}
+		}
+	case budget.Exists():
+		switch tokens := budget.Int(); {
+		case tokens == 0:
+			out, _ = sjson.Set(out, "thinking.type", "disabled")
+		case tokens < 0:
+			out, _ = sjson.Set(out, "thinking.type", "enabled")
+		default:
+			out, _ = sjson.Set(out, "thinking.type", "enabled")
+			out, _ = sjson.Set(out, "thinking.budget_tokens", tokens)
+		}
+	case includeThoughts.Type == gjson.True:
+		out, _ = sjson.Set(out, "thinking.type", "enabled")
+	}
+	return out
+}
+
+// convertFunctionDeclarations converts Gemini tools[].functionDeclarations into Claude tools.
+// Gemini schema type names are upper case ("OBJECT"); they are lowered for Kiro's JSON Schema.
+func convertFunctionDeclarations(tools gjson.Result) []interface{} {
+	var converted []interface{}
+	tools.ForEach(func(_, tool gjson.Result) bool {
+		decls := tool.Get("functionDeclarations")
+		if !decls.Exists() {
+			decls = tool.Get("function_declarations")
+		}
+		decls.ForEach(func(_, decl gjson.Result) bool {
+			name := decl.Get("name").String()
+			if name == "" {
+				return true
+			}
+			var schema interface{}
+			if params := decl.Get("parametersJsonSchema"); params.IsObject() {
+				schema = params.Value()
+			} else if params = decl.Get("parameters"); params.IsObject() {
+				schema = lowerSchemaTypes(params.Value())
+			}
+			if schema == nil {
+				schema = map[string]interface{}{"type": "object", "properties": map[string]interface{}{}}
+			}
+			converted = append(converted, map[string]interface{}{
+				"name":         name,
+				"description":  decl.Get("description").String(),
+				"input_schema": schema,
+			})
+			return true
+		})
+		return true
+	})
+	return converted
+}
+
+// lowerSchemaTypes lower-cases every "type" value in an OpenAPI-style Gemini schema.
+func lowerSchemaTypes(node interface{}) interface{} {
+	switch value := node.(type) {
+	case map[string]interface{}:
+		for key, child := range value {
+			if typeName, ok := child.(string); ok && key == "type" {
+				value[key] = strings.ToLower(typeName)
+				continue
+			}
+			value[key] = lowerSchemaTypes(child)
+		}
+	case []interface{}:
+		for i, child := range value {
+			value[i] = lowerSchemaTypes(child)
+		}
+	}
+	return node
+}
+
+// joinTextParts concatenates the text of Gemini parts with newlines.
+func joinTextParts(parts gjson.Result) string {
+	var sb strings.Builder
+	parts.ForEach(func(_, part gjson.Result) bool {
+		if text := part.Get("text"); text.Exists() && text.String() != "" {
+			if sb.Len() > 0 {
+				sb.WriteString("\n")
+			}
+			sb.WriteString(text.String())
+		}
+		return true
+	})
+	return sb.String()
+}
@@ -0,0 +1,142 @@
+package gemini
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	kiroclaude "github.com/router-for-me/CLIProxyAPI/v6/internal/translator/kiro/claude"
+	"github.com/tidwall/gjson"
+)
+
+// TestGeminiRequestBuildsKiroPayload verifies that a Gemini request with a system instruction,
+// an inline image, a function call round trip, function declarations and thinking enabled
+// produces a complete Kiro payload through the shared Claude payload builder.
+func TestGeminiRequestBuildsKiroPayload(t *testing.T) {
+	input := []byte(`{
+		"systemInstruction": {"parts": [{"text": "You are helpful."}]},
+		"contents": [
+			{"role": "user", "parts": [
+				{"text": "What is in this picture?"},
+				{"inlineData": {"mimeType": "image/png", "data": "aGVsbG8="}}
+			]},
+			{"role": "model", "parts": [
+				{"text": "Let me check the weather.", "thought": true},
+				{"functionCall": {"name": "get_weather", "args": {"city": "Paris"}}}
+			]},
+			{"role": "user", "parts": [
+				{"functionResponse": {"name": "get_weather", "response": {"result": "sunny"}}}
+			]}
+		],
+		"tools": [{"functionDeclarations": [{
+			"name": "get_weather",
+			"description": "Look up the weather",
+			"parameters": {"type": "OBJECT", "properties": {"city": {"type": "STRING"}}, "required": ["city"]}
+		}]}],
+		"generationConfig": {"maxOutputTokens": 1024, "thinkingConfig": {"thinkingBudget": 2048}}
+	}`)
+
+	claudeBody := ConvertGeminiRequestToKiro("kiro-claude-sonnet-4-5", input, true)
+	if got := gjson.GetBytes(claudeBody, "thinking.type").String(); got != "enabled" {
+		t.Fatalf("expected thinking to be enabled, got %q in %s", got, claudeBody)
+	}
+	if got := gjson.GetBytes(claudeBody, "system").String(); got != "You are helpful." {
+		t.Fatalf("expected the system instruction to become the system prompt, got %q", got)
+	}
+	toolUseID := gjson.GetBytes(claudeBody, "messages.1.content.0.id").String()
+	if toolUseID == "" || gjson.GetBytes(claudeBody, "messages.2.content.0.tool_use_id").String() != toolUseID {
+		t.Fatalf("expected the function response to be paired with the function call: %s", claudeBody)
+	}
+
+	result, thinkingEnabled := kiroclaude.BuildKiroPayload(claudeBody, "kiro-model", "", "CLI", false, false, nil, nil)
+	if !thinkingEnabled {
+		t.Fatal("expected thinking mode to be injected")
+	}
+	var payload kiroclaude.KiroPayload
+	if err := json.Unmarshal(result, &payload); err != nil {
+		t.Fatalf("Failed to unmarshal result: %v", err)
+	}
+
+	history := payload.ConversationState.History
+	if len(history) != 2 || history[0].UserInputMessage == nil || history[1].AssistantResponseMessage == nil {
+		t.Fatalf("expected user + assistant history, got %s", result)
+	}
+	if images := history[0].UserInputMessage.Images; len(images) != 1 || images[0].Format != "png" || images[0].Source.Bytes != "aGVsbG8=" {
+		t.Errorf("expected the inline image to be attached, got %+v", images)
+	}
+	assistant := history[1].AssistantResponseMessage
+	if strings.Contains(assistant.Content, "Let me check the weather.") {
+		t.Errorf("expected thought parts to be dropped, got %q", assistant.Content)
+	}
+	if len(assistant.ToolUses) != 1 || assistant.ToolUses[0].Name != "get_weather" || assistant.ToolUses[0].Input["city"] != "Paris" {
+		t.Errorf("expected the function call as a tool use, got %+v", assistant.ToolUses)
+	}
+
+	ctx := payload.ConversationState.CurrentMessage.UserInputMessage.UserInputMessageContext
+	if ctx == nil {
+		t.Fatal("expected the current message to carry tools and tool results")
+	}
+	if len(ctx.ToolResults) != 1 || ctx.ToolResults[0].ToolUseID != toolUseID || ctx.ToolResults[0].Content[0].Text != "sunny" {
+		t.Errorf("unexpected tool results %+v", ctx.ToolResults)
+	}
+	if len(ctx.Tools) != 1 || ctx.Tools[0].ToolSpecification.Name != "get_weather" {
+		t.Fatalf("unexpected tools %+v", ctx.Tools)
+	}
+	schema, _ := json.Marshal(ctx.Tools[0].ToolSpecification.InputSchema.JSON)
+	if gjson.GetBytes(schema, "type").String() != "object" || gjson.GetBytes(schema, "properties.city.type").String() != "string" {
+		t.Errorf("expected Gemini schema types to be lowered, got %s", schema)
+	}
+}
+
+func TestConvertKiroStreamToGemini(t *testing.T) {
+	events := []string{
+		"event: message_start\ndata: {\"type\":\"message_start\",\"message\":{\"id\":\"msg_1\",\"model\":\"kiro-model\"}}",
+		"event: content_block_start\ndata: {\"type\":\"content_block_start\",\"index\":0,\"content_block\":{\"type\":\"thinking\",\"thinking\":\"\"}}",
+		"event: content_block_delta\ndata: {\"type\":\"content_block_delta\",\"index\":0,\"delta\":{\"type\":\"thinking_delta\",\"thinking\":\"hmm\"}}",
+		"event: content_block_delta\ndata: {\"type\":\"content_block_delta\",\"index\":1,\"delta\":{\"type\":\"text_delta\",\"text\":\"Hi\"}}",
+		"event: content_block_start\ndata: {\"type\":\"content_block_start\",\"index\":2,\"content_block\":{\"type\":\"tool_use\",\"id\":\"toolu_1\",\"name\":\"get_weather\",\"input\":{}}}",
+		"event: content_block_delta\ndata: {\"type\":\"content_block_delta\",\"index\":2,\"delta\":{\"type\":\"input_json_delta\",\"partial_json\":\"{\\\"city\\\":\\\"Paris\\\"}\"}}",
+		"event: content_block_stop\ndata: {\"type\":\"content_block_stop\",\"index\":2}",
+		"event: ping\ndata: {\"type\":\"ping\"}",
+		"event: message_delta\ndata: {\"type\":\"message_delta\",\"delta\":{\"stop_reason\":\"tool_use\"},\"usage\":{\"input_tokens\":5,\"output_tokens\":7}}",
+		"event: message_stop\ndata: {\"type\":\"message_stop\"}",
+	}
+	var param any
+	var chunks []string
+	for _, event := range events {
+		chunks = append(chunks, ConvertKiroStreamToGemini(context.Background(), "gemini-model", nil, nil, []byte(event), &param)...)
+	}
+	if len(chunks) != 4 {
+		t.Fatalf("expected 4 chunks, got %d: %v", len(chunks), chunks)
+	}
+	if part := gjson.Get(chunks[0], "candidates.0.content.parts.0"); !part.Get("thought").Bool() || part.Get("text").String() != "hmm" {
+		t.Errorf("expected a thought part, got %s", chunks[0])
+	}
+	if gjson.Get(chunks[1], "candidates.0.content.parts.0.text").String() != "Hi" {
+		t.Errorf("expected a text part, got %s", chunks[1])
+	}
+	if fc := gjson.Get(chunks[2], "candidates.0.content.parts.0.functionCall"); fc.Get("name").String() != "get_weather" || fc.Get("args.city").String() != "Paris" {
+		t.Errorf("expected a function call part, got %s", chunks[2])
+	}
+	if gjson.Get(chunks[3], "usageMetadata.totalTokenCount").Int() != 12 || gjson.Get(chunks[3], "candidates.0.finishReason").String() != "STOP" {
+		t.Errorf("expected usage and finish reason, got %s", chunks[3])
+	}
+}
+
+func TestConvertKiroNonStreamToGemini(t *testing.T) {
+	claudeResponse := []byte(`{"id":"msg_1","type":"message","role":"assistant","model":"kiro-model","content":[
+		{"type":"thinking","thinking":"hmm"},
+		{"type":"text","text":"Checking."},
+		{"type":"tool_use","id":"toolu_1","name":"get_weather","input":{"city":"Paris"}}
+	],"stop_reason":"tool_use","usage":{"input_tokens":5,"output_tokens":7}}`)
+
+	out := ConvertKiroNonStreamToGemini(context.Background(), "gemini-model", nil, nil, claudeResponse, nil)
+	parts := gjson.Get(out, "candidates.0.content.parts").Array()
+	if len(parts) != 3 || !parts[0].Get("thought").Bool() || parts[1].Get("text").String() != "Checking." || parts[2].Get("functionCall.args.city").String() != "Paris" {
+		t.Fatalf("unexpected parts in %s", out)
+	}
+	if gjson.Get(out, "candidates.0.finishReason").String() != "STOP" || gjson.Get(out, "usageMetadata.totalTokenCount").Int() != 12 {
+		t.Fatalf("unexpected finish reason or usage in %s", out)
+	}
+}
@@ -0,0 +1,75 @@
+package gemini
+
+import (
+	"bytes"
+	"context"
+	"strings"
+
+	claudegemini "github.com/router-for-me/CLIProxyAPI/v6/internal/translator/claude/gemini"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// ConvertKiroStreamToGemini converts Kiro streaming events to Gemini streamGenerateContent
+// chunks. The Kiro executor emits Claude SSE events ("event: ...\ndata: {...}"), which are
+// handed to the Claude-to-Gemini stream converter one data payload at a time.
+func ConvertKiroStreamToGemini(ctx context.Context, model string, originalRequest, request, rawResponse []byte, param *any) []string {
+	var data []byte
+	for _, line := range bytes.Split(rawResponse, []byte("\n")) {
+		line = bytes.TrimSpace(line)
+		if bytes.HasPrefix(line, []byte("data:")) {
+			data = bytes.TrimSpace(line[len("data:"):])
+		}
+	}
+	if len(data) == 0 {
+		return nil
+	}
+	return claudegemini.ConvertClaudeResponseToGemini(ctx, model, originalRequest, request, append([]byte("data: "), data...), param)
+}
+
+// ConvertKiroNonStreamToGemini converts a Kiro non-streaming response, which the executor
+// returns as a Claude message, into a Gemini generateContent response.
+func ConvertKiroNonStreamToGemini(_ context.Context, model string, _, _, rawResponse []byte, _ *any) string {
+	response := gjson.ParseBytes(rawResponse)
+
+	out := `{"candidates":[{"content":{"role":"model","parts":[]},"finishReason":"STOP","index":0}],"usageMetadata":{},"modelVersion":"","responseId":""}`
+	out, _ = sjson.Set(out, "modelVersion", model)
+	out, _ = sjson.Set(out, "responseId", response.Get("id").String())
+
+	response.Get("content").ForEach(func(_, block gjson.Result) bool {
+		switch block.Get("type").String() {
+		case "text":
+			if text := block.Get("text").String(); text != "" {
+				part := `{"text":""}`
+				part, _ = sjson.Set(part, "text", text)
+				out, _ = sjson.SetRaw(out, "candidates.0.content.parts.-1", part)
+			}
+		case "thinking":
+			if text := block.Get("thinking").String(); text != "" {
+				part := `{"thought":true,"text":""}`
+				part, _ = sjson.Set(part, "text", text)
+				out, _ = sjson.SetRaw(out, "candidates.0.content.parts.-1", part)
+			}
+		case "tool_use":
+			part := `{"functionCall":{"name":"","args":{}}}`
+			part, _ = sjson.Set(part, "functionCall.id", block.Get("id").String())
+			part, _ = sjson.Set(part, "functionCall.name", block.Get("name").String())
+			if input := block.Get("input"); input.IsObject() {
+				part, _ = sjson.SetRaw(part, "functionCall.args", input.Raw)
+			}
+			out, _ = sjson.SetRaw(out, "candidates.0.content.parts.-1", part)
+		}
+		return true
+	})
+
+	if strings.EqualFold(response.Get("stop_reason").String(), "max_tokens") {
+		out, _ = sjson.Set(out, "candidates.0.finishReason", "MAX_TOKENS")
+	}
+
+	inputTokens := response.Get("usage.input_tokens").Int()
+	outputTokens := response.Get("usage.output_tokens").Int()
+	out, _ = sjson.Set(out, "usageMetadata.promptTokenCount", inputTokens)
+	out, _ = sjson.Set(out, "usageMetadata.candidatesTokenCount", outputTokens)
+	out, _ = sjson.Set(out, "usageMetadata.totalTokenCount", inputTokens+outputTokens)
+	return out
+}
@@ -224,18 +224,18 @@ func ConvertOpenAIResponseToGemini(_ context.Context, _ string, originalRequestR
 					(*param).(*ConvertOpenAIResponseToGeminiParams).ToolCallsAccumulator = make(map[int]*ToolCallAccumulator)
 				}
 
+				// Some providers report usage on the finishing chunk instead of a separate one
+				if usage := root.Get("usage"); usage.Exists() && usage.Type != gjson.Null {
+					template = setGeminiUsageMetadata(template, usage)
+				}
+
 				results = append(results, template)
 				return true
 			}
 
 			// Handle usage information
 			if usage := root.Get("usage"); usage.Exists() {
-				template, _ = sjson.Set(template, "usageMetadata.promptTokenCount", usage.Get("prompt_tokens").Int())
-				template, _ = sjson.Set(template, "usageMetadata.candidatesTokenCount", usage.Get("completion_tokens").Int())
-				template, _ = sjson.Set(template, "usageMetadata.totalTokenCount", usage.Get("total_tokens").Int())
-				if reasoningTokens := reasoningTokensFromUsage(usage); reasoningTokens > 0 {
-					template, _ = sjson.Set(template, "usageMetadata.thoughtsTokenCount", reasoningTokens)
-				}
+				template = setGeminiUsageMetadata(template, usage)
 				results = append(results, template)
 				return true
 			}
@@ -624,6 +624,17 @@ func GeminiTokenCount(ctx context.Context, count int64) string {
 	return fmt.Sprintf(`{"totalTokens":%d,"promptTokensDetails":[{"modality":"TEXT","tokenCount":%d}]}`, count, count)
 }
 
+// setGeminiUsageMetadata copies OpenAI usage counts into the usageMetadata of a Gemini chunk.
+func setGeminiUsageMetadata(template string, usage gjson.Result) string {
+	template, _ = sjson.Set(template, "usageMetadata.promptTokenCount", usage.Get("prompt_tokens").Int())
+	template, _ = sjson.Set(template, "usageMetadata.candidatesTokenCount", usage.Get("completion_tokens").Int())
+	template, _ = sjson.Set(template, "usageMetadata.totalTokenCount", usage.Get("total_tokens").Int())
+	if reasoningTokens := reasoningTokensFromUsage(usage); reasoningTokens > 0 {
+		template, _ = sjson.Set(template, "usageMetadata.thoughtsTokenCount", reasoningTokens)
+	}
+	return template
+}
+
 func reasoningTokensFromUsage(usage gjson.Result) int64 {
 	if usage.Exists() {
 		if v := usage.Get("completion_tokens_details.reasoning_tokens"); v.Exists() {
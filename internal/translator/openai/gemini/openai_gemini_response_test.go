@@ -0,0 +1,25 @@
+package gemini
+
+import (
+	"context"
+	"testing"
+
+	"github.com/tidwall/gjson"
+)
+
+func TestConvertOpenAIResponseToGemini_UsageOnFinishChunk(t *testing.T) {
+	var param any
+	raw := []byte(`data: {"id":"c1","model":"gpt-5","choices":[{"index":0,"delta":{},"finish_reason":"stop"}],"usage":{"prompt_tokens":3,"completion_tokens":5,"total_tokens":8}}`)
+
+	out := ConvertOpenAIResponseToGemini(context.Background(), "gpt-5", nil, nil, raw, &param)
+
+	if len(out) != 1 {
+		t.Fatalf("expected one chunk, got %v", out)
+	}
+	if got := gjson.Get(out[0], "candidates.0.finishReason").String(); got != "STOP" {
+		t.Fatalf("expected finishReason STOP, got %s", out[0])
+	}
+	if got := gjson.Get(out[0], "usageMetadata.totalTokenCount").Int(); got != 8 {
+		t.Fatalf("expected usage carried on the finishing chunk, got %s", out[0])
+	}
+}
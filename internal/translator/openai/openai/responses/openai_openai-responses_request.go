@@ -76,6 +76,9 @@ func ConvertOpenAIResponsesRequestToOpenAIChatCompletions(modelName string, inpu
 				if content := item.Get("content"); content.Exists() && content.IsArray() {
 					var messageContent string
 					var toolCalls []interface{}
+					// contentParts mirrors the content as Chat Completions parts, used once an image appears
+					contentParts := `[]`
+					hasImage := false
 
 					content.ForEach(func(_, contentItem gjson.Result) bool {
 						contentType := contentItem.Get("type").String()
@@ -91,6 +94,8 @@ func ConvertOpenAIResponsesRequestToOpenAIChatCompletions(modelName string, inpu
 							} else {
 								messageContent = text
 							}
+							part, _ := sjson.Set(`{"type":"text","text":""}`, "text", text)
+							contentParts, _ = sjson.SetRaw(contentParts, "-1", part)
 						case "output_text":
 							text := contentItem.Get("text").String()
 							if messageContent != "" {
@@ -98,11 +103,28 @@ func ConvertOpenAIResponsesRequestToOpenAIChatCompletions(modelName string, inpu
 							} else {
 								messageContent = text
 							}
+							part, _ := sjson.Set(`{"type":"text","text":""}`, "text", text)
+							contentParts, _ = sjson.SetRaw(contentParts, "-1", part)
+						case "input_image":
+							imageURL := contentItem.Get("image_url")
+							if imageURL.IsObject() {
+								imageURL = imageURL.Get("url")
+							}
+							if imageURL.String() != "" {
+								part, _ := sjson.Set(`{"type":"image_url","image_url":{"url":""}}`, "image_url.url", imageURL.String())
+								if detail := contentItem.Get("detail"); detail.Exists() {
+									part, _ = sjson.Set(part, "image_url.detail", detail.String())
+								}
+								contentParts, _ = sjson.SetRaw(contentParts, "-1", part)
+								hasImage = true
+							}
 						}
 						return true
 					})
 
-					if messageContent != "" {
+					if hasImage {
+						message, _ = sjson.SetRaw(message, "content", contentParts)
+					} else if messageContent != "" {
 						message, _ = sjson.Set(message, "content", messageContent)
 					}
 
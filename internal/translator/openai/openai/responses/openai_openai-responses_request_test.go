@@ -0,0 +1,47 @@
+package responses
+
+import (
+	"testing"
+
+	"github.com/tidwall/gjson"
+)
+
+func TestConvertOpenAIResponsesRequestToOpenAIChatCompletions_InputImage(t *testing.T) {
+	inputJSON := []byte(`{
+		"model": "gpt-5",
+		"input": [
+			{
+				"type": "message",
+				"role": "user",
+				"content": [
+					{"type": "input_text", "text": "What is this?"},
+					{"type": "input_image", "image_url": "data:image/png;base64,aGVsbG8=", "detail": "low"}
+				]
+			}
+		]
+	}`)
+
+	output := ConvertOpenAIResponsesRequestToOpenAIChatCompletions("gpt-5", inputJSON, false)
+
+	content := gjson.GetBytes(output, "messages.0.content")
+	if !content.IsArray() {
+		t.Fatalf("expected content parts once an image is present, got %s", content.Raw)
+	}
+	if got := content.Get("0.text").String(); got != "What is this?" {
+		t.Fatalf("expected the text part first, got %s", content.Raw)
+	}
+	image := content.Get("1.image_url")
+	if image.Get("url").String() != "data:image/png;base64,aGVsbG8=" || image.Get("detail").String() != "low" {
+		t.Fatalf("expected the image_url part, got %s", content.Raw)
+	}
+}
+
+func TestConvertOpenAIResponsesRequestToOpenAIChatCompletions_TextOnlyStaysString(t *testing.T) {
+	inputJSON := []byte(`{"model":"gpt-5","input":[{"type":"message","role":"user","content":[{"type":"input_text","text":"hi"}]}]}`)
+
+	output := ConvertOpenAIResponsesRequestToOpenAIChatCompletions("gpt-5", inputJSON, false)
+
+	if content := gjson.GetBytes(output, "messages.0.content"); content.Type != gjson.String || content.String() != "hi" {
+		t.Fatalf("expected text-only content to stay a string, got %s", content.Raw)
+	}
+}
@@ -25,6 +25,17 @@ func Register(from, to string, request interfaces.TranslateRequestFunc, response
 	registry.Register(sdktranslator.FromString(from), sdktranslator.FromString(to), request, response)
 }
 
+// SetCapabilities declares the features a registered translator pair carries, which decides
+// the routes chained through hub formats.
+//
+// Parameters:
+//   - from: The source API format identifier
+//   - to: The target API format identifier
+//   - capabilities: The features the pair carries
+func SetCapabilities(from, to string, capabilities sdktranslator.Capability) {
+	registry.SetCapabilities(sdktranslator.FromString(from), sdktranslator.FromString(to), capabilities)
+}
+
 // Request translates a request from one API format to another.
 //
 // Parameters:
@@ -91,9 +91,16 @@ type modelStats struct {
 type RequestDetail struct {
 	Timestamp time.Time  `json:"timestamp"`
 	Source    string     `json:"source"`
+	Provider  string     `json:"provider,omitempty"`
 	AuthIndex string     `json:"auth_index"`
 	Tokens    TokenStats `json:"tokens"`
 	Failed    bool       `json:"failed"`
+	// FallbackFrom names the originally requested model when a fallback model served the request.
+	FallbackFrom string `json:"fallback_from,omitempty"`
+	// ResponseCached marks requests answered from the response cache.
+	ResponseCached bool `json:"response_cached,omitempty"`
+	// Estimated marks input tokens counted locally because the upstream reported no usage.
+	Estimated bool `json:"estimated,omitempty"`
 }
 
 // TokenStats captures the token usage breakdown for a request.
@@ -197,11 +204,15 @@ func (s *RequestStatistics) Record(ctx context.Context, record coreusage.Record)
 		s.apis[statsKey] = stats
 	}
 	s.updateAPIStats(stats, modelName, RequestDetail{
-		Timestamp: timestamp,
-		Source:    record.Source,
-		AuthIndex: record.AuthIndex,
-		Tokens:    detail,
-		Failed:    failed,
+		Timestamp:      timestamp,
+		Source:         record.Source,
+		Provider:       record.Provider,
+		AuthIndex:      record.AuthIndex,
+		Tokens:         detail,
+		Failed:         failed,
+		FallbackFrom:   record.FallbackFrom,
+		ResponseCached: record.ResponseCached,
+		Estimated:      record.Estimated,
 	})
 
 	s.requestsByDay[dayKey]++
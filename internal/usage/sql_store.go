@@ -0,0 +1,671 @@
+package usage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	coreusage "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
+	log "github.com/sirupsen/logrus"
+	_ "modernc.org/sqlite"
+)
+
+// Supported persistent usage store drivers.
+const (
+	StoreDriverSQLite   = "sqlite"
+	StoreDriverPostgres = "postgres"
+)
+
+// Rollup granularities kept by the persistent store.
+const (
+	GranularityHour = "hour"
+	GranularityDay  = "day"
+)
+
+const (
+	defaultUsageDBFile     = "usage.db"
+	defaultRawRetention    = 30 * 24 * time.Hour
+	defaultHourlyRetention = 90 * 24 * time.Hour
+	usagePruneInterval     = time.Hour
+	usageEventsTable       = "usage_events"
+	usageRollupsTable      = "usage_rollups"
+)
+
+var activeStore atomic.Pointer[SQLStore]
+
+func init() {
+	coreusage.RegisterPlugin(persistentPlugin{})
+}
+
+// SetPersistentStore installs the store that receives every usage record. Passing nil disables persistence.
+func SetPersistentStore(store *SQLStore) { activeStore.Store(store) }
+
+// PersistentStore returns the active persistent store, or nil when persistence is disabled.
+func PersistentStore() *SQLStore { return activeStore.Load() }
+
+// persistentPlugin forwards usage records to the active persistent store.
+type persistentPlugin struct{}
+
+// HandleUsage implements coreusage.Plugin.
+func (persistentPlugin) HandleUsage(ctx context.Context, record coreusage.Record) {
+	store := activeStore.Load()
+	if store == nil {
+		return
+	}
+	if err := store.Record(ctx, record); err != nil {
+		log.Warnf("usage store: failed to persist usage record: %v", err)
+	}
+}
+
+// RetentionPolicy controls how long raw records and rollups are kept. Non-positive values keep data forever.
+type RetentionPolicy struct {
+	Raw    time.Duration
+	Hourly time.Duration
+	Daily  time.Duration
+}
+
+// RetentionFromConfig resolves the retention policy with defaults applied.
+func RetentionFromConfig(cfg config.UsageStoreConfig) RetentionPolicy {
+	policy := RetentionPolicy{
+		Raw:    defaultRawRetention,
+		Hourly: defaultHourlyRetention,
+	}
+	if cfg.RawRetentionDays > 0 {
+		policy.Raw = time.Duration(cfg.RawRetentionDays) * 24 * time.Hour
+	}
+	if cfg.HourlyRetentionDays > 0 {
+		policy.Hourly = time.Duration(cfg.HourlyRetentionDays) * 24 * time.Hour
+	}
+	if cfg.DailyRetentionDays > 0 {
+		policy.Daily = time.Duration(cfg.DailyRetentionDays) * 24 * time.Hour
+	}
+	return policy
+}
+
+// QueryFilter narrows persisted or in-memory statistics. Zero values match everything.
+type QueryFilter struct {
+	From      time.Time
+	To        time.Time
+	APIKey    string
+	Provider  string
+	Model     string
+	AuthIndex string
+}
+
+// IsZero reports whether the filter matches every record.
+func (f QueryFilter) IsZero() bool {
+	return f.From.IsZero() && f.To.IsZero() && f.APIKey == "" && f.Provider == "" && f.Model == "" && f.AuthIndex == ""
+}
+
+func (f QueryFilter) matches(apiKey, model string, detail RequestDetail) bool {
+	if !f.From.IsZero() && detail.Timestamp.Before(f.From) {
+		return false
+	}
+	if !f.To.IsZero() && !detail.Timestamp.Before(f.To) {
+		return false
+	}
+	if f.APIKey != "" && f.APIKey != apiKey {
+		return false
+	}
+	if f.Model != "" && f.Model != model {
+		return false
+	}
+	if f.Provider != "" && !strings.EqualFold(f.Provider, detail.Provider) {
+		return false
+	}
+	if f.AuthIndex != "" && f.AuthIndex != detail.AuthIndex {
+		return false
+	}
+	return true
+}
+
+// UsageBucket is one time-bucketed rollup row.
+type UsageBucket struct {
+	Start           time.Time `json:"start"`
+	APIKey          string    `json:"api_key"`
+	Provider        string    `json:"provider"`
+	Model           string    `json:"model"`
+	AuthIndex       string    `json:"auth_index"`
+	Requests        int64     `json:"requests"`
+	Failures        int64     `json:"failures"`
+	InputTokens     int64     `json:"input_tokens"`
+	OutputTokens    int64     `json:"output_tokens"`
+	ReasoningTokens int64     `json:"reasoning_tokens"`
+	CachedTokens    int64     `json:"cached_tokens"`
+	TotalTokens     int64     `json:"total_tokens"`
+}
+
+// SQLStore persists usage records in SQLite or PostgreSQL. Every record is kept as a raw
+// event and folded into hourly and daily rollups; old rows are pruned per RetentionPolicy.
+type SQLStore struct {
+	db           *sql.DB
+	driver       string
+	ownsDB       bool
+	eventsTable  string
+	rollupsTable string
+
+	mu        sync.Mutex
+	retention RetentionPolicy
+	lastPrune time.Time
+	now       func() time.Time
+}
+
+// OpenStore opens the persistent usage store described by cfg. SQLite paths resolve against baseDir.
+// The postgres driver reuses shared (the token store connection) and creates its tables in schema.
+func OpenStore(ctx context.Context, cfg config.UsageStoreConfig, baseDir string, shared *sql.DB, schema string) (*SQLStore, error) {
+	driver := strings.ToLower(strings.TrimSpace(cfg.Driver))
+	switch driver {
+	case "", StoreDriverSQLite, "sqlite3":
+		path := strings.TrimSpace(cfg.Path)
+		if path == "" {
+			path = defaultUsageDBFile
+		}
+		if !filepath.IsAbs(path) && baseDir != "" {
+			path = filepath.Join(baseDir, path)
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+			return nil, fmt.Errorf("usage store: create database directory: %w", err)
+		}
+		db, err := sql.Open("sqlite", "file:"+path+"?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)")
+		if err != nil {
+			return nil, fmt.Errorf("usage store: open sqlite database: %w", err)
+		}
+		db.SetMaxOpenConns(1)
+		store, err := NewSQLStore(ctx, db, StoreDriverSQLite, "", RetentionFromConfig(cfg))
+		if err != nil {
+			_ = db.Close()
+			return nil, err
+		}
+		store.ownsDB = true
+		return store, nil
+	case StoreDriverPostgres, "postgresql", "pg":
+		if shared == nil {
+			return nil, fmt.Errorf("usage store: postgres driver requires the Postgres-backed store (PGSTORE_DSN)")
+		}
+		return NewSQLStore(ctx, shared, StoreDriverPostgres, schema, RetentionFromConfig(cfg))
+	default:
+		return nil, fmt.Errorf("usage store: unsupported driver %q", cfg.Driver)
+	}
+}
+
+// NewSQLStore wraps an open database and ensures the usage tables exist.
+func NewSQLStore(ctx context.Context, db *sql.DB, driver, schema string, retention RetentionPolicy) (*SQLStore, error) {
+	if db == nil {
+		return nil, fmt.Errorf("usage store: database is nil")
+	}
+	store := &SQLStore{
+		db:           db,
+		driver:       driver,
+		eventsTable:  qualifiedTable(driver, schema, usageEventsTable),
+		rollupsTable: qualifiedTable(driver, schema, usageRollupsTable),
+		retention:    retention,
+		now:          time.Now,
+	}
+	if err := store.ensureSchema(ctx, schema); err != nil {
+		return nil, err
+	}
+	return store, nil
+}
+
+// Close releases the database when the store opened it.
+func (s *SQLStore) Close() error {
+	if s == nil || s.db == nil || !s.ownsDB {
+		return nil
+	}
+	return s.db.Close()
+}
+
+// SetRetention replaces the retention policy; it applies on the next prune.
+func (s *SQLStore) SetRetention(policy RetentionPolicy) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	s.retention = policy
+	s.lastPrune = time.Time{}
+	s.mu.Unlock()
+}
+
+func qualifiedTable(driver, schema, name string) string {
+	if driver == StoreDriverPostgres && strings.TrimSpace(schema) != "" {
+		return quoteIdentifier(strings.TrimSpace(schema)) + "." + quoteIdentifier(name)
+	}
+	return quoteIdentifier(name)
+}
+
+func quoteIdentifier(identifier string) string {
+	return "\"" + strings.ReplaceAll(identifier, "\"", "\"\"") + "\""
+}
+
+// rebind rewrites '?' placeholders to the driver's positional form.
+func (s *SQLStore) rebind(query string) string {
+	if s.driver != StoreDriverPostgres {
+		return query
+	}
+	var b strings.Builder
+	n := 0
+	for _, r := range query {
+		if r == '?' {
+			n++
+			b.WriteByte('$')
+			b.WriteString(strconv.Itoa(n))
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+func (s *SQLStore) ensureSchema(ctx context.Context, schema string) error {
+	if s.driver == StoreDriverPostgres && strings.TrimSpace(schema) != "" {
+		if _, err := s.db.ExecContext(ctx, "CREATE SCHEMA IF NOT EXISTS "+quoteIdentifier(strings.TrimSpace(schema))); err != nil {
+			return fmt.Errorf("usage store: create schema: %w", err)
+		}
+	}
+	statements := []string{
+		fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+			requested_at BIGINT NOT NULL,
+			api_key TEXT NOT NULL,
+			provider TEXT NOT NULL,
+			model TEXT NOT NULL,
+			auth_id TEXT NOT NULL,
+			auth_index TEXT NOT NULL,
+			source TEXT NOT NULL,
+			failed INTEGER NOT NULL,
+			input_tokens BIGINT NOT NULL,
+			output_tokens BIGINT NOT NULL,
+			reasoning_tokens BIGINT NOT NULL,
+			cached_tokens BIGINT NOT NULL,
+			total_tokens BIGINT NOT NULL,
+			fallback_from TEXT NOT NULL DEFAULT '',
+			response_cached INTEGER NOT NULL DEFAULT 0
+		)`, s.eventsTable),
+		fmt.Sprintf(`CREATE INDEX IF NOT EXISTS usage_events_requested_at_idx ON %s (requested_at)`, s.eventsTable),
+		fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+			granularity TEXT NOT NULL,
+			bucket_start BIGINT NOT NULL,
+			api_key TEXT NOT NULL,
+			provider TEXT NOT NULL,
+			model TEXT NOT NULL,
+			auth_index TEXT NOT NULL,
+			requests BIGINT NOT NULL,
+			failures BIGINT NOT NULL,
+			input_tokens BIGINT NOT NULL,
+			output_tokens BIGINT NOT NULL,
+			reasoning_tokens BIGINT NOT NULL,
+			cached_tokens BIGINT NOT NULL,
+			total_tokens BIGINT NOT NULL,
+			PRIMARY KEY (granularity, bucket_start, api_key, provider, model, auth_index)
+		)`, s.rollupsTable),
+	}
+	for _, stmt := range statements {
+		if _, err := s.db.ExecContext(ctx, stmt); err != nil {
+			return fmt.Errorf("usage store: ensure schema: %w", err)
+		}
+	}
+	return nil
+}
+
+func bucketStart(ts time.Time, granularity string) time.Time {
+	ts = ts.UTC()
+	if granularity == GranularityDay {
+		return time.Date(ts.Year(), ts.Month(), ts.Day(), 0, 0, 0, 0, time.UTC)
+	}
+	return ts.Truncate(time.Hour)
+}
+
+// Record persists a usage record and updates its hourly and daily rollups.
+func (s *SQLStore) Record(ctx context.Context, record coreusage.Record) error {
+	if s == nil || s.db == nil {
+		return nil
+	}
+	timestamp := record.RequestedAt
+	if timestamp.IsZero() {
+		timestamp = s.now()
+	}
+	detail := normaliseDetail(record.Detail)
+	apiKey := record.APIKey
+	if apiKey == "" {
+		apiKey = resolveAPIIdentifier(ctx, record)
+	}
+	failed := record.Failed
+	if !failed {
+		failed = !resolveSuccess(ctx)
+	}
+	failures := int64(0)
+	if failed {
+		failures = 1
+	}
+	responseCached := int64(0)
+	if record.ResponseCached {
+		responseCached = 1
+	}
+	model := record.Model
+	if model == "" {
+		model = "unknown"
+	}
+
+	// Records are delivered after the request finished, so its context may already be cancelled.
+	writeCtx := context.Background()
+	tx, err := s.db.BeginTx(writeCtx, nil)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	_, err = tx.ExecContext(writeCtx, s.rebind(fmt.Sprintf(`INSERT INTO %s
+		(requested_at, api_key, provider, model, auth_id, auth_index, source, failed,
+		 input_tokens, output_tokens, reasoning_tokens, cached_tokens, total_tokens, fallback_from, response_cached)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`, s.eventsTable)),
+		timestamp.UnixNano(), apiKey, record.Provider, model, record.AuthID, record.AuthIndex, record.Source, failures,
+		detail.InputTokens, detail.OutputTokens, detail.ReasoningTokens, detail.CachedTokens, detail.TotalTokens, record.FallbackFrom,
+		responseCached)
+	if err != nil {
+		_ = tx.Rollback()
+		return fmt.Errorf("insert event: %w", err)
+	}
+	upsert := s.rebind(fmt.Sprintf(`INSERT INTO %s AS r
+		(granularity, bucket_start, api_key, provider, model, auth_index, requests, failures,
+		 input_tokens, output_tokens, reasoning_tokens, cached_tokens, total_tokens)
+		VALUES (?, ?, ?, ?, ?, ?, 1, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (granularity, bucket_start, api_key, provider, model, auth_index) DO UPDATE SET
+			requests = r.requests + 1,
+			failures = r.failures + excluded.failures,
+			input_tokens = r.input_tokens + excluded.input_tokens,
+			output_tokens = r.output_tokens + excluded.output_tokens,
+			reasoning_tokens = r.reasoning_tokens + excluded.reasoning_tokens,
+			cached_tokens = r.cached_tokens + excluded.cached_tokens,
+			total_tokens = r.total_tokens + excluded.total_tokens`, s.rollupsTable))
+	for _, granularity := range []string{GranularityHour, GranularityDay} {
+		_, err = tx.ExecContext(writeCtx, upsert,
+			granularity, bucketStart(timestamp, granularity).Unix(), apiKey, record.Provider, model, record.AuthIndex, failures,
+			detail.InputTokens, detail.OutputTokens, detail.ReasoningTokens, detail.CachedTokens, detail.TotalTokens)
+		if err != nil {
+			_ = tx.Rollback()
+			return fmt.Errorf("update %s rollup: %w", granularity, err)
+		}
+	}
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("commit: %w", err)
+	}
+	s.maybePrune(writeCtx)
+	return nil
+}
+
+func (s *SQLStore) maybePrune(ctx context.Context) {
+	now := s.now()
+	s.mu.Lock()
+	if !s.lastPrune.IsZero() && now.Sub(s.lastPrune) < usagePruneInterval {
+		s.mu.Unlock()
+		return
+	}
+	s.lastPrune = now
+	s.mu.Unlock()
+	if err := s.Prune(ctx); err != nil {
+		log.Warnf("usage store: prune failed: %v", err)
+	}
+}
+
+// Prune deletes raw events and rollups older than the retention policy.
+func (s *SQLStore) Prune(ctx context.Context) error {
+	if s == nil || s.db == nil {
+		return nil
+	}
+	now := s.now()
+	s.mu.Lock()
+	retention := s.retention
+	s.mu.Unlock()
+	if retention.Raw > 0 {
+		cutoff := now.Add(-retention.Raw).UnixNano()
+		if _, err := s.db.ExecContext(ctx, s.rebind(fmt.Sprintf("DELETE FROM %s WHERE requested_at < ?", s.eventsTable)), cutoff); err != nil {
+			return fmt.Errorf("prune events: %w", err)
+		}
+	}
+	for granularity, keep := range map[string]time.Duration{GranularityHour: retention.Hourly, GranularityDay: retention.Daily} {
+		if keep <= 0 {
+			continue
+		}
+		cutoff := bucketStart(now.Add(-keep), granularity).Unix()
+		if _, err := s.db.ExecContext(ctx, s.rebind(fmt.Sprintf("DELETE FROM %s WHERE granularity = ? AND bucket_start < ?", s.rollupsTable)), granularity, cutoff); err != nil {
+			return fmt.Errorf("prune %s rollups: %w", granularity, err)
+		}
+	}
+	return nil
+}
+
+// rollupWhere builds the WHERE clause for rollup queries. Range bounds are widened to bucket edges.
+func rollupWhere(granularity string, filter QueryFilter) (string, []any) {
+	conds := []string{"granularity = ?"}
+	args := []any{granularity}
+	if !filter.From.IsZero() {
+		conds = append(conds, "bucket_start >= ?")
+		args = append(args, bucketStart(filter.From, granularity).Unix())
+	}
+	if !filter.To.IsZero() {
+		conds = append(conds, "bucket_start < ?")
+		args = append(args, filter.To.Unix())
+	}
+	conds, args = appendDimensionFilters(conds, args, filter)
+	return strings.Join(conds, " AND "), args
+}
+
+func appendDimensionFilters(conds []string, args []any, filter QueryFilter) ([]string, []any) {
+	if filter.APIKey != "" {
+		conds = append(conds, "api_key = ?")
+		args = append(args, filter.APIKey)
+	}
+	if filter.Provider != "" {
+		conds = append(conds, "LOWER(provider) = ?")
+		args = append(args, strings.ToLower(filter.Provider))
+	}
+	if filter.Model != "" {
+		conds = append(conds, "model = ?")
+		args = append(args, filter.Model)
+	}
+	if filter.AuthIndex != "" {
+		conds = append(conds, "auth_index = ?")
+		args = append(args, filter.AuthIndex)
+	}
+	return conds, args
+}
+
+// Buckets returns the rollup rows of the given granularity that match filter, oldest first.
+func (s *SQLStore) Buckets(ctx context.Context, granularity string, filter QueryFilter) ([]UsageBucket, error) {
+	if s == nil || s.db == nil {
+		return nil, nil
+	}
+	if granularity != GranularityDay {
+		granularity = GranularityHour
+	}
+	where, args := rollupWhere(granularity, filter)
+	rows, err := s.db.QueryContext(ctx, s.rebind(fmt.Sprintf(`SELECT bucket_start, api_key, provider, model, auth_index,
+		requests, failures, input_tokens, output_tokens, reasoning_tokens, cached_tokens, total_tokens
+		FROM %s WHERE %s ORDER BY bucket_start, api_key, provider, model, auth_index`, s.rollupsTable, where)), args...)
+	if err != nil {
+		return nil, fmt.Errorf("usage store: query rollups: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+	var out []UsageBucket
+	for rows.Next() {
+		var bucket UsageBucket
+		var start int64
+		if err = rows.Scan(&start, &bucket.APIKey, &bucket.Provider, &bucket.Model, &bucket.AuthIndex,
+			&bucket.Requests, &bucket.Failures, &bucket.InputTokens, &bucket.OutputTokens,
+			&bucket.ReasoningTokens, &bucket.CachedTokens, &bucket.TotalTokens); err != nil {
+			return nil, fmt.Errorf("usage store: scan rollup: %w", err)
+		}
+		bucket.Start = time.Unix(start, 0).UTC()
+		out = append(out, bucket)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("usage store: iterate rollups: %w", err)
+	}
+	return out, nil
+}
+
+// Snapshot builds a StatisticsSnapshot from persisted data. Totals and per-day series come from
+// daily rollups, per-hour series from hourly rollups (UTC), and request details from raw events
+// still within raw retention.
+func (s *SQLStore) Snapshot(ctx context.Context, filter QueryFilter) (StatisticsSnapshot, error) {
+	result := StatisticsSnapshot{
+		APIs:           make(map[string]APISnapshot),
+		RequestsByDay:  make(map[string]int64),
+		RequestsByHour: make(map[string]int64),
+		TokensByDay:    make(map[string]int64),
+		TokensByHour:   make(map[string]int64),
+	}
+	if s == nil || s.db == nil {
+		return result, nil
+	}
+
+	daily, err := s.Buckets(ctx, GranularityDay, filter)
+	if err != nil {
+		return result, err
+	}
+	models := make(map[string]map[string]*ModelSnapshot)
+	for _, bucket := range daily {
+		result.TotalRequests += bucket.Requests
+		result.FailureCount += bucket.Failures
+		result.SuccessCount += bucket.Requests - bucket.Failures
+		result.TotalTokens += bucket.TotalTokens
+		day := bucket.Start.Format("2006-01-02")
+		result.RequestsByDay[day] += bucket.Requests
+		result.TokensByDay[day] += bucket.TotalTokens
+
+		api := result.APIs[bucket.APIKey]
+		api.TotalRequests += bucket.Requests
+		api.TotalTokens += bucket.TotalTokens
+		result.APIs[bucket.APIKey] = api
+		if models[bucket.APIKey] == nil {
+			models[bucket.APIKey] = make(map[string]*ModelSnapshot)
+		}
+		modelSnapshot := models[bucket.APIKey][bucket.Model]
+		if modelSnapshot == nil {
+			modelSnapshot = &ModelSnapshot{}
+			models[bucket.APIKey][bucket.Model] = modelSnapshot
+		}
+		modelSnapshot.TotalRequests += bucket.Requests
+		modelSnapshot.TotalTokens += bucket.TotalTokens
+	}
+
+	hourly, err := s.Buckets(ctx, GranularityHour, filter)
+	if err != nil {
+		return result, err
+	}
+	for _, bucket := range hourly {
+		hour := formatHour(bucket.Start.Hour())
+		result.RequestsByHour[hour] += bucket.Requests
+		result.TokensByHour[hour] += bucket.TotalTokens
+	}
+
+	details, err := s.queryEvents(ctx, filter)
+	if err != nil {
+		return result, err
+	}
+	for _, event := range details {
+		if models[event.apiKey] == nil {
+			models[event.apiKey] = make(map[string]*ModelSnapshot)
+		}
+		modelSnapshot := models[event.apiKey][event.model]
+		if modelSnapshot == nil {
+			modelSnapshot = &ModelSnapshot{}
+			models[event.apiKey][event.model] = modelSnapshot
+		}
+		modelSnapshot.Details = append(modelSnapshot.Details, event.detail)
+	}
+
+	for apiKey, byModel := range models {
+		api := result.APIs[apiKey]
+		api.Models = make(map[string]ModelSnapshot, len(byModel))
+		for modelName, modelSnapshot := range byModel {
+			if modelSnapshot.Details == nil {
+				modelSnapshot.Details = []RequestDetail{}
+			}
+			api.Models[modelName] = *modelSnapshot
+		}
+		result.APIs[apiKey] = api
+	}
+	return result, nil
+}
+
+type storedEvent struct {
+	apiKey string
+	model  string
+	detail RequestDetail
+}
+
+func (s *SQLStore) queryEvents(ctx context.Context, filter QueryFilter) ([]storedEvent, error) {
+	conds := []string{"1 = 1"}
+	var args []any
+	if !filter.From.IsZero() {
+		conds = append(conds, "requested_at >= ?")
+		args = append(args, filter.From.UnixNano())
+	}
+	if !filter.To.IsZero() {
+		conds = append(conds, "requested_at < ?")
+		args = append(args, filter.To.UnixNano())
+	}
+	conds, args = appendDimensionFilters(conds, args, filter)
+	rows, err := s.db.QueryContext(ctx, s.rebind(fmt.Sprintf(`SELECT requested_at, api_key, provider, model, auth_index, source, failed,
+		input_tokens, output_tokens, reasoning_tokens, cached_tokens, total_tokens, fallback_from, response_cached
+		FROM %s WHERE %s ORDER BY requested_at`, s.eventsTable, strings.Join(conds, " AND "))), args...)
+	if err != nil {
+		return nil, fmt.Errorf("usage store: query events: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+	var out []storedEvent
+	for rows.Next() {
+		var event storedEvent
+		var requestedAt, failed, responseCached int64
+		if err = rows.Scan(&requestedAt, &event.apiKey, &event.detail.Provider, &event.model, &event.detail.AuthIndex,
+			&event.detail.Source, &failed, &event.detail.Tokens.InputTokens, &event.detail.Tokens.OutputTokens,
+			&event.detail.Tokens.ReasoningTokens, &event.detail.Tokens.CachedTokens, &event.detail.Tokens.TotalTokens,
+			&event.detail.FallbackFrom, &responseCached); err != nil {
+			return nil, fmt.Errorf("usage store: scan event: %w", err)
+		}
+		event.detail.Timestamp = time.Unix(0, requestedAt)
+		event.detail.Failed = failed != 0
+		event.detail.ResponseCached = responseCached != 0
+		out = append(out, event)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("usage store: iterate events: %w", err)
+	}
+	return out, nil
+}
+
+// FilterSnapshot recomputes an in-memory snapshot from the request details matching filter.
+func FilterSnapshot(snapshot StatisticsSnapshot, filter QueryFilter) StatisticsSnapshot {
+	if filter.IsZero() {
+		return snapshot
+	}
+	stats := NewRequestStatistics()
+	apiNames := make([]string, 0, len(snapshot.APIs))
+	for apiName := range snapshot.APIs {
+		apiNames = append(apiNames, apiName)
+	}
+	sort.Strings(apiNames)
+	for _, apiName := range apiNames {
+		for modelName, modelSnapshot := range snapshot.APIs[apiName].Models {
+			for _, detail := range modelSnapshot.Details {
+				if !filter.matches(apiName, modelName, detail) {
+					continue
+				}
+				apiStatsValue, ok := stats.apis[apiName]
+				if !ok {
+					apiStatsValue = &apiStats{Models: make(map[string]*modelStats)}
+					stats.apis[apiName] = apiStatsValue
+				}
+				stats.recordImported(apiName, modelName, apiStatsValue, detail)
+			}
+		}
+	}
+	return stats.Snapshot()
+}
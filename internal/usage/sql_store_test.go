@@ -0,0 +1,141 @@
+package usage
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	coreusage "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
+)
+
+func openTestStore(t *testing.T) *SQLStore {
+	t.Helper()
+	store, err := OpenStore(context.Background(), config.UsageStoreConfig{Enable: true}, t.TempDir(), nil, "")
+	if err != nil {
+		t.Fatalf("OpenStore: %v", err)
+	}
+	t.Cleanup(func() { _ = store.Close() })
+	return store
+}
+
+func TestSQLStore_RecordAndSnapshot(t *testing.T) {
+	store := openTestStore(t)
+	ctx := context.Background()
+	base := time.Date(2026, 3, 10, 9, 15, 0, 0, time.UTC)
+	store.now = func() time.Time { return base.AddDate(0, 0, 2) }
+
+	records := []coreusage.Record{
+		{APIKey: "team-a", Provider: "claude", Model: "claude-sonnet-4-5", AuthIndex: "1", RequestedAt: base, Detail: coreusage.Detail{InputTokens: 10, OutputTokens: 5}},
+		{APIKey: "team-a", Provider: "claude", Model: "claude-sonnet-4-5", AuthIndex: "1", RequestedAt: base.Add(10 * time.Minute), Detail: coreusage.Detail{TotalTokens: 30}},
+		{APIKey: "team-a", Provider: "gemini", Model: "gemini-2.5-pro", AuthIndex: "2", RequestedAt: base.Add(2 * time.Hour), Failed: true},
+		{APIKey: "team-b", Provider: "codex", Model: "gpt-5", AuthIndex: "3", RequestedAt: base.AddDate(0, 0, 1), Detail: coreusage.Detail{TotalTokens: 100}},
+	}
+	for _, record := range records {
+		if err := store.Record(ctx, record); err != nil {
+			t.Fatalf("Record: %v", err)
+		}
+	}
+
+	snapshot, err := store.Snapshot(ctx, QueryFilter{})
+	if err != nil {
+		t.Fatalf("Snapshot: %v", err)
+	}
+	if snapshot.TotalRequests != 4 || snapshot.FailureCount != 1 || snapshot.TotalTokens != 145 {
+		t.Fatalf("unexpected totals: %+v", snapshot)
+	}
+	if got := snapshot.APIs["team-a"].Models["claude-sonnet-4-5"]; got.TotalRequests != 2 || got.TotalTokens != 45 || len(got.Details) != 2 {
+		t.Fatalf("unexpected model snapshot: %+v", got)
+	}
+	if snapshot.RequestsByDay["2026-03-10"] != 3 || snapshot.RequestsByHour["09"] != 3 {
+		t.Fatalf("unexpected series: day=%v hour=%v", snapshot.RequestsByDay, snapshot.RequestsByHour)
+	}
+
+	filtered, err := store.Snapshot(ctx, QueryFilter{Provider: "CLAUDE"})
+	if err != nil {
+		t.Fatalf("Snapshot filtered: %v", err)
+	}
+	if filtered.TotalRequests != 2 || len(filtered.APIs) != 1 {
+		t.Fatalf("unexpected provider filtered snapshot: %+v", filtered)
+	}
+
+	buckets, err := store.Buckets(ctx, GranularityHour, QueryFilter{APIKey: "team-a", From: base, To: base.Add(time.Hour)})
+	if err != nil {
+		t.Fatalf("Buckets: %v", err)
+	}
+	if len(buckets) != 1 || buckets[0].Requests != 2 || buckets[0].TotalTokens != 45 || !buckets[0].Start.Equal(base.Truncate(time.Hour)) {
+		t.Fatalf("unexpected hourly buckets: %+v", buckets)
+	}
+}
+
+func TestSQLStore_PersistsAcrossReopen(t *testing.T) {
+	dir := t.TempDir()
+	cfg := config.UsageStoreConfig{Enable: true, Path: filepath.Join("data", "usage.db")}
+	store, err := OpenStore(context.Background(), cfg, dir, nil, "")
+	if err != nil {
+		t.Fatalf("OpenStore: %v", err)
+	}
+	if err = store.Record(context.Background(), coreusage.Record{APIKey: "k", Model: "m", RequestedAt: time.Now(), Detail: coreusage.Detail{TotalTokens: 7}}); err != nil {
+		t.Fatalf("Record: %v", err)
+	}
+	_ = store.Close()
+
+	reopened, err := OpenStore(context.Background(), cfg, dir, nil, "")
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	defer func() { _ = reopened.Close() }()
+	snapshot, err := reopened.Snapshot(context.Background(), QueryFilter{})
+	if err != nil {
+		t.Fatalf("Snapshot: %v", err)
+	}
+	if snapshot.TotalRequests != 1 || snapshot.TotalTokens != 7 {
+		t.Fatalf("expected persisted record, got %+v", snapshot)
+	}
+}
+
+func TestSQLStore_PruneRespectsRetention(t *testing.T) {
+	store := openTestStore(t)
+	ctx := context.Background()
+	now := time.Date(2026, 6, 1, 12, 0, 0, 0, time.UTC)
+	store.now = func() time.Time { return now }
+	store.SetRetention(RetentionPolicy{Raw: 24 * time.Hour, Hourly: 48 * time.Hour})
+
+	old := now.AddDate(0, 0, -5)
+	if err := store.Record(ctx, coreusage.Record{APIKey: "k", Model: "m", RequestedAt: old, Detail: coreusage.Detail{TotalTokens: 3}}); err != nil {
+		t.Fatalf("Record: %v", err)
+	}
+	if err := store.Prune(ctx); err != nil {
+		t.Fatalf("Prune: %v", err)
+	}
+	snapshot, err := store.Snapshot(ctx, QueryFilter{})
+	if err != nil {
+		t.Fatalf("Snapshot: %v", err)
+	}
+	if snapshot.TotalRequests != 1 {
+		t.Fatalf("daily rollup should be kept, got %d requests", snapshot.TotalRequests)
+	}
+	if details := snapshot.APIs["k"].Models["m"].Details; len(details) != 0 {
+		t.Fatalf("raw events should be pruned, got %d", len(details))
+	}
+	if len(snapshot.RequestsByHour) != 0 {
+		t.Fatalf("hourly rollups should be pruned, got %v", snapshot.RequestsByHour)
+	}
+}
+
+func TestFilterSnapshot(t *testing.T) {
+	stats := NewRequestStatistics()
+	ts := time.Date(2026, 3, 10, 9, 0, 0, 0, time.UTC)
+	stats.Record(context.Background(), coreusage.Record{APIKey: "a", Provider: "claude", Model: "m1", AuthIndex: "1", RequestedAt: ts, Detail: coreusage.Detail{TotalTokens: 5}})
+	stats.Record(context.Background(), coreusage.Record{APIKey: "b", Provider: "gemini", Model: "m2", AuthIndex: "2", RequestedAt: ts.Add(48 * time.Hour), Detail: coreusage.Detail{TotalTokens: 9}})
+
+	filtered := FilterSnapshot(stats.Snapshot(), QueryFilter{Provider: "gemini"})
+	if filtered.TotalRequests != 1 || filtered.TotalTokens != 9 || len(filtered.APIs) != 1 {
+		t.Fatalf("unexpected provider filter result: %+v", filtered)
+	}
+	filtered = FilterSnapshot(stats.Snapshot(), QueryFilter{To: ts.Add(time.Hour)})
+	if filtered.TotalRequests != 1 || filtered.APIs["a"].TotalTokens != 5 {
+		t.Fatalf("unexpected range filter result: %+v", filtered)
+	}
+}
@@ -6,8 +6,51 @@ import (
 	"image"
 	"image/draw"
 	"image/png"
+	"math"
+	"strconv"
+	"strings"
 )
 
+// imageAspectRatios lists the aspect ratios accepted by Gemini image models.
+var imageAspectRatios = []struct {
+	name  string
+	ratio float64
+}{
+	{"1:1", 1},
+	{"2:3", 2.0 / 3.0},
+	{"3:2", 3.0 / 2.0},
+	{"3:4", 3.0 / 4.0},
+	{"4:3", 4.0 / 3.0},
+	{"4:5", 4.0 / 5.0},
+	{"5:4", 5.0 / 4.0},
+	{"9:16", 9.0 / 16.0},
+	{"16:9", 16.0 / 9.0},
+	{"21:9", 21.0 / 9.0},
+}
+
+// AspectRatioFromSize maps an OpenAI image size such as "1792x1024" to the closest
+// Gemini aspect ratio. It returns an empty string for "auto" or unparsable sizes.
+func AspectRatioFromSize(size string) string {
+	width, height, ok := strings.Cut(strings.ToLower(strings.TrimSpace(size)), "x")
+	if !ok {
+		return ""
+	}
+	w, errW := strconv.Atoi(width)
+	h, errH := strconv.Atoi(height)
+	if errW != nil || errH != nil || w <= 0 || h <= 0 {
+		return ""
+	}
+	target := float64(w) / float64(h)
+	best := ""
+	bestDiff := math.MaxFloat64
+	for _, candidate := range imageAspectRatios {
+		if diff := math.Abs(candidate.ratio - target); diff < bestDiff {
+			best, bestDiff = candidate.name, diff
+		}
+	}
+	return best
+}
+
 func CreateWhiteImageBase64(aspectRatio string) (string, error) {
 	width := 1024
 	height := 1024
@@ -0,0 +1,24 @@
+package util
+
+import "testing"
+
+func TestMatchModelPattern(t *testing.T) {
+	cases := []struct {
+		pattern, value string
+		want           bool
+	}{
+		{"gpt-5", "GPT-5", true},
+		{"gpt-5", "gpt-5-mini", false},
+		{"gpt-*", "gpt-5-mini", true},
+		{"*-mini", "gpt-5-mini", true},
+		{"claude-*-4-5", "claude-sonnet-4-5", true},
+		{"claude-*-4-5", "claude-sonnet-4-1", false},
+		{"*", "anything", true},
+		{"", "anything", false},
+	}
+	for _, tc := range cases {
+		if got := MatchModelPattern(tc.pattern, tc.value); got != tc.want {
+			t.Errorf("MatchModelPattern(%q, %q) = %v, want %v", tc.pattern, tc.value, got, tc.want)
+		}
+	}
+}
@@ -33,12 +33,59 @@ func BuildConfigChangeDetails(oldCfg, newCfg *config.Config) []string {
 	if strings.TrimSpace(oldCfg.Pprof.Addr) != strings.TrimSpace(newCfg.Pprof.Addr) {
 		changes = append(changes, fmt.Sprintf("pprof.addr: %s -> %s", strings.TrimSpace(oldCfg.Pprof.Addr), strings.TrimSpace(newCfg.Pprof.Addr)))
 	}
+	if oldCfg.Metrics.Enable != newCfg.Metrics.Enable {
+		changes = append(changes, fmt.Sprintf("metrics.enable: %t -> %t", oldCfg.Metrics.Enable, newCfg.Metrics.Enable))
+	}
+	if oldCfg.Metrics.Key != newCfg.Metrics.Key {
+		changes = append(changes, "metrics.key: updated")
+	}
+	if oldCfg.Tracing.Enable != newCfg.Tracing.Enable {
+		changes = append(changes, fmt.Sprintf("tracing.enable: %t -> %t", oldCfg.Tracing.Enable, newCfg.Tracing.Enable))
+	}
+	if oldCfg.Tracing.Endpoint != newCfg.Tracing.Endpoint {
+		changes = append(changes, fmt.Sprintf("tracing.endpoint: %s -> %s", oldCfg.Tracing.Endpoint, newCfg.Tracing.Endpoint))
+	}
 	if oldCfg.LoggingToFile != newCfg.LoggingToFile {
 		changes = append(changes, fmt.Sprintf("logging-to-file: %t -> %t", oldCfg.LoggingToFile, newCfg.LoggingToFile))
 	}
 	if oldCfg.UsageStatisticsEnabled != newCfg.UsageStatisticsEnabled {
 		changes = append(changes, fmt.Sprintf("usage-statistics-enabled: %t -> %t", oldCfg.UsageStatisticsEnabled, newCfg.UsageStatisticsEnabled))
 	}
+	if oldCfg.UsageStore.Enable != newCfg.UsageStore.Enable {
+		changes = append(changes, fmt.Sprintf("usage-store.enable: %t -> %t", oldCfg.UsageStore.Enable, newCfg.UsageStore.Enable))
+	}
+	if oldCfg.UsageStore.Driver != newCfg.UsageStore.Driver {
+		changes = append(changes, fmt.Sprintf("usage-store.driver: %s -> %s", oldCfg.UsageStore.Driver, newCfg.UsageStore.Driver))
+	}
+	if oldCfg.UsageStore.Path != newCfg.UsageStore.Path {
+		changes = append(changes, fmt.Sprintf("usage-store.path: %s -> %s", oldCfg.UsageStore.Path, newCfg.UsageStore.Path))
+	}
+	if oldCfg.UsageStore.RawRetentionDays != newCfg.UsageStore.RawRetentionDays ||
+		oldCfg.UsageStore.HourlyRetentionDays != newCfg.UsageStore.HourlyRetentionDays ||
+		oldCfg.UsageStore.DailyRetentionDays != newCfg.UsageStore.DailyRetentionDays {
+		changes = append(changes, "usage-store retention updated")
+	}
+	if oldCfg.ResponseCache.Enable != newCfg.ResponseCache.Enable {
+		changes = append(changes, fmt.Sprintf("response-cache.enable: %t -> %t", oldCfg.ResponseCache.Enable, newCfg.ResponseCache.Enable))
+	}
+	if oldCfg.ResponseCache.Backend != newCfg.ResponseCache.Backend {
+		changes = append(changes, fmt.Sprintf("response-cache.backend: %s -> %s", oldCfg.ResponseCache.Backend, newCfg.ResponseCache.Backend))
+	}
+	if oldCfg.ResponseCache.Dir != newCfg.ResponseCache.Dir ||
+		oldCfg.ResponseCache.TTLSeconds != newCfg.ResponseCache.TTLSeconds ||
+		oldCfg.ResponseCache.MaxSizeMB != newCfg.ResponseCache.MaxSizeMB ||
+		oldCfg.ResponseCache.IncludeNonDeterministic != newCfg.ResponseCache.IncludeNonDeterministic {
+		changes = append(changes, "response-cache settings updated")
+	}
+	if oldCfg.Batch.Dir != newCfg.Batch.Dir {
+		changes = append(changes, fmt.Sprintf("batch.dir: %s -> %s", oldCfg.Batch.Dir, newCfg.Batch.Dir))
+	}
+	if oldCfg.Batch.Concurrency != newCfg.Batch.Concurrency {
+		changes = append(changes, fmt.Sprintf("batch.concurrency: %d -> %d", oldCfg.Batch.Concurrency, newCfg.Batch.Concurrency))
+	}
+	if oldCfg.Batch.MaxAttempts != newCfg.Batch.MaxAttempts {
+		changes = append(changes, fmt.Sprintf("batch.max-attempts: %d -> %d", oldCfg.Batch.MaxAttempts, newCfg.Batch.MaxAttempts))
+	}
 	if oldCfg.DisableCooling != newCfg.DisableCooling {
 		changes = append(changes, fmt.Sprintf("disable-cooling: %t -> %t", oldCfg.DisableCooling, newCfg.DisableCooling))
 	}
@@ -69,6 +116,9 @@ func BuildConfigChangeDetails(oldCfg, newCfg *config.Config) []string {
 	if oldCfg.NonStreamKeepAliveInterval != newCfg.NonStreamKeepAliveInterval {
 		changes = append(changes, fmt.Sprintf("nonstream-keepalive-interval: %d -> %d", oldCfg.NonStreamKeepAliveInterval, newCfg.NonStreamKeepAliveInterval))
 	}
+	if oldCfg.RequestDedup != newCfg.RequestDedup {
+		changes = append(changes, fmt.Sprintf("request-dedup: %t -> %t", oldCfg.RequestDedup, newCfg.RequestDedup))
+	}
 
 	// Quota-exceeded behavior
 	if oldCfg.QuotaExceeded.SwitchProject != newCfg.QuotaExceeded.SwitchProject {
@@ -77,16 +127,36 @@ func BuildConfigChangeDetails(oldCfg, newCfg *config.Config) []string {
 	if oldCfg.QuotaExceeded.SwitchPreviewModel != newCfg.QuotaExceeded.SwitchPreviewModel {
 		changes = append(changes, fmt.Sprintf("quota-exceeded.switch-preview-model: %t -> %t", oldCfg.QuotaExceeded.SwitchPreviewModel, newCfg.QuotaExceeded.SwitchPreviewModel))
 	}
+	if oldCfg.QuotaPolling.Enabled != newCfg.QuotaPolling.Enabled {
+		changes = append(changes, fmt.Sprintf("quota-polling.enabled: %t -> %t", oldCfg.QuotaPolling.Enabled, newCfg.QuotaPolling.Enabled))
+	}
+	if oldCfg.QuotaPolling.IntervalSeconds != newCfg.QuotaPolling.IntervalSeconds {
+		changes = append(changes, fmt.Sprintf("quota-polling.interval-seconds: %d -> %d", oldCfg.QuotaPolling.IntervalSeconds, newCfg.QuotaPolling.IntervalSeconds))
+	}
 
 	if oldCfg.Routing.Strategy != newCfg.Routing.Strategy {
 		changes = append(changes, fmt.Sprintf("routing.strategy: %s -> %s", oldCfg.Routing.Strategy, newCfg.Routing.Strategy))
 	}
+	if oldCfg.Routing.SessionAffinity.Enable != newCfg.Routing.SessionAffinity.Enable {
+		changes = append(changes, fmt.Sprintf("routing.session-affinity.enable: %t -> %t", oldCfg.Routing.SessionAffinity.Enable, newCfg.Routing.SessionAffinity.Enable))
+	}
+	if oldCfg.Routing.SessionAffinity.TTLSeconds != newCfg.Routing.SessionAffinity.TTLSeconds {
+		changes = append(changes, fmt.Sprintf("routing.session-affinity.ttl-seconds: %d -> %d", oldCfg.Routing.SessionAffinity.TTLSeconds, newCfg.Routing.SessionAffinity.TTLSeconds))
+	}
+	if oldCfg.Routing.SessionAffinity.Header != newCfg.Routing.SessionAffinity.Header {
+		changes = append(changes, fmt.Sprintf("routing.session-affinity.header: %s -> %s", oldCfg.Routing.SessionAffinity.Header, newCfg.Routing.SessionAffinity.Header))
+	}
+	if !reflect.DeepEqual(oldCfg.Routing.CredentialPolicies, newCfg.Routing.CredentialPolicies) {
+		changes = append(changes, fmt.Sprintf("routing.credential-policies: updated (%d -> %d policies)", len(oldCfg.Routing.CredentialPolicies), len(newCfg.Routing.CredentialPolicies)))
+	}
 
 	// API keys (redacted) and counts
 	if len(oldCfg.APIKeys) != len(newCfg.APIKeys) {
 		changes = append(changes, fmt.Sprintf("api-keys count: %d -> %d", len(oldCfg.APIKeys), len(newCfg.APIKeys)))
-	} else if !reflect.DeepEqual(trimStrings(oldCfg.APIKeys), trimStrings(newCfg.APIKeys)) {
+	} else if !reflect.DeepEqual(trimStrings(oldCfg.APIKeyValues()), trimStrings(newCfg.APIKeyValues())) {
 		changes = append(changes, "api-keys: values updated (count unchanged, redacted)")
+	} else if !reflect.DeepEqual(oldCfg.APIKeys, newCfg.APIKeys) {
+		changes = append(changes, "api-keys: policies updated")
 	}
 	if len(oldCfg.GeminiKey) != len(newCfg.GeminiKey) {
 		changes = append(changes, fmt.Sprintf("gemini-api-key count: %d -> %d", len(oldCfg.GeminiKey), len(newCfg.GeminiKey)))
@@ -242,6 +312,12 @@ func BuildConfigChangeDetails(oldCfg, newCfg *config.Config) []string {
 	if entries, _ := DiffOAuthModelAliasChanges(oldCfg.OAuthModelAlias, newCfg.OAuthModelAlias); len(entries) > 0 {
 		changes = append(changes, entries...)
 	}
+	if !reflect.DeepEqual(oldCfg.ModelFallbacks, newCfg.ModelFallbacks) {
+		changes = append(changes, fmt.Sprintf("model-fallbacks: updated (%d -> %d chains)", len(oldCfg.ModelFallbacks), len(newCfg.ModelFallbacks)))
+	}
+	if !reflect.DeepEqual(oldCfg.Hedging, newCfg.Hedging) {
+		changes = append(changes, fmt.Sprintf("hedging: updated (%d -> %d rules)", len(oldCfg.Hedging), len(newCfg.Hedging)))
+	}
 
 	// Remote management (never print the key)
 	if oldCfg.RemoteManagement.AllowRemote != newCfg.RemoteManagement.AllowRemote {
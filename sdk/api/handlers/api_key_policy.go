@@ -0,0 +1,211 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/thinking"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
+	coreexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	coreusage "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+)
+
+var defaultAPIKeyLimiter = newAPIKeyLimiter()
+
+func init() {
+	coreusage.RegisterPlugin(defaultAPIKeyLimiter)
+}
+
+// apiKeyLimiter tracks per client key request windows and daily token consumption.
+// It implements coreusage.Plugin so token usage is accounted once responses complete.
+type apiKeyLimiter struct {
+	mu       sync.Mutex
+	requests map[string][]time.Time
+	tokens   map[string]*dailyTokenUsage
+	now      func() time.Time
+}
+
+type dailyTokenUsage struct {
+	day  string
+	used int64
+}
+
+func newAPIKeyLimiter() *apiKeyLimiter {
+	return &apiKeyLimiter{
+		requests: make(map[string][]time.Time),
+		tokens:   make(map[string]*dailyTokenUsage),
+		now:      time.Now,
+	}
+}
+
+// HandleUsage implements coreusage.Plugin.
+func (l *apiKeyLimiter) HandleUsage(_ context.Context, record coreusage.Record) {
+	// Cached responses did not consume upstream tokens.
+	if l == nil || record.APIKey == "" || record.ResponseCached {
+		return
+	}
+	total := record.Detail.TotalTokens
+	if total == 0 {
+		total = record.Detail.InputTokens + record.Detail.OutputTokens + record.Detail.ReasoningTokens
+	}
+	if total <= 0 {
+		return
+	}
+	day := l.now().UTC().Format(time.DateOnly)
+	l.mu.Lock()
+	usage := l.tokens[record.APIKey]
+	if usage == nil || usage.day != day {
+		usage = &dailyTokenUsage{day: day}
+		l.tokens[record.APIKey] = usage
+	}
+	usage.used += total
+	l.mu.Unlock()
+}
+
+// admit checks the daily token budget and the rolling request window for the policy's key.
+// When consume is true an admitted request is counted against the request window.
+// It returns the wait until the limit resets and a description when the request is rejected.
+func (l *apiKeyLimiter) admit(policy *config.APIKeyEntry, consume bool) (time.Duration, string) {
+	now := l.now()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if policy.TokensPerDay > 0 {
+		day := now.UTC().Format(time.DateOnly)
+		if usage := l.tokens[policy.APIKey]; usage != nil && usage.day == day && usage.used >= policy.TokensPerDay {
+			utc := now.UTC()
+			midnight := time.Date(utc.Year(), utc.Month(), utc.Day()+1, 0, 0, 0, 0, time.UTC)
+			return midnight.Sub(utc), fmt.Sprintf("daily token limit of %d exceeded", policy.TokensPerDay)
+		}
+	}
+
+	if policy.RequestsPerMinute <= 0 {
+		return 0, ""
+	}
+	windowStart := now.Add(-time.Minute)
+	window := l.requests[policy.APIKey]
+	kept := window[:0]
+	for _, ts := range window {
+		if ts.After(windowStart) {
+			kept = append(kept, ts)
+		}
+	}
+	if len(kept) >= policy.RequestsPerMinute {
+		l.requests[policy.APIKey] = kept
+		return kept[0].Add(time.Minute).Sub(now), fmt.Sprintf("rate limit of %d requests per minute exceeded", policy.RequestsPerMinute)
+	}
+	if consume {
+		kept = append(kept, now)
+	}
+	if len(kept) == 0 {
+		delete(l.requests, policy.APIKey)
+	} else {
+		l.requests[policy.APIKey] = kept
+	}
+	return 0, ""
+}
+
+// apiKeyPolicyMetadata records the calling key's allow-lists in the execution metadata so
+// model fallbacks chosen by the auth manager honor them as well, and keeps the request out of
+// the response cache when the key opts out of it.
+func (h *BaseAPIHandler) apiKeyPolicyMetadata(ctx context.Context, meta map[string]any) {
+	if h == nil || h.Cfg == nil || meta == nil {
+		return
+	}
+	policy := h.Cfg.APIKeyPolicyFor(clientAPIKeyFromContext(ctx))
+	if policy == nil {
+		return
+	}
+	if len(policy.AllowedModels) > 0 {
+		meta[coreexecutor.AllowedModelsMetadataKey] = append([]string(nil), policy.AllowedModels...)
+	}
+	if len(policy.AllowedProviders) > 0 {
+		meta[coreexecutor.AllowedProvidersMetadataKey] = append([]string(nil), policy.AllowedProviders...)
+	}
+	if policy.DisableResponseCache {
+		meta[coreexecutor.ResponseCacheBypassMetadataKey] = true
+	}
+}
+
+// clientAPIKeyFromContext returns the authenticated client key stored by the access middleware.
+func clientAPIKeyFromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	ginCtx, ok := ctx.Value("gin").(*gin.Context)
+	if !ok || ginCtx == nil {
+		return ""
+	}
+	return ClientAPIKey(ginCtx)
+}
+
+// ClientAPIKey returns the authenticated client key of the request, or "" when access
+// control is disabled.
+func ClientAPIKey(c *gin.Context) string {
+	if v, exists := c.Get("apiKey"); exists {
+		if key, okKey := v.(string); okKey {
+			return key
+		}
+	}
+	return ""
+}
+
+// applyAPIKeyPolicy enforces the calling key's allow-lists and limits before a credential is picked.
+// It returns the providers the request may be routed to. Count requests pass consume=false so they
+// are checked against the allow-lists without using up the request budget.
+func (h *BaseAPIHandler) applyAPIKeyPolicy(ctx context.Context, handlerType, model string, providers []string, consume bool) ([]string, *interfaces.ErrorMessage) {
+	if h == nil || h.Cfg == nil {
+		return providers, nil
+	}
+	policy := h.Cfg.APIKeyPolicyFor(clientAPIKeyFromContext(ctx))
+	if policy == nil {
+		return providers, nil
+	}
+
+	if len(policy.AllowedModels) > 0 {
+		baseModel := thinking.ParseSuffix(model).ModelName
+		allowed := false
+		for _, pattern := range policy.AllowedModels {
+			if util.MatchModelPattern(pattern, baseModel) || util.MatchModelPattern(pattern, model) {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return nil, newFormatErrorMessage(handlerType, http.StatusForbidden, fmt.Sprintf("model %s is not allowed for this API key", model), 0)
+		}
+	}
+
+	if len(policy.AllowedProviders) > 0 {
+		filtered := make([]string, 0, len(providers))
+		for _, provider := range providers {
+			for _, allowedProvider := range policy.AllowedProviders {
+				if strings.EqualFold(provider, allowedProvider) {
+					filtered = append(filtered, provider)
+					break
+				}
+			}
+		}
+		if len(filtered) == 0 {
+			return nil, newFormatErrorMessage(handlerType, http.StatusForbidden, fmt.Sprintf("no allowed provider serves model %s for this API key", model), 0)
+		}
+		providers = filtered
+	}
+
+	if wait, reason := defaultAPIKeyLimiter.admit(policy, consume); reason != "" {
+		retryAfter := int(math.Ceil(wait.Seconds()))
+		if retryAfter < 1 {
+			retryAfter = 1
+		}
+		return nil, newFormatErrorMessage(handlerType, http.StatusTooManyRequests, reason, retryAfter)
+	}
+	return providers, nil
+}
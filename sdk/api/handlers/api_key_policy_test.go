@@ -0,0 +1,161 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	coreexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	coreusage "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+)
+
+func policyTestContext(apiKey string) context.Context {
+	gin.SetMode(gin.TestMode)
+	ginCtx, _ := gin.CreateTestContext(httptest.NewRecorder())
+	ginCtx.Request = httptest.NewRequest(http.MethodPost, "/v1/chat/completions", nil)
+	ginCtx.Set("apiKey", apiKey)
+	return context.WithValue(context.Background(), "gin", ginCtx)
+}
+
+func withTestLimiter(t *testing.T, now *time.Time) *apiKeyLimiter {
+	t.Helper()
+	limiter := newAPIKeyLimiter()
+	limiter.now = func() time.Time { return *now }
+	previous := defaultAPIKeyLimiter
+	defaultAPIKeyLimiter = limiter
+	t.Cleanup(func() { defaultAPIKeyLimiter = previous })
+	return limiter
+}
+
+func TestApplyAPIKeyPolicy_AllowLists(t *testing.T) {
+	now := time.Now()
+	withTestLimiter(t, &now)
+	cfg := &sdkconfig.SDKConfig{
+		APIKeys: []sdkconfig.APIKeyEntry{{
+			APIKey:           "k1",
+			AllowedModels:    []string{"gemini-2.5-*", "claude-sonnet-4-5"},
+			AllowedProviders: []string{"gemini"},
+		}, {APIKey: "k2"}},
+	}
+	handler := NewBaseAPIHandlers(cfg, coreauth.NewManager(nil, nil, nil))
+
+	providers, errMsg := handler.applyAPIKeyPolicy(policyTestContext("k1"), "openai", "gemini-2.5-pro(8192)", []string{"gemini", "antigravity"}, true)
+	if errMsg != nil {
+		t.Fatalf("unexpected error: %v", errMsg.Error)
+	}
+	if !reflect.DeepEqual(providers, []string{"gemini"}) {
+		t.Fatalf("providers = %v, want [gemini]", providers)
+	}
+
+	if _, errMsg = handler.applyAPIKeyPolicy(policyTestContext("k1"), "claude", "gpt-5", []string{"openai"}, true); errMsg == nil || errMsg.StatusCode != http.StatusForbidden {
+		t.Fatalf("expected 403 for disallowed model, got %+v", errMsg)
+	}
+	if !strings.Contains(errMsg.Error.Error(), `"permission_error"`) {
+		t.Fatalf("expected claude shaped error, got %s", errMsg.Error.Error())
+	}
+
+	if _, errMsg = handler.applyAPIKeyPolicy(policyTestContext("k1"), "openai", "claude-sonnet-4-5", []string{"claude"}, true); errMsg == nil || errMsg.StatusCode != http.StatusForbidden {
+		t.Fatalf("expected 403 for disallowed provider, got %+v", errMsg)
+	}
+
+	providers, errMsg = handler.applyAPIKeyPolicy(policyTestContext("k2"), "openai", "gpt-5", []string{"openai"}, true)
+	if errMsg != nil || !reflect.DeepEqual(providers, []string{"openai"}) {
+		t.Fatalf("unrestricted key should pass, providers=%v err=%+v", providers, errMsg)
+	}
+
+	meta := map[string]any{}
+	handler.apiKeyPolicyMetadata(policyTestContext("k1"), meta)
+	if !reflect.DeepEqual(meta[coreexecutor.AllowedModelsMetadataKey], []string{"gemini-2.5-*", "claude-sonnet-4-5"}) ||
+		!reflect.DeepEqual(meta[coreexecutor.AllowedProvidersMetadataKey], []string{"gemini"}) {
+		t.Fatalf("allow-list metadata = %v", meta)
+	}
+	meta = map[string]any{}
+	handler.apiKeyPolicyMetadata(policyTestContext("k2"), meta)
+	if len(meta) != 0 {
+		t.Fatalf("unrestricted key should add no metadata, got %v", meta)
+	}
+}
+
+func TestAPIKeyPolicyMetadata_DisableResponseCache(t *testing.T) {
+	cfg := &sdkconfig.SDKConfig{
+		APIKeys: []sdkconfig.APIKeyEntry{{APIKey: "k1", DisableResponseCache: true}, {APIKey: "k2"}},
+	}
+	handler := NewBaseAPIHandlers(cfg, coreauth.NewManager(nil, nil, nil))
+
+	meta := map[string]any{}
+	handler.apiKeyPolicyMetadata(policyTestContext("k1"), meta)
+	if bypass, _ := meta[coreexecutor.ResponseCacheBypassMetadataKey].(bool); !bypass {
+		t.Fatalf("expected the opted-out key to bypass the response cache, got %v", meta)
+	}
+	meta = map[string]any{}
+	handler.apiKeyPolicyMetadata(policyTestContext("k2"), meta)
+	if _, ok := meta[coreexecutor.ResponseCacheBypassMetadataKey]; ok {
+		t.Fatalf("expected other keys to keep the response cache, got %v", meta)
+	}
+}
+
+func TestApplyAPIKeyPolicy_RequestsPerMinute(t *testing.T) {
+	now := time.Date(2026, 1, 2, 10, 0, 0, 0, time.UTC)
+	withTestLimiter(t, &now)
+	cfg := &sdkconfig.SDKConfig{
+		APIKeys: []sdkconfig.APIKeyEntry{{APIKey: "k1", RequestsPerMinute: 2}},
+	}
+	handler := NewBaseAPIHandlers(cfg, coreauth.NewManager(nil, nil, nil))
+	ctx := policyTestContext("k1")
+
+	for i := 0; i < 2; i++ {
+		if _, errMsg := handler.applyAPIKeyPolicy(ctx, "openai", "gpt-5", []string{"openai"}, true); errMsg != nil {
+			t.Fatalf("request %d rejected: %v", i, errMsg.Error)
+		}
+		now = now.Add(10 * time.Second)
+	}
+	if _, errMsg := handler.applyAPIKeyPolicy(ctx, "openai", "gpt-5", []string{"openai"}, false); errMsg == nil {
+		t.Fatal("count request should still observe the exhausted window")
+	}
+	_, errMsg := handler.applyAPIKeyPolicy(ctx, "gemini", "gpt-5", []string{"openai"}, true)
+	if errMsg == nil || errMsg.StatusCode != http.StatusTooManyRequests {
+		t.Fatalf("expected 429, got %+v", errMsg)
+	}
+	if got := errMsg.Addon.Get("Retry-After"); got != "40" {
+		t.Fatalf("Retry-After = %q, want 40", got)
+	}
+	if !strings.Contains(errMsg.Error.Error(), `"RESOURCE_EXHAUSTED"`) {
+		t.Fatalf("expected gemini shaped error, got %s", errMsg.Error.Error())
+	}
+
+	now = now.Add(41 * time.Second)
+	if _, errMsg = handler.applyAPIKeyPolicy(ctx, "openai", "gpt-5", []string{"openai"}, true); errMsg != nil {
+		t.Fatalf("request after window rejected: %v", errMsg.Error)
+	}
+}
+
+func TestApplyAPIKeyPolicy_TokensPerDay(t *testing.T) {
+	now := time.Date(2026, 1, 2, 23, 0, 0, 0, time.UTC)
+	limiter := withTestLimiter(t, &now)
+	cfg := &sdkconfig.SDKConfig{
+		APIKeys: []sdkconfig.APIKeyEntry{{APIKey: "k1", TokensPerDay: 100}},
+	}
+	handler := NewBaseAPIHandlers(cfg, coreauth.NewManager(nil, nil, nil))
+	ctx := policyTestContext("k1")
+
+	limiter.HandleUsage(context.Background(), coreusage.Record{APIKey: "k1", Detail: coreusage.Detail{InputTokens: 60, OutputTokens: 50}})
+	_, errMsg := handler.applyAPIKeyPolicy(ctx, "openai", "gpt-5", []string{"openai"}, true)
+	if errMsg == nil || errMsg.StatusCode != http.StatusTooManyRequests {
+		t.Fatalf("expected 429, got %+v", errMsg)
+	}
+	if got := errMsg.Addon.Get("Retry-After"); got != "3600" {
+		t.Fatalf("Retry-After = %q, want 3600", got)
+	}
+
+	now = now.Add(time.Hour)
+	if _, errMsg = handler.applyAPIKeyPolicy(ctx, "openai", "gpt-5", []string{"openai"}, true); errMsg != nil {
+		t.Fatalf("request on next day rejected: %v", errMsg.Error)
+	}
+}
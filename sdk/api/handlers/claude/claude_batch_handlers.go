@@ -0,0 +1,577 @@
+package claude
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/batch"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	log "github.com/sirupsen/logrus"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+const (
+	// batchFormat identifies Message Batches in the batch manager.
+	batchFormat = "claude"
+	// batchEndpoint is the endpoint every request of a Message Batch is sent to.
+	batchEndpoint = "/v1/messages"
+	// maxBatchRequests mirrors the Anthropic limit on requests per Message Batch.
+	maxBatchRequests = 100000
+	// anthropicBatchesURL is the Message Batches endpoint of the Anthropic API.
+	anthropicBatchesURL = "https://api.anthropic.com/v1/messages/batches"
+	anthropicVersion    = "2023-06-01"
+)
+
+var batchCustomIDPattern = regexp.MustCompile(`^[a-zA-Z0-9_-]{1,64}$`)
+
+// ClaudeBatchAPIHandler implements the Anthropic Message Batches API. Batches whose models
+// are all served by one Claude API key are created on the Anthropic API itself; all others
+// are emulated by running each request in the background through the regular messages
+// handler, so they work with any backend.
+type ClaudeBatchAPIHandler struct {
+	*handlers.BaseAPIHandler
+	batches  *batch.Manager
+	messages gin.HandlerFunc
+}
+
+// NewClaudeBatchAPIHandler creates the Message Batches handlers and registers the Claude batch
+// format with the batch manager. Emulated requests are executed by the given Claude handler.
+func NewClaudeBatchAPIHandler(apiHandlers *handlers.BaseAPIHandler, batches *batch.Manager, claudeHandlers *ClaudeCodeAPIHandler) *ClaudeBatchAPIHandler {
+	h := &ClaudeBatchAPIHandler{
+		BaseAPIHandler: apiHandlers,
+		batches:        batches,
+		messages:       claudeHandlers.ClaudeMessages,
+	}
+	batches.RegisterHandler(batchFormat, batch.Handler{Execute: h.executeRequest, Authorize: h.AuthorizeBatchJob})
+	return h
+}
+
+// executeRequest runs one request of an emulated batch through the messages handler.
+func (h *ClaudeBatchAPIHandler) executeRequest(ctx context.Context, job batch.Job, req batch.Request) (int, http.Header, []byte) {
+	// Batch results are complete messages.
+	body, _ := sjson.DeleteBytes(req.Body, "stream")
+	return h.InvokeBatchRequest(ctx, h.messages, job, http.MethodPost, batchEndpoint, body)
+}
+
+// CreateBatch handles POST /v1/messages/batches.
+func (h *ClaudeBatchAPIHandler) CreateBatch(c *gin.Context) {
+	rawJSON, err := c.GetRawData()
+	if err != nil || !gjson.ValidBytes(rawJSON) {
+		writeClaudeBatchError(c, http.StatusBadRequest, "invalid_request_error", "Invalid request: body must be a JSON object")
+		return
+	}
+	requests, err := parseMessageBatchRequests(rawJSON)
+	if err != nil {
+		writeClaudeBatchError(c, http.StatusBadRequest, "invalid_request_error", err.Error())
+		return
+	}
+
+	owner := handlers.ClientAPIKey(c)
+	if auth := h.passthroughAuth(owner, requests); auth != nil {
+		if h.createUpstreamBatch(c, auth, owner, rawJSON, requests) {
+			return
+		}
+	}
+
+	job, err := h.batches.CreateJob(batch.Job{
+		ID:               batch.NewID("msgbatch_"),
+		Format:           batchFormat,
+		Owner:            owner,
+		Endpoint:         batchEndpoint,
+		CompletionWindow: batch.CompletionWindow,
+	}, requests)
+	if err != nil {
+		writeClaudeBatchError(c, http.StatusInternalServerError, "api_error", err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, messageBatchObject(c, job))
+}
+
+// parseMessageBatchRequests validates the requests of a Message Batch.
+func parseMessageBatchRequests(rawJSON []byte) ([]batch.Request, error) {
+	items := gjson.GetBytes(rawJSON, "requests")
+	if !items.IsArray() || len(items.Array()) == 0 {
+		return nil, fmt.Errorf("requests: at least one request is required")
+	}
+	if count := len(items.Array()); count > maxBatchRequests {
+		return nil, fmt.Errorf("requests: a batch can contain at most %d requests, got %d", maxBatchRequests, count)
+	}
+	requests := make([]batch.Request, 0, len(items.Array()))
+	seen := make(map[string]struct{})
+	for i, item := range items.Array() {
+		customID := item.Get("custom_id").String()
+		if !batchCustomIDPattern.MatchString(customID) {
+			return nil, fmt.Errorf("requests.%d.custom_id: must be 1 to 64 letters, digits, underscores or hyphens", i)
+		}
+		if _, dup := seen[customID]; dup {
+			return nil, fmt.Errorf("requests.%d.custom_id: duplicate custom_id %q", i, customID)
+		}
+		seen[customID] = struct{}{}
+		params := item.Get("params")
+		if !params.IsObject() || params.Get("model").String() == "" {
+			return nil, fmt.Errorf("requests.%d.params: must be an object with a model", i)
+		}
+		requests = append(requests, batch.Request{
+			CustomID: customID,
+			Method:   http.MethodPost,
+			URL:      batchEndpoint,
+			Body:     json.RawMessage(params.Raw),
+		})
+	}
+	return requests, nil
+}
+
+// passthroughAuth returns a Claude API key credential on the Anthropic API that serves every
+// model of the batch, or nil when the batch has to be emulated. Keys with an API key policy
+// are always emulated so that their allow-lists and limits apply to each request.
+func (h *ClaudeBatchAPIHandler) passthroughAuth(owner string, requests []batch.Request) *coreauth.Auth {
+	if h.AuthManager == nil || h.Cfg.APIKeyPolicyFor(owner) != nil {
+		return nil
+	}
+	models := make(map[string]struct{})
+	for _, req := range requests {
+		models[gjson.GetBytes(req.Body, "model").String()] = struct{}{}
+	}
+	reg := registry.GetGlobalRegistry()
+	for _, auth := range h.AuthManager.List() {
+		if auth == nil || auth.Disabled || auth.Unavailable || auth.Status == coreauth.StatusDisabled {
+			continue
+		}
+		if !strings.EqualFold(auth.Provider, "claude") || auth.Attributes == nil || strings.TrimSpace(auth.Attributes["api_key"]) == "" {
+			continue
+		}
+		if baseURL := strings.TrimSpace(auth.Attributes["base_url"]); baseURL != "" {
+			if parsed, err := url.Parse(baseURL); err != nil || !strings.EqualFold(parsed.Host, "api.anthropic.com") {
+				continue
+			}
+		}
+		servesAll := true
+		for model := range models {
+			if !reg.ClientSupportsModel(auth.ID, model) {
+				servesAll = false
+				break
+			}
+		}
+		if servesAll {
+			return auth
+		}
+	}
+	return nil
+}
+
+// createUpstreamBatch creates the batch on the Anthropic API with auth and records it for the
+// owner. It returns false when the Anthropic API could not be reached, in which case the
+// batch is emulated instead.
+func (h *ClaudeBatchAPIHandler) createUpstreamBatch(c *gin.Context, auth *coreauth.Auth, owner string, rawJSON []byte, requests []batch.Request) bool {
+	body := rawJSON
+	for i, req := range requests {
+		model := gjson.GetBytes(req.Body, "model").String()
+		if upstream := h.AuthManager.UpstreamModel(auth, model); upstream != model {
+			body, _ = sjson.SetBytes(body, fmt.Sprintf("requests.%d.params.model", i), upstream)
+		}
+	}
+	status, header, respBody, err := h.upstreamRequest(c, auth, http.MethodPost, anthropicBatchesURL, body)
+	if err != nil || status >= http.StatusInternalServerError {
+		log.Warnf("claude batch: anthropic batch api unavailable, emulating batch: status %d, %v", status, err)
+		return false
+	}
+	if status < 200 || status >= 300 {
+		c.Data(status, header.Get("Content-Type"), respBody)
+		return true
+	}
+	upstreamID := gjson.GetBytes(respBody, "id").String()
+	if upstreamID == "" {
+		log.Warnf("claude batch: anthropic batch api returned no batch id, emulating batch")
+		return false
+	}
+	_, err = h.batches.CreateJob(batch.Job{
+		ID:               upstreamID,
+		Format:           batchFormat,
+		Owner:            owner,
+		Endpoint:         batchEndpoint,
+		CompletionWindow: batch.CompletionWindow,
+		Upstream:         &batch.Upstream{AuthID: auth.ID, ID: upstreamID},
+	}, requests)
+	if err != nil {
+		writeClaudeBatchError(c, http.StatusInternalServerError, "api_error", err.Error())
+		return true
+	}
+	c.Data(http.StatusOK, "application/json", rewriteResultsURL(c, respBody))
+	return true
+}
+
+// upstreamRequest sends a request to the Anthropic API with the credentials of auth.
+func (h *ClaudeBatchAPIHandler) upstreamRequest(c *gin.Context, auth *coreauth.Auth, method, target string, body []byte) (int, http.Header, []byte, error) {
+	resp, err := h.sendUpstream(c, auth, method, target, body)
+	if err != nil {
+		return 0, nil, nil, err
+	}
+	defer func() {
+		if errClose := resp.Body.Close(); errClose != nil {
+			log.Errorf("claude batch: close response body: %v", errClose)
+		}
+	}()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return 0, nil, nil, err
+	}
+	return resp.StatusCode, resp.Header, data, nil
+}
+
+func (h *ClaudeBatchAPIHandler) sendUpstream(c *gin.Context, auth *coreauth.Auth, method, target string, body []byte) (*http.Response, error) {
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(c.Request.Context(), method, target, reader)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Anthropic-Version", anthropicVersion)
+	if beta := c.GetHeader("Anthropic-Beta"); beta != "" {
+		req.Header.Set("Anthropic-Beta", beta)
+	}
+	return h.AuthManager.HttpRequest(c.Request.Context(), auth, req)
+}
+
+// upstreamAuth returns the credential an upstream batch was created with.
+func (h *ClaudeBatchAPIHandler) upstreamAuth(c *gin.Context, job batch.Job) *coreauth.Auth {
+	if h.AuthManager != nil {
+		if auth, ok := h.AuthManager.GetByID(job.Upstream.AuthID); ok {
+			return auth
+		}
+	}
+	writeClaudeBatchError(c, http.StatusBadGateway, "api_error", fmt.Sprintf("The credential that created batch %s is no longer configured", job.ID))
+	return nil
+}
+
+// relayUpstream forwards a batch request to the Anthropic API and writes its response.
+func (h *ClaudeBatchAPIHandler) relayUpstream(c *gin.Context, job batch.Job, method, suffix string) (int, bool) {
+	auth := h.upstreamAuth(c, job)
+	if auth == nil {
+		return 0, false
+	}
+	status, header, body, err := h.upstreamRequest(c, auth, method, anthropicBatchesURL+"/"+job.Upstream.ID+suffix, nil)
+	if err != nil {
+		writeClaudeBatchError(c, http.StatusBadGateway, "api_error", fmt.Sprintf("Anthropic API request failed: %v", err))
+		return 0, false
+	}
+	if status >= 200 && status < 300 {
+		body = rewriteResultsURL(c, body)
+	}
+	c.Data(status, header.Get("Content-Type"), body)
+	return status, true
+}
+
+// lookupJob returns the batch named in the path for the calling key, writing an error when it
+// does not exist.
+func (h *ClaudeBatchAPIHandler) lookupJob(c *gin.Context) (batch.Job, bool) {
+	job, err := h.batches.Job(c.Param("batch_id"), handlers.ClientAPIKey(c))
+	if err != nil || job.Format != batchFormat {
+		writeClaudeBatchLookupError(c, err)
+		return batch.Job{}, false
+	}
+	return job, true
+}
+
+// GetBatch handles GET /v1/messages/batches/:batch_id.
+func (h *ClaudeBatchAPIHandler) GetBatch(c *gin.Context) {
+	job, ok := h.lookupJob(c)
+	if !ok {
+		return
+	}
+	if job.Upstream != nil {
+		h.relayUpstream(c, job, http.MethodGet, "")
+		return
+	}
+	c.JSON(http.StatusOK, messageBatchObject(c, job))
+}
+
+// ListBatches handles GET /v1/messages/batches with the before_id, after_id and limit
+// pagination parameters. Batches are listed newest first.
+func (h *ClaudeBatchAPIHandler) ListBatches(c *gin.Context) {
+	jobs := h.batches.Jobs(handlers.ClientAPIKey(c), batchFormat)
+	limit := 20
+	if value, err := strconv.Atoi(c.Query("limit")); err == nil && value > 0 {
+		limit = min(value, 1000)
+	}
+	hasMore := false
+	if beforeID := c.Query("before_id"); beforeID != "" {
+		for i, job := range jobs {
+			if job.ID == beforeID {
+				jobs = jobs[:i]
+				break
+			}
+		}
+		if len(jobs) > limit {
+			jobs, hasMore = jobs[len(jobs)-limit:], true
+		}
+	} else {
+		if afterID := c.Query("after_id"); afterID != "" {
+			for i, job := range jobs {
+				if job.ID == afterID {
+					jobs = jobs[i+1:]
+					break
+				}
+			}
+		}
+		if len(jobs) > limit {
+			jobs, hasMore = jobs[:limit], true
+		}
+	}
+
+	data := make([]any, 0, len(jobs))
+	for _, job := range jobs {
+		data = append(data, h.listedBatch(c, job))
+	}
+	var firstID, lastID any
+	if len(jobs) > 0 {
+		firstID, lastID = jobs[0].ID, jobs[len(jobs)-1].ID
+	}
+	c.JSON(http.StatusOK, gin.H{"data": data, "has_more": hasMore, "first_id": firstID, "last_id": lastID})
+}
+
+// listedBatch returns the list entry of job. Upstream batches are fetched from the Anthropic
+// API; when that fails they are listed as in progress without counts.
+func (h *ClaudeBatchAPIHandler) listedBatch(c *gin.Context, job batch.Job) any {
+	if job.Upstream == nil {
+		return messageBatchObject(c, job)
+	}
+	if h.AuthManager != nil {
+		if auth, ok := h.AuthManager.GetByID(job.Upstream.AuthID); ok {
+			status, _, body, err := h.upstreamRequest(c, auth, http.MethodGet, anthropicBatchesURL+"/"+job.Upstream.ID, nil)
+			if err == nil && status == http.StatusOK && gjson.ValidBytes(body) {
+				return json.RawMessage(rewriteResultsURL(c, body))
+			}
+		}
+	}
+	job.Counts = batch.Counts{Total: job.Counts.Total}
+	return messageBatchObject(c, job)
+}
+
+// CancelBatch handles POST /v1/messages/batches/:batch_id/cancel.
+func (h *ClaudeBatchAPIHandler) CancelBatch(c *gin.Context) {
+	job, ok := h.lookupJob(c)
+	if !ok {
+		return
+	}
+	if job.Upstream != nil {
+		h.relayUpstream(c, job, http.MethodPost, "/cancel")
+		return
+	}
+	job, err := h.batches.CancelJob(job.ID, job.Owner)
+	if err != nil {
+		writeClaudeBatchLookupError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, messageBatchObject(c, job))
+}
+
+// DeleteBatch handles DELETE /v1/messages/batches/:batch_id. Only ended batches can be deleted.
+func (h *ClaudeBatchAPIHandler) DeleteBatch(c *gin.Context) {
+	job, ok := h.lookupJob(c)
+	if !ok {
+		return
+	}
+	if job.Upstream != nil {
+		auth := h.upstreamAuth(c, job)
+		if auth == nil {
+			return
+		}
+		status, header, body, err := h.upstreamRequest(c, auth, http.MethodDelete, anthropicBatchesURL+"/"+job.Upstream.ID, nil)
+		if err != nil {
+			writeClaudeBatchError(c, http.StatusBadGateway, "api_error", fmt.Sprintf("Anthropic API request failed: %v", err))
+			return
+		}
+		if (status < 200 || status >= 300) && status != http.StatusNotFound {
+			c.Data(status, header.Get("Content-Type"), body)
+			return
+		}
+	}
+	if err := h.batches.DeleteJob(job.ID, job.Owner); err != nil {
+		if errors.Is(err, batch.ErrJobRunning) {
+			writeClaudeBatchError(c, http.StatusBadRequest, "invalid_request_error", fmt.Sprintf("Batch %s cannot be deleted while it is processing; cancel it first", job.ID))
+			return
+		}
+		writeClaudeBatchLookupError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"id": job.ID, "type": "message_batch_deleted"})
+}
+
+// GetResults handles GET /v1/messages/batches/:batch_id/results, which streams the results of
+// an ended batch as JSONL.
+func (h *ClaudeBatchAPIHandler) GetResults(c *gin.Context) {
+	job, ok := h.lookupJob(c)
+	if !ok {
+		return
+	}
+	if job.Upstream != nil {
+		h.streamUpstreamResults(c, job)
+		return
+	}
+	if !job.Terminal() {
+		writeClaudeBatchError(c, http.StatusBadRequest, "invalid_request_error", fmt.Sprintf("Batch %s is still processing; results are available once it has ended", job.ID))
+		return
+	}
+	results, err := h.batches.Results(job.ID, job.Owner)
+	if err != nil {
+		writeClaudeBatchLookupError(c, err)
+		return
+	}
+	var buf bytes.Buffer
+	for _, result := range results {
+		buf.Write(renderMessageBatchResult(result))
+		buf.WriteByte('\n')
+	}
+	c.Data(http.StatusOK, "application/x-jsonl", buf.Bytes())
+}
+
+func (h *ClaudeBatchAPIHandler) streamUpstreamResults(c *gin.Context, job batch.Job) {
+	auth := h.upstreamAuth(c, job)
+	if auth == nil {
+		return
+	}
+	resp, err := h.sendUpstream(c, auth, http.MethodGet, anthropicBatchesURL+"/"+job.Upstream.ID+"/results", nil)
+	if err != nil {
+		writeClaudeBatchError(c, http.StatusBadGateway, "api_error", fmt.Sprintf("Anthropic API request failed: %v", err))
+		return
+	}
+	defer func() {
+		if errClose := resp.Body.Close(); errClose != nil {
+			log.Errorf("claude batch: close results body: %v", errClose)
+		}
+	}()
+	c.Status(resp.StatusCode)
+	c.Header("Content-Type", resp.Header.Get("Content-Type"))
+	if _, err = io.Copy(c.Writer, resp.Body); err != nil {
+		log.Warnf("claude batch: stream results of %s: %v", job.ID, err)
+	}
+}
+
+// renderMessageBatchResult formats a result as a line of the Message Batches results file.
+func renderMessageBatchResult(result batch.Result) []byte {
+	var outcome map[string]any
+	switch {
+	case result.Error != nil && result.Error.Code == batch.ErrorCodeCancelled:
+		outcome = map[string]any{"type": "canceled"}
+	case result.Error != nil && result.Error.Code == batch.ErrorCodeExpired:
+		outcome = map[string]any{"type": "expired"}
+	case result.Succeeded():
+		outcome = map[string]any{"type": "succeeded", "message": result.Body}
+	default:
+		errType := gjson.GetBytes(result.Body, "error.type").String()
+		if errType == "" {
+			errType = "api_error"
+		}
+		message := gjson.GetBytes(result.Body, "error.message").String()
+		if message == "" {
+			message = strings.TrimSpace(string(result.Body))
+		}
+		if message == "" && result.Error != nil {
+			message = result.Error.Message
+		}
+		outcome = map[string]any{
+			"type": "errored",
+			"error": claudeErrorResponse{
+				Type:  "error",
+				Error: claudeErrorDetail{Type: errType, Message: message},
+			},
+		}
+	}
+	data, _ := json.Marshal(map[string]any{"custom_id": result.CustomID, "result": outcome})
+	return data
+}
+
+// messageBatchObject renders job as an Anthropic message_batch object.
+func messageBatchObject(c *gin.Context, job batch.Job) map[string]any {
+	timestamp := func(value int64) any {
+		if value == 0 {
+			return nil
+		}
+		return time.Unix(value, 0).UTC().Format(time.RFC3339)
+	}
+	status := "in_progress"
+	var resultsURL any
+	switch {
+	case job.Terminal():
+		status = "ended"
+		resultsURL = batchResultsURL(c, job.ID)
+	case job.Status == batch.StatusCancelling:
+		status = "canceling"
+	}
+	return map[string]any{
+		"id":                  job.ID,
+		"type":                "message_batch",
+		"processing_status":   status,
+		"created_at":          timestamp(job.CreatedAt),
+		"expires_at":          timestamp(job.ExpiresAt),
+		"ended_at":            timestamp(job.EndedAt()),
+		"cancel_initiated_at": timestamp(job.CancellingAt),
+		"archived_at":         nil,
+		"results_url":         resultsURL,
+		"request_counts": map[string]int{
+			"processing": job.Counts.Processing(),
+			"succeeded":  job.Counts.Succeeded,
+			"errored":    job.Counts.Errored,
+			"canceled":   job.Counts.Cancelled,
+			"expired":    job.Counts.Expired,
+		},
+	}
+}
+
+// batchResultsURL returns the URL clients fetch the results of batch id from.
+func batchResultsURL(c *gin.Context, id string) string {
+	scheme := "http"
+	if c.Request.TLS != nil {
+		scheme = "https"
+	}
+	if proto := c.GetHeader("X-Forwarded-Proto"); proto != "" {
+		scheme = strings.TrimSpace(strings.Split(proto, ",")[0])
+	}
+	return scheme + "://" + c.Request.Host + "/v1/messages/batches/" + id + "/results"
+}
+
+// rewriteResultsURL points the results_url of an Anthropic message_batch at this server, as
+// clients do not hold the upstream credential.
+func rewriteResultsURL(c *gin.Context, body []byte) []byte {
+	id := gjson.GetBytes(body, "id").String()
+	if id == "" || gjson.GetBytes(body, "results_url").Type != gjson.String {
+		return body
+	}
+	rewritten, err := sjson.SetBytes(body, "results_url", batchResultsURL(c, id))
+	if err != nil {
+		return body
+	}
+	return rewritten
+}
+
+func writeClaudeBatchError(c *gin.Context, status int, errType, message string) {
+	c.JSON(status, claudeErrorResponse{
+		Type:  "error",
+		Error: claudeErrorDetail{Type: errType, Message: message},
+	})
+}
+
+func writeClaudeBatchLookupError(c *gin.Context, err error) {
+	if err == nil || errors.Is(err, batch.ErrNotFound) {
+		writeClaudeBatchError(c, http.StatusNotFound, "not_found_error", fmt.Sprintf("No such message batch: %s", c.Param("batch_id")))
+		return
+	}
+	writeClaudeBatchError(c, http.StatusInternalServerError, "api_error", err.Error())
+}
@@ -0,0 +1,213 @@
+package claude
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/batch"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	coreexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+	"github.com/tidwall/gjson"
+)
+
+// messageBatchExecutor answers messages and records the raw HTTP requests sent with it.
+type messageBatchExecutor struct {
+	provider string
+	mu       sync.Mutex
+	requests []string
+}
+
+func (e *messageBatchExecutor) Identifier() string { return e.provider }
+
+func (e *messageBatchExecutor) Execute(_ context.Context, _ *coreauth.Auth, req coreexecutor.Request, opts coreexecutor.Options) (coreexecutor.Response, error) {
+	if opts.Stream {
+		return coreexecutor.Response{}, errors.New("batch requests must not stream")
+	}
+	content := gjson.GetBytes(req.Payload, "messages.0.content").String()
+	if content == "fail" {
+		return coreexecutor.Response{}, &coreauth.Error{HTTPStatus: http.StatusBadRequest, Message: "invalid prompt"}
+	}
+	return coreexecutor.Response{Payload: []byte(`{"type":"message","role":"assistant","content":[{"type":"text","text":"echo ` + content + `"}]}`)}, nil
+}
+
+func (e *messageBatchExecutor) ExecuteStream(context.Context, *coreauth.Auth, coreexecutor.Request, coreexecutor.Options) (<-chan coreexecutor.StreamChunk, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (e *messageBatchExecutor) Refresh(_ context.Context, auth *coreauth.Auth) (*coreauth.Auth, error) {
+	return auth, nil
+}
+
+func (e *messageBatchExecutor) CountTokens(context.Context, *coreauth.Auth, coreexecutor.Request, coreexecutor.Options) (coreexecutor.Response, error) {
+	return coreexecutor.Response{}, errors.New("not implemented")
+}
+
+func (e *messageBatchExecutor) HttpRequest(_ context.Context, _ *coreauth.Auth, req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		body, _ = io.ReadAll(req.Body)
+	}
+	e.mu.Lock()
+	e.requests = append(e.requests, req.Method+" "+req.URL.String()+" "+string(body))
+	e.mu.Unlock()
+	payload := `{"id":"msgbatch_upstream","type":"message_batch","processing_status":"in_progress","results_url":null}`
+	if strings.HasSuffix(req.URL.Path, "/results") {
+		payload = `{"custom_id":"a","result":{"type":"succeeded","message":{}}}` + "\n"
+	} else if req.Method == http.MethodGet {
+		payload = `{"id":"msgbatch_upstream","type":"message_batch","processing_status":"ended","results_url":"https://api.anthropic.com/v1/messages/batches/msgbatch_upstream/results"}`
+	}
+	return &http.Response{StatusCode: http.StatusOK, Header: http.Header{"Content-Type": []string{"application/json"}}, Body: io.NopCloser(strings.NewReader(payload))}, nil
+}
+
+func setupMessageBatchRouter(t *testing.T, executor *messageBatchExecutor, auth *coreauth.Auth, model string) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	manager := coreauth.NewManager(nil, nil, nil)
+	manager.RegisterExecutor(executor)
+	if _, err := manager.Register(context.Background(), auth); err != nil {
+		t.Fatalf("Register auth: %v", err)
+	}
+	registry.GetGlobalRegistry().RegisterClient(auth.ID, auth.Provider, []*registry.ModelInfo{{ID: model}})
+	t.Cleanup(func() {
+		registry.GetGlobalRegistry().UnregisterClient(auth.ID)
+	})
+
+	batches, err := batch.NewManager(t.TempDir(), batch.Options{})
+	if err != nil {
+		t.Fatalf("NewManager: %v", err)
+	}
+	t.Cleanup(batches.Stop)
+	base := handlers.NewBaseAPIHandlers(&sdkconfig.SDKConfig{APIKeys: sdkconfig.APIKeyEntries("key-a", "key-b")}, manager)
+	h := NewClaudeBatchAPIHandler(base, batches, NewClaudeCodeAPIHandler(base))
+	batches.Start()
+
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set("apiKey", c.GetHeader("X-Api-Key"))
+	})
+	router.POST("/v1/messages/batches", h.CreateBatch)
+	router.GET("/v1/messages/batches", h.ListBatches)
+	router.GET("/v1/messages/batches/:batch_id", h.GetBatch)
+	router.GET("/v1/messages/batches/:batch_id/results", h.GetResults)
+	return router
+}
+
+func serveMessageBatchRequest(router *gin.Engine, method, path, body, apiKey string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, bytes.NewBufferString(body))
+	req.Header.Set("X-Api-Key", apiKey)
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+	return resp
+}
+
+func TestMessageBatch_EmulatedBatchReturnsAnthropicResults(t *testing.T) {
+	executor := &messageBatchExecutor{provider: "message-batch-test"}
+	router := setupMessageBatchRouter(t, executor, &coreauth.Auth{ID: "message-batch-auth", Provider: executor.provider, Status: coreauth.StatusActive}, "batch-model")
+
+	resp := serveMessageBatchRequest(router, http.MethodPost, "/v1/messages/batches", `{"requests":[
+		{"custom_id":"ok","params":{"model":"batch-model","max_tokens":16,"stream":true,"messages":[{"role":"user","content":"hi"}]}},
+		{"custom_id":"bad","params":{"model":"batch-model","max_tokens":16,"messages":[{"role":"user","content":"fail"}]}}]}`, "key-a")
+	if resp.Code != http.StatusOK {
+		t.Fatalf("create status = %d, body %s", resp.Code, resp.Body.String())
+	}
+	id := gjson.Get(resp.Body.String(), "id").String()
+	if !strings.HasPrefix(id, "msgbatch_") || gjson.Get(resp.Body.String(), "type").String() != "message_batch" {
+		t.Fatalf("unexpected batch %s", resp.Body.String())
+	}
+	if other := serveMessageBatchRequest(router, http.MethodGet, "/v1/messages/batches/"+id, "", "key-b"); other.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 for another key, got %d", other.Code)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		resp = serveMessageBatchRequest(router, http.MethodGet, "/v1/messages/batches/"+id, "", "key-a")
+		if gjson.Get(resp.Body.String(), "processing_status").String() == "ended" {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	body := resp.Body.String()
+	if gjson.Get(body, "processing_status").String() != "ended" {
+		t.Fatalf("batch did not end, last response %s", body)
+	}
+	if gjson.Get(body, "request_counts.succeeded").Int() != 1 || gjson.Get(body, "request_counts.errored").Int() != 1 {
+		t.Fatalf("unexpected request counts %s", body)
+	}
+	if !strings.HasSuffix(gjson.Get(body, "results_url").String(), "/v1/messages/batches/"+id+"/results") {
+		t.Fatalf("unexpected results_url %s", body)
+	}
+
+	resp = serveMessageBatchRequest(router, http.MethodGet, "/v1/messages/batches/"+id+"/results", "", "key-a")
+	if resp.Code != http.StatusOK {
+		t.Fatalf("results status = %d, body %s", resp.Code, resp.Body.String())
+	}
+	results := make(map[string]string)
+	for _, line := range strings.Split(strings.TrimSpace(resp.Body.String()), "\n") {
+		results[gjson.Get(line, "custom_id").String()] = line
+	}
+	if got := gjson.Get(results["ok"], "result.type").String(); got != "succeeded" {
+		t.Fatalf("expected ok to succeed, got %s", results["ok"])
+	}
+	if got := gjson.Get(results["ok"], "result.message.content.0.text").String(); got != "echo hi" {
+		t.Fatalf("unexpected message %s", results["ok"])
+	}
+	if gjson.Get(results["bad"], "result.type").String() != "errored" || gjson.Get(results["bad"], "result.error.type").String() != "error" {
+		t.Fatalf("expected bad to be errored, got %s", results["bad"])
+	}
+}
+
+func TestMessageBatch_ClaudeAPIKeyBatchIsCreatedUpstream(t *testing.T) {
+	executor := &messageBatchExecutor{provider: "claude"}
+	auth := &coreauth.Auth{
+		ID:         "message-batch-claude-key",
+		Provider:   "claude",
+		Status:     coreauth.StatusActive,
+		Attributes: map[string]string{"api_key": "sk-test"},
+	}
+	router := setupMessageBatchRouter(t, executor, auth, "claude-batch-model")
+
+	resp := serveMessageBatchRequest(router, http.MethodPost, "/v1/messages/batches", `{"requests":[{"custom_id":"a","params":{"model":"claude-batch-model","max_tokens":16,"messages":[{"role":"user","content":"hi"}]}}]}`, "key-a")
+	if resp.Code != http.StatusOK || gjson.Get(resp.Body.String(), "id").String() != "msgbatch_upstream" {
+		t.Fatalf("create status = %d, body %s", resp.Code, resp.Body.String())
+	}
+
+	resp = serveMessageBatchRequest(router, http.MethodGet, "/v1/messages/batches/msgbatch_upstream", "", "key-a")
+	if got := gjson.Get(resp.Body.String(), "results_url").String(); !strings.HasPrefix(got, "http://example.com/") {
+		t.Fatalf("expected results_url to point at the proxy, got %s", resp.Body.String())
+	}
+	resp = serveMessageBatchRequest(router, http.MethodGet, "/v1/messages/batches/msgbatch_upstream/results", "", "key-a")
+	if gjson.Get(resp.Body.String(), "custom_id").String() != "a" {
+		t.Fatalf("unexpected results %s", resp.Body.String())
+	}
+	if other := serveMessageBatchRequest(router, http.MethodGet, "/v1/messages/batches/msgbatch_upstream", "", "key-b"); other.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 for another key, got %d", other.Code)
+	}
+
+	executor.mu.Lock()
+	defer executor.mu.Unlock()
+	want := []string{
+		"POST " + anthropicBatchesURL,
+		"GET " + anthropicBatchesURL + "/msgbatch_upstream",
+		"GET " + anthropicBatchesURL + "/msgbatch_upstream/results",
+	}
+	if len(executor.requests) != len(want) {
+		t.Fatalf("unexpected upstream requests %v", executor.requests)
+	}
+	for i, prefix := range want {
+		if !strings.HasPrefix(executor.requests[i], prefix+" ") {
+			t.Fatalf("request %d = %q, want %q", i, executor.requests[i], prefix)
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/constant"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
+)
+
+// formattedError carries a pre-rendered JSON error body so WriteErrorResponse
+// emits it unchanged, together with the HTTP status it belongs to.
+type formattedError struct {
+	status int
+	body   string
+}
+
+func (e *formattedError) Error() string   { return e.body }
+func (e *formattedError) StatusCode() int { return e.status }
+
+// BuildFormatErrorBody renders an error body in the wire format of the inbound handler,
+// so Claude, Gemini and OpenAI clients each receive an error shape they understand.
+func BuildFormatErrorBody(handlerType string, status int, message string) []byte {
+	if status <= 0 {
+		status = http.StatusInternalServerError
+	}
+	if strings.TrimSpace(message) == "" {
+		message = http.StatusText(status)
+	}
+	var payload any
+	switch handlerType {
+	case constant.Claude:
+		payload = map[string]any{
+			"type": "error",
+			"error": map[string]any{
+				"type":    claudeErrorType(status),
+				"message": message,
+			},
+		}
+	case constant.Gemini, constant.GeminiCLI:
+		payload = map[string]any{
+			"error": map[string]any{
+				"code":    status,
+				"message": message,
+				"status":  geminiErrorStatus(status),
+			},
+		}
+	default:
+		return BuildErrorResponseBody(status, message)
+	}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return BuildErrorResponseBody(status, message)
+	}
+	return data
+}
+
+// newFormatErrorMessage builds an ErrorMessage whose body matches the handler format.
+// A positive retryAfter adds a Retry-After header (whole seconds, rounded up).
+func newFormatErrorMessage(handlerType string, status int, message string, retryAfterSeconds int) *interfaces.ErrorMessage {
+	msg := &interfaces.ErrorMessage{
+		StatusCode: status,
+		Error:      &formattedError{status: status, body: string(BuildFormatErrorBody(handlerType, status, message))},
+	}
+	if retryAfterSeconds > 0 {
+		msg.Addon = http.Header{}
+		msg.Addon.Set("Retry-After", strconv.Itoa(retryAfterSeconds))
+	}
+	return msg
+}
+
+func claudeErrorType(status int) string {
+	switch status {
+	case http.StatusBadRequest:
+		return "invalid_request_error"
+	case http.StatusUnauthorized:
+		return "authentication_error"
+	case http.StatusForbidden:
+		return "permission_error"
+	case http.StatusNotFound:
+		return "not_found_error"
+	case http.StatusRequestEntityTooLarge:
+		return "request_too_large"
+	case http.StatusTooManyRequests:
+		return "rate_limit_error"
+	case 529:
+		return "overloaded_error"
+	default:
+		if status >= http.StatusInternalServerError {
+			return "api_error"
+		}
+		return "invalid_request_error"
+	}
+}
+
+func geminiErrorStatus(status int) string {
+	switch status {
+	case http.StatusBadRequest:
+		return "INVALID_ARGUMENT"
+	case http.StatusUnauthorized:
+		return "UNAUTHENTICATED"
+	case http.StatusForbidden:
+		return "PERMISSION_DENIED"
+	case http.StatusNotFound:
+		return "NOT_FOUND"
+	case http.StatusTooManyRequests:
+		return "RESOURCE_EXHAUSTED"
+	case http.StatusServiceUnavailable:
+		return "UNAVAILABLE"
+	default:
+		if status >= http.StatusInternalServerError {
+			return "INTERNAL"
+		}
+		return "FAILED_PRECONDITION"
+	}
+}
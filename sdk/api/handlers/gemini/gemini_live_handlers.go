@@ -0,0 +1,176 @@
+package gemini
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
+	log "github.com/sirupsen/logrus"
+	"github.com/tidwall/gjson"
+)
+
+const (
+	// liveSetupTimeout bounds the wait for the setup message that opens a Live session.
+	liveSetupTimeout = 30 * time.Second
+	// liveHeartbeatInterval is how often idle client connections are pinged.
+	liveHeartbeatInterval = 30 * time.Second
+	liveWriteTimeout      = 10 * time.Second
+	maxLiveMessageBytes   = 16 << 20
+	// maxCloseReasonBytes is the room left for the reason in a websocket close frame.
+	maxCloseReasonBytes = 123
+)
+
+var liveUpgrader = websocket.Upgrader{
+	ReadBufferSize:  4096,
+	WriteBufferSize: 4096,
+	CheckOrigin: func(r *http.Request) bool {
+		return true
+	},
+}
+
+// GeminiLiveHandler proxies Gemini Live API (BidiGenerateContent) websocket sessions. The first
+// client message must be the setup message; its model selects a credential from the pool, and
+// all further messages are relayed unchanged in both directions until either side closes.
+func (h *GeminiAPIHandler) GeminiLiveHandler(c *gin.Context) {
+	client, err := liveUpgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		log.Warnf("gemini live: upgrade failed: %v", err)
+		return
+	}
+	defer func() {
+		_ = client.Close()
+	}()
+	client.SetReadLimit(maxLiveMessageBytes)
+
+	_ = client.SetReadDeadline(time.Now().Add(liveSetupTimeout))
+	messageType, setup, err := client.ReadMessage()
+	if err != nil {
+		return
+	}
+	_ = client.SetReadDeadline(time.Time{})
+	modelName := liveSetupModel(setup)
+	if modelName == "" {
+		closeLiveClient(client, websocket.ClosePolicyViolation, "setup message with a model is required as the first message")
+		return
+	}
+
+	cliCtx, cliCancel := h.GetContextWithCancel(h, c, context.Background())
+	session, errMsg := h.ExecuteLiveWithAuthManager(cliCtx, h.HandlerType(), modelName, setup)
+	if errMsg != nil {
+		cliCancel(errMsg.Error)
+		closeLiveClient(client, liveCloseCode(errMsg), errMsg.Error.Error())
+		return
+	}
+	defer cliCancel()
+	defer func() {
+		_ = session.Close()
+	}()
+	if err = session.Send(messageType, setup); err != nil {
+		closeLiveClient(client, websocket.CloseInternalServerErr, err.Error())
+		return
+	}
+
+	var writeMu sync.Mutex
+	stopHeartbeat := startLiveHeartbeat(client, &writeMu)
+	defer stopHeartbeat()
+
+	relayCtx, stopRelay := context.WithCancel(cliCtx)
+	defer stopRelay()
+	upstreamDone := make(chan struct{})
+	go func() {
+		defer close(upstreamDone)
+		for {
+			msgType, data, errRecv := session.Receive()
+			if errRecv != nil {
+				code, reason := websocket.CloseNormalClosure, ""
+				var closeErr *websocket.CloseError
+				if errors.As(errRecv, &closeErr) && closeErr.Code != websocket.CloseAbnormalClosure {
+					code, reason = closeErr.Code, closeErr.Text
+				} else if relayCtx.Err() == nil {
+					code, reason = websocket.CloseInternalServerErr, errRecv.Error()
+				}
+				closeLiveClient(client, code, reason)
+				return
+			}
+			writeMu.Lock()
+			_ = client.SetWriteDeadline(time.Now().Add(liveWriteTimeout))
+			errWrite := client.WriteMessage(msgType, data)
+			writeMu.Unlock()
+			if errWrite != nil {
+				return
+			}
+		}
+	}()
+
+	for {
+		msgType, data, errRead := client.ReadMessage()
+		if errRead != nil {
+			break
+		}
+		if errSend := session.Send(msgType, data); errSend != nil {
+			break
+		}
+	}
+	stopRelay()
+	_ = session.Close()
+	<-upstreamDone
+}
+
+// liveSetupModel returns the model named in a BidiGenerateContent setup message. Both
+// "models/{model}" and full Vertex resource names are accepted.
+func liveSetupModel(setup []byte) string {
+	model := strings.TrimSpace(gjson.GetBytes(setup, "setup.model").String())
+	if idx := strings.LastIndex(model, "models/"); idx >= 0 {
+		model = model[idx+len("models/"):]
+	}
+	return model
+}
+
+// liveCloseCode maps a failure to open the upstream session to a websocket close code.
+func liveCloseCode(msg *interfaces.ErrorMessage) int {
+	switch {
+	case msg.StatusCode == http.StatusTooManyRequests:
+		return websocket.CloseTryAgainLater
+	case msg.StatusCode >= 400 && msg.StatusCode < 500:
+		return websocket.ClosePolicyViolation
+	}
+	return websocket.CloseInternalServerErr
+}
+
+func closeLiveClient(client *websocket.Conn, code int, reason string) {
+	if len(reason) > maxCloseReasonBytes {
+		reason = reason[:maxCloseReasonBytes]
+	}
+	_ = client.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, reason), time.Now().Add(liveWriteTimeout))
+	// Give the client a moment to acknowledge the close before the connection is dropped.
+	_ = client.SetReadDeadline(time.Now().Add(liveWriteTimeout))
+}
+
+// startLiveHeartbeat pings the client periodically so that idle sessions survive intermediaries.
+func startLiveHeartbeat(client *websocket.Conn, writeMu *sync.Mutex) func() {
+	stop := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(liveHeartbeatInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				writeMu.Lock()
+				err := client.WriteControl(websocket.PingMessage, nil, time.Now().Add(liveWriteTimeout))
+				writeMu.Unlock()
+				if err != nil {
+					return
+				}
+			}
+		}
+	}()
+	return func() { close(stop) }
+}
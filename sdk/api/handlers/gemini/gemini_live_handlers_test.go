@@ -0,0 +1,152 @@
+package gemini
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	coreexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+)
+
+// echoLiveSession answers every client message with an "echo:" message and closes normally
+// after the client says "bye".
+type echoLiveSession struct {
+	outbound chan []byte
+	closed   chan struct{}
+}
+
+func (s *echoLiveSession) Send(_ int, data []byte) error {
+	s.outbound <- append([]byte("echo:"), data...)
+	return nil
+}
+
+func (s *echoLiveSession) Receive() (int, []byte, error) {
+	select {
+	case data := <-s.outbound:
+		if strings.Contains(string(data), "bye") {
+			return 0, nil, &websocket.CloseError{Code: websocket.CloseNormalClosure, Text: "goodbye"}
+		}
+		return websocket.TextMessage, data, nil
+	case <-s.closed:
+		return 0, nil, errors.New("session closed")
+	}
+}
+
+func (s *echoLiveSession) Close() error {
+	select {
+	case <-s.closed:
+	default:
+		close(s.closed)
+	}
+	return nil
+}
+
+type liveTestExecutor struct {
+	model string
+}
+
+func (e *liveTestExecutor) Identifier() string { return "live-test-provider" }
+
+func (e *liveTestExecutor) ExecuteLive(_ context.Context, _ *coreauth.Auth, req coreexecutor.Request, _ coreexecutor.Options) (coreexecutor.LiveSession, error) {
+	e.model = req.Model
+	return &echoLiveSession{outbound: make(chan []byte, 8), closed: make(chan struct{})}, nil
+}
+
+func (e *liveTestExecutor) Execute(context.Context, *coreauth.Auth, coreexecutor.Request, coreexecutor.Options) (coreexecutor.Response, error) {
+	return coreexecutor.Response{}, errors.New("not implemented")
+}
+
+func (e *liveTestExecutor) ExecuteStream(context.Context, *coreauth.Auth, coreexecutor.Request, coreexecutor.Options) (<-chan coreexecutor.StreamChunk, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (e *liveTestExecutor) Refresh(_ context.Context, auth *coreauth.Auth) (*coreauth.Auth, error) {
+	return auth, nil
+}
+
+func (e *liveTestExecutor) CountTokens(context.Context, *coreauth.Auth, coreexecutor.Request, coreexecutor.Options) (coreexecutor.Response, error) {
+	return coreexecutor.Response{}, errors.New("not implemented")
+}
+
+func (e *liveTestExecutor) HttpRequest(context.Context, *coreauth.Auth, *http.Request) (*http.Response, error) {
+	return nil, errors.New("not implemented")
+}
+
+func TestGeminiLiveHandler_RelaysSessionMessages(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	executor := &liveTestExecutor{}
+	manager := coreauth.NewManager(nil, nil, nil)
+	manager.RegisterExecutor(executor)
+	auth := &coreauth.Auth{ID: "live-auth", Provider: executor.Identifier(), Status: coreauth.StatusActive}
+	if _, err := manager.Register(context.Background(), auth); err != nil {
+		t.Fatalf("Register auth: %v", err)
+	}
+	registry.GetGlobalRegistry().RegisterClient(auth.ID, auth.Provider, []*registry.ModelInfo{{ID: "live-model"}})
+	t.Cleanup(func() {
+		registry.GetGlobalRegistry().UnregisterClient(auth.ID)
+	})
+	h := NewGeminiAPIHandler(handlers.NewBaseAPIHandlers(&sdkconfig.SDKConfig{}, manager))
+	router := gin.New()
+	router.GET("/ws/live", h.GeminiLiveHandler)
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/ws/live", nil)
+	if err != nil {
+		t.Fatalf("Dial() error = %v", err)
+	}
+	defer func() { _ = conn.Close() }()
+	_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+
+	for _, msg := range []string{`{"setup":{"model":"models/live-model"}}`, `{"realtimeInput":{}}`} {
+		if err = conn.WriteMessage(websocket.TextMessage, []byte(msg)); err != nil {
+			t.Fatalf("WriteMessage() error = %v", err)
+		}
+		_, data, errRead := conn.ReadMessage()
+		if errRead != nil || string(data) != "echo:"+msg {
+			t.Fatalf("expected echo of %s, got %q (%v)", msg, data, errRead)
+		}
+	}
+	if executor.model != "live-model" {
+		t.Fatalf("expected the session to be opened for live-model, got %q", executor.model)
+	}
+
+	_ = conn.WriteMessage(websocket.TextMessage, []byte(`bye`))
+	_, _, err = conn.ReadMessage()
+	var closeErr *websocket.CloseError
+	if !errors.As(err, &closeErr) || closeErr.Code != websocket.CloseNormalClosure || closeErr.Text != "goodbye" {
+		t.Fatalf("expected the upstream close to be relayed, got %v", err)
+	}
+}
+
+func TestGeminiLiveHandler_ClosesWithRoutingError(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := NewGeminiAPIHandler(handlers.NewBaseAPIHandlers(&sdkconfig.SDKConfig{}, coreauth.NewManager(nil, nil, nil)))
+	router := gin.New()
+	router.GET("/ws/live", h.GeminiLiveHandler)
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/ws/live", nil)
+	if err != nil {
+		t.Fatalf("Dial() error = %v", err)
+	}
+	defer func() { _ = conn.Close() }()
+	_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	_ = conn.WriteMessage(websocket.TextMessage, []byte(`{"setup":{"model":"models/no-such-live-model"}}`))
+	_, _, err = conn.ReadMessage()
+	var closeErr *websocket.CloseError
+	if !errors.As(err, &closeErr) || closeErr.Code != websocket.CloseInternalServerErr || !strings.Contains(closeErr.Text, "unknown provider") {
+		t.Fatalf("expected the session to be closed with the routing error, got %v", err)
+	}
+}
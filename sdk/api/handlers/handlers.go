@@ -22,6 +22,8 @@ import (
 	coreexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
 	"github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
 	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+	log "github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/trace"
 	"golang.org/x/net/context"
 )
 
@@ -140,6 +142,14 @@ func StreamingBootstrapRetries(cfg *config.SDKConfig) int {
 	return retries
 }
 
+// StreamingContinuationRetries returns how many times a stream that breaks after bytes were sent may be resumed.
+func StreamingContinuationRetries(cfg *config.SDKConfig) int {
+	if cfg == nil || cfg.Streaming.ContinuationRetries < 0 {
+		return 0
+	}
+	return cfg.Streaming.ContinuationRetries
+}
+
 func requestExecutionMetadata(ctx context.Context) map[string]any {
 	// Idempotency-Key is an optional client-supplied header used to correlate retries.
 	// It is forwarded as execution metadata; when absent we generate a UUID.
@@ -164,6 +174,9 @@ type BaseAPIHandler struct {
 
 	// Cfg holds the current application configuration.
 	Cfg *config.SDKConfig
+
+	// dedup coalesces concurrent identical requests when request-dedup is enabled.
+	dedup requestDedup
 }
 
 // NewBaseAPIHandlers creates a new API handlers instance.
@@ -242,13 +255,20 @@ func (h *BaseAPIHandler) GetContextWithCancel(handler interfaces.APIHandler, c *
 			parentCtx = logging.WithRequestID(parentCtx, requestID)
 		}
 	}
+	if requestCtx != nil && !trace.SpanContextFromContext(parentCtx).IsValid() {
+		// Keep the handler span opened by the tracing middleware as the parent of pipeline spans.
+		if span := trace.SpanFromContext(requestCtx); span.SpanContext().IsValid() {
+			parentCtx = trace.ContextWithSpan(parentCtx, span)
+		}
+	}
 	newCtx, cancel := context.WithCancel(parentCtx)
 	if requestCtx != nil && requestCtx != parentCtx {
+		done := newCtx.Done()
 		go func() {
 			select {
 			case <-requestCtx.Done():
 				cancel()
-			case <-newCtx.Done():
+			case <-done:
 			}
 		}()
 	}
@@ -369,6 +389,44 @@ func appendAPIResponse(c *gin.Context, data []byte) {
 	c.Set("API_RESPONSE", bytes.Clone(data))
 }
 
+// responseCacheBypassed reports whether the client opted out of the response cache with
+// "Cache-Control: no-cache" or "no-store".
+func responseCacheBypassed(ctx context.Context) bool {
+	if ctx == nil {
+		return false
+	}
+	ginCtx, ok := ctx.Value("gin").(*gin.Context)
+	if !ok || ginCtx == nil || ginCtx.Request == nil {
+		return false
+	}
+	for _, directive := range strings.Split(ginCtx.GetHeader("Cache-Control"), ",") {
+		switch strings.ToLower(strings.TrimSpace(directive)) {
+		case "no-cache", "no-store":
+			return true
+		}
+	}
+	return false
+}
+
+// markResponseCacheHit tells the client and the request log that the response cache answered
+// the request, since no upstream call was made.
+func markResponseCacheHit(ctx context.Context) {
+	if ctx == nil {
+		return
+	}
+	ginCtx, ok := ctx.Value("gin").(*gin.Context)
+	if !ok || ginCtx == nil {
+		return
+	}
+	ginCtx.Header("X-Response-Cache", "hit")
+	appendAPIResponse(ginCtx, []byte("[served from response cache; no upstream request was made]"))
+}
+
+// requestDedupEnabled reports whether concurrent identical requests should share one upstream call.
+func (h *BaseAPIHandler) requestDedupEnabled(ctx context.Context) bool {
+	return ctx != nil && h.Cfg != nil && h.Cfg.RequestDedup
+}
+
 // ExecuteWithAuthManager executes a non-streaming request via the core auth manager.
 // This path is the only supported execution route.
 func (h *BaseAPIHandler) ExecuteWithAuthManager(ctx context.Context, handlerType, modelName string, rawJSON []byte, alt string) ([]byte, *interfaces.ErrorMessage) {
@@ -376,7 +434,15 @@ func (h *BaseAPIHandler) ExecuteWithAuthManager(ctx context.Context, handlerType
 	if errMsg != nil {
 		return nil, errMsg
 	}
+	providers, errMsg = h.applyAPIKeyPolicy(ctx, handlerType, normalizedModel, providers, true)
+	if errMsg != nil {
+		return nil, errMsg
+	}
+	if errMsg = h.validateModelCapabilities(ctx, handlerType, normalizedModel, providers, rawJSON); errMsg != nil {
+		return nil, errMsg
+	}
 	reqMeta := requestExecutionMetadata(ctx)
+	h.apiKeyPolicyMetadata(ctx, reqMeta)
 	reqMeta[coreexecutor.RequestedModelMetadataKey] = normalizedModel
 	payload := rawJSON
 	if len(payload) == 0 {
@@ -392,7 +458,21 @@ func (h *BaseAPIHandler) ExecuteWithAuthManager(ctx context.Context, handlerType
 		OriginalRequest: rawJSON,
 		SourceFormat:    sdktranslator.FromString(handlerType),
 	}
+	if responseCacheBypassed(ctx) {
+		reqMeta[coreexecutor.ResponseCacheBypassMetadataKey] = true
+	}
 	opts.Metadata = reqMeta
+	if h.requestDedupEnabled(ctx) {
+		key := requestDedupKey(false, clientAPIKeyFromContext(ctx), handlerType, alt, normalizedModel, providers, rawJSON)
+		return h.coalesceNonStreaming(ctx, key, func(execCtx context.Context) ([]byte, *interfaces.ErrorMessage) {
+			return h.executeNonStreaming(execCtx, providers, req, opts)
+		})
+	}
+	return h.executeNonStreaming(ctx, providers, req, opts)
+}
+
+// executeNonStreaming runs a prepared non-streaming request through the core auth manager.
+func (h *BaseAPIHandler) executeNonStreaming(ctx context.Context, providers []string, req coreexecutor.Request, opts coreexecutor.Options) ([]byte, *interfaces.ErrorMessage) {
 	resp, err := h.AuthManager.Execute(ctx, providers, req, opts)
 	if err != nil {
 		status := http.StatusInternalServerError
@@ -409,6 +489,9 @@ func (h *BaseAPIHandler) ExecuteWithAuthManager(ctx context.Context, handlerType
 		}
 		return nil, &interfaces.ErrorMessage{StatusCode: status, Error: err, Addon: addon}
 	}
+	if hit, _ := resp.Metadata[coreexecutor.ResponseCacheMetadataKey].(string); hit == "hit" {
+		markResponseCacheHit(ctx)
+	}
 	return resp.Payload, nil
 }
 
@@ -419,7 +502,16 @@ func (h *BaseAPIHandler) ExecuteCountWithAuthManager(ctx context.Context, handle
 	if errMsg != nil {
 		return nil, errMsg
 	}
+	providers, errMsg = h.applyAPIKeyPolicy(ctx, handlerType, normalizedModel, providers, false)
+	if errMsg != nil {
+		return nil, errMsg
+	}
+	mode := h.Cfg.TokenCountingMode()
+	if mode == config.TokenCountingLocal {
+		return countTokensLocally(handlerType, normalizedModel, rawJSON)
+	}
 	reqMeta := requestExecutionMetadata(ctx)
+	h.apiKeyPolicyMetadata(ctx, reqMeta)
 	reqMeta[coreexecutor.RequestedModelMetadataKey] = normalizedModel
 	payload := rawJSON
 	if len(payload) == 0 {
@@ -437,6 +529,10 @@ func (h *BaseAPIHandler) ExecuteCountWithAuthManager(ctx context.Context, handle
 	}
 	opts.Metadata = reqMeta
 	resp, err := h.AuthManager.ExecuteCount(ctx, providers, req, opts)
+	if err != nil && mode == config.TokenCountingFallback {
+		log.Debugf("upstream token count for model %s failed, counting locally: %v", normalizedModel, err)
+		return countTokensLocally(handlerType, normalizedModel, rawJSON)
+	}
 	if err != nil {
 		status := http.StatusInternalServerError
 		if se, ok := err.(interface{ StatusCode() int }); ok && se != nil {
@@ -455,10 +551,53 @@ func (h *BaseAPIHandler) ExecuteCountWithAuthManager(ctx context.Context, handle
 	return resp.Payload, nil
 }
 
+// ExecuteLiveWithAuthManager opens a bidirectional live session for modelName via the core
+// auth manager. rawJSON is the client's setup message; the caller sends it on the session.
+func (h *BaseAPIHandler) ExecuteLiveWithAuthManager(ctx context.Context, handlerType, modelName string, rawJSON []byte) (coreexecutor.LiveSession, *interfaces.ErrorMessage) {
+	providers, normalizedModel, errMsg := h.getRequestDetails(modelName)
+	if errMsg != nil {
+		return nil, errMsg
+	}
+	providers, errMsg = h.applyAPIKeyPolicy(ctx, handlerType, normalizedModel, providers, true)
+	if errMsg != nil {
+		return nil, errMsg
+	}
+	reqMeta := requestExecutionMetadata(ctx)
+	h.apiKeyPolicyMetadata(ctx, reqMeta)
+	reqMeta[coreexecutor.RequestedModelMetadataKey] = normalizedModel
+	req := coreexecutor.Request{
+		Model:   normalizedModel,
+		Payload: rawJSON,
+	}
+	opts := coreexecutor.Options{
+		Stream:          true,
+		OriginalRequest: rawJSON,
+		SourceFormat:    sdktranslator.FromString(handlerType),
+		Metadata:        reqMeta,
+	}
+	session, err := h.AuthManager.ExecuteLive(ctx, providers, req, opts)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if se, ok := err.(interface{ StatusCode() int }); ok && se != nil {
+			if code := se.StatusCode(); code > 0 {
+				status = code
+			}
+		}
+		return nil, &interfaces.ErrorMessage{StatusCode: status, Error: err}
+	}
+	return session, nil
+}
+
 // ExecuteStreamWithAuthManager executes a streaming request via the core auth manager.
 // This path is the only supported execution route.
 func (h *BaseAPIHandler) ExecuteStreamWithAuthManager(ctx context.Context, handlerType, modelName string, rawJSON []byte, alt string) (<-chan []byte, <-chan *interfaces.ErrorMessage) {
 	providers, normalizedModel, errMsg := h.getRequestDetails(modelName)
+	if errMsg == nil {
+		providers, errMsg = h.applyAPIKeyPolicy(ctx, handlerType, normalizedModel, providers, true)
+	}
+	if errMsg == nil {
+		errMsg = h.validateModelCapabilities(ctx, handlerType, normalizedModel, providers, rawJSON)
+	}
 	if errMsg != nil {
 		errChan := make(chan *interfaces.ErrorMessage, 1)
 		errChan <- errMsg
@@ -466,6 +605,7 @@ func (h *BaseAPIHandler) ExecuteStreamWithAuthManager(ctx context.Context, handl
 		return nil, errChan
 	}
 	reqMeta := requestExecutionMetadata(ctx)
+	h.apiKeyPolicyMetadata(ctx, reqMeta)
 	reqMeta[coreexecutor.RequestedModelMetadataKey] = normalizedModel
 	payload := rawJSON
 	if len(payload) == 0 {
@@ -482,7 +622,48 @@ func (h *BaseAPIHandler) ExecuteStreamWithAuthManager(ctx context.Context, handl
 		SourceFormat:    sdktranslator.FromString(handlerType),
 	}
 	opts.Metadata = reqMeta
-	chunks, err := h.AuthManager.ExecuteStream(ctx, providers, req, opts)
+	if h.requestDedupEnabled(ctx) {
+		key := requestDedupKey(true, clientAPIKeyFromContext(ctx), handlerType, alt, normalizedModel, providers, rawJSON)
+		return h.coalesceStreaming(ctx, key, func(execCtx context.Context) (<-chan []byte, <-chan *interfaces.ErrorMessage) {
+			return h.executeStreaming(execCtx, handlerType, rawJSON, alt, providers, req, opts)
+		})
+	}
+	return h.executeStreaming(ctx, handlerType, rawJSON, alt, providers, req, opts)
+}
+
+// executeStreaming runs a prepared streaming request through the core auth manager, handling
+// bootstrap retries and mid-stream continuation.
+func (h *BaseAPIHandler) executeStreaming(ctx context.Context, handlerType string, rawJSON []byte, alt string, providers []string, req coreexecutor.Request, opts coreexecutor.Options) (<-chan []byte, <-chan *interfaces.ErrorMessage) {
+	// Mid-stream continuation needs to know which credential served each attempt so the
+	// resumed request can be routed elsewhere.
+	maxContinuations := StreamingContinuationRetries(h.Cfg)
+	var continuation *streamContinuation
+	var servedAuth string
+	if maxContinuations > 0 {
+		continuation = newStreamContinuation(handlerType, alt)
+	}
+	if continuation != nil {
+		ctx = coreexecutor.WithStreamContinuation(ctx)
+		ctx = coreexecutor.WithAuthObserver(ctx, func(authID string) { servedAuth = authID })
+	}
+	// Each upstream attempt runs under its own cancellable context so a stream abandoned for a
+	// continuation is torn down instead of being left to run to completion.
+	cancelAttempt := context.CancelFunc(func() {})
+	startAttempt := func(attemptCtx context.Context, attemptReq coreexecutor.Request, attemptOpts coreexecutor.Options) (<-chan coreexecutor.StreamChunk, error) {
+		if continuation == nil || attemptCtx == nil {
+			return h.AuthManager.ExecuteStream(attemptCtx, providers, attemptReq, attemptOpts)
+		}
+		attemptCtx, cancel := context.WithCancel(attemptCtx)
+		attemptChunks, errAttempt := h.AuthManager.ExecuteStream(attemptCtx, providers, attemptReq, attemptOpts)
+		if errAttempt != nil {
+			cancel()
+			return nil, errAttempt
+		}
+		cancelAttempt()
+		cancelAttempt = cancel
+		return attemptChunks, nil
+	}
+	chunks, err := startAttempt(ctx, req, opts)
 	if err != nil {
 		errChan := make(chan *interfaces.ErrorMessage, 1)
 		status := http.StatusInternalServerError
@@ -506,9 +687,12 @@ func (h *BaseAPIHandler) ExecuteStreamWithAuthManager(ctx context.Context, handl
 	go func() {
 		defer close(dataChan)
 		defer close(errChan)
+		defer func() { cancelAttempt() }()
 		sentPayload := false
 		bootstrapRetries := 0
 		maxBootstrapRetries := StreamingBootstrapRetries(h.Cfg)
+		continuations := 0
+		var failedAuths []string
 
 		sendErr := func(msg *interfaces.ErrorMessage) bool {
 			if ctx == nil {
@@ -536,6 +720,36 @@ func (h *BaseAPIHandler) ExecuteStreamWithAuthManager(ctx context.Context, handl
 			}
 		}
 
+		// resume re-issues the request with the partial answer as a prefill once the stream has
+		// broken after bytes were sent, preferring credentials that have not failed yet.
+		resume := func(streamErr error) bool {
+			if continuation == nil || continuations >= maxContinuations || !continuation.resumable() {
+				return false
+			}
+			payload, errPayload := continuation.request(rawJSON)
+			if errPayload != nil {
+				log.Debugf("stream continuation: build request: %v", errPayload)
+				return false
+			}
+			continuations++
+			if servedAuth != "" {
+				failedAuths = append(failedAuths, servedAuth)
+			}
+			log.Infof("stream continuation: resuming %s stream after mid-stream failure (%d/%d): %v", handlerType, continuations, maxContinuations, streamErr)
+			resumeReq := req
+			resumeReq.Payload = payload
+			resumeOpts := opts
+			resumeOpts.OriginalRequest = payload
+			resumeChunks, errResume := startAttempt(coreexecutor.WithAvoidedAuths(ctx, failedAuths), resumeReq, resumeOpts)
+			if errResume != nil {
+				log.Debugf("stream continuation: resume failed: %v", errResume)
+				return false
+			}
+			continuation.beginSplice()
+			chunks = resumeChunks
+			return true
+		}
+
 		bootstrapEligible := func(err error) bool {
 			status := statusFromError(err)
 			if status == 0 {
@@ -574,13 +788,15 @@ func (h *BaseAPIHandler) ExecuteStreamWithAuthManager(ctx context.Context, handl
 					if !sentPayload {
 						if bootstrapRetries < maxBootstrapRetries && bootstrapEligible(streamErr) {
 							bootstrapRetries++
-							retryChunks, retryErr := h.AuthManager.ExecuteStream(ctx, providers, req, opts)
+							retryChunks, retryErr := startAttempt(ctx, req, opts)
 							if retryErr == nil {
 								chunks = retryChunks
 								continue outer
 							}
 							streamErr = retryErr
 						}
+					} else if resume(streamErr) {
+						continue outer
 					}
 
 					status := http.StatusInternalServerError
@@ -598,12 +814,27 @@ func (h *BaseAPIHandler) ExecuteStreamWithAuthManager(ctx context.Context, handl
 					_ = sendErr(&interfaces.ErrorMessage{StatusCode: status, Error: streamErr, Addon: addon})
 					return
 				}
-				if len(chunk.Payload) > 0 {
+				payload := chunk.Payload
+				interrupted := false
+				if continuation != nil && len(payload) > 0 {
+					payload, interrupted = continuation.process(payload)
+				}
+				if len(payload) > 0 {
 					sentPayload = true
-					if okSendData := sendData(cloneBytes(chunk.Payload)); !okSendData {
+					if okSendData := sendData(cloneBytes(payload)); !okSendData {
 						return
 					}
 				}
+				if interrupted {
+					// The upstream reported an incomplete answer in-band; cancel the rest of it.
+					interruptErr := continuation.interruption()
+					cancelAttempt()
+					if resume(interruptErr) {
+						continue outer
+					}
+					_ = sendErr(&interfaces.ErrorMessage{StatusCode: http.StatusBadGateway, Error: interruptErr})
+					return
+				}
 			}
 		}
 	}()
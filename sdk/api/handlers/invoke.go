@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/batch"
+)
+
+// InvokeHandler runs an API handler for a request that did not arrive over HTTP, such as a
+// line of a batch job, and returns the status, headers and body it wrote. The request is
+// attributed to apiKey so that API key policies and usage accounting apply as for a direct
+// call; cancelling ctx aborts the upstream request.
+func InvokeHandler(ctx context.Context, handler gin.HandlerFunc, apiKey, method, path string, body []byte) (int, http.Header, []byte) {
+	req, err := http.NewRequestWithContext(ctx, method, path, bytes.NewReader(body))
+	if err != nil {
+		return http.StatusInternalServerError, nil, BuildErrorResponseBody(http.StatusInternalServerError, err.Error())
+	}
+	req.Header.Set("Content-Type", "application/json")
+	engine := gin.New()
+	engine.Handle(method, req.URL.Path, func(c *gin.Context) {
+		if apiKey != "" {
+			c.Set("apiKey", apiKey)
+		}
+		handler(c)
+	})
+	w := &bufferedResponse{header: make(http.Header)}
+	engine.ServeHTTP(w, req)
+	return w.status, w.header, w.body.Bytes()
+}
+
+// bufferedResponse collects a response written by a handler in memory.
+type bufferedResponse struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (w *bufferedResponse) Header() http.Header { return w.header }
+
+func (w *bufferedResponse) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+}
+
+func (w *bufferedResponse) Write(data []byte) (int, error) {
+	w.WriteHeader(http.StatusOK)
+	return w.body.Write(data)
+}
+
+// Flush is a no-op; handlers that stream flush after each chunk.
+func (w *bufferedResponse) Flush() {}
+
+// BatchOwnerAPIKey returns the configured client key that a batch owner ID belongs to. It
+// reports false once the key was removed from the configuration, so that the owner's jobs
+// stop instead of running without its policy. Jobs without an owner run without a key.
+func (h *BaseAPIHandler) BatchOwnerAPIKey(owner string) (string, bool) {
+	if owner == "" {
+		return "", true
+	}
+	if h == nil || h.Cfg == nil {
+		return "", false
+	}
+	for _, key := range h.Cfg.APIKeyValues() {
+		if batch.OwnerID(key) == owner {
+			return key, true
+		}
+	}
+	return "", false
+}
+
+// AuthorizeBatchJob reports whether the owner of job still exists; it is meant for
+// batch.Handler.Authorize.
+func (h *BaseAPIHandler) AuthorizeBatchJob(job batch.Job) bool {
+	_, ok := h.BatchOwnerAPIKey(job.Owner)
+	return ok
+}
+
+// InvokeBatchRequest runs a request of a batch job through handler on behalf of the job's
+// owner. Requests of owners whose key was removed are refused.
+func (h *BaseAPIHandler) InvokeBatchRequest(ctx context.Context, handler gin.HandlerFunc, job batch.Job, method, path string, body []byte) (int, http.Header, []byte) {
+	apiKey, ok := h.BatchOwnerAPIKey(job.Owner)
+	if !ok {
+		return http.StatusUnauthorized, nil, BuildErrorResponseBody(http.StatusUnauthorized, "the API key that created this batch no longer exists")
+	}
+	return InvokeHandler(ctx, handler, apiKey, method, path, body)
+}
@@ -0,0 +1,29 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestInvokeHandler_BuffersResponse(t *testing.T) {
+	handler := func(c *gin.Context) {
+		body, _ := c.GetRawData()
+		c.Header("X-Route", c.FullPath())
+		c.JSON(http.StatusAccepted, gin.H{"key": ClientAPIKey(c), "body": string(body)})
+	}
+
+	status, header, body := InvokeHandler(context.Background(), handler, "k1", http.MethodPost, "/v1/chat/completions", []byte(`{"a":1}`))
+
+	if status != http.StatusAccepted {
+		t.Fatalf("status = %d, want %d", status, http.StatusAccepted)
+	}
+	if got := header.Get("X-Route"); got != "/v1/chat/completions" {
+		t.Fatalf("expected the handler to see its route, got %q", got)
+	}
+	if string(body) != `{"body":"{\"a\":1}","key":"k1"}` {
+		t.Fatalf("unexpected body %s", body)
+	}
+}
@@ -0,0 +1,180 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/thinking"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/tokencount"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+	"github.com/tidwall/gjson"
+)
+
+// validateModelCapabilities rejects requests the target model cannot serve according to its
+// registered capabilities, before a credential attempt is spent on them. The request passes
+// when any provider serving the model accepts it or has no capability metadata for it.
+func (h *BaseAPIHandler) validateModelCapabilities(ctx context.Context, handlerType, modelName string, providers []string, rawJSON []byte) *interfaces.ErrorMessage {
+	if len(providers) == 0 || !gjson.ValidBytes(rawJSON) {
+		return nil
+	}
+	baseModel := strings.TrimSpace(thinking.ParseSuffix(modelName).ModelName)
+	skipContext := longContextRequested(ctx)
+	reason := ""
+	for _, provider := range providers {
+		problem := checkModelRequest(registry.GetGlobalRegistry().GetModelInfo(baseModel, provider), handlerType, rawJSON, skipContext)
+		if problem == "" {
+			return nil
+		}
+		if reason == "" {
+			reason = problem
+		}
+	}
+	return newFormatErrorMessage(handlerType, http.StatusBadRequest, reason, 0)
+}
+
+// longContextRequested reports whether the client opted into an extended context window
+// through the Anthropic beta header, which the static context lengths do not account for.
+func longContextRequested(ctx context.Context) bool {
+	ginCtx, ok := ctx.Value("gin").(*gin.Context)
+	if !ok || ginCtx == nil || ginCtx.Request == nil {
+		return false
+	}
+	for _, value := range ginCtx.Request.Header.Values("Anthropic-Beta") {
+		if strings.Contains(value, "context-1m") {
+			return true
+		}
+	}
+	return false
+}
+
+// checkModelRequest returns why info cannot serve the request, or "" when it can or when
+// the model has no capability metadata.
+func checkModelRequest(info *registry.ModelInfo, handlerType string, rawJSON []byte, skipContext bool) string {
+	if info == nil || info.Capabilities == nil {
+		return ""
+	}
+	root := gjson.ParseBytes(rawJSON)
+	features := sdktranslator.RequestFeatures(rawJSON)
+	switch {
+	case features.Has(sdktranslator.CapabilityImages) && !info.Capabilities.Vision:
+		return fmt.Sprintf("model %s does not support image inputs", info.ID)
+	case requestUsesAudio(root) && !info.Capabilities.Audio:
+		return fmt.Sprintf("model %s does not support audio inputs", info.ID)
+	case features.Has(sdktranslator.CapabilityTools) && !info.Capabilities.ToolUse:
+		return fmt.Sprintf("model %s does not support tool use", info.ID)
+	case requestUsesJSONSchema(root) && !info.Capabilities.JSONSchema:
+		return fmt.Sprintf("model %s does not support JSON schema output", info.ID)
+	}
+	if limit, requested := info.MaxOutputTokens(), requestedMaxOutputTokens(root); limit > 0 && requested > limit {
+		return fmt.Sprintf("max output tokens %d exceeds the limit of %d for model %s", requested, limit, info.ID)
+	}
+	if window := info.ContextWindow(); window > 0 && !skipContext {
+		if estimate := countPromptTokens(info, handlerType, rawJSON, window); estimate > window {
+			return fmt.Sprintf("prompt is about %d tokens, which exceeds the %d-token context window of model %s", estimate, window, info.ID)
+		}
+	}
+	return ""
+}
+
+// requestUsesAudio reports whether a request carries audio content in any supported format.
+func requestUsesAudio(value gjson.Result) bool {
+	found := false
+	var walk func(gjson.Result)
+	walk = func(v gjson.Result) {
+		if found {
+			return
+		}
+		switch {
+		case v.IsArray():
+			v.ForEach(func(_, item gjson.Result) bool {
+				walk(item)
+				return !found
+			})
+		case v.IsObject():
+			switch v.Get("type").String() {
+			case "input_audio", "audio":
+				found = true
+				return
+			}
+			for _, path := range []string{"inlineData", "inline_data", "fileData", "file_data"} {
+				part := v.Get(path)
+				if !part.IsObject() {
+					continue
+				}
+				mime := part.Get("mimeType").String()
+				if mime == "" {
+					mime = part.Get("mime_type").String()
+				}
+				if strings.HasPrefix(strings.ToLower(mime), "audio/") {
+					found = true
+					return
+				}
+			}
+			v.ForEach(func(_, item gjson.Result) bool {
+				if item.IsObject() || item.IsArray() {
+					walk(item)
+				}
+				return !found
+			})
+		}
+	}
+	walk(value)
+	return found
+}
+
+// requestUsesJSONSchema reports whether a request asks for schema-constrained JSON output.
+func requestUsesJSONSchema(root gjson.Result) bool {
+	for _, path := range []string{"response_format.type", "text.format.type", "output_format.type"} {
+		if root.Get(path).String() == "json_schema" {
+			return true
+		}
+	}
+	for _, section := range []string{"generationConfig", "generation_config", "request.generationConfig"} {
+		for _, field := range []string{"responseSchema", "responseJsonSchema", "response_schema", "response_json_schema"} {
+			if root.Get(section + "." + field).Exists() {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// requestedMaxOutputTokens returns the output token cap the request sets, or 0 when unset.
+func requestedMaxOutputTokens(root gjson.Result) int {
+	for _, path := range []string{
+		"max_tokens",
+		"max_completion_tokens",
+		"max_output_tokens",
+		"generationConfig.maxOutputTokens",
+		"generation_config.max_output_tokens",
+		"request.generationConfig.maxOutputTokens",
+	} {
+		if n := root.Get(path).Int(); n > 0 {
+			return int(n)
+		}
+	}
+	return 0
+}
+
+// countPromptTokens counts the prompt with the local tokenizer of the model. Payloads smaller
+// than half the window are assumed to fit and are not tokenized. Counting errors yield 0,
+// leaving the decision to the upstream.
+func countPromptTokens(info *registry.ModelInfo, handlerType string, rawJSON []byte, window int) int {
+	if len(rawJSON) < window/2 {
+		return 0
+	}
+	counter, err := tokencount.ForModelInfo(info)
+	if err != nil {
+		return 0
+	}
+	count, err := tokencount.CountRequest(counter, handlerType, rawJSON)
+	if err != nil {
+		return 0
+	}
+	return int(count)
+}
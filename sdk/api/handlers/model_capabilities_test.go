@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	"github.com/tidwall/gjson"
+)
+
+func TestValidateModelCapabilities(t *testing.T) {
+	modelRegistry := registry.GetGlobalRegistry()
+	modelRegistry.RegisterClient("test-capabilities-text", "openai-compatibility", []*registry.ModelInfo{{
+		ID:                  "test-capabilities-model",
+		ContextLength:       1000,
+		MaxCompletionTokens: 500,
+		Capabilities:        &registry.ModelCapabilities{ToolUse: true},
+	}})
+	t.Cleanup(func() { modelRegistry.UnregisterClient("test-capabilities-text") })
+
+	handler := &BaseAPIHandler{}
+	providers := []string{"openai-compatibility"}
+	longText := strings.Repeat("word ", 2000)
+
+	tests := []struct {
+		name    string
+		payload string
+		reason  string
+	}{
+		{name: "plain text", payload: `{"messages":[{"role":"user","content":"hi"}]}`},
+		{name: "tools", payload: `{"messages":[{"role":"user","content":"hi"}],"tools":[{"type":"function","function":{"name":"f"}}]}`},
+		{name: "image", payload: `{"messages":[{"role":"user","content":[{"type":"image_url","image_url":{"url":"data:image/png;base64,AA=="}}]}]}`, reason: "does not support image inputs"},
+		{name: "audio", payload: `{"messages":[{"role":"user","content":[{"type":"input_audio","input_audio":{"data":"AA==","format":"wav"}}]}]}`, reason: "does not support audio inputs"},
+		{name: "json schema", payload: `{"messages":[],"response_format":{"type":"json_schema","json_schema":{"name":"x"}}}`, reason: "does not support JSON schema output"},
+		{name: "max output", payload: `{"messages":[],"max_tokens":501}`, reason: "exceeds the limit of 500"},
+		{name: "context window", payload: `{"messages":[{"role":"user","content":"` + longText + `"}]}`, reason: "exceeds the 1000-token context window"},
+		{name: "model suffix", payload: `{"messages":[],"max_completion_tokens":501}`, reason: "exceeds the limit of 500"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			model := "test-capabilities-model"
+			if tt.name == "model suffix" {
+				model += "(high)"
+			}
+			errMsg := handler.validateModelCapabilities(context.Background(), "openai", model, providers, []byte(tt.payload))
+			if tt.reason == "" {
+				if errMsg != nil {
+					t.Fatalf("unexpected rejection: %v", errMsg.Error)
+				}
+				return
+			}
+			if errMsg == nil || errMsg.StatusCode != http.StatusBadRequest {
+				t.Fatalf("expected a 400 rejection, got %+v", errMsg)
+			}
+			if message := gjson.Get(errMsg.Error.Error(), "error.message").String(); !strings.Contains(message, tt.reason) {
+				t.Fatalf("error message %q does not mention %q", message, tt.reason)
+			}
+		})
+	}
+}
+
+func TestValidateModelCapabilitiesAcrossProviders(t *testing.T) {
+	modelRegistry := registry.GetGlobalRegistry()
+	modelRegistry.RegisterClient("test-capabilities-blind", "provider-a", []*registry.ModelInfo{{
+		ID:           "test-capabilities-shared",
+		Capabilities: &registry.ModelCapabilities{},
+	}})
+	t.Cleanup(func() { modelRegistry.UnregisterClient("test-capabilities-blind") })
+
+	handler := &BaseAPIHandler{}
+	image := []byte(`{"messages":[{"role":"user","content":[{"type":"image","source":{"type":"base64","media_type":"image/png","data":"AA=="}}]}]}`)
+
+	errMsg := handler.validateModelCapabilities(context.Background(), "claude", "test-capabilities-shared", []string{"provider-a"}, image)
+	if errMsg == nil {
+		t.Fatal("expected the image request to be rejected")
+	}
+	body := errMsg.Error.Error()
+	if gjson.Get(body, "type").String() != "error" || gjson.Get(body, "error.type").String() != "invalid_request_error" {
+		t.Fatalf("expected a Claude-shaped error body, got %s", body)
+	}
+
+	modelRegistry.RegisterClient("test-capabilities-vision", "provider-b", []*registry.ModelInfo{{
+		ID:           "test-capabilities-shared",
+		Capabilities: &registry.ModelCapabilities{Vision: true},
+	}})
+	t.Cleanup(func() { modelRegistry.UnregisterClient("test-capabilities-vision") })
+	if errMsg = handler.validateModelCapabilities(context.Background(), "claude", "test-capabilities-shared", []string{"provider-a", "provider-b"}, image); errMsg != nil {
+		t.Fatalf("a provider accepting images must let the request through: %v", errMsg.Error)
+	}
+}
@@ -0,0 +1,414 @@
+package openai
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/batch"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+const (
+	// batchFormat identifies OpenAI batch jobs in the batch manager.
+	batchFormat = "openai"
+	// batchFilePurpose is the only upload purpose accepted by /v1/files.
+	batchFilePurpose = "batch"
+	// maxBatchFileBytes mirrors the OpenAI limit for batch input files.
+	maxBatchFileBytes = 200 << 20
+	// maxBatchRequests mirrors the OpenAI limit on requests per batch.
+	maxBatchRequests = 50000
+)
+
+// OpenAIBatchAPIHandler emulates the OpenAI Files and Batch APIs. Batch lines run in the
+// background through the regular OpenAI handlers, so routing, translation, cooldowns and
+// API key policies apply to them as to direct calls.
+type OpenAIBatchAPIHandler struct {
+	*handlers.BaseAPIHandler
+	batches   *batch.Manager
+	endpoints map[string]gin.HandlerFunc
+}
+
+// NewOpenAIBatchAPIHandler creates the batch handlers and registers the OpenAI batch format
+// with the batch manager. Lines are executed by the given OpenAI API handler.
+func NewOpenAIBatchAPIHandler(apiHandlers *handlers.BaseAPIHandler, batches *batch.Manager, openaiHandlers *OpenAIAPIHandler) *OpenAIBatchAPIHandler {
+	h := &OpenAIBatchAPIHandler{
+		BaseAPIHandler: apiHandlers,
+		batches:        batches,
+		endpoints: map[string]gin.HandlerFunc{
+			"/v1/chat/completions": openaiHandlers.ChatCompletions,
+			"/v1/completions":      openaiHandlers.Completions,
+			"/v1/embeddings":       openaiHandlers.Embeddings,
+		},
+	}
+	batches.RegisterHandler(batchFormat, batch.Handler{
+		Execute:      h.executeLine,
+		RenderResult: renderBatchResult,
+		Authorize:    h.AuthorizeBatchJob,
+	})
+	return h
+}
+
+// executeLine runs one batch line through the handler of the batch endpoint.
+func (h *OpenAIBatchAPIHandler) executeLine(ctx context.Context, job batch.Job, req batch.Request) (int, http.Header, []byte) {
+	handler, ok := h.endpoints[job.Endpoint]
+	if !ok {
+		return http.StatusBadRequest, nil, handlers.BuildErrorResponseBody(http.StatusBadRequest, fmt.Sprintf("unsupported endpoint %s", job.Endpoint))
+	}
+	// Batch results are complete responses.
+	body, _ := sjson.DeleteBytes(req.Body, "stream")
+	body, _ = sjson.DeleteBytes(body, "stream_options")
+	return h.InvokeBatchRequest(ctx, handler, job, http.MethodPost, job.Endpoint, body)
+}
+
+// renderBatchResult formats a result as a line of an OpenAI batch output or error file.
+func renderBatchResult(_ batch.Job, result batch.Result) []byte {
+	line := map[string]any{
+		"id":        result.ID,
+		"custom_id": result.CustomID,
+		"response":  nil,
+		"error":     nil,
+	}
+	if result.Error != nil {
+		line["error"] = map[string]any{"code": result.Error.Code, "message": result.Error.Message}
+	} else {
+		line["response"] = map[string]any{
+			"status_code": result.StatusCode,
+			"request_id":  result.ID,
+			"body":        result.Body,
+		}
+	}
+	data, _ := json.Marshal(line)
+	return data
+}
+
+// UploadFile handles POST /v1/files. Only batch input files are accepted.
+func (h *OpenAIBatchAPIHandler) UploadFile(c *gin.Context) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxBatchFileBytes+1<<20)
+	purpose := strings.TrimSpace(c.PostForm("purpose"))
+	if purpose != batchFilePurpose {
+		writeBatchError(c, http.StatusBadRequest, "invalid_request_error", fmt.Sprintf("Invalid purpose %q: only %q files are supported", purpose, batchFilePurpose))
+		return
+	}
+	header, err := c.FormFile("file")
+	if err != nil {
+		writeBatchError(c, http.StatusBadRequest, "invalid_request_error", fmt.Sprintf("Invalid request: file is required: %v", err))
+		return
+	}
+	if header.Size > maxBatchFileBytes {
+		writeBatchError(c, http.StatusBadRequest, "invalid_request_error", fmt.Sprintf("File exceeds the %d MB limit", maxBatchFileBytes>>20))
+		return
+	}
+	src, err := header.Open()
+	if err != nil {
+		writeBatchError(c, http.StatusBadRequest, "invalid_request_error", fmt.Sprintf("Invalid request: %v", err))
+		return
+	}
+	data, err := io.ReadAll(src)
+	_ = src.Close()
+	if err != nil {
+		writeBatchError(c, http.StatusBadRequest, "invalid_request_error", fmt.Sprintf("Invalid request: %v", err))
+		return
+	}
+	file, err := h.batches.CreateFile(handlers.ClientAPIKey(c), purpose, header.Filename, data)
+	if err != nil {
+		writeBatchError(c, http.StatusInternalServerError, "server_error", err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, fileObject(file))
+}
+
+// ListFiles handles GET /v1/files.
+func (h *OpenAIBatchAPIHandler) ListFiles(c *gin.Context) {
+	files := h.batches.Files(handlers.ClientAPIKey(c), c.Query("purpose"))
+	data := make([]map[string]any, 0, len(files))
+	for _, file := range files {
+		data = append(data, fileObject(file))
+	}
+	c.JSON(http.StatusOK, gin.H{"object": "list", "data": data, "has_more": false})
+}
+
+// GetFile handles GET /v1/files/:file_id.
+func (h *OpenAIBatchAPIHandler) GetFile(c *gin.Context) {
+	file, err := h.batches.File(c.Param("file_id"), handlers.ClientAPIKey(c))
+	if err != nil {
+		writeBatchLookupError(c, err, "file")
+		return
+	}
+	c.JSON(http.StatusOK, fileObject(file))
+}
+
+// GetFileContent handles GET /v1/files/:file_id/content.
+func (h *OpenAIBatchAPIHandler) GetFileContent(c *gin.Context) {
+	data, err := h.batches.FileContent(c.Param("file_id"), handlers.ClientAPIKey(c))
+	if err != nil {
+		writeBatchLookupError(c, err, "file")
+		return
+	}
+	c.Data(http.StatusOK, "application/octet-stream", data)
+}
+
+// DeleteFile handles DELETE /v1/files/:file_id.
+func (h *OpenAIBatchAPIHandler) DeleteFile(c *gin.Context) {
+	id := c.Param("file_id")
+	if err := h.batches.DeleteFile(id, handlers.ClientAPIKey(c)); err != nil {
+		writeBatchLookupError(c, err, "file")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"id": id, "object": "file", "deleted": true})
+}
+
+// CreateBatch handles POST /v1/batches. The input file is validated up front; the batch then
+// runs in the background and is polled with GET /v1/batches/:batch_id.
+func (h *OpenAIBatchAPIHandler) CreateBatch(c *gin.Context) {
+	rawJSON, err := c.GetRawData()
+	if err != nil || !gjson.ValidBytes(rawJSON) {
+		writeBatchError(c, http.StatusBadRequest, "invalid_request_error", "Invalid request: body must be a JSON object")
+		return
+	}
+	endpoint := gjson.GetBytes(rawJSON, "endpoint").String()
+	if _, ok := h.endpoints[endpoint]; !ok {
+		writeBatchError(c, http.StatusBadRequest, "invalid_request_error", fmt.Sprintf("Unsupported endpoint %q: use /v1/chat/completions, /v1/completions or /v1/embeddings", endpoint))
+		return
+	}
+	if window := gjson.GetBytes(rawJSON, "completion_window").String(); window != batch.CompletionWindow {
+		writeBatchError(c, http.StatusBadRequest, "invalid_request_error", fmt.Sprintf("Invalid completion_window %q: only %q is supported", window, batch.CompletionWindow))
+		return
+	}
+	metadata := make(map[string]string)
+	gjson.GetBytes(rawJSON, "metadata").ForEach(func(key, value gjson.Result) bool {
+		metadata[key.String()] = value.String()
+		return true
+	})
+
+	owner := handlers.ClientAPIKey(c)
+	inputFileID := gjson.GetBytes(rawJSON, "input_file_id").String()
+	file, err := h.batches.File(inputFileID, owner)
+	if err != nil || file.Purpose != batchFilePurpose {
+		writeBatchError(c, http.StatusBadRequest, "invalid_request_error", fmt.Sprintf("Invalid input_file_id %q: no batch input file with this id", inputFileID))
+		return
+	}
+	data, err := h.batches.FileContent(inputFileID, owner)
+	if err != nil {
+		writeBatchError(c, http.StatusInternalServerError, "server_error", err.Error())
+		return
+	}
+	requests, err := parseBatchInput(data, endpoint)
+	if err != nil {
+		writeBatchError(c, http.StatusBadRequest, "invalid_request_error", err.Error())
+		return
+	}
+
+	job, err := h.batches.CreateJob(batch.Job{
+		Format:           batchFormat,
+		Owner:            owner,
+		Endpoint:         endpoint,
+		InputFileID:      inputFileID,
+		CompletionWindow: batch.CompletionWindow,
+		Metadata:         metadata,
+	}, requests)
+	if err != nil {
+		writeBatchError(c, http.StatusInternalServerError, "server_error", err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, batchObject(job))
+}
+
+// parseBatchInput validates a JSONL batch input file and returns its requests.
+func parseBatchInput(data []byte, endpoint string) ([]batch.Request, error) {
+	var requests []batch.Request
+	seen := make(map[string]struct{})
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64<<10), maxBatchFileBytes)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		if !gjson.ValidBytes(line) {
+			return nil, fmt.Errorf("Line %d: invalid JSON", lineNo)
+		}
+		customID := gjson.GetBytes(line, "custom_id").String()
+		if customID == "" {
+			return nil, fmt.Errorf("Line %d: custom_id is required", lineNo)
+		}
+		if _, dup := seen[customID]; dup {
+			return nil, fmt.Errorf("Line %d: duplicate custom_id %q", lineNo, customID)
+		}
+		seen[customID] = struct{}{}
+		if method := gjson.GetBytes(line, "method").String(); method != "" && !strings.EqualFold(method, http.MethodPost) {
+			return nil, fmt.Errorf("Line %d: method must be POST", lineNo)
+		}
+		if url := gjson.GetBytes(line, "url").String(); url != endpoint {
+			return nil, fmt.Errorf("Line %d: url %q does not match the batch endpoint %s", lineNo, url, endpoint)
+		}
+		body := gjson.GetBytes(line, "body")
+		if !body.IsObject() || body.Get("model").String() == "" {
+			return nil, fmt.Errorf("Line %d: body must be an object with a model", lineNo)
+		}
+		requests = append(requests, batch.Request{
+			CustomID: customID,
+			Method:   http.MethodPost,
+			URL:      endpoint,
+			Body:     json.RawMessage(body.Raw),
+		})
+		if len(requests) > maxBatchRequests {
+			return nil, fmt.Errorf("Batch exceeds the limit of %d requests", maxBatchRequests)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("Invalid input file: %v", err)
+	}
+	if len(requests) == 0 {
+		return nil, fmt.Errorf("Input file contains no requests")
+	}
+	return requests, nil
+}
+
+// GetBatch handles GET /v1/batches/:batch_id.
+func (h *OpenAIBatchAPIHandler) GetBatch(c *gin.Context) {
+	job, err := h.job(c)
+	if err != nil {
+		writeBatchLookupError(c, err, "batch")
+		return
+	}
+	c.JSON(http.StatusOK, batchObject(job))
+}
+
+// job returns the OpenAI batch named in the path for the calling key.
+func (h *OpenAIBatchAPIHandler) job(c *gin.Context) (batch.Job, error) {
+	job, err := h.batches.Job(c.Param("batch_id"), handlers.ClientAPIKey(c))
+	if err == nil && job.Format != batchFormat {
+		err = batch.ErrNotFound
+	}
+	return job, err
+}
+
+// ListBatches handles GET /v1/batches with the after and limit pagination parameters.
+func (h *OpenAIBatchAPIHandler) ListBatches(c *gin.Context) {
+	jobs := h.batches.Jobs(handlers.ClientAPIKey(c), batchFormat)
+	if after := c.Query("after"); after != "" {
+		for i, job := range jobs {
+			if job.ID == after {
+				jobs = jobs[i+1:]
+				break
+			}
+		}
+	}
+	limit := 20
+	if value, err := strconv.Atoi(c.Query("limit")); err == nil && value > 0 {
+		limit = min(value, 100)
+	}
+	hasMore := len(jobs) > limit
+	if hasMore {
+		jobs = jobs[:limit]
+	}
+	data := make([]map[string]any, 0, len(jobs))
+	for _, job := range jobs {
+		data = append(data, batchObject(job))
+	}
+	var firstID, lastID any
+	if len(jobs) > 0 {
+		firstID, lastID = jobs[0].ID, jobs[len(jobs)-1].ID
+	}
+	c.JSON(http.StatusOK, gin.H{"object": "list", "data": data, "first_id": firstID, "last_id": lastID, "has_more": hasMore})
+}
+
+// CancelBatch handles POST /v1/batches/:batch_id/cancel.
+func (h *OpenAIBatchAPIHandler) CancelBatch(c *gin.Context) {
+	job, err := h.job(c)
+	if err == nil {
+		job, err = h.batches.CancelJob(job.ID, job.Owner)
+	}
+	if err != nil {
+		writeBatchLookupError(c, err, "batch")
+		return
+	}
+	c.JSON(http.StatusOK, batchObject(job))
+}
+
+func fileObject(file batch.File) map[string]any {
+	return map[string]any{
+		"id":         file.ID,
+		"object":     "file",
+		"bytes":      file.Bytes,
+		"created_at": file.CreatedAt,
+		"filename":   file.Filename,
+		"purpose":    file.Purpose,
+		"status":     "processed",
+	}
+}
+
+func batchObject(job batch.Job) map[string]any {
+	timestamp := func(value int64) any {
+		if value == 0 {
+			return nil
+		}
+		return value
+	}
+	optional := func(value string) any {
+		if value == "" {
+			return nil
+		}
+		return value
+	}
+	var metadata any
+	if len(job.Metadata) > 0 {
+		metadata = job.Metadata
+	}
+	return map[string]any{
+		"id":                job.ID,
+		"object":            "batch",
+		"endpoint":          job.Endpoint,
+		"errors":            nil,
+		"input_file_id":     job.InputFileID,
+		"completion_window": job.CompletionWindow,
+		"status":            job.Status,
+		"output_file_id":    optional(job.OutputFileID),
+		"error_file_id":     optional(job.ErrorFileID),
+		"created_at":        job.CreatedAt,
+		"in_progress_at":    timestamp(job.InProgressAt),
+		"expires_at":        timestamp(job.ExpiresAt),
+		"finalizing_at":     timestamp(job.FinalizingAt),
+		"completed_at":      timestamp(job.CompletedAt),
+		"failed_at":         nil,
+		"expired_at":        timestamp(job.ExpiredAt),
+		"cancelling_at":     timestamp(job.CancellingAt),
+		"cancelled_at":      timestamp(job.CancelledAt),
+		"request_counts": map[string]int{
+			"total":     job.Counts.Total,
+			"completed": job.Counts.Succeeded,
+			"failed":    job.Counts.Failed(),
+		},
+		"metadata": metadata,
+	}
+}
+
+func writeBatchError(c *gin.Context, status int, errType, message string) {
+	c.JSON(status, handlers.ErrorResponse{
+		Error: handlers.ErrorDetail{
+			Message: message,
+			Type:    errType,
+		},
+	})
+}
+
+func writeBatchLookupError(c *gin.Context, err error, kind string) {
+	if errors.Is(err, batch.ErrNotFound) {
+		writeBatchError(c, http.StatusNotFound, "invalid_request_error", fmt.Sprintf("No such %s: %s", kind, c.Param(kind+"_id")))
+		return
+	}
+	writeBatchError(c, http.StatusInternalServerError, "server_error", err.Error())
+}
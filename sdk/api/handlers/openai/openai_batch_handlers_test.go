@@ -0,0 +1,172 @@
+package openai
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/batch"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	coreexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+	"github.com/tidwall/gjson"
+)
+
+type batchEchoExecutor struct{}
+
+func (e *batchEchoExecutor) Identifier() string { return "batch-test-provider" }
+
+func (e *batchEchoExecutor) Execute(_ context.Context, _ *coreauth.Auth, req coreexecutor.Request, opts coreexecutor.Options) (coreexecutor.Response, error) {
+	if opts.Stream {
+		return coreexecutor.Response{}, errors.New("batch lines must not stream")
+	}
+	content := gjson.GetBytes(req.Payload, "messages.0.content").String()
+	return coreexecutor.Response{Payload: []byte(`{"object":"chat.completion","choices":[{"index":0,"message":{"role":"assistant","content":"echo ` + content + `"}}]}`)}, nil
+}
+
+func (e *batchEchoExecutor) ExecuteStream(context.Context, *coreauth.Auth, coreexecutor.Request, coreexecutor.Options) (<-chan coreexecutor.StreamChunk, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (e *batchEchoExecutor) Refresh(ctx context.Context, auth *coreauth.Auth) (*coreauth.Auth, error) {
+	return auth, nil
+}
+
+func (e *batchEchoExecutor) CountTokens(context.Context, *coreauth.Auth, coreexecutor.Request, coreexecutor.Options) (coreexecutor.Response, error) {
+	return coreexecutor.Response{}, errors.New("not implemented")
+}
+
+func (e *batchEchoExecutor) HttpRequest(context.Context, *coreauth.Auth, *http.Request) (*http.Response, error) {
+	return nil, errors.New("not implemented")
+}
+
+func setupBatchRouter(t *testing.T) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	executor := &batchEchoExecutor{}
+	manager := coreauth.NewManager(nil, nil, nil)
+	manager.RegisterExecutor(executor)
+	auth := &coreauth.Auth{ID: "batch-auth", Provider: executor.Identifier(), Status: coreauth.StatusActive}
+	if _, err := manager.Register(context.Background(), auth); err != nil {
+		t.Fatalf("Register auth: %v", err)
+	}
+	registry.GetGlobalRegistry().RegisterClient(auth.ID, auth.Provider, []*registry.ModelInfo{{ID: "batch-model"}})
+	t.Cleanup(func() {
+		registry.GetGlobalRegistry().UnregisterClient(auth.ID)
+	})
+
+	batches, err := batch.NewManager(t.TempDir(), batch.Options{})
+	if err != nil {
+		t.Fatalf("NewManager: %v", err)
+	}
+	t.Cleanup(batches.Stop)
+	base := handlers.NewBaseAPIHandlers(&sdkconfig.SDKConfig{APIKeys: sdkconfig.APIKeyEntries("key-a", "key-b")}, manager)
+	h := NewOpenAIBatchAPIHandler(base, batches, NewOpenAIAPIHandler(base))
+	batches.Start()
+
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set("apiKey", c.GetHeader("Authorization"))
+	})
+	router.POST("/v1/files", h.UploadFile)
+	router.GET("/v1/files/:file_id/content", h.GetFileContent)
+	router.POST("/v1/batches", h.CreateBatch)
+	router.GET("/v1/batches/:batch_id", h.GetBatch)
+	return router
+}
+
+func serveBatchRequest(router *gin.Engine, req *http.Request, apiKey string) *httptest.ResponseRecorder {
+	req.Header.Set("Authorization", apiKey)
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+	return resp
+}
+
+func TestOpenAIBatch_RunsUploadedFileAndReturnsOutput(t *testing.T) {
+	router := setupBatchRouter(t)
+
+	input := `{"custom_id":"r1","method":"POST","url":"/v1/chat/completions","body":{"model":"batch-model","stream":true,"messages":[{"role":"user","content":"one"}]}}
+{"custom_id":"r2","method":"POST","url":"/v1/chat/completions","body":{"model":"batch-model","messages":[{"role":"user","content":"two"}]}}
+`
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	_ = writer.WriteField("purpose", "batch")
+	part, _ := writer.CreateFormFile("file", "input.jsonl")
+	_, _ = part.Write([]byte(input))
+	_ = writer.Close()
+	upload := httptest.NewRequest(http.MethodPost, "/v1/files", &body)
+	upload.Header.Set("Content-Type", writer.FormDataContentType())
+	resp := serveBatchRequest(router, upload, "key-a")
+	if resp.Code != http.StatusOK {
+		t.Fatalf("upload status = %d, body %s", resp.Code, resp.Body.String())
+	}
+	fileID := gjson.Get(resp.Body.String(), "id").String()
+
+	create := httptest.NewRequest(http.MethodPost, "/v1/batches", strings.NewReader(`{"input_file_id":"`+fileID+`","endpoint":"/v1/chat/completions","completion_window":"24h","metadata":{"job":"nightly"}}`))
+	resp = serveBatchRequest(router, create, "key-a")
+	if resp.Code != http.StatusOK {
+		t.Fatalf("create status = %d, body %s", resp.Code, resp.Body.String())
+	}
+	batchID := gjson.Get(resp.Body.String(), "id").String()
+	if gjson.Get(resp.Body.String(), "metadata.job").String() != "nightly" {
+		t.Fatalf("expected metadata to be kept, got %s", resp.Body.String())
+	}
+
+	// Batches are only visible to the key that created them.
+	if other := serveBatchRequest(router, httptest.NewRequest(http.MethodGet, "/v1/batches/"+batchID, nil), "key-b"); other.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 for another key, got %d", other.Code)
+	}
+
+	var status string
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		resp = serveBatchRequest(router, httptest.NewRequest(http.MethodGet, "/v1/batches/"+batchID, nil), "key-a")
+		status = gjson.Get(resp.Body.String(), "status").String()
+		if status == batch.StatusCompleted {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if status != batch.StatusCompleted {
+		t.Fatalf("batch did not complete, last response %s", resp.Body.String())
+	}
+	if completed := gjson.Get(resp.Body.String(), "request_counts.completed").Int(); completed != 2 {
+		t.Fatalf("expected 2 completed requests, got %s", resp.Body.String())
+	}
+
+	outputID := gjson.Get(resp.Body.String(), "output_file_id").String()
+	resp = serveBatchRequest(router, httptest.NewRequest(http.MethodGet, "/v1/files/"+outputID+"/content", nil), "key-a")
+	if resp.Code != http.StatusOK {
+		t.Fatalf("content status = %d", resp.Code)
+	}
+	answers := make(map[string]string)
+	for _, line := range strings.Split(strings.TrimSpace(resp.Body.String()), "\n") {
+		if code := gjson.Get(line, "response.status_code").Int(); code != http.StatusOK {
+			t.Fatalf("unexpected output line %s", line)
+		}
+		answers[gjson.Get(line, "custom_id").String()] = gjson.Get(line, "response.body.choices.0.message.content").String()
+	}
+	if answers["r1"] != "echo one" || answers["r2"] != "echo two" {
+		t.Fatalf("unexpected answers %v", answers)
+	}
+}
+
+func TestOpenAIBatch_RejectsInvalidInput(t *testing.T) {
+	requests, err := parseBatchInput([]byte(`{"custom_id":"a","url":"/v1/embeddings","body":{"model":"m"}}`), "/v1/chat/completions")
+	if err == nil || !strings.Contains(err.Error(), "Line 1") {
+		t.Fatalf("expected a url mismatch error, got %v (%d requests)", err, len(requests))
+	}
+	_, err = parseBatchInput([]byte("{\"custom_id\":\"a\",\"url\":\"/v1/chat/completions\",\"body\":{\"model\":\"m\"}}\n{\"custom_id\":\"a\",\"url\":\"/v1/chat/completions\",\"body\":{\"model\":\"m\"}}"), "/v1/chat/completions")
+	if err == nil || !strings.Contains(err.Error(), "duplicate custom_id") {
+		t.Fatalf("expected a duplicate custom_id error, got %v", err)
+	}
+}
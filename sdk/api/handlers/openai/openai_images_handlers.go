@@ -0,0 +1,179 @@
+package openai
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/misc"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// Executor Alt values that select the image endpoints upstream.
+const (
+	imagesGenerationsAlt = "images/generations"
+	imagesEditsAlt       = "images/edits"
+)
+
+// maxImageUploadBytes bounds the multipart form accepted by the edits endpoint.
+const maxImageUploadBytes = 50 << 20
+
+// ImagesGenerations handles the /v1/images/generations endpoint.
+// Gemini-family providers serve it through their image-capable chat models, while
+// OpenAI-compatible providers receive the request unchanged.
+//
+// Parameters:
+//   - c: The Gin context containing the HTTP request and response
+func (h *OpenAIAPIHandler) ImagesGenerations(c *gin.Context) {
+	rawJSON, err := c.GetRawData()
+	if err != nil {
+		writeImagesBadRequest(c, fmt.Sprintf("Invalid request: %v", err))
+		return
+	}
+	h.executeImages(c, rawJSON, imagesGenerationsAlt)
+}
+
+// ImagesEdits handles the /v1/images/edits endpoint.
+// Multipart uploads are converted to a JSON body carrying the images and mask as
+// base64 data URLs so every provider receives the same request shape.
+//
+// Parameters:
+//   - c: The Gin context containing the HTTP request and response
+func (h *OpenAIAPIHandler) ImagesEdits(c *gin.Context) {
+	var rawJSON []byte
+	var err error
+	if strings.HasPrefix(c.ContentType(), "multipart/") {
+		rawJSON, err = imagesEditsFormToJSON(c)
+	} else {
+		rawJSON, err = c.GetRawData()
+	}
+	if err != nil {
+		writeImagesBadRequest(c, fmt.Sprintf("Invalid request: %v", err))
+		return
+	}
+	if !gjson.GetBytes(rawJSON, "image").Exists() && !gjson.GetBytes(rawJSON, "images").Exists() {
+		writeImagesBadRequest(c, "Invalid request: image is required")
+		return
+	}
+	h.executeImages(c, rawJSON, imagesEditsAlt)
+}
+
+func (h *OpenAIAPIHandler) executeImages(c *gin.Context, rawJSON []byte, alt string) {
+	modelName := gjson.GetBytes(rawJSON, "model").String()
+	if modelName == "" {
+		writeImagesBadRequest(c, "Invalid request: model is required")
+		return
+	}
+	if strings.TrimSpace(gjson.GetBytes(rawJSON, "prompt").String()) == "" {
+		writeImagesBadRequest(c, "Invalid request: prompt is required")
+		return
+	}
+	if gjson.GetBytes(rawJSON, "stream").Bool() {
+		writeImagesBadRequest(c, "Streaming not supported for image requests")
+		return
+	}
+
+	c.Header("Content-Type", "application/json")
+	cliCtx, cliCancel := h.GetContextWithCancel(h, c, context.Background())
+	stopKeepAlive := h.StartNonStreamingKeepAlive(c, cliCtx)
+	resp, errMsg := h.ExecuteWithAuthManager(cliCtx, h.HandlerType(), modelName, rawJSON, alt)
+	stopKeepAlive()
+	if errMsg != nil {
+		h.WriteErrorResponse(c, errMsg)
+		cliCancel(errMsg.Error)
+		return
+	}
+	_, _ = c.Writer.Write(resp)
+	cliCancel()
+}
+
+// imagesEditsFormToJSON converts an OpenAI multipart edits request into JSON. Scalar form
+// fields are copied (numeric ones as numbers), "image"/"image[]" files become the "image"
+// array and the optional "mask" file becomes "mask", each as a data URL.
+func imagesEditsFormToJSON(c *gin.Context) ([]byte, error) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxImageUploadBytes)
+	form, err := c.MultipartForm()
+	if err != nil {
+		return nil, err
+	}
+	out := []byte(`{}`)
+	for key, values := range form.Value {
+		if len(values) == 0 {
+			continue
+		}
+		value := values[0]
+		if key == "n" {
+			if n, errAtoi := strconv.Atoi(value); errAtoi == nil {
+				out, _ = sjson.SetBytes(out, key, n)
+				continue
+			}
+		}
+		out, _ = sjson.SetBytes(out, key, value)
+	}
+
+	var images []string
+	for _, field := range []string{"image", "image[]"} {
+		for _, file := range form.File[field] {
+			dataURL, errRead := fileToDataURL(file)
+			if errRead != nil {
+				return nil, errRead
+			}
+			images = append(images, dataURL)
+		}
+	}
+	if len(images) > 0 {
+		out, _ = sjson.SetBytes(out, "image", images)
+	}
+	if masks := form.File["mask"]; len(masks) > 0 {
+		dataURL, errRead := fileToDataURL(masks[0])
+		if errRead != nil {
+			return nil, errRead
+		}
+		out, _ = sjson.SetBytes(out, "mask", dataURL)
+	}
+	return out, nil
+}
+
+// fileToDataURL reads an uploaded file into a base64 data URL. The mime type comes from the
+// part header, then the file extension, then content sniffing.
+func fileToDataURL(header *multipart.FileHeader) (string, error) {
+	file, err := header.Open()
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return "", err
+	}
+	mimeType := header.Header.Get("Content-Type")
+	if mimeType == "" || mimeType == "application/octet-stream" {
+		ext := strings.TrimPrefix(strings.ToLower(filepath.Ext(header.Filename)), ".")
+		if known, ok := misc.MimeTypes[ext]; ok {
+			mimeType = known
+		} else {
+			mimeType = http.DetectContentType(data)
+		}
+	}
+	return "data:" + mimeType + ";base64," + base64.StdEncoding.EncodeToString(data), nil
+}
+
+func writeImagesBadRequest(c *gin.Context, message string) {
+	c.JSON(http.StatusBadRequest, handlers.ErrorResponse{
+		Error: handlers.ErrorDetail{
+			Message: message,
+			Type:    "invalid_request_error",
+		},
+	})
+}
@@ -0,0 +1,262 @@
+package handlers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
+	log "github.com/sirupsen/logrus"
+)
+
+// requestDedup coalesces concurrent identical requests. The first caller starts a flight that
+// runs the upstream call detached from its own cancellation; callers arriving while it is in
+// flight join it and receive the same result. A flight is cancelled once every caller left.
+type requestDedup struct {
+	mu      sync.Mutex
+	flights map[string]*requestFlight
+}
+
+// requestFlight is one shared upstream call and the output it produced so far.
+type requestFlight struct {
+	// waiters counts the callers still attached; it is guarded by requestDedup.mu.
+	waiters int
+	cancel  context.CancelFunc
+
+	mu sync.Mutex
+	// ginCtx is the leader's gin context, used for request logging until the leader leaves;
+	// gin recycles it once the leader's handler returns.
+	ginCtx  *gin.Context
+	chunks  [][]byte
+	err     *interfaces.ErrorMessage
+	done    bool
+	updated chan struct{}
+}
+
+// requestDedupKey identifies requests that can share one upstream call. Providers are part of
+// the key so that callers restricted to different providers by API key policies never share.
+// The client key is part of it as well, since only the leader's usage is recorded and every
+// key must be charged for its own requests.
+func requestDedupKey(stream bool, apiKey, handlerType, alt, model string, providers []string, rawJSON []byte) string {
+	h := sha256.New()
+	_, _ = fmt.Fprintf(h, "%t\x00%s\x00%s\x00%s\x00%s\x00%s\x00", stream, apiKey, handlerType, alt, model, strings.Join(providers, ","))
+	h.Write(rawJSON)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// join attaches the caller to the flight for key, starting it with run when none is in flight.
+// run receives a context detached from ctx and must publish its output on the flight.
+func (d *requestDedup) join(ctx context.Context, key string, run func(context.Context, *requestFlight)) (*requestFlight, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if flight, ok := d.flights[key]; ok {
+		flight.waiters++
+		return flight, false
+	}
+	if d.flights == nil {
+		d.flights = make(map[string]*requestFlight)
+	}
+	detached, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	flight := &requestFlight{waiters: 1, cancel: cancel, updated: make(chan struct{})}
+	flight.ginCtx, _ = ctx.Value("gin").(*gin.Context)
+	sharedCtx := &flightContext{Context: detached, flight: flight}
+	d.flights[key] = flight
+	go func() {
+		run(sharedCtx, flight)
+		flight.finish(nil)
+		d.forget(key, flight)
+		cancel()
+	}()
+	return flight, true
+}
+
+// leave detaches a caller; the last one to leave cancels the upstream call if it is still
+// running.
+func (d *requestDedup) leave(key string, flight *requestFlight, leader bool) {
+	if leader {
+		flight.mu.Lock()
+		flight.ginCtx = nil
+		flight.mu.Unlock()
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	flight.waiters--
+	if flight.waiters > 0 {
+		return
+	}
+	if d.flights[key] == flight {
+		delete(d.flights, key)
+	}
+	flight.cancel()
+}
+
+// forget removes a finished flight so that later requests start a new one.
+func (d *requestDedup) forget(key string, flight *requestFlight) {
+	d.mu.Lock()
+	if d.flights[key] == flight {
+		delete(d.flights, key)
+	}
+	d.mu.Unlock()
+}
+
+// flightContext is the context a flight runs under. It outlives the leader's request but only
+// exposes the leader's gin context while the leader is still attached.
+type flightContext struct {
+	context.Context
+	flight *requestFlight
+}
+
+// Value implements context.Context.
+func (c *flightContext) Value(key any) any {
+	if name, ok := key.(string); ok && name == "gin" {
+		c.flight.mu.Lock()
+		defer c.flight.mu.Unlock()
+		if c.flight.ginCtx == nil {
+			return nil
+		}
+		return c.flight.ginCtx
+	}
+	return c.Context.Value(key)
+}
+
+// publish appends an output chunk and wakes the waiters.
+func (f *requestFlight) publish(chunk []byte) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.done {
+		return
+	}
+	f.chunks = append(f.chunks, chunk)
+	close(f.updated)
+	f.updated = make(chan struct{})
+}
+
+// finish marks the flight complete with an optional error. Only the first call has effect.
+func (f *requestFlight) finish(err *interfaces.ErrorMessage) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.done {
+		return
+	}
+	f.err = err
+	f.done = true
+	close(f.updated)
+}
+
+// next returns the chunks after position from, whether the flight is complete with its error,
+// and a channel that is closed on the next update.
+func (f *requestFlight) next(from int) ([][]byte, bool, *interfaces.ErrorMessage, <-chan struct{}) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.chunks[from:], f.done, f.err, f.updated
+}
+
+// coalesceNonStreaming runs execute once for all concurrent callers with the same key.
+func (h *BaseAPIHandler) coalesceNonStreaming(ctx context.Context, key string, execute func(context.Context) ([]byte, *interfaces.ErrorMessage)) ([]byte, *interfaces.ErrorMessage) {
+	flight, leader := h.dedup.join(ctx, key, func(sharedCtx context.Context, flight *requestFlight) {
+		payload, errMsg := execute(sharedCtx)
+		if errMsg == nil {
+			flight.publish(payload)
+		}
+		flight.finish(errMsg)
+	})
+	if !leader {
+		markRequestCoalesced(ctx)
+	}
+	for {
+		chunks, done, errMsg, updated := flight.next(0)
+		if done {
+			h.dedup.leave(key, flight, leader)
+			if errMsg != nil {
+				return nil, errMsg
+			}
+			if len(chunks) == 0 {
+				return nil, nil
+			}
+			return cloneBytes(chunks[0]), nil
+		}
+		select {
+		case <-ctx.Done():
+			h.dedup.leave(key, flight, leader)
+			return nil, &interfaces.ErrorMessage{StatusCode: http.StatusInternalServerError, Error: ctx.Err()}
+		case <-updated:
+		}
+	}
+}
+
+// coalesceStreaming starts or joins the shared stream for key. Callers joining late first
+// receive the chunks already produced, so every client sees the complete stream.
+func (h *BaseAPIHandler) coalesceStreaming(ctx context.Context, key string, execute func(context.Context) (<-chan []byte, <-chan *interfaces.ErrorMessage)) (<-chan []byte, <-chan *interfaces.ErrorMessage) {
+	flight, leader := h.dedup.join(ctx, key, func(sharedCtx context.Context, flight *requestFlight) {
+		dataChan, errChan := execute(sharedCtx)
+		if dataChan != nil {
+			for chunk := range dataChan {
+				flight.publish(chunk)
+			}
+		}
+		var errMsg *interfaces.ErrorMessage
+		if errChan != nil {
+			for msg := range errChan {
+				if msg != nil && errMsg == nil {
+					errMsg = msg
+				}
+			}
+		}
+		flight.finish(errMsg)
+	})
+	if !leader {
+		markRequestCoalesced(ctx)
+	}
+
+	dataChan := make(chan []byte)
+	errChan := make(chan *interfaces.ErrorMessage, 1)
+	go func() {
+		defer close(dataChan)
+		defer close(errChan)
+		defer h.dedup.leave(key, flight, leader)
+		sent := 0
+		for {
+			chunks, done, errMsg, updated := flight.next(sent)
+			for _, chunk := range chunks {
+				select {
+				case <-ctx.Done():
+					return
+				case dataChan <- cloneBytes(chunk):
+					sent++
+				}
+			}
+			if done && len(chunks) == 0 {
+				if errMsg != nil {
+					errChan <- errMsg
+				}
+				return
+			}
+			if len(chunks) > 0 {
+				continue
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-updated:
+			}
+		}
+	}()
+	return dataChan, errChan
+}
+
+// markRequestCoalesced tells the client and the request log that the request shared the
+// upstream call of an identical in-flight request.
+func markRequestCoalesced(ctx context.Context) {
+	ginCtx, ok := ctx.Value("gin").(*gin.Context)
+	if !ok || ginCtx == nil {
+		return
+	}
+	log.Debugf("request dedup: joined an in-flight identical request for %s", ginCtx.FullPath())
+	ginCtx.Header("X-Request-Coalesced", "true")
+	appendAPIResponse(ginCtx, []byte("[coalesced with an identical in-flight request; no separate upstream request was made]"))
+}
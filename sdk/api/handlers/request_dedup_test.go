@@ -0,0 +1,201 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	coreexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+)
+
+// gatedExecutor blocks every upstream call until release is closed.
+type gatedExecutor struct {
+	calls   atomic.Int32
+	started chan struct{}
+	release chan struct{}
+}
+
+func (e *gatedExecutor) Identifier() string { return "deduptest" }
+
+func (e *gatedExecutor) Execute(ctx context.Context, _ *coreauth.Auth, _ coreexecutor.Request, _ coreexecutor.Options) (coreexecutor.Response, error) {
+	e.calls.Add(1)
+	e.started <- struct{}{}
+	<-e.release
+	return coreexecutor.Response{Payload: []byte(`{"answer":"shared"}`)}, nil
+}
+
+func (e *gatedExecutor) ExecuteStream(ctx context.Context, _ *coreauth.Auth, _ coreexecutor.Request, _ coreexecutor.Options) (<-chan coreexecutor.StreamChunk, error) {
+	e.calls.Add(1)
+	ch := make(chan coreexecutor.StreamChunk)
+	go func() {
+		defer close(ch)
+		ch <- coreexecutor.StreamChunk{Payload: []byte("first")}
+		e.started <- struct{}{}
+		<-e.release
+		ch <- coreexecutor.StreamChunk{Payload: []byte("second")}
+	}()
+	return ch, nil
+}
+
+func (e *gatedExecutor) Refresh(ctx context.Context, auth *coreauth.Auth) (*coreauth.Auth, error) {
+	return auth, nil
+}
+
+func (e *gatedExecutor) CountTokens(context.Context, *coreauth.Auth, coreexecutor.Request, coreexecutor.Options) (coreexecutor.Response, error) {
+	return coreexecutor.Response{}, &coreauth.Error{Code: "not_implemented", Message: "CountTokens not implemented"}
+}
+
+func (e *gatedExecutor) HttpRequest(ctx context.Context, auth *coreauth.Auth, req *http.Request) (*http.Response, error) {
+	return nil, &coreauth.Error{Code: "not_implemented", Message: "HttpRequest not implemented", HTTPStatus: http.StatusNotImplemented}
+}
+
+func setupDedupHandler(t *testing.T) (*BaseAPIHandler, *gatedExecutor) {
+	t.Helper()
+	executor := &gatedExecutor{started: make(chan struct{}, 1), release: make(chan struct{})}
+	manager := coreauth.NewManager(nil, nil, nil)
+	manager.RegisterExecutor(executor)
+	auth := &coreauth.Auth{ID: "dedup1", Provider: "deduptest", Status: coreauth.StatusActive}
+	if _, err := manager.Register(context.Background(), auth); err != nil {
+		t.Fatalf("manager.Register: %v", err)
+	}
+	registry.GetGlobalRegistry().RegisterClient(auth.ID, "deduptest", []*registry.ModelInfo{{ID: "dedup-model"}})
+	t.Cleanup(func() { registry.GetGlobalRegistry().UnregisterClient(auth.ID) })
+	return NewBaseAPIHandlers(&sdkconfig.SDKConfig{RequestDedup: true}, manager), executor
+}
+
+// waitForWaiters blocks until every in-flight request has n attached callers.
+func waitForWaiters(t *testing.T, h *BaseAPIHandler, n int) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		h.dedup.mu.Lock()
+		ready := len(h.dedup.flights) == 1
+		for _, flight := range h.dedup.flights {
+			ready = ready && flight.waiters == n
+		}
+		h.dedup.mu.Unlock()
+		if ready {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("timed out waiting for %d callers to join the flight", n)
+}
+
+func TestExecuteWithAuthManager_CoalescesConcurrentIdenticalRequests(t *testing.T) {
+	handler, executor := setupDedupHandler(t)
+	raw := []byte(`{"model":"dedup-model","messages":[{"role":"user","content":"hi"}]}`)
+
+	const callers = 3
+	results := make([]string, callers)
+	var wg sync.WaitGroup
+	run := func(i int) {
+		defer wg.Done()
+		payload, errMsg := handler.ExecuteWithAuthManager(context.Background(), "openai", "dedup-model", raw, "")
+		if errMsg != nil {
+			t.Errorf("caller %d: unexpected error %v", i, errMsg.Error)
+			return
+		}
+		results[i] = string(payload)
+	}
+	wg.Add(1)
+	go run(0)
+	<-executor.started
+	for i := 1; i < callers; i++ {
+		wg.Add(1)
+		go run(i)
+	}
+	waitForWaiters(t, handler, callers)
+	close(executor.release)
+	wg.Wait()
+
+	if got := executor.calls.Load(); got != 1 {
+		t.Fatalf("expected one upstream call, got %d", got)
+	}
+	for i, result := range results {
+		if result != `{"answer":"shared"}` {
+			t.Fatalf("caller %d: unexpected payload %q", i, result)
+		}
+	}
+
+	// Once the flight finished, the next identical request goes upstream again.
+	executor.started = make(chan struct{}, 1)
+	if _, errMsg := handler.ExecuteWithAuthManager(context.Background(), "openai", "dedup-model", raw, ""); errMsg != nil {
+		t.Fatalf("unexpected error %v", errMsg.Error)
+	}
+	if got := executor.calls.Load(); got != 2 {
+		t.Fatalf("expected a new upstream call after the flight finished, got %d calls", got)
+	}
+}
+
+func TestExecuteStreamWithAuthManager_LateJoinerReceivesWholeStream(t *testing.T) {
+	handler, executor := setupDedupHandler(t)
+	raw := []byte(`{"model":"dedup-model","stream":true,"messages":[{"role":"user","content":"hi"}]}`)
+
+	collect := func(dataChan <-chan []byte, errChan <-chan *interfaces.ErrorMessage) string {
+		var out strings.Builder
+		for chunk := range dataChan {
+			out.Write(chunk)
+			out.WriteByte('|')
+		}
+		for msg := range errChan {
+			if msg != nil {
+				t.Errorf("unexpected error %v", msg.Error)
+			}
+		}
+		return out.String()
+	}
+
+	leaderData, leaderErr := handler.ExecuteStreamWithAuthManager(context.Background(), "openai", "dedup-model", raw, "")
+	// The first chunk was produced before the follower joins.
+	<-executor.started
+	followerData, followerErr := handler.ExecuteStreamWithAuthManager(context.Background(), "openai", "dedup-model", raw, "")
+	waitForWaiters(t, handler, 2)
+	close(executor.release)
+
+	var leader, follower string
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() { defer wg.Done(); leader = collect(leaderData, leaderErr) }()
+	go func() { defer wg.Done(); follower = collect(followerData, followerErr) }()
+	wg.Wait()
+
+	if got := executor.calls.Load(); got != 1 {
+		t.Fatalf("expected one upstream stream, got %d", got)
+	}
+	if leader != "first|second|" || follower != leader {
+		t.Fatalf("expected both clients to receive the whole stream, got leader %q follower %q", leader, follower)
+	}
+}
+
+func TestExecuteWithAuthManager_DoesNotCoalesceAcrossAPIKeys(t *testing.T) {
+	handler, executor := setupDedupHandler(t)
+	raw := []byte(`{"model":"dedup-model","messages":[{"role":"user","content":"hi"}]}`)
+
+	var wg sync.WaitGroup
+	for _, key := range []string{"k1", "k2"} {
+		wg.Add(1)
+		go func(key string) {
+			defer wg.Done()
+			if _, errMsg := handler.ExecuteWithAuthManager(policyTestContext(key), "openai", "dedup-model", raw, ""); errMsg != nil {
+				t.Errorf("%s: unexpected error %v", key, errMsg.Error)
+			}
+		}(key)
+		// Each key starts its own upstream call while the other one is still in flight.
+		<-executor.started
+	}
+	close(executor.release)
+	wg.Wait()
+
+	if got := executor.calls.Load(); got != 2 {
+		t.Fatalf("expected one upstream call per API key, got %d", got)
+	}
+}
@@ -0,0 +1,346 @@
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// errStreamInterrupted reports an upstream stream that ended without completing its answer,
+// e.g. a Gemini candidate finishing with reason OTHER.
+var errStreamInterrupted = errors.New("upstream stream ended before the response was complete")
+
+// streamContinuation follows the client-visible stream of a request so that, when the
+// upstream dies midway, the request can be re-issued with the partial assistant output as a
+// prefill and the new stream spliced onto what the client already received.
+type streamContinuation struct {
+	format string
+
+	// text is the assistant text the client has received so far.
+	text strings.Builder
+	// tools records that a tool call was streamed; those cannot be resumed via prefill.
+	tools bool
+	// done records that the stream reached its natural end.
+	done bool
+	// failure describes the in-band error event that interrupted the stream, if any.
+	failure error
+
+	// splicing is set once a continuation stream replaces the interrupted one.
+	splicing bool
+	// trimLeading drops the leading whitespace of the continuation text, which the prefill
+	// had trimmed off the end of the partial answer.
+	trimLeading bool
+
+	// id is the OpenAI completion id of the original stream, kept across continuations.
+	id string
+
+	// Claude SSE state: the held event line, whether the following blank line belongs to a
+	// dropped event, the open and next content block indices, and the index remapping.
+	pendingEvent []byte
+	dropBlank    bool
+	openIndex    int
+	openType     string
+	nextIndex    int
+	mapped       bool
+	offset       int
+}
+
+// newStreamContinuation returns a tracker for handlerType, or nil when streams in that
+// format cannot be resumed.
+func newStreamContinuation(handlerType, alt string) *streamContinuation {
+	switch handlerType {
+	case "openai", "claude":
+	case "gemini":
+		if alt != "" {
+			return nil
+		}
+	default:
+		return nil
+	}
+	return &streamContinuation{format: handlerType, openIndex: -1}
+}
+
+// resumable reports whether the interrupted stream can be continued via prefill.
+func (s *streamContinuation) resumable() bool {
+	return !s.tools && !s.done
+}
+
+// request builds the continuation request from the original client payload by appending the
+// partial answer as a trailing assistant message.
+func (s *streamContinuation) request(raw []byte) ([]byte, error) {
+	partial := strings.TrimRight(s.text.String(), " \t\r\n")
+	s.trimLeading = partial != s.text.String()
+	if partial == "" {
+		return raw, nil
+	}
+	switch s.format {
+	case "openai":
+		return sjson.SetBytes(raw, "messages.-1", map[string]any{"role": "assistant", "content": partial})
+	case "claude":
+		return sjson.SetBytes(raw, "messages.-1", map[string]any{
+			"role":    "assistant",
+			"content": []map[string]any{{"type": "text", "text": partial}},
+		})
+	case "gemini":
+		return sjson.SetBytes(raw, "contents.-1", map[string]any{
+			"role":  "model",
+			"parts": []map[string]any{{"text": partial}},
+		})
+	}
+	return nil, fmt.Errorf("stream continuation: unsupported format %s", s.format)
+}
+
+// interruption returns the error describing an in-band interruption.
+func (s *streamContinuation) interruption() error {
+	if s.failure != nil {
+		return s.failure
+	}
+	return errStreamInterrupted
+}
+
+// beginSplice switches the tracker to rewriting the chunks of a continuation stream.
+func (s *streamContinuation) beginSplice() {
+	s.splicing = true
+	s.failure = nil
+	s.mapped = false
+	s.pendingEvent = nil
+	s.dropBlank = false
+}
+
+// process rewrites chunk for the client and records its content. It reports whether the
+// chunk marks the stream as interrupted.
+func (s *streamContinuation) process(chunk []byte) ([]byte, bool) {
+	switch s.format {
+	case "openai":
+		return s.processJSON(chunk, s.processOpenAI)
+	case "claude":
+		return s.processClaude(chunk)
+	case "gemini":
+		return s.processJSON(chunk, s.processGemini)
+	}
+	return chunk, false
+}
+
+// processJSON applies fn to a JSON chunk, keeping an optional SSE "data:" prefix intact.
+func (s *streamContinuation) processJSON(chunk []byte, fn func([]byte) ([]byte, bool)) ([]byte, bool) {
+	trimmed := bytes.TrimSpace(chunk)
+	prefixed := bytes.HasPrefix(trimmed, []byte("data:"))
+	if prefixed {
+		trimmed = bytes.TrimSpace(trimmed[len("data:"):])
+	}
+	if !gjson.ValidBytes(trimmed) {
+		return chunk, false
+	}
+	out, interrupted := fn(trimmed)
+	if out == nil || !prefixed {
+		return out, interrupted
+	}
+	return append(append([]byte("data: "), out...), "\n\n"...), interrupted
+}
+
+// trimContinuationText strips the whitespace already sent before the prefill from the start
+// of the continuation text.
+func (s *streamContinuation) trimContinuationText(text string) string {
+	if !s.splicing || !s.trimLeading {
+		return text
+	}
+	text = strings.TrimLeft(text, " \t\r\n")
+	if text != "" {
+		s.trimLeading = false
+	}
+	return text
+}
+
+func (s *streamContinuation) processOpenAI(data []byte) ([]byte, bool) {
+	choice := gjson.GetBytes(data, "choices.0")
+	delta := choice.Get("delta")
+	if s.splicing {
+		if s.id != "" {
+			data, _ = sjson.SetBytes(data, "id", s.id)
+		}
+		roleOnly := delta.Get("role").Exists() && delta.Get("content").String() == "" &&
+			!delta.Get("tool_calls").Exists() && !delta.Get("reasoning_content").Exists() &&
+			choice.Get("finish_reason").String() == "" && !gjson.GetBytes(data, "usage").Exists()
+		if roleOnly {
+			return nil, false
+		}
+		if content := delta.Get("content"); content.Type == gjson.String && content.String() != "" {
+			text := s.trimContinuationText(content.String())
+			if text != content.String() {
+				data, _ = sjson.SetBytes(data, "choices.0.delta.content", text)
+			}
+		}
+	} else if s.id == "" {
+		s.id = gjson.GetBytes(data, "id").String()
+	}
+
+	delta = gjson.GetBytes(data, "choices.0.delta")
+	s.text.WriteString(delta.Get("content").String())
+	if delta.Get("tool_calls").Exists() {
+		s.tools = true
+	}
+	if reason := choice.Get("finish_reason").String(); reason != "" {
+		if strings.EqualFold(choice.Get("native_finish_reason").String(), "other") {
+			data, _ = sjson.DeleteBytes(data, "choices.0.finish_reason")
+			data, _ = sjson.DeleteBytes(data, "choices.0.native_finish_reason")
+			return data, true
+		}
+		s.done = true
+	}
+	return data, false
+}
+
+func (s *streamContinuation) processGemini(data []byte) ([]byte, bool) {
+	candidate := gjson.GetBytes(data, "candidates.0")
+	candidate.Get("content.parts").ForEach(func(key, part gjson.Result) bool {
+		if part.Get("functionCall").Exists() {
+			s.tools = true
+		}
+		text := part.Get("text")
+		if !text.Exists() || part.Get("thought").Bool() {
+			return true
+		}
+		value := s.trimContinuationText(text.String())
+		if value != text.String() {
+			data, _ = sjson.SetBytes(data, "candidates.0.content.parts."+key.String()+".text", value)
+		}
+		s.text.WriteString(value)
+		return true
+	})
+	if reason := candidate.Get("finishReason").String(); reason != "" {
+		if reason == "OTHER" {
+			data, _ = sjson.DeleteBytes(data, "candidates.0.finishReason")
+			if !gjson.GetBytes(data, "candidates.0.content.parts.0").Exists() {
+				return nil, true
+			}
+			return data, true
+		}
+		s.done = true
+	}
+	return data, false
+}
+
+// processClaude handles Claude SSE chunks, which carry either whole events or single lines.
+// Event lines are held until their data line arrives so that a dropped event disappears
+// together with its event and blank lines.
+func (s *streamContinuation) processClaude(chunk []byte) ([]byte, bool) {
+	var out bytes.Buffer
+	interrupted := false
+	for _, line := range bytes.SplitAfter(chunk, []byte("\n")) {
+		if len(line) == 0 {
+			continue
+		}
+		trimmed := bytes.TrimSpace(line)
+		switch {
+		case bytes.HasPrefix(trimmed, []byte("event:")):
+			s.pendingEvent = append(s.pendingEvent[:0], line...)
+		case bytes.HasPrefix(trimmed, []byte("data:")):
+			data := bytes.TrimSpace(trimmed[len("data:"):])
+			prefix, rewritten, keep, stop := s.claudeEvent(data)
+			if stop {
+				interrupted = true
+			}
+			out.Write(prefix)
+			if keep {
+				out.Write(s.pendingEvent)
+				out.WriteString("data: ")
+				out.Write(rewritten)
+				out.WriteByte('\n')
+			}
+			s.pendingEvent = s.pendingEvent[:0]
+			s.dropBlank = !keep
+		case len(trimmed) == 0:
+			if s.dropBlank {
+				s.dropBlank = false
+				continue
+			}
+			out.Write(line)
+		default:
+			out.Write(line)
+		}
+	}
+	return out.Bytes(), interrupted
+}
+
+// claudeStopEvent closes content block index in a spliced Claude stream.
+func claudeStopEvent(index int) []byte {
+	return []byte(fmt.Sprintf("event: content_block_stop\ndata: {\"type\":\"content_block_stop\",\"index\":%d}\n\n", index))
+}
+
+// claudeEvent rewrites one Claude event. It returns events to emit before it, the rewritten
+// data, whether to keep the event and whether it reports an interrupted stream.
+func (s *streamContinuation) claudeEvent(data []byte) (prefix, out []byte, keep, interrupted bool) {
+	if !gjson.ValidBytes(data) {
+		return nil, data, true, false
+	}
+	eventType := gjson.GetBytes(data, "type").String()
+	if eventType == "error" {
+		s.failure = fmt.Errorf("upstream stream error: %s", gjson.GetBytes(data, "error.message").String())
+		return nil, nil, false, true
+	}
+	if s.splicing {
+		switch eventType {
+		case "message_start", "ping":
+			return nil, nil, false, false
+		case "content_block_start":
+			index := int(gjson.GetBytes(data, "index").Int())
+			if !s.mapped {
+				s.mapped = true
+				blockType := gjson.GetBytes(data, "content_block.type").String()
+				if s.openIndex >= 0 && s.openType == "text" && blockType == "text" {
+					// The interrupted text block simply continues.
+					s.offset = s.openIndex - index
+					return nil, nil, false, false
+				}
+				if s.openIndex >= 0 {
+					prefix = claudeStopEvent(s.openIndex)
+					s.openIndex = -1
+				}
+				s.offset = s.nextIndex - index
+			}
+			data, _ = sjson.SetBytes(data, "index", index+s.offset)
+		case "content_block_delta", "content_block_stop":
+			data, _ = sjson.SetBytes(data, "index", gjson.GetBytes(data, "index").Int()+int64(s.offset))
+			if gjson.GetBytes(data, "delta.type").String() == "text_delta" {
+				text := gjson.GetBytes(data, "delta.text").String()
+				if trimmed := s.trimContinuationText(text); trimmed != text {
+					if trimmed == "" {
+						return nil, nil, false, false
+					}
+					data, _ = sjson.SetBytes(data, "delta.text", trimmed)
+				}
+			}
+		case "message_delta":
+			if !s.mapped && s.openIndex >= 0 {
+				prefix = claudeStopEvent(s.openIndex)
+				s.openIndex = -1
+			}
+		}
+	}
+
+	switch eventType {
+	case "content_block_start":
+		s.openIndex = int(gjson.GetBytes(data, "index").Int())
+		s.openType = gjson.GetBytes(data, "content_block.type").String()
+		s.nextIndex = s.openIndex + 1
+		if strings.HasSuffix(s.openType, "tool_use") {
+			s.tools = true
+		}
+	case "content_block_delta":
+		switch gjson.GetBytes(data, "delta.type").String() {
+		case "text_delta":
+			s.text.WriteString(gjson.GetBytes(data, "delta.text").String())
+		case "input_json_delta":
+			s.tools = true
+		}
+	case "content_block_stop":
+		s.openIndex = -1
+	case "message_stop":
+		s.done = true
+	}
+	return prefix, data, true, false
+}
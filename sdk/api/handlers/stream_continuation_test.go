@@ -0,0 +1,281 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	coreexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+	"github.com/tidwall/gjson"
+)
+
+// breakOnceStreamExecutor breaks the first stream after some output and completes the next one.
+type breakOnceStreamExecutor struct {
+	mu       sync.Mutex
+	auths    []string
+	payloads [][]byte
+}
+
+func (e *breakOnceStreamExecutor) Identifier() string { return "continuetest" }
+
+func (e *breakOnceStreamExecutor) Execute(context.Context, *coreauth.Auth, coreexecutor.Request, coreexecutor.Options) (coreexecutor.Response, error) {
+	return coreexecutor.Response{}, &coreauth.Error{Code: "not_implemented", Message: "Execute not implemented"}
+}
+
+func (e *breakOnceStreamExecutor) ExecuteStream(ctx context.Context, auth *coreauth.Auth, req coreexecutor.Request, _ coreexecutor.Options) (<-chan coreexecutor.StreamChunk, error) {
+	e.mu.Lock()
+	e.auths = append(e.auths, auth.ID)
+	e.payloads = append(e.payloads, req.Payload)
+	call := len(e.auths)
+	e.mu.Unlock()
+
+	ch := make(chan coreexecutor.StreamChunk, 4)
+	if call == 1 {
+		if !coreexecutor.StreamContinuation(ctx) {
+			ch <- coreexecutor.StreamChunk{Err: errors.New("continuation not signalled")}
+			close(ch)
+			return ch, nil
+		}
+		ch <- coreexecutor.StreamChunk{Payload: []byte(`{"id":"first","choices":[{"index":0,"delta":{"role":"assistant","content":""}}]}`)}
+		ch <- coreexecutor.StreamChunk{Payload: []byte(`{"id":"first","choices":[{"index":0,"delta":{"content":"Hello "}}]}`)}
+		ch <- coreexecutor.StreamChunk{Err: errors.New("connection reset by peer")}
+		close(ch)
+		return ch, nil
+	}
+	ch <- coreexecutor.StreamChunk{Payload: []byte(`{"id":"second","choices":[{"index":0,"delta":{"role":"assistant","content":""}}]}`)}
+	ch <- coreexecutor.StreamChunk{Payload: []byte(`{"id":"second","choices":[{"index":0,"delta":{"content":" world"}}]}`)}
+	ch <- coreexecutor.StreamChunk{Payload: []byte(`{"id":"second","choices":[{"index":0,"delta":{},"finish_reason":"stop"}]}`)}
+	close(ch)
+	return ch, nil
+}
+
+func (e *breakOnceStreamExecutor) Refresh(ctx context.Context, auth *coreauth.Auth) (*coreauth.Auth, error) {
+	return auth, nil
+}
+
+func (e *breakOnceStreamExecutor) CountTokens(context.Context, *coreauth.Auth, coreexecutor.Request, coreexecutor.Options) (coreexecutor.Response, error) {
+	return coreexecutor.Response{}, &coreauth.Error{Code: "not_implemented", Message: "CountTokens not implemented"}
+}
+
+func (e *breakOnceStreamExecutor) HttpRequest(ctx context.Context, auth *coreauth.Auth, req *http.Request) (*http.Response, error) {
+	return nil, &coreauth.Error{Code: "not_implemented", Message: "HttpRequest not implemented", HTTPStatus: http.StatusNotImplemented}
+}
+
+func TestExecuteStreamWithAuthManager_ContinuesAfterMidStreamFailure(t *testing.T) {
+	executor := &breakOnceStreamExecutor{}
+	manager := coreauth.NewManager(nil, nil, nil)
+	manager.RegisterExecutor(executor)
+	for _, id := range []string{"cont1", "cont2"} {
+		auth := &coreauth.Auth{ID: id, Provider: "continuetest", Status: coreauth.StatusActive}
+		if _, err := manager.Register(context.Background(), auth); err != nil {
+			t.Fatalf("manager.Register(%s): %v", id, err)
+		}
+		registry.GetGlobalRegistry().RegisterClient(id, "continuetest", []*registry.ModelInfo{{ID: "continue-model"}})
+		t.Cleanup(func() { registry.GetGlobalRegistry().UnregisterClient(id) })
+	}
+
+	handler := NewBaseAPIHandlers(&sdkconfig.SDKConfig{
+		Streaming: sdkconfig.StreamingConfig{ContinuationRetries: 1},
+	}, manager)
+	raw := []byte(`{"model":"continue-model","messages":[{"role":"user","content":"hi"}]}`)
+	dataChan, errChan := handler.ExecuteStreamWithAuthManager(context.Background(), "openai", "continue-model", raw, "")
+
+	var text strings.Builder
+	var chunks int
+	for chunk := range dataChan {
+		chunks++
+		if id := gjson.GetBytes(chunk, "id").String(); id != "first" {
+			t.Fatalf("expected spliced chunks to keep id first, got %q", id)
+		}
+		text.WriteString(gjson.GetBytes(chunk, "choices.0.delta.content").String())
+	}
+	for msg := range errChan {
+		if msg != nil {
+			t.Fatalf("unexpected error: %+v", msg)
+		}
+	}
+
+	if text.String() != "Hello world" {
+		t.Fatalf("expected spliced text %q, got %q", "Hello world", text.String())
+	}
+	if chunks != 4 {
+		t.Fatalf("expected 4 chunks without the duplicate role chunk, got %d", chunks)
+	}
+	if len(executor.auths) != 2 || executor.auths[0] == executor.auths[1] {
+		t.Fatalf("expected the continuation on another credential, got %v", executor.auths)
+	}
+	last := gjson.GetBytes(executor.payloads[1], "messages.1")
+	if last.Get("role").String() != "assistant" || last.Get("content").String() != "Hello" {
+		t.Fatalf("expected assistant prefill %q, got %s", "Hello", last.Raw)
+	}
+}
+
+// interruptOnceStreamExecutor reports an in-band interruption on the first stream and then
+// holds it open until its context is cancelled.
+type interruptOnceStreamExecutor struct {
+	breakOnceStreamExecutor
+	cancelled chan struct{}
+}
+
+func (e *interruptOnceStreamExecutor) ExecuteStream(ctx context.Context, auth *coreauth.Auth, req coreexecutor.Request, _ coreexecutor.Options) (<-chan coreexecutor.StreamChunk, error) {
+	e.mu.Lock()
+	e.auths = append(e.auths, auth.ID)
+	call := len(e.auths)
+	e.mu.Unlock()
+
+	ch := make(chan coreexecutor.StreamChunk, 1)
+	if call == 1 {
+		ch <- coreexecutor.StreamChunk{Payload: []byte(`{"candidates":[{"content":{"role":"model","parts":[{"text":"Partial "}]},"finishReason":"OTHER"}]}`)}
+		go func() {
+			defer close(ch)
+			<-ctx.Done()
+			close(e.cancelled)
+		}()
+		return ch, nil
+	}
+	ch <- coreexecutor.StreamChunk{Payload: []byte(`{"candidates":[{"content":{"role":"model","parts":[{"text":" answer"}]},"finishReason":"STOP"}]}`)}
+	close(ch)
+	return ch, nil
+}
+
+func TestExecuteStreamWithAuthManager_CancelsInterruptedAttempt(t *testing.T) {
+	executor := &interruptOnceStreamExecutor{cancelled: make(chan struct{})}
+	manager := coreauth.NewManager(nil, nil, nil)
+	manager.RegisterExecutor(executor)
+	for _, id := range []string{"intr1", "intr2"} {
+		auth := &coreauth.Auth{ID: id, Provider: "continuetest", Status: coreauth.StatusActive}
+		if _, err := manager.Register(context.Background(), auth); err != nil {
+			t.Fatalf("manager.Register(%s): %v", id, err)
+		}
+		registry.GetGlobalRegistry().RegisterClient(id, "continuetest", []*registry.ModelInfo{{ID: "interrupt-model"}})
+		t.Cleanup(func() { registry.GetGlobalRegistry().UnregisterClient(id) })
+	}
+
+	handler := NewBaseAPIHandlers(&sdkconfig.SDKConfig{
+		Streaming: sdkconfig.StreamingConfig{ContinuationRetries: 1},
+	}, manager)
+	raw := []byte(`{"contents":[{"role":"user","parts":[{"text":"hi"}]}]}`)
+	dataChan, errChan := handler.ExecuteStreamWithAuthManager(context.Background(), "gemini", "interrupt-model", raw, "")
+
+	var text strings.Builder
+	for chunk := range dataChan {
+		text.WriteString(gjson.GetBytes(chunk, "candidates.0.content.parts.0.text").String())
+	}
+	for msg := range errChan {
+		if msg != nil {
+			t.Fatalf("unexpected error: %+v", msg)
+		}
+	}
+	if text.String() != "Partial answer" {
+		t.Fatalf("expected spliced text %q, got %q", "Partial answer", text.String())
+	}
+
+	select {
+	case <-executor.cancelled:
+	case <-time.After(2 * time.Second):
+		t.Fatalf("expected the interrupted attempt's context to be cancelled")
+	}
+}
+
+func TestStreamContinuation_ClaudeSplicesIntoOpenTextBlock(t *testing.T) {
+	s := newStreamContinuation("claude", "")
+	first := "event: message_start\ndata: {\"type\":\"message_start\",\"message\":{\"id\":\"msg_1\"}}\n\n" +
+		"event: content_block_start\ndata: {\"type\":\"content_block_start\",\"index\":0,\"content_block\":{\"type\":\"thinking\"}}\n\n" +
+		"event: content_block_stop\ndata: {\"type\":\"content_block_stop\",\"index\":0}\n\n" +
+		"event: content_block_start\ndata: {\"type\":\"content_block_start\",\"index\":1,\"content_block\":{\"type\":\"text\",\"text\":\"\"}}\n\n" +
+		"event: content_block_delta\ndata: {\"type\":\"content_block_delta\",\"index\":1,\"delta\":{\"type\":\"text_delta\",\"text\":\"Hi\"}}\n\n"
+	if out, interrupted := s.process([]byte(first)); interrupted || string(out) != first {
+		t.Fatalf("expected the original stream to pass through unchanged, got %q", out)
+	}
+	if !s.resumable() {
+		t.Fatalf("expected stream to be resumable")
+	}
+	payload, err := s.request([]byte(`{"messages":[{"role":"user","content":"hello"}]}`))
+	if err != nil {
+		t.Fatalf("request: %v", err)
+	}
+	if got := gjson.GetBytes(payload, "messages.1.content.0.text").String(); got != "Hi" {
+		t.Fatalf("expected prefill Hi, got %q", got)
+	}
+
+	s.beginSplice()
+	// Passthrough Claude streams deliver one line per chunk.
+	lines := []string{
+		"event: message_start\n", "data: {\"type\":\"message_start\",\"message\":{\"id\":\"msg_2\"}}\n", "\n",
+		"event: content_block_start\n", "data: {\"type\":\"content_block_start\",\"index\":0,\"content_block\":{\"type\":\"text\",\"text\":\"\"}}\n", "\n",
+		"event: ping\n", "data: {\"type\":\"ping\"}\n", "\n",
+		"event: content_block_delta\n", "data: {\"type\":\"content_block_delta\",\"index\":0,\"delta\":{\"type\":\"text_delta\",\"text\":\" there\"}}\n", "\n",
+		"event: content_block_stop\n", "data: {\"type\":\"content_block_stop\",\"index\":0}\n", "\n",
+		"event: message_stop\n", "data: {\"type\":\"message_stop\"}\n", "\n",
+	}
+	var spliced strings.Builder
+	for _, line := range lines {
+		out, _ := s.process([]byte(line))
+		spliced.Write(out)
+	}
+	want := "event: content_block_delta\ndata: {\"type\":\"content_block_delta\",\"index\":1,\"delta\":{\"type\":\"text_delta\",\"text\":\" there\"}}\n\n" +
+		"event: content_block_stop\ndata: {\"type\":\"content_block_stop\",\"index\":1}\n\n" +
+		"event: message_stop\ndata: {\"type\":\"message_stop\"}\n\n"
+	if spliced.String() != want {
+		t.Fatalf("unexpected spliced stream:\n%s", spliced.String())
+	}
+	if s.resumable() {
+		t.Fatalf("expected completed stream not to be resumable")
+	}
+}
+
+func TestStreamContinuation_ClaudeErrorEventInterrupts(t *testing.T) {
+	s := newStreamContinuation("claude", "")
+	out, interrupted := s.process([]byte("event: error\ndata: {\"type\":\"error\",\"error\":{\"type\":\"overloaded_error\",\"message\":\"Overloaded\"}}\n\n"))
+	if !interrupted || len(out) != 0 {
+		t.Fatalf("expected error event to be dropped and interrupt the stream, got %q", out)
+	}
+	if err := s.interruption(); err == nil || !strings.Contains(err.Error(), "Overloaded") {
+		t.Fatalf("expected interruption to carry the upstream message, got %v", err)
+	}
+}
+
+func TestStreamContinuation_GeminiFinishReasonOther(t *testing.T) {
+	s := newStreamContinuation("gemini", "")
+	out, interrupted := s.process([]byte(`{"candidates":[{"content":{"role":"model","parts":[{"text":"Partial "}]},"finishReason":"OTHER"}]}`))
+	if !interrupted {
+		t.Fatalf("expected finishReason OTHER to interrupt the stream")
+	}
+	if gjson.GetBytes(out, "candidates.0.finishReason").Exists() {
+		t.Fatalf("expected finishReason to be stripped, got %s", out)
+	}
+	payload, err := s.request([]byte(`{"contents":[{"role":"user","parts":[{"text":"hi"}]}]}`))
+	if err != nil {
+		t.Fatalf("request: %v", err)
+	}
+	if got := gjson.GetBytes(payload, "contents.1.parts.0.text").String(); got != "Partial" {
+		t.Fatalf("expected prefill Partial, got %q", got)
+	}
+
+	s.beginSplice()
+	out, _ = s.process([]byte(`{"candidates":[{"content":{"role":"model","parts":[{"text":" answer"}]},"finishReason":"STOP"}]}`))
+	if got := gjson.GetBytes(out, "candidates.0.content.parts.0.text").String(); got != "answer" {
+		t.Fatalf("expected leading whitespace trimmed after the prefill, got %q", got)
+	}
+	if s.resumable() {
+		t.Fatalf("expected completed stream not to be resumable")
+	}
+}
+
+func TestStreamContinuation_ToolCallsAreNotResumed(t *testing.T) {
+	s := newStreamContinuation("openai", "")
+	s.process([]byte(`{"id":"x","choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"function":{"name":"f"}}]}}]}`))
+	if s.resumable() {
+		t.Fatalf("expected stream with tool calls not to be resumable")
+	}
+	if newStreamContinuation("openai-response", "") != nil {
+		t.Fatalf("expected unsupported formats to disable continuation")
+	}
+}
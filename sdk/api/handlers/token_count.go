@@ -0,0 +1,28 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/tokencount"
+)
+
+// countTokensLocally answers a count_tokens request with the offline tokenizer of the model,
+// in the response shape of the handler's API.
+func countTokensLocally(handlerType, modelName string, rawJSON []byte) ([]byte, *interfaces.ErrorMessage) {
+	counter, err := tokencount.ForModel(modelName)
+	if err != nil {
+		return nil, &interfaces.ErrorMessage{StatusCode: http.StatusInternalServerError, Error: err}
+	}
+	count, err := tokencount.CountRequest(counter, handlerType, rawJSON)
+	if err != nil {
+		return nil, &interfaces.ErrorMessage{StatusCode: http.StatusInternalServerError, Error: err}
+	}
+	switch handlerType {
+	case "gemini", "gemini-cli":
+		return []byte(fmt.Sprintf(`{"totalTokens":%d}`, count)), nil
+	default:
+		return []byte(fmt.Sprintf(`{"input_tokens":%d}`, count)), nil
+	}
+}
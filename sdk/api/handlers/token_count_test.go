@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+	"github.com/tidwall/gjson"
+)
+
+func TestExecuteCountWithAuthManager_TokenCountingModes(t *testing.T) {
+	manager := coreauth.NewManager(nil, nil, nil)
+	manager.RegisterExecutor(&failOnceStreamExecutor{})
+	auth := &coreauth.Auth{ID: "count-auth", Provider: "codex", Status: coreauth.StatusActive}
+	if _, err := manager.Register(context.Background(), auth); err != nil {
+		t.Fatalf("manager.Register: %v", err)
+	}
+	registry.GetGlobalRegistry().RegisterClient(auth.ID, auth.Provider, []*registry.ModelInfo{{ID: "count-model"}})
+	t.Cleanup(func() { registry.GetGlobalRegistry().UnregisterClient(auth.ID) })
+
+	claudeBody := []byte(`{"model":"count-model","messages":[{"role":"user","content":"How many tokens is this?"}]}`)
+	geminiBody := []byte(`{"contents":[{"role":"user","parts":[{"text":"How many tokens is this?"}]}]}`)
+
+	upstream := NewBaseAPIHandlers(&sdkconfig.SDKConfig{}, manager)
+	if _, errMsg := upstream.ExecuteCountWithAuthManager(context.Background(), "claude", "count-model", claudeBody, ""); errMsg == nil {
+		t.Fatal("expected the upstream error when counting upstream only")
+	}
+
+	local := NewBaseAPIHandlers(&sdkconfig.SDKConfig{TokenCounting: sdkconfig.TokenCountingLocal}, manager)
+	payload, errMsg := local.ExecuteCountWithAuthManager(context.Background(), "claude", "count-model", claudeBody, "")
+	if errMsg != nil {
+		t.Fatalf("local count failed: %v", errMsg.Error)
+	}
+	if gjson.GetBytes(payload, "input_tokens").Int() <= 0 {
+		t.Fatalf("expected a Claude count response, got %s", payload)
+	}
+	payload, errMsg = local.ExecuteCountWithAuthManager(context.Background(), "gemini", "count-model", geminiBody, "")
+	if errMsg != nil {
+		t.Fatalf("local count failed: %v", errMsg.Error)
+	}
+	if gjson.GetBytes(payload, "totalTokens").Int() <= 0 {
+		t.Fatalf("expected a Gemini count response, got %s", payload)
+	}
+
+	fallback := NewBaseAPIHandlers(&sdkconfig.SDKConfig{TokenCounting: sdkconfig.TokenCountingFallback}, manager)
+	payload, errMsg = fallback.ExecuteCountWithAuthManager(context.Background(), "claude", "count-model", claudeBody, "")
+	if errMsg != nil {
+		t.Fatalf("fallback count failed: %v", errMsg.Error)
+	}
+	if gjson.GetBytes(payload, "input_tokens").Int() <= 0 {
+		t.Fatalf("expected a local count after the upstream failure, got %s", payload)
+	}
+}
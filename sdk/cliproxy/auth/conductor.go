@@ -19,6 +19,7 @@ import (
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/logging"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/thinking"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/tracing"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
 	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
 	log "github.com/sirupsen/logrus"
@@ -142,8 +143,23 @@ type Manager struct {
 	// Optional HTTP RoundTripper provider injected by host.
 	rtProvider RoundTripperProvider
 
+	// executionHooks observe every executor attempt in registration order.
+	executionHooks []ExecutionHook
+
+	// policies tracks per-credential pacing, daily usage and health scores for the
+	// configured routing.credential-policies.
+	policies *policyTracker
+
 	// Auto refresh state
 	refreshCancel context.CancelFunc
+
+	// Quota polling state; quotaPolls maps auth IDs to their last poll time.
+	quotaCancel context.CancelFunc
+	quotaPolls  sync.Map
+
+	// responseCache is the store behind response-cache, reopened when its settings change.
+	responseCacheMu sync.Mutex
+	responseCache   *responseCache
 }
 
 // NewManager constructs a manager with optional custom selector and hook.
@@ -161,7 +177,9 @@ func NewManager(store Store, selector Selector, hook Hook) *Manager {
 		hook:            hook,
 		auths:           make(map[string]*Auth),
 		providerOffsets: make(map[string]int),
+		policies:        newPolicyTracker(),
 	}
+	manager.policies.policyFor = manager.credentialPolicyFor
 	// atomic.Value requires non-nil initial value.
 	manager.runtimeConfig.Store(&internalconfig.Config{})
 	manager.apiKeyModelAlias.Store(apiKeyModelAliasTable(nil))
@@ -471,7 +489,43 @@ func (m *Manager) Load(ctx context.Context) error {
 
 // Execute performs a non-streaming execution using the configured selector and executor.
 // It supports multiple providers for the same model and round-robins the starting provider per model.
+// When the model stays unavailable after retries, the configured model fallback chain is tried in order.
+// Models matching a hedging rule race a second credential once the first attempt is slow.
+// With response-cache enabled, repeated deterministic requests are answered from the cache.
 func (m *Manager) Execute(ctx context.Context, providers []string, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	if rc, key, ok := m.responseCacheKeyFor(providers, req, opts); ok {
+		return m.executeCached(ctx, rc, key, providers, req, opts)
+	}
+	return m.execute(ctx, providers, req, opts)
+}
+
+func (m *Manager) execute(ctx context.Context, providers []string, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	if delay, ok := m.hedgeDelay(req, opts); ok {
+		return m.executeHedged(ctx, providers, req, opts, delay)
+	}
+	return m.executeWithFallback(ctx, providers, req, opts)
+}
+
+func (m *Manager) executeWithFallback(ctx context.Context, providers []string, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	resp, errExec := m.executeWithRetry(ctx, providers, req, opts)
+	if errExec == nil {
+		return resp, nil
+	}
+	for _, target := range m.fallbackTargets(req.Model, opts, errExec) {
+		logFallback(ctx, req.Model, target.model, errExec)
+		fallbackCtx, fallbackReq, fallbackOpts := prepareFallback(ctx, target, req, opts)
+		fallbackResult, errFallback := m.executeWithRetry(fallbackCtx, target.providers, fallbackReq, fallbackOpts)
+		if errFallback == nil {
+			return fallbackResult, nil
+		}
+		if !isFallbackError(errFallback) {
+			break
+		}
+	}
+	return resp, errExec
+}
+
+func (m *Manager) executeWithRetry(ctx context.Context, providers []string, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
 	normalized := m.normalizeProviders(providers)
 	if len(normalized) == 0 {
 		return cliproxyexecutor.Response{}, &Error{Code: "provider_not_found", Message: "no provider supplied"}
@@ -490,6 +544,7 @@ func (m *Manager) Execute(ctx context.Context, providers []string, req cliproxye
 		if !shouldRetry {
 			break
 		}
+		m.notifyRetry(ctx, req.Model, attempt, wait, errExec)
 		if errWait := waitForCooldown(ctx, wait); errWait != nil {
 			return cliproxyexecutor.Response{}, errWait
 		}
@@ -502,7 +557,27 @@ func (m *Manager) Execute(ctx context.Context, providers []string, req cliproxye
 
 // ExecuteCount performs a non-streaming execution using the configured selector and executor.
 // It supports multiple providers for the same model and round-robins the starting provider per model.
+// When the model stays unavailable after retries, the configured model fallback chain is tried in order.
 func (m *Manager) ExecuteCount(ctx context.Context, providers []string, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	resp, errExec := m.executeCountWithRetry(ctx, providers, req, opts)
+	if errExec == nil {
+		return resp, nil
+	}
+	for _, target := range m.fallbackTargets(req.Model, opts, errExec) {
+		logFallback(ctx, req.Model, target.model, errExec)
+		fallbackCtx, fallbackReq, fallbackOpts := prepareFallback(ctx, target, req, opts)
+		fallbackResult, errFallback := m.executeCountWithRetry(fallbackCtx, target.providers, fallbackReq, fallbackOpts)
+		if errFallback == nil {
+			return fallbackResult, nil
+		}
+		if !isFallbackError(errFallback) {
+			break
+		}
+	}
+	return resp, errExec
+}
+
+func (m *Manager) executeCountWithRetry(ctx context.Context, providers []string, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
 	normalized := m.normalizeProviders(providers)
 	if len(normalized) == 0 {
 		return cliproxyexecutor.Response{}, &Error{Code: "provider_not_found", Message: "no provider supplied"}
@@ -521,6 +596,7 @@ func (m *Manager) ExecuteCount(ctx context.Context, providers []string, req clip
 		if !shouldRetry {
 			break
 		}
+		m.notifyRetry(ctx, req.Model, attempt, wait, errExec)
 		if errWait := waitForCooldown(ctx, wait); errWait != nil {
 			return cliproxyexecutor.Response{}, errWait
 		}
@@ -533,7 +609,27 @@ func (m *Manager) ExecuteCount(ctx context.Context, providers []string, req clip
 
 // ExecuteStream performs a streaming execution using the configured selector and executor.
 // It supports multiple providers for the same model and round-robins the starting provider per model.
+// When the model stays unavailable after retries, the configured model fallback chain is tried in order.
 func (m *Manager) ExecuteStream(ctx context.Context, providers []string, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (<-chan cliproxyexecutor.StreamChunk, error) {
+	chunks, errExec := m.executeStreamWithRetry(ctx, providers, req, opts)
+	if errExec == nil {
+		return chunks, nil
+	}
+	for _, target := range m.fallbackTargets(req.Model, opts, errExec) {
+		logFallback(ctx, req.Model, target.model, errExec)
+		fallbackCtx, fallbackReq, fallbackOpts := prepareFallback(ctx, target, req, opts)
+		fallbackResult, errFallback := m.executeStreamWithRetry(fallbackCtx, target.providers, fallbackReq, fallbackOpts)
+		if errFallback == nil {
+			return fallbackResult, nil
+		}
+		if !isFallbackError(errFallback) {
+			break
+		}
+	}
+	return chunks, errExec
+}
+
+func (m *Manager) executeStreamWithRetry(ctx context.Context, providers []string, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (<-chan cliproxyexecutor.StreamChunk, error) {
 	normalized := m.normalizeProviders(providers)
 	if len(normalized) == 0 {
 		return nil, &Error{Code: "provider_not_found", Message: "no provider supplied"}
@@ -552,6 +648,7 @@ func (m *Manager) ExecuteStream(ctx context.Context, providers []string, req cli
 		if !shouldRetry {
 			break
 		}
+		m.notifyRetry(ctx, req.Model, attempt, wait, errStream)
 		if errWait := waitForCooldown(ctx, wait); errWait != nil {
 			return nil, errWait
 		}
@@ -579,20 +676,38 @@ func (m *Manager) executeMixedOnce(ctx context.Context, providers []string, req
 			return cliproxyexecutor.Response{}, errPick
 		}
 
+		tried[auth.ID] = struct{}{}
+		if !claimHedgeCredential(ctx, auth.ID) {
+			m.releaseCredential(auth)
+			continue
+		}
+
 		entry := logEntryWithRequestID(ctx)
 		debugLogAuthSelection(entry, auth, provider, req.Model)
 
-		tried[auth.ID] = struct{}{}
 		execCtx := ctx
 		if rt := m.roundTripperFor(auth); rt != nil {
 			execCtx = context.WithValue(execCtx, roundTripperContextKey{}, rt)
 			execCtx = context.WithValue(execCtx, "cliproxy.roundtripper", rt)
 		}
+		execCtx = m.withRateLimitReporter(execCtx, auth.ID, routeModel)
 		execReq := req
 		execReq.Model = rewriteModelForAuth(routeModel, auth)
 		execReq.Model = m.applyOAuthModelAlias(auth, execReq.Model)
 		execReq.Model = m.applyAPIKeyModelAlias(auth, execReq.Model)
-		resp, errExec := executor.Execute(execCtx, auth, execReq, opts)
+		if errPace := m.paceCredential(execCtx, auth); errPace != nil {
+			if errCtx := execCtx.Err(); errCtx != nil {
+				return cliproxyexecutor.Response{}, errCtx
+			}
+			lastErr = errPace
+			continue
+		}
+		execCtx, span := startAttemptSpan(execCtx, auth, provider, routeModel, len(tried), false)
+		attempt := m.beginAttempt(execCtx, auth, provider, routeModel, execReq, opts)
+		execCtx, execReq, execOpts := attempt.apply(execCtx, execReq, opts)
+		resp, errExec := executor.Execute(execCtx, auth, execReq, execOpts)
+		attempt.finish(execCtx, resp, errExec)
+		tracing.End(span, errExec)
 		result := Result{AuthID: auth.ID, Provider: provider, Model: routeModel, Success: errExec == nil}
 		if errExec != nil {
 			if errCtx := execCtx.Err(); errCtx != nil {
@@ -641,11 +756,24 @@ func (m *Manager) executeCountMixedOnce(ctx context.Context, providers []string,
 			execCtx = context.WithValue(execCtx, roundTripperContextKey{}, rt)
 			execCtx = context.WithValue(execCtx, "cliproxy.roundtripper", rt)
 		}
+		execCtx = m.withRateLimitReporter(execCtx, auth.ID, routeModel)
 		execReq := req
 		execReq.Model = rewriteModelForAuth(routeModel, auth)
 		execReq.Model = m.applyOAuthModelAlias(auth, execReq.Model)
 		execReq.Model = m.applyAPIKeyModelAlias(auth, execReq.Model)
-		resp, errExec := executor.CountTokens(execCtx, auth, execReq, opts)
+		if errPace := m.paceCredential(execCtx, auth); errPace != nil {
+			if errCtx := execCtx.Err(); errCtx != nil {
+				return cliproxyexecutor.Response{}, errCtx
+			}
+			lastErr = errPace
+			continue
+		}
+		execCtx, span := startAttemptSpan(execCtx, auth, provider, routeModel, len(tried), false)
+		attempt := m.beginAttempt(execCtx, auth, provider, routeModel, execReq, opts)
+		execCtx, execReq, execOpts := attempt.apply(execCtx, execReq, opts)
+		resp, errExec := executor.CountTokens(execCtx, auth, execReq, execOpts)
+		attempt.finish(execCtx, resp, errExec)
+		tracing.End(span, errExec)
 		result := Result{AuthID: auth.ID, Provider: provider, Model: routeModel, Success: errExec == nil}
 		if errExec != nil {
 			if errCtx := execCtx.Err(); errCtx != nil {
@@ -675,10 +803,22 @@ func (m *Manager) executeStreamMixedOnce(ctx context.Context, providers []string
 	routeModel := req.Model
 	opts = ensureRequestedModelMetadata(opts, routeModel)
 	tried := make(map[string]struct{})
+	avoided := cliproxyexecutor.AvoidedAuths(ctx)
+	for _, id := range avoided {
+		tried[id] = struct{}{}
+	}
 	var lastErr error
 	for {
 		auth, executor, provider, errPick := m.pickNextMixed(ctx, providers, routeModel, opts, tried)
 		if errPick != nil {
+			if lastErr == nil && len(avoided) > 0 {
+				// Nothing else is available: fall back to the credentials the caller wanted to avoid.
+				for _, id := range avoided {
+					delete(tried, id)
+				}
+				avoided = nil
+				continue
+			}
 			if lastErr != nil {
 				return nil, lastErr
 			}
@@ -694,12 +834,25 @@ func (m *Manager) executeStreamMixedOnce(ctx context.Context, providers []string
 			execCtx = context.WithValue(execCtx, roundTripperContextKey{}, rt)
 			execCtx = context.WithValue(execCtx, "cliproxy.roundtripper", rt)
 		}
+		execCtx = m.withRateLimitReporter(execCtx, auth.ID, routeModel)
 		execReq := req
 		execReq.Model = rewriteModelForAuth(routeModel, auth)
 		execReq.Model = m.applyOAuthModelAlias(auth, execReq.Model)
 		execReq.Model = m.applyAPIKeyModelAlias(auth, execReq.Model)
-		chunks, errStream := executor.ExecuteStream(execCtx, auth, execReq, opts)
+		if errPace := m.paceCredential(execCtx, auth); errPace != nil {
+			if errCtx := execCtx.Err(); errCtx != nil {
+				return nil, errCtx
+			}
+			lastErr = errPace
+			continue
+		}
+		execCtx, span := startAttemptSpan(execCtx, auth, provider, routeModel, len(tried), true)
+		attempt := m.beginAttempt(execCtx, auth, provider, routeModel, execReq, opts)
+		execCtx, execReq, execOpts := attempt.apply(execCtx, execReq, opts)
+		chunks, errStream := executor.ExecuteStream(execCtx, auth, execReq, execOpts)
 		if errStream != nil {
+			attempt.finish(execCtx, cliproxyexecutor.Response{}, errStream)
+			tracing.End(span, errStream)
 			if errCtx := execCtx.Err(); errCtx != nil {
 				return nil, errCtx
 			}
@@ -714,14 +867,18 @@ func (m *Manager) executeStreamMixedOnce(ctx context.Context, providers []string
 			lastErr = errStream
 			continue
 		}
+		cliproxyexecutor.ObserveAuth(ctx, auth.ID)
 		out := make(chan cliproxyexecutor.StreamChunk)
 		go func(streamCtx context.Context, streamAuth *Auth, streamProvider string, streamChunks <-chan cliproxyexecutor.StreamChunk) {
 			defer close(out)
 			var failed bool
+			var streamErr error
 			forward := true
 			for chunk := range streamChunks {
+				attempt.chunk(streamCtx, chunk)
 				if chunk.Err != nil && !failed {
 					failed = true
+					streamErr = chunk.Err
 					rerr := &Error{Message: chunk.Err.Error()}
 					var se cliproxyexecutor.StatusError
 					if errors.As(chunk.Err, &se) && se != nil {
@@ -745,6 +902,8 @@ func (m *Manager) executeStreamMixedOnce(ctx context.Context, providers []string
 			if !failed {
 				m.MarkResult(streamCtx, Result{AuthID: streamAuth.ID, Provider: streamProvider, Model: routeModel, Success: true})
 			}
+			attempt.finish(streamCtx, cliproxyexecutor.Response{}, streamErr)
+			tracing.End(span, streamErr)
 		}(execCtx, auth.Clone(), provider, chunks)
 		return out, nil
 	}
@@ -804,6 +963,14 @@ func rewriteModelForAuth(model string, auth *Auth) string {
 	return strings.TrimPrefix(model, needle)
 }
 
+// UpstreamModel returns the model name sent upstream when auth serves requestedModel, after
+// the auth prefix is stripped and model aliases are applied.
+func (m *Manager) UpstreamModel(auth *Auth, requestedModel string) string {
+	model := rewriteModelForAuth(requestedModel, auth)
+	model = m.applyOAuthModelAlias(auth, model)
+	return m.applyAPIKeyModelAlias(auth, model)
+}
+
 func (m *Manager) applyAPIKeyModelAlias(auth *Auth, requestedModel string) string {
 	if m == nil || auth == nil {
 		return requestedModel
@@ -1277,7 +1444,13 @@ func resetModelState(state *ModelState, now time.Time) {
 	state.StatusMessage = ""
 	state.NextRetryAfter = time.Time{}
 	state.LastError = nil
-	state.Quota = QuotaState{}
+	// Keep the quota last reported by the provider; only the cooldown is cleared.
+	state.Quota = QuotaState{
+		Remaining: state.Quota.Remaining,
+		Limit:     state.Quota.Limit,
+		ResetAt:   state.Quota.ResetAt,
+		CheckedAt: state.Quota.CheckedAt,
+	}
 	state.UpdatedAt = now
 }
 
@@ -1529,6 +1702,13 @@ func (m *Manager) GetByID(id string) (*Auth, bool) {
 }
 
 func (m *Manager) pickNext(ctx context.Context, provider, model string, opts cliproxyexecutor.Options, tried map[string]struct{}) (*Auth, ProviderExecutor, error) {
+	span := startSelectSpan(ctx, model, provider)
+	auth, executor, err := m.pickNextCandidate(ctx, provider, model, opts, tried)
+	endSelectSpan(span, auth, provider, err)
+	return auth, executor, err
+}
+
+func (m *Manager) pickNextCandidate(ctx context.Context, provider, model string, opts cliproxyexecutor.Options, tried map[string]struct{}) (*Auth, ProviderExecutor, error) {
 	m.mu.RLock()
 	executor, okExecutor := m.executors[provider]
 	if !okExecutor {
@@ -1561,7 +1741,9 @@ func (m *Manager) pickNext(ctx context.Context, provider, model string, opts cli
 		m.mu.RUnlock()
 		return nil, nil, &Error{Code: "auth_not_found", Message: "no auth available"}
 	}
-	selected, errPick := m.selector.Pick(ctx, provider, model, opts, candidates)
+	selected, errPick := m.applyCredentialPolicies(candidates, provider, model, func(candidates []*Auth) (*Auth, error) {
+		return m.selector.Pick(ctx, provider, model, opts, candidates)
+	})
 	if errPick != nil {
 		m.mu.RUnlock()
 		return nil, nil, errPick
@@ -1584,6 +1766,13 @@ func (m *Manager) pickNext(ctx context.Context, provider, model string, opts cli
 }
 
 func (m *Manager) pickNextMixed(ctx context.Context, providers []string, model string, opts cliproxyexecutor.Options, tried map[string]struct{}) (*Auth, ProviderExecutor, string, error) {
+	span := startSelectSpan(ctx, model, providers...)
+	auth, executor, provider, err := m.pickNextMixedCandidate(ctx, providers, model, opts, tried)
+	endSelectSpan(span, auth, provider, err)
+	return auth, executor, provider, err
+}
+
+func (m *Manager) pickNextMixedCandidate(ctx context.Context, providers []string, model string, opts cliproxyexecutor.Options, tried map[string]struct{}) (*Auth, ProviderExecutor, string, error) {
 	providerSet := make(map[string]struct{}, len(providers))
 	for _, provider := range providers {
 		p := strings.TrimSpace(strings.ToLower(provider))
@@ -1633,7 +1822,9 @@ func (m *Manager) pickNextMixed(ctx context.Context, providers []string, model s
 		m.mu.RUnlock()
 		return nil, nil, "", &Error{Code: "auth_not_found", Message: "no auth available"}
 	}
-	selected, errPick := m.selector.Pick(ctx, "mixed", model, opts, candidates)
+	selected, errPick := m.applyCredentialPolicies(candidates, "mixed", model, func(candidates []*Auth) (*Auth, error) {
+		return m.selector.Pick(ctx, "mixed", model, opts, candidates)
+	})
 	if errPick != nil {
 		m.mu.RUnlock()
 		return nil, nil, "", errPick
@@ -1646,6 +1837,7 @@ func (m *Manager) pickNextMixed(ctx context.Context, providers []string, model s
 	executor, okExecutor := m.executors[providerKey]
 	if !okExecutor {
 		m.mu.RUnlock()
+		m.releaseCredential(selected)
 		return nil, nil, "", &Error{Code: "executor_not_found", Message: "executor not registered"}
 	}
 	authCopy := selected.Clone()
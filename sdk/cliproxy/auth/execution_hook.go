@@ -0,0 +1,151 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+)
+
+// ExecutionAttempt describes a single executor invocation performed by the Manager.
+// A new attempt is created every time a credential is selected, so retries onto a
+// different credential produce separate attempts.
+type ExecutionAttempt struct {
+	// Auth is a copy of the credential selected for this attempt. Changes hooks make to it
+	// are not persisted; credential state is only updated by the Manager.
+	Auth *Auth
+	// Provider is the executor key handling the attempt.
+	Provider string
+	// Model is the routed model name used for credential selection.
+	Model string
+	// Request is the provider facing request. Hooks may replace it in BeforeAttempt.
+	Request cliproxyexecutor.Request
+	// Options carries execution flags. Hooks may replace them in BeforeAttempt.
+	Options cliproxyexecutor.Options
+	// RoundTripper overrides the outbound transport of the attempt when set. Hooks may set
+	// it in BeforeAttempt.
+	RoundTripper http.RoundTripper
+
+	hooks []ExecutionHook
+}
+
+// ExecutionHook observes executor invocations made by the Manager.
+// Hooks are invoked in registration order for every attempt of Execute,
+// ExecuteStream and ExecuteCount. A Selector that also implements ExecutionHook
+// is notified before any registered hook, and the credential policy tracker before
+// the selector when routing.credential-policies is configured.
+type ExecutionHook interface {
+	// BeforeAttempt fires after an auth has been selected and before the executor runs.
+	BeforeAttempt(ctx context.Context, attempt *ExecutionAttempt)
+	// AfterAttempt fires once the attempt finished. For streams it fires after the
+	// last chunk was produced, with the first terminal error encountered (if any).
+	AfterAttempt(ctx context.Context, attempt *ExecutionAttempt, resp cliproxyexecutor.Response, err error)
+	// OnAttemptChunk fires for every chunk emitted by a streaming attempt.
+	OnAttemptChunk(ctx context.Context, attempt *ExecutionAttempt, chunk cliproxyexecutor.StreamChunk)
+}
+
+// RetryObserver is an optional interface for execution hooks that want to be told when the
+// Manager waits for a cooldown and retries a request after all credentials failed.
+type RetryObserver interface {
+	// OnRetry fires before the Manager sleeps for wait and starts retry round attempt+1.
+	OnRetry(ctx context.Context, model string, attempt int, wait time.Duration, err error)
+}
+
+// SetExecutionHooks replaces the ordered list of execution hooks.
+func (m *Manager) SetExecutionHooks(hooks ...ExecutionHook) {
+	if m == nil {
+		return
+	}
+	filtered := make([]ExecutionHook, 0, len(hooks))
+	for _, hook := range hooks {
+		if hook != nil {
+			filtered = append(filtered, hook)
+		}
+	}
+	m.mu.Lock()
+	m.executionHooks = filtered
+	m.mu.Unlock()
+}
+
+// AddExecutionHook appends a hook to the end of the execution hook chain.
+func (m *Manager) AddExecutionHook(hook ExecutionHook) {
+	if m == nil || hook == nil {
+		return
+	}
+	m.mu.Lock()
+	m.executionHooks = append(m.executionHooks, hook)
+	m.mu.Unlock()
+}
+
+// beginAttempt runs BeforeAttempt on the registered hooks. It returns nil when no hooks
+// are registered; the returned attempt carries the (possibly modified) request and options.
+func (m *Manager) beginAttempt(ctx context.Context, auth *Auth, provider, model string, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) *ExecutionAttempt {
+	m.mu.RLock()
+	hooks := m.executionHooks
+	if selectorHook, ok := m.selector.(ExecutionHook); ok && selectorHook != nil {
+		hooks = append([]ExecutionHook{selectorHook}, hooks...)
+	}
+	if m.policies != nil && m.hasCredentialPolicies() {
+		hooks = append([]ExecutionHook{m.policies}, hooks...)
+	}
+	m.mu.RUnlock()
+	if len(hooks) == 0 {
+		return nil
+	}
+	attempt := &ExecutionAttempt{
+		Auth:     auth.Clone(),
+		Provider: provider,
+		Model:    model,
+		Request:  req,
+		Options:  opts,
+		hooks:    hooks,
+	}
+	for _, hook := range hooks {
+		hook.BeforeAttempt(ctx, attempt)
+	}
+	return attempt
+}
+
+// apply returns the context, request and options the attempt runs with after the hooks ran.
+func (a *ExecutionAttempt) apply(ctx context.Context, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (context.Context, cliproxyexecutor.Request, cliproxyexecutor.Options) {
+	if a == nil {
+		return ctx, req, opts
+	}
+	if a.RoundTripper != nil {
+		ctx = context.WithValue(ctx, roundTripperContextKey{}, a.RoundTripper)
+		ctx = context.WithValue(ctx, "cliproxy.roundtripper", a.RoundTripper)
+	}
+	return ctx, a.Request, a.Options
+}
+
+func (a *ExecutionAttempt) finish(ctx context.Context, resp cliproxyexecutor.Response, err error) {
+	if a == nil {
+		return
+	}
+	for _, hook := range a.hooks {
+		hook.AfterAttempt(ctx, a, resp, err)
+	}
+}
+
+func (a *ExecutionAttempt) chunk(ctx context.Context, chunk cliproxyexecutor.StreamChunk) {
+	if a == nil {
+		return
+	}
+	for _, hook := range a.hooks {
+		hook.OnAttemptChunk(ctx, a, chunk)
+	}
+}
+
+// notifyRetry informs hooks implementing RetryObserver about a scheduled retry round.
+func (m *Manager) notifyRetry(ctx context.Context, model string, attempt int, wait time.Duration, err error) {
+	recordRetryEvent(ctx, model, attempt, wait, err)
+	m.mu.RLock()
+	hooks := m.executionHooks
+	m.mu.RUnlock()
+	for _, hook := range hooks {
+		if observer, ok := hook.(RetryObserver); ok {
+			observer.OnRetry(ctx, model, attempt, wait, err)
+		}
+	}
+}
@@ -0,0 +1,179 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+)
+
+type hookTestExecutor struct {
+	mu            sync.Mutex
+	failIDs       map[string]bool
+	payloads      []string
+	roundTrippers []http.RoundTripper
+}
+
+func (e *hookTestExecutor) Identifier() string { return "hooktest" }
+
+func (e *hookTestExecutor) Execute(ctx context.Context, auth *Auth, req cliproxyexecutor.Request, _ cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	e.mu.Lock()
+	e.payloads = append(e.payloads, string(req.Payload))
+	rt, _ := ctx.Value("cliproxy.roundtripper").(http.RoundTripper)
+	e.roundTrippers = append(e.roundTrippers, rt)
+	e.mu.Unlock()
+	if e.failIDs[auth.ID] {
+		return cliproxyexecutor.Response{}, &Error{HTTPStatus: http.StatusInternalServerError, Message: "boom"}
+	}
+	return cliproxyexecutor.Response{Payload: []byte("ok:" + auth.ID)}, nil
+}
+
+func (e *hookTestExecutor) ExecuteStream(_ context.Context, auth *Auth, _ cliproxyexecutor.Request, _ cliproxyexecutor.Options) (<-chan cliproxyexecutor.StreamChunk, error) {
+	ch := make(chan cliproxyexecutor.StreamChunk, 2)
+	ch <- cliproxyexecutor.StreamChunk{Payload: []byte("a")}
+	ch <- cliproxyexecutor.StreamChunk{Payload: []byte("b")}
+	close(ch)
+	return ch, nil
+}
+
+func (e *hookTestExecutor) Refresh(_ context.Context, auth *Auth) (*Auth, error) { return auth, nil }
+
+func (e *hookTestExecutor) CountTokens(ctx context.Context, auth *Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	return e.Execute(ctx, auth, req, opts)
+}
+
+func (e *hookTestExecutor) HttpRequest(context.Context, *Auth, *http.Request) (*http.Response, error) {
+	return nil, errors.New("not implemented")
+}
+
+type recordingExecutionHook struct {
+	mu     sync.Mutex
+	before []string
+	after  []string
+	chunks int
+}
+
+func (h *recordingExecutionHook) BeforeAttempt(_ context.Context, attempt *ExecutionAttempt) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.before = append(h.before, attempt.Auth.ID)
+	attempt.Request.Payload = []byte("redacted")
+}
+
+func (h *recordingExecutionHook) AfterAttempt(_ context.Context, attempt *ExecutionAttempt, _ cliproxyexecutor.Response, err error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	status := "ok"
+	if err != nil {
+		status = "err"
+	}
+	h.after = append(h.after, attempt.Auth.ID+":"+status)
+}
+
+func (h *recordingExecutionHook) OnAttemptChunk(context.Context, *ExecutionAttempt, cliproxyexecutor.StreamChunk) {
+	h.mu.Lock()
+	h.chunks++
+	h.mu.Unlock()
+}
+
+func TestManagerExecutionHooks_SeeEveryAttempt(t *testing.T) {
+	m := NewManager(nil, &FillFirstSelector{}, nil)
+	exec := &hookTestExecutor{failIDs: map[string]bool{"a": true}}
+	m.RegisterExecutor(exec)
+	for _, id := range []string{"a", "b"} {
+		if _, err := m.Register(context.Background(), &Auth{ID: id, Provider: "hooktest"}); err != nil {
+			t.Fatalf("register %s: %v", id, err)
+		}
+	}
+	hook := &recordingExecutionHook{}
+	m.SetExecutionHooks(hook)
+
+	resp, err := m.Execute(context.Background(), []string{"hooktest"}, cliproxyexecutor.Request{Payload: []byte("secret")}, cliproxyexecutor.Options{})
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if string(resp.Payload) != "ok:b" {
+		t.Fatalf("Execute() payload = %q, want %q", resp.Payload, "ok:b")
+	}
+	if got := hook.before; len(got) != 2 || got[0] != "a" || got[1] != "b" {
+		t.Fatalf("before = %v, want [a b]", got)
+	}
+	if got := hook.after; len(got) != 2 || got[0] != "a:err" || got[1] != "b:ok" {
+		t.Fatalf("after = %v, want [a:err b:ok]", got)
+	}
+	for _, payload := range exec.payloads {
+		if payload != "redacted" {
+			t.Fatalf("executor saw payload %q, want hook-modified payload", payload)
+		}
+	}
+}
+
+func TestManagerExecutionHooks_StreamChunks(t *testing.T) {
+	m := NewManager(nil, &FillFirstSelector{}, nil)
+	m.RegisterExecutor(&hookTestExecutor{})
+	if _, err := m.Register(context.Background(), &Auth{ID: "a", Provider: "hooktest"}); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+	hook := &recordingExecutionHook{}
+	m.AddExecutionHook(hook)
+
+	chunks, err := m.ExecuteStream(context.Background(), []string{"hooktest"}, cliproxyexecutor.Request{}, cliproxyexecutor.Options{Stream: true})
+	if err != nil {
+		t.Fatalf("ExecuteStream() error = %v", err)
+	}
+	for range chunks {
+	}
+	hook.mu.Lock()
+	defer hook.mu.Unlock()
+	if hook.chunks != 2 {
+		t.Fatalf("chunks = %d, want 2", hook.chunks)
+	}
+	if len(hook.after) != 1 || hook.after[0] != "a:ok" {
+		t.Fatalf("after = %v, want [a:ok]", hook.after)
+	}
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) { return f(req) }
+
+type mutatingExecutionHook struct {
+	transport http.RoundTripper
+}
+
+func (h *mutatingExecutionHook) BeforeAttempt(_ context.Context, attempt *ExecutionAttempt) {
+	attempt.Auth.Label = "mutated"
+	attempt.Auth.Disabled = true
+	attempt.RoundTripper = h.transport
+}
+
+func (h *mutatingExecutionHook) AfterAttempt(context.Context, *ExecutionAttempt, cliproxyexecutor.Response, error) {
+}
+
+func (h *mutatingExecutionHook) OnAttemptChunk(context.Context, *ExecutionAttempt, cliproxyexecutor.StreamChunk) {
+}
+
+func TestManagerExecutionHooks_IsolateAuthAndApplyTransport(t *testing.T) {
+	m := NewManager(nil, &FillFirstSelector{}, nil)
+	exec := &hookTestExecutor{}
+	m.RegisterExecutor(exec)
+	if _, err := m.Register(context.Background(), &Auth{ID: "a", Provider: "hooktest", Label: "original"}); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+	transport := roundTripperFunc(func(*http.Request) (*http.Response, error) { return nil, errors.New("unused") })
+	m.SetExecutionHooks(&mutatingExecutionHook{transport: transport})
+
+	if _, err := m.Execute(context.Background(), []string{"hooktest"}, cliproxyexecutor.Request{}, cliproxyexecutor.Options{}); err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	auth, ok := m.GetByID("a")
+	if !ok || auth.Label != "original" || auth.Disabled {
+		t.Fatalf("hook changes leaked into the managed credential: %+v", auth)
+	}
+	if len(exec.roundTrippers) != 1 || exec.roundTrippers[0] == nil {
+		t.Fatalf("expected the hook transport in the executor context, got %v", exec.roundTrippers)
+	}
+}
@@ -0,0 +1,191 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/thinking"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// fallbackTarget is a resolved entry of a model fallback chain.
+type fallbackTarget struct {
+	model     string
+	providers []string
+}
+
+// fallbackTargets resolves the configured fallback chain for model when err qualifies for
+// falling back. Fallback models without any registered provider are skipped. A thinking
+// suffix on the requested model is carried over to fallbacks that do not specify one.
+func (m *Manager) fallbackTargets(model string, opts cliproxyexecutor.Options, err error) []fallbackTarget {
+	if m == nil || !isFallbackError(err) {
+		return nil
+	}
+	return m.fallbackChain(model, opts)
+}
+
+// fallbackChain resolves the configured fallback chain for model regardless of any error.
+// Targets outside the calling key's allowed models and providers (see opts.Metadata) are dropped.
+func (m *Manager) fallbackChain(model string, opts cliproxyexecutor.Options) []fallbackTarget {
+	cfg, _ := m.runtimeConfig.Load().(*internalconfig.Config)
+	if cfg == nil || len(cfg.ModelFallbacks) == 0 {
+		return nil
+	}
+	parsed := thinking.ParseSuffix(model)
+	baseModel := strings.TrimSpace(parsed.ModelName)
+	if baseModel == "" {
+		baseModel = strings.TrimSpace(model)
+	}
+	var chain []string
+	for _, entry := range cfg.ModelFallbacks {
+		if util.MatchModelPattern(entry.Model, baseModel) || util.MatchModelPattern(entry.Model, model) {
+			chain = entry.Fallbacks
+			break
+		}
+	}
+	allowedModels := metadataStrings(opts.Metadata, cliproxyexecutor.AllowedModelsMetadataKey)
+	allowedProviders := metadataStrings(opts.Metadata, cliproxyexecutor.AllowedProvidersMetadataKey)
+	targets := make([]fallbackTarget, 0, len(chain))
+	for _, fallback := range chain {
+		fallbackParsed := thinking.ParseSuffix(fallback)
+		fallbackBase := strings.TrimSpace(fallbackParsed.ModelName)
+		if strings.EqualFold(fallbackBase, baseModel) {
+			continue
+		}
+		if len(allowedModels) > 0 && !matchAnyModelPattern(allowedModels, fallbackBase, fallback) {
+			continue
+		}
+		providers := filterAllowedProviders(m.normalizeProviders(util.GetProviderName(fallbackBase)), allowedProviders)
+		if len(providers) == 0 {
+			continue
+		}
+		target := fallback
+		if parsed.HasSuffix && !fallbackParsed.HasSuffix {
+			target = fmt.Sprintf("%s(%s)", fallbackBase, parsed.RawSuffix)
+		}
+		targets = append(targets, fallbackTarget{model: target, providers: providers})
+	}
+	return targets
+}
+
+// metadataStrings returns the []string stored under key in meta.
+func metadataStrings(meta map[string]any, key string) []string {
+	if len(meta) == 0 {
+		return nil
+	}
+	switch v := meta[key].(type) {
+	case []string:
+		return v
+	case []any:
+		out := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				out = append(out, s)
+			}
+		}
+		return out
+	}
+	return nil
+}
+
+func matchAnyModelPattern(patterns []string, models ...string) bool {
+	for _, pattern := range patterns {
+		for _, model := range models {
+			if util.MatchModelPattern(pattern, model) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// filterAllowedProviders keeps the providers listed in allowed. An empty allow-list keeps all.
+func filterAllowedProviders(providers, allowed []string) []string {
+	if len(allowed) == 0 {
+		return providers
+	}
+	filtered := make([]string, 0, len(providers))
+	for _, provider := range providers {
+		for _, allowedProvider := range allowed {
+			if strings.EqualFold(provider, allowedProvider) {
+				filtered = append(filtered, provider)
+				break
+			}
+		}
+	}
+	return filtered
+}
+
+// isFallbackError reports whether err signals that the requested model is temporarily
+// unusable: every credential cooling down, quota exhaustion, or an upstream 5xx.
+func isFallbackError(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var cooldownErr *modelCooldownError
+	if errors.As(err, &cooldownErr) {
+		return true
+	}
+	var authErr *Error
+	if errors.As(err, &authErr) && authErr != nil && authErr.Code == "auth_unavailable" {
+		return true
+	}
+	status := statusCodeFromError(err)
+	return status == http.StatusTooManyRequests || status >= http.StatusInternalServerError
+}
+
+// prepareFallback rewrites the request for the fallback model. The payload stays in the
+// client's source format so the executor of the fallback provider re-translates it, and the
+// requested model metadata is updated so responses report the model that actually served.
+func prepareFallback(ctx context.Context, target fallbackTarget, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (context.Context, cliproxyexecutor.Request, cliproxyexecutor.Options) {
+	primary := req.Model
+	if from := cliproxyexecutor.FallbackFrom(ctx); from != "" {
+		primary = from
+	}
+	ctx = cliproxyexecutor.WithFallbackFrom(ctx, primary)
+
+	fallbackReq := req
+	fallbackReq.Model = target.model
+	fallbackReq.Payload = rewritePayloadModel(req.Payload, target.model)
+
+	fallbackOpts := opts
+	fallbackOpts.OriginalRequest = rewritePayloadModel(opts.OriginalRequest, target.model)
+	meta := make(map[string]any, len(opts.Metadata)+1)
+	for k, v := range opts.Metadata {
+		meta[k] = v
+	}
+	meta[cliproxyexecutor.RequestedModelMetadataKey] = target.model
+	fallbackOpts.Metadata = meta
+	return ctx, fallbackReq, fallbackOpts
+}
+
+// rewritePayloadModel replaces the top-level "model" field when the payload carries one.
+func rewritePayloadModel(payload []byte, model string) []byte {
+	if len(payload) == 0 || !gjson.GetBytes(payload, "model").Exists() {
+		return payload
+	}
+	updated, err := sjson.SetBytes(payload, "model", model)
+	if err != nil {
+		return payload
+	}
+	return updated
+}
+
+func logFallback(ctx context.Context, from, to string, err error) {
+	entry := logEntryWithRequestID(ctx)
+	if status := statusCodeFromError(err); status > 0 {
+		entry.Warnf("model fallback: %s unavailable (status %d), routing to %s", from, status, to)
+		return
+	}
+	entry.Warnf("model fallback: %s unavailable, routing to %s", from, to)
+}
@@ -0,0 +1,170 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+)
+
+type fallbackTestExecutor struct {
+	id     string
+	status int
+
+	mu       sync.Mutex
+	models   []string
+	payloads []string
+	fallback []string
+}
+
+func (e *fallbackTestExecutor) Identifier() string { return e.id }
+
+func (e *fallbackTestExecutor) Execute(ctx context.Context, _ *Auth, req cliproxyexecutor.Request, _ cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	e.mu.Lock()
+	e.models = append(e.models, req.Model)
+	e.payloads = append(e.payloads, string(req.Payload))
+	e.fallback = append(e.fallback, cliproxyexecutor.FallbackFrom(ctx))
+	e.mu.Unlock()
+	if e.status != 0 {
+		return cliproxyexecutor.Response{}, &Error{HTTPStatus: e.status, Message: "unavailable"}
+	}
+	return cliproxyexecutor.Response{Payload: []byte(`{"model":"` + req.Model + `"}`)}, nil
+}
+
+func (e *fallbackTestExecutor) ExecuteStream(ctx context.Context, auth *Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (<-chan cliproxyexecutor.StreamChunk, error) {
+	resp, err := e.Execute(ctx, auth, req, opts)
+	if err != nil {
+		return nil, err
+	}
+	ch := make(chan cliproxyexecutor.StreamChunk, 1)
+	ch <- cliproxyexecutor.StreamChunk{Payload: resp.Payload}
+	close(ch)
+	return ch, nil
+}
+
+func (e *fallbackTestExecutor) Refresh(_ context.Context, auth *Auth) (*Auth, error) {
+	return auth, nil
+}
+
+func (e *fallbackTestExecutor) CountTokens(ctx context.Context, auth *Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	return e.Execute(ctx, auth, req, opts)
+}
+
+func (e *fallbackTestExecutor) HttpRequest(context.Context, *Auth, *http.Request) (*http.Response, error) {
+	return nil, errors.New("not implemented")
+}
+
+func setupFallbackManager(t *testing.T, primaryStatus, secondaryStatus int) (*Manager, *fallbackTestExecutor, *fallbackTestExecutor) {
+	t.Helper()
+	m := NewManager(nil, &FillFirstSelector{}, nil)
+	primary := &fallbackTestExecutor{id: "fbprimary", status: primaryStatus}
+	secondary := &fallbackTestExecutor{id: "fbsecondary", status: secondaryStatus}
+	m.RegisterExecutor(primary)
+	m.RegisterExecutor(secondary)
+
+	reg := registry.GetGlobalRegistry()
+	for _, entry := range []struct{ id, provider, model string }{
+		{"fb-primary-auth", "fbprimary", "fb-opus"},
+		{"fb-secondary-auth", "fbsecondary", "fb-pro"},
+	} {
+		if _, err := m.Register(context.Background(), &Auth{ID: entry.id, Provider: entry.provider}); err != nil {
+			t.Fatalf("register %s: %v", entry.id, err)
+		}
+		reg.RegisterClient(entry.id, entry.provider, []*registry.ModelInfo{{ID: entry.model}})
+		id := entry.id
+		t.Cleanup(func() { reg.UnregisterClient(id) })
+	}
+	m.SetConfig(&internalconfig.Config{ModelFallbacks: []internalconfig.ModelFallback{
+		{Model: "fb-*", Fallbacks: []string{"fb-missing", "fb-pro"}},
+	}})
+	return m, primary, secondary
+}
+
+func TestManagerExecute_FallsBackAcrossProviders(t *testing.T) {
+	m, primary, secondary := setupFallbackManager(t, http.StatusTooManyRequests, 0)
+
+	req := cliproxyexecutor.Request{Model: "fb-opus(high)", Payload: []byte(`{"model":"fb-opus(high)","messages":[]}`)}
+	resp, err := m.Execute(context.Background(), []string{"fbprimary"}, req, cliproxyexecutor.Options{})
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if string(resp.Payload) != `{"model":"fb-pro(high)"}` {
+		t.Fatalf("unexpected response %s", resp.Payload)
+	}
+	if len(primary.models) != 1 {
+		t.Fatalf("primary attempts = %d, want 1", len(primary.models))
+	}
+	if got := secondary.models; len(got) != 1 || got[0] != "fb-pro(high)" {
+		t.Fatalf("secondary models = %v", got)
+	}
+	if got := secondary.payloads[0]; got != `{"model":"fb-pro(high)","messages":[]}` {
+		t.Fatalf("fallback payload = %s", got)
+	}
+	if got := secondary.fallback[0]; got != "fb-opus(high)" {
+		t.Fatalf("fallback context = %q, want primary model", got)
+	}
+}
+
+func TestManagerExecute_NoFallbackOnClientError(t *testing.T) {
+	m, _, secondary := setupFallbackManager(t, http.StatusBadRequest, 0)
+
+	_, err := m.Execute(context.Background(), []string{"fbprimary"}, cliproxyexecutor.Request{Model: "fb-opus"}, cliproxyexecutor.Options{})
+	if statusCodeFromError(err) != http.StatusBadRequest {
+		t.Fatalf("expected primary 400, got %v", err)
+	}
+	if len(secondary.models) != 0 {
+		t.Fatalf("fallback must not run for client errors, got %v", secondary.models)
+	}
+}
+
+func TestManagerExecuteStream_ReturnsPrimaryErrorWhenChainFails(t *testing.T) {
+	m, _, secondary := setupFallbackManager(t, http.StatusServiceUnavailable, http.StatusBadGateway)
+
+	_, err := m.ExecuteStream(context.Background(), []string{"fbprimary"}, cliproxyexecutor.Request{Model: "fb-opus"}, cliproxyexecutor.Options{Stream: true})
+	if statusCodeFromError(err) != http.StatusServiceUnavailable {
+		t.Fatalf("expected primary 503, got %v", err)
+	}
+	if len(secondary.models) != 1 {
+		t.Fatalf("fallback attempts = %d, want 1", len(secondary.models))
+	}
+}
+
+func TestManagerExecute_FallbackHonorsKeyAllowLists(t *testing.T) {
+	cases := []struct {
+		name string
+		meta map[string]any
+	}{
+		{"model not allowed", map[string]any{cliproxyexecutor.AllowedModelsMetadataKey: []string{"fb-opus"}}},
+		{"provider not allowed", map[string]any{cliproxyexecutor.AllowedProvidersMetadataKey: []string{"fbprimary"}}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			m, _, secondary := setupFallbackManager(t, http.StatusTooManyRequests, 0)
+
+			_, err := m.Execute(context.Background(), []string{"fbprimary"}, cliproxyexecutor.Request{Model: "fb-opus"}, cliproxyexecutor.Options{Metadata: tc.meta})
+			if statusCodeFromError(err) != http.StatusTooManyRequests {
+				t.Fatalf("expected primary 429, got %v", err)
+			}
+			if len(secondary.models) != 0 {
+				t.Fatalf("disallowed fallback must not run, got %v", secondary.models)
+			}
+		})
+	}
+
+	m, _, secondary := setupFallbackManager(t, http.StatusTooManyRequests, 0)
+	meta := map[string]any{
+		cliproxyexecutor.AllowedModelsMetadataKey:    []string{"fb-*"},
+		cliproxyexecutor.AllowedProvidersMetadataKey: []string{"fbprimary", "fbsecondary"},
+	}
+	if _, err := m.Execute(context.Background(), []string{"fbprimary"}, cliproxyexecutor.Request{Model: "fb-opus"}, cliproxyexecutor.Options{Metadata: meta}); err != nil {
+		t.Fatalf("allowed fallback failed: %v", err)
+	}
+	if len(secondary.models) != 1 {
+		t.Fatalf("fallback attempts = %d, want 1", len(secondary.models))
+	}
+}
@@ -0,0 +1,238 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/thinking"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	"github.com/tidwall/gjson"
+)
+
+// defaultHedgeDelay applies when a hedging rule does not set delay-ms.
+const defaultHedgeDelay = time.Second
+
+// sideEffectToolPrefixes lists tool types the upstream (or an MCP server it calls) executes on
+// the caller's behalf. Running such a request twice could repeat the action, so it is never hedged.
+var sideEffectToolPrefixes = []string{"mcp", "code_interpreter", "code_execution", "computer", "local_shell", "shell", "bash", "text_editor", "apply_patch"}
+
+// hedgeGroup coordinates the two legs of a hedged request. Each leg claims the credentials it
+// uses so both never run on the same one. Usage records are held back until executeHedged has
+// settled on the leg whose response the client gets; only that leg's usage is published.
+type hedgeGroup struct {
+	mu      sync.Mutex
+	claimed map[string]int
+	settled bool
+	// winner is the index of the returned leg, or -1 when every leg failed.
+	winner  int
+	pending [2][]func()
+}
+
+type hedgeLegKey struct{}
+
+type hedgeLeg struct {
+	group *hedgeGroup
+	index int
+}
+
+func withHedgeLeg(ctx context.Context, group *hedgeGroup, index int) context.Context {
+	ctx = context.WithValue(ctx, hedgeLegKey{}, hedgeLeg{group: group, index: index})
+	return cliproxyexecutor.WithUsageGate(ctx, group.usageGate(index))
+}
+
+// claimHedgeCredential reserves authID for the hedge leg serving ctx. It reports false when
+// the other leg already uses the credential; outside hedged requests it always succeeds.
+func claimHedgeCredential(ctx context.Context, authID string) bool {
+	leg, ok := ctx.Value(hedgeLegKey{}).(hedgeLeg)
+	if !ok || leg.group == nil {
+		return true
+	}
+	leg.group.mu.Lock()
+	defer leg.group.mu.Unlock()
+	if owner, claimed := leg.group.claimed[authID]; claimed && owner != leg.index {
+		return false
+	}
+	leg.group.claimed[authID] = leg.index
+	return true
+}
+
+// usageGate holds back the usage records of leg index until the group is settled, then
+// publishes them only when the leg won or every leg failed.
+func (g *hedgeGroup) usageGate(index int) func(publish func()) {
+	return func(publish func()) {
+		g.mu.Lock()
+		if !g.settled {
+			g.pending[index] = append(g.pending[index], publish)
+			g.mu.Unlock()
+			return
+		}
+		allowed := g.winner < 0 || g.winner == index
+		g.mu.Unlock()
+		if allowed {
+			publish()
+		}
+	}
+}
+
+// settle records the leg whose result is returned, or -1 when every leg failed, and publishes
+// the usage held back for it. Records the other leg publishes later are dropped.
+func (g *hedgeGroup) settle(winner int) {
+	g.mu.Lock()
+	g.settled = true
+	g.winner = winner
+	var release []func()
+	for index, pending := range g.pending {
+		if winner < 0 || winner == index {
+			release = append(release, pending...)
+		}
+	}
+	g.pending = [2][]func(){}
+	g.mu.Unlock()
+	for _, publish := range release {
+		publish()
+	}
+}
+
+// hedgeDelay returns the hedging delay configured for a non-streaming request, if any.
+func (m *Manager) hedgeDelay(req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (time.Duration, bool) {
+	if m == nil || opts.Stream || opts.Alt != "" {
+		return 0, false
+	}
+	cfg, _ := m.runtimeConfig.Load().(*internalconfig.Config)
+	if cfg == nil || len(cfg.Hedging) == 0 {
+		return 0, false
+	}
+	baseModel := strings.TrimSpace(thinking.ParseSuffix(req.Model).ModelName)
+	for _, rule := range cfg.Hedging {
+		if !util.MatchModelPattern(rule.Model, baseModel) && !util.MatchModelPattern(rule.Model, req.Model) {
+			continue
+		}
+		payload := opts.OriginalRequest
+		if len(payload) == 0 {
+			payload = req.Payload
+		}
+		if hasSideEffectTools(payload) {
+			return 0, false
+		}
+		if rule.DelayMS <= 0 {
+			return defaultHedgeDelay, true
+		}
+		return time.Duration(rule.DelayMS) * time.Millisecond, true
+	}
+	return 0, false
+}
+
+// hasSideEffectTools reports whether payload declares tools the upstream executes itself:
+// OpenAI/Claude tool types from sideEffectToolPrefixes, Claude mcp_servers and Gemini code
+// execution or computer use.
+func hasSideEffectTools(payload []byte) bool {
+	if len(payload) == 0 {
+		return false
+	}
+	root := gjson.ParseBytes(payload)
+	if len(root.Get("mcp_servers").Array()) > 0 {
+		return true
+	}
+	for _, tool := range root.Get("tools").Array() {
+		toolType := strings.ToLower(tool.Get("type").String())
+		for _, prefix := range sideEffectToolPrefixes {
+			if strings.HasPrefix(toolType, prefix) {
+				return true
+			}
+		}
+		if tool.Get("codeExecution").Exists() || tool.Get("code_execution").Exists() || tool.Get("computerUse").Exists() {
+			return true
+		}
+	}
+	return false
+}
+
+type hedgeOutcome struct {
+	resp  cliproxyexecutor.Response
+	err   error
+	index int
+}
+
+// executeHedged runs the regular execution path and, when it has not finished after delay,
+// races a second leg on another credential. The first success is returned and the other leg
+// is cancelled; if both fail the primary leg's error is returned.
+func (m *Manager) executeHedged(ctx context.Context, providers []string, req cliproxyexecutor.Request, opts cliproxyexecutor.Options, delay time.Duration) (cliproxyexecutor.Response, error) {
+	group := &hedgeGroup{claimed: make(map[string]int)}
+	results := make(chan hedgeOutcome, 2)
+	primaryCtx, cancelPrimary := context.WithCancel(withHedgeLeg(ctx, group, 0))
+	defer cancelPrimary()
+	go func() {
+		resp, err := m.executeWithFallback(primaryCtx, providers, req, opts)
+		results <- hedgeOutcome{resp: resp, err: err, index: 0}
+	}()
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case out := <-results:
+		group.settle(out.index)
+		return out.resp, out.err
+	case <-ctx.Done():
+		out := <-results
+		group.settle(out.index)
+		return out.resp, out.err
+	case <-timer.C:
+	}
+
+	logEntryWithRequestID(ctx).Debugf("hedging %s: no response after %s, starting second attempt", req.Model, delay)
+	hedgeCtx, cancelHedge := context.WithCancel(withHedgeLeg(ctx, group, 1))
+	defer cancelHedge()
+	go func() {
+		resp, err := m.executeHedgeLeg(hedgeCtx, providers, req, opts)
+		results <- hedgeOutcome{resp: resp, err: err, index: 1}
+	}()
+
+	var errs [2]error
+	for pending := 2; pending > 0; pending-- {
+		out := <-results
+		if out.err == nil {
+			if out.index == 0 {
+				cancelHedge()
+			} else {
+				cancelPrimary()
+			}
+			group.settle(out.index)
+			return out.resp, nil
+		}
+		errs[out.index] = out.err
+	}
+	group.settle(-1)
+	return cliproxyexecutor.Response{}, errs[0]
+}
+
+// executeHedgeLeg serves the hedge on another credential of the requested model or, when none
+// is free, on the first fallback model that has one. Unlike the primary leg it never waits
+// out cooldowns.
+func (m *Manager) executeHedgeLeg(ctx context.Context, providers []string, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	resp, errExec := m.executeMixedOnce(ctx, m.normalizeProviders(providers), req, opts)
+	if errExec == nil || ctx.Err() != nil || !(isFallbackError(errExec) || isNoCredentialError(errExec)) {
+		return resp, errExec
+	}
+	for _, target := range m.fallbackChain(req.Model, opts) {
+		fallbackCtx, fallbackReq, fallbackOpts := prepareFallback(ctx, target, req, opts)
+		fallbackResp, errFallback := m.executeMixedOnce(fallbackCtx, target.providers, fallbackReq, fallbackOpts)
+		if errFallback == nil {
+			return fallbackResp, nil
+		}
+		if ctx.Err() != nil {
+			return cliproxyexecutor.Response{}, ctx.Err()
+		}
+	}
+	return resp, errExec
+}
+
+// isNoCredentialError reports whether err means no credential could be picked.
+func isNoCredentialError(err error) bool {
+	var authErr *Error
+	return errors.As(err, &authErr) && authErr != nil && authErr.Code == "auth_not_found"
+}
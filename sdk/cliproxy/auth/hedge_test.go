@@ -0,0 +1,224 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	coreusage "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
+)
+
+type hedgeTestExecutor struct {
+	delays map[string]time.Duration
+
+	mu        sync.Mutex
+	calls     []string
+	cancelled []string
+}
+
+func (e *hedgeTestExecutor) Identifier() string { return "hedgetest" }
+
+func (e *hedgeTestExecutor) Execute(ctx context.Context, auth *Auth, _ cliproxyexecutor.Request, _ cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	e.mu.Lock()
+	e.calls = append(e.calls, auth.ID)
+	e.mu.Unlock()
+	select {
+	case <-time.After(e.delays[auth.ID]):
+		return cliproxyexecutor.Response{Payload: []byte(auth.ID)}, nil
+	case <-ctx.Done():
+		e.mu.Lock()
+		e.cancelled = append(e.cancelled, auth.ID)
+		e.mu.Unlock()
+		return cliproxyexecutor.Response{}, ctx.Err()
+	}
+}
+
+func (e *hedgeTestExecutor) ExecuteStream(context.Context, *Auth, cliproxyexecutor.Request, cliproxyexecutor.Options) (<-chan cliproxyexecutor.StreamChunk, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (e *hedgeTestExecutor) Refresh(_ context.Context, auth *Auth) (*Auth, error) {
+	return auth, nil
+}
+
+func (e *hedgeTestExecutor) CountTokens(ctx context.Context, auth *Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	return e.Execute(ctx, auth, req, opts)
+}
+
+func (e *hedgeTestExecutor) HttpRequest(context.Context, *Auth, *http.Request) (*http.Response, error) {
+	return nil, errors.New("not implemented")
+}
+
+func setupHedgeManager(t *testing.T, delays map[string]time.Duration) (*Manager, *hedgeTestExecutor) {
+	t.Helper()
+	m := NewManager(nil, &FillFirstSelector{}, nil)
+	exec := &hedgeTestExecutor{delays: delays}
+	m.RegisterExecutor(exec)
+	for _, id := range []string{"a", "b"} {
+		if _, err := m.Register(context.Background(), &Auth{ID: id, Provider: "hedgetest"}); err != nil {
+			t.Fatalf("register %s: %v", id, err)
+		}
+	}
+	m.SetConfig(&internalconfig.Config{Hedging: []internalconfig.HedgingRule{{Model: "*", DelayMS: 20}}})
+	return m, exec
+}
+
+func TestManagerExecute_HedgeReturnsFasterCredential(t *testing.T) {
+	m, exec := setupHedgeManager(t, map[string]time.Duration{"a": 2 * time.Second, "b": 0})
+
+	start := time.Now()
+	resp, err := m.Execute(context.Background(), []string{"hedgetest"}, cliproxyexecutor.Request{}, cliproxyexecutor.Options{})
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if string(resp.Payload) != "b" {
+		t.Fatalf("response from %s, want hedge credential b", resp.Payload)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("hedged request took %v", elapsed)
+	}
+	deadline := time.Now().Add(time.Second)
+	for {
+		exec.mu.Lock()
+		cancelled := append([]string(nil), exec.cancelled...)
+		exec.mu.Unlock()
+		if len(cancelled) == 1 && cancelled[0] == "a" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("cancelled = %v, want slow primary cancelled", cancelled)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestManagerExecute_NoHedgeWithSideEffectTools(t *testing.T) {
+	m, exec := setupHedgeManager(t, map[string]time.Duration{"a": 60 * time.Millisecond, "b": 0})
+
+	req := cliproxyexecutor.Request{Payload: []byte(`{"tools":[{"type":"mcp","server_url":"https://example.com"}]}`)}
+	resp, err := m.Execute(context.Background(), []string{"hedgetest"}, req, cliproxyexecutor.Options{})
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if string(resp.Payload) != "a" || len(exec.calls) != 1 {
+		t.Fatalf("response %s after calls %v, want a single unhedged attempt", resp.Payload, exec.calls)
+	}
+}
+
+func TestHasSideEffectTools(t *testing.T) {
+	cases := map[string]bool{
+		`{"tools":[{"type":"function","function":{"name":"lookup"}}]}`: false,
+		`{"tools":[{"type":"web_search_preview"}]}`:                    false,
+		`{"tools":[{"type":"code_interpreter"}]}`:                      true,
+		`{"tools":[{"type":"computer_20250124"}]}`:                     true,
+		`{"mcp_servers":[{"type":"url","url":"https://example.com"}]}`: true,
+		`{"tools":[{"codeExecution":{}}]}`:                             true,
+		`{"messages":[]}`:                                              false,
+	}
+	for payload, want := range cases {
+		if got := hasSideEffectTools([]byte(payload)); got != want {
+			t.Errorf("hasSideEffectTools(%s) = %v, want %v", payload, got, want)
+		}
+	}
+}
+
+func TestHedgeGroupRecordsOnlyWinnerUsage(t *testing.T) {
+	group := &hedgeGroup{claimed: make(map[string]int)}
+	var published []string
+	record := func(name string) func() { return func() { published = append(published, name) } }
+
+	primary := withHedgeLeg(context.Background(), group, 0)
+	hedge := withHedgeLeg(context.Background(), group, 1)
+	cliproxyexecutor.PublishUsage(primary, record("primary"))
+	cliproxyexecutor.PublishUsage(hedge, record("hedge"))
+	if len(published) != 0 {
+		t.Fatalf("usage must be held back until the group settles, got %v", published)
+	}
+	group.settle(1)
+	cliproxyexecutor.PublishUsage(primary, record("primary-late"))
+	cliproxyexecutor.PublishUsage(hedge, record("hedge-late"))
+	if strings.Join(published, ",") != "hedge,hedge-late" {
+		t.Fatalf("published = %v, want only the winning leg's usage", published)
+	}
+
+	failed := &hedgeGroup{claimed: make(map[string]int)}
+	published = nil
+	cliproxyexecutor.PublishUsage(withHedgeLeg(context.Background(), failed, 0), record("primary"))
+	cliproxyexecutor.PublishUsage(withHedgeLeg(context.Background(), failed, 1), record("hedge"))
+	failed.settle(-1)
+	if len(published) != 2 {
+		t.Fatalf("published = %v, want the usage of both failed legs", published)
+	}
+
+	if !claimHedgeCredential(primary, "a") || !claimHedgeCredential(primary, "a") {
+		t.Fatal("a leg may reuse its own credential")
+	}
+	if claimHedgeCredential(hedge, "a") {
+		t.Fatal("the hedge leg must not reuse the primary credential")
+	}
+	if !claimHedgeCredential(context.Background(), "a") {
+		t.Fatal("unhedged requests always claim")
+	}
+}
+
+// usageHedgeExecutor publishes usage for credential a before its response is ready, so the
+// slow primary's record arrives first although the hedge's response is returned.
+type usageHedgeExecutor struct {
+	hedgeTestExecutor
+}
+
+func (e *usageHedgeExecutor) Execute(ctx context.Context, auth *Auth, _ cliproxyexecutor.Request, _ cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	publish := func() {
+		cliproxyexecutor.PublishUsage(ctx, func() {
+			coreusage.PublishRecord(ctx, coreusage.Record{Provider: "hedgetest", AuthID: auth.ID, Detail: coreusage.Detail{TotalTokens: 1}})
+		})
+	}
+	if auth.ID == "a" {
+		time.Sleep(30 * time.Millisecond)
+		publish()
+		time.Sleep(200 * time.Millisecond)
+		return cliproxyexecutor.Response{Payload: []byte(auth.ID)}, nil
+	}
+	time.Sleep(20 * time.Millisecond)
+	publish()
+	return cliproxyexecutor.Response{Payload: []byte(auth.ID)}, nil
+}
+
+func TestManagerExecute_HedgeRecordsUsageOfReturnedLeg(t *testing.T) {
+	m := NewManager(nil, &FillFirstSelector{}, nil)
+	m.RegisterExecutor(&usageHedgeExecutor{})
+	for _, id := range []string{"a", "b"} {
+		if _, err := m.Register(context.Background(), &Auth{ID: id, Provider: "hedgetest"}); err != nil {
+			t.Fatalf("register %s: %v", id, err)
+		}
+	}
+	m.SetConfig(&internalconfig.Config{Hedging: []internalconfig.HedgingRule{{Model: "*", DelayMS: 20}}})
+
+	var mu sync.Mutex
+	var records []string
+	ctx := coreusage.WithRecordObserver(context.Background(), func(record coreusage.Record) {
+		mu.Lock()
+		records = append(records, record.AuthID)
+		mu.Unlock()
+	})
+	resp, err := m.Execute(ctx, []string{"hedgetest"}, cliproxyexecutor.Request{}, cliproxyexecutor.Options{})
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if string(resp.Payload) != "b" {
+		t.Fatalf("response from %s, want hedge credential b", resp.Payload)
+	}
+	// Let the primary finish and publish its late record.
+	time.Sleep(250 * time.Millisecond)
+	mu.Lock()
+	defer mu.Unlock()
+	if len(records) != 1 || records[0] != "b" {
+		t.Fatalf("usage records = %v, want only the returned leg b", records)
+	}
+}
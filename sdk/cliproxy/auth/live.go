@@ -0,0 +1,90 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+)
+
+// LiveExecutor is implemented by provider executors that can open bidirectional live
+// sessions, such as the Gemini Live API.
+type LiveExecutor interface {
+	// ExecuteLive opens an upstream session for req.Model. req.Payload carries the client's
+	// setup message, which the caller sends on the returned session once it is open.
+	ExecuteLive(ctx context.Context, auth *Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (cliproxyexecutor.LiveSession, error)
+}
+
+// ExecuteLive opens a live session with the first available credential among providers whose
+// executor implements LiveExecutor. Credentials that fail to connect are marked like failed
+// requests and the next one is tried. The session is bound to ctx.
+func (m *Manager) ExecuteLive(ctx context.Context, providers []string, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (cliproxyexecutor.LiveSession, error) {
+	normalized := m.normalizeProviders(providers)
+	if len(normalized) == 0 {
+		return nil, &Error{Code: "provider_not_found", Message: "no provider supplied"}
+	}
+	routeModel := req.Model
+	opts = ensureRequestedModelMetadata(opts, routeModel)
+	tried := make(map[string]struct{})
+	var lastErr error
+	for {
+		auth, executor, provider, errPick := m.pickNextMixed(ctx, normalized, routeModel, opts, tried)
+		if errPick != nil {
+			if lastErr != nil {
+				return nil, lastErr
+			}
+			return nil, errPick
+		}
+		tried[auth.ID] = struct{}{}
+		liveExecutor, ok := executor.(LiveExecutor)
+		if !ok {
+			m.releaseCredential(auth)
+			lastErr = &Error{Code: "not_supported", Message: "live sessions are not supported for model " + routeModel, HTTPStatus: http.StatusNotImplemented}
+			continue
+		}
+
+		debugLogAuthSelection(logEntryWithRequestID(ctx), auth, provider, routeModel)
+		execCtx := ctx
+		if rt := m.roundTripperFor(auth); rt != nil {
+			execCtx = context.WithValue(execCtx, roundTripperContextKey{}, rt)
+			execCtx = context.WithValue(execCtx, "cliproxy.roundtripper", rt)
+		}
+		execReq := req
+		execReq.Model = m.UpstreamModel(auth, routeModel)
+		if errPace := m.paceCredential(execCtx, auth); errPace != nil {
+			if errCtx := execCtx.Err(); errCtx != nil {
+				return nil, errCtx
+			}
+			lastErr = errPace
+			continue
+		}
+		session, errExec := liveExecutor.ExecuteLive(execCtx, auth, execReq, opts)
+		if errors.Is(errExec, cliproxyexecutor.ErrLiveUnsupported) {
+			m.releaseCredential(auth)
+			if lastErr == nil {
+				lastErr = &Error{Code: "not_supported", Message: errExec.Error(), HTTPStatus: http.StatusNotImplemented}
+			}
+			continue
+		}
+		result := Result{AuthID: auth.ID, Provider: provider, Model: routeModel, Success: errExec == nil}
+		if errExec != nil {
+			if errCtx := execCtx.Err(); errCtx != nil {
+				return nil, errCtx
+			}
+			result.Error = &Error{Message: errExec.Error()}
+			var se cliproxyexecutor.StatusError
+			if errors.As(errExec, &se) && se != nil {
+				result.Error.HTTPStatus = se.StatusCode()
+			}
+			if ra := retryAfterFromError(errExec); ra != nil {
+				result.RetryAfter = ra
+			}
+			m.MarkResult(execCtx, result)
+			lastErr = errExec
+			continue
+		}
+		m.MarkResult(execCtx, result)
+		return session, nil
+	}
+}
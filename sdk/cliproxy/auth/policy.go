@@ -0,0 +1,407 @@
+package auth
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	cliproxypolicy "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/policy"
+)
+
+// defaultSuspendCooldown is how long a credential reported as suspended is skipped when the
+// policy does not configure a cooldown.
+const defaultSuspendCooldown = time.Hour
+
+// suspendKeywords mark upstream errors that indicate the account itself was suspended,
+// as opposed to a transient rate limit handled by the regular cooldown.
+var suspendKeywords = []string{
+	"suspended",
+	"banned",
+	"account has been disabled",
+	"account disabled",
+	"account has been deactivated",
+}
+
+// credentialPolicy is the resolved form of a config.CredentialPolicy.
+type credentialPolicy struct {
+	minInterval     time.Duration
+	maxInterval     time.Duration
+	dailyMax        int
+	score           bool
+	suspendCooldown time.Duration
+}
+
+// CredentialHealth is a read-only snapshot of the health tracked for a credential.
+type CredentialHealth struct {
+	// Score is the current selection score in [0, 1]; higher is better.
+	Score float64
+	// SuccessRate is the fraction of successful attempts.
+	SuccessRate float64
+	// AvgLatency is the mean attempt latency (time to first byte for streams).
+	AvgLatency time.Duration
+	// FailStreak counts consecutive failed attempts.
+	FailStreak int
+	// TotalRequests counts the tracked attempts.
+	TotalRequests int
+	// DailyRequests counts the requests sent during the current UTC day.
+	DailyRequests int
+	// QuotaRemaining is the last reported remaining quota fraction in [0, 1].
+	QuotaRemaining float64
+	// SuspendedUntil is set while the credential is skipped after a suspension error.
+	SuspendedUntil time.Time
+	// SuspendReason carries the upstream message that triggered the suspension.
+	SuspendReason string
+}
+
+// policyTracker implements the per-credential policies configured under
+// routing.credential-policies on top of the shared scorer, rate limiter and cooldown
+// manager in sdk/cliproxy/policy. It is registered as an implicit execution hook so
+// attempt outcomes and latencies feed the credential scores.
+type policyTracker struct {
+	scorer    *cliproxypolicy.TokenScorer
+	cooldowns *cliproxypolicy.CooldownManager
+
+	mu       sync.Mutex
+	limiters map[string]*providerLimiter
+	started  map[*ExecutionAttempt]time.Time
+	latency  map[*ExecutionAttempt]time.Duration
+
+	// policyFor resolves the policy of a provider from the Manager's runtime config.
+	policyFor func(provider string) (credentialPolicy, bool)
+}
+
+// providerLimiter paces and caps the credentials of one provider.
+type providerLimiter struct {
+	policy  credentialPolicy
+	limiter *cliproxypolicy.RateLimiter
+}
+
+func newPolicyTracker() *policyTracker {
+	return &policyTracker{
+		scorer:    cliproxypolicy.NewTokenScorer(),
+		cooldowns: cliproxypolicy.NewCooldownManager(),
+		limiters:  make(map[string]*providerLimiter),
+		started:   make(map[*ExecutionAttempt]time.Time),
+		latency:   make(map[*ExecutionAttempt]time.Duration),
+	}
+}
+
+// limiterFor returns the rate limiter of provider, replacing it when the policy changed.
+func (t *policyTracker) limiterFor(provider string, policy credentialPolicy) *cliproxypolicy.RateLimiter {
+	provider = strings.ToLower(strings.TrimSpace(provider))
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if entry, ok := t.limiters[provider]; ok && entry.policy == policy {
+		return entry.limiter
+	}
+	dailyMax := policy.dailyMax
+	if dailyMax <= 0 {
+		dailyMax = math.MaxInt
+	}
+	// RateLimiterConfig treats zero durations as unset, so a zero minimum is raised to 1ns.
+	limiter := cliproxypolicy.NewRateLimiterWithConfig(cliproxypolicy.RateLimiterConfig{
+		MinTokenInterval: max(policy.minInterval, time.Nanosecond),
+		MaxTokenInterval: max(policy.maxInterval, time.Nanosecond),
+		DailyMaxRequests: dailyMax,
+		SuspendCooldown:  policy.suspendCooldown,
+		NoJitter:         true,
+	})
+	t.limiters[provider] = &providerLimiter{policy: policy, limiter: limiter}
+	return limiter
+}
+
+// credentialPolicyFor resolves the policy configured for provider, falling back to the "*" entry.
+func (m *Manager) credentialPolicyFor(provider string) (credentialPolicy, bool) {
+	if m == nil {
+		return credentialPolicy{}, false
+	}
+	cfg, _ := m.runtimeConfig.Load().(*internalconfig.Config)
+	if cfg == nil || len(cfg.Routing.CredentialPolicies) == 0 {
+		return credentialPolicy{}, false
+	}
+	provider = strings.ToLower(strings.TrimSpace(provider))
+	var match *internalconfig.CredentialPolicy
+	for i := range cfg.Routing.CredentialPolicies {
+		entry := &cfg.Routing.CredentialPolicies[i]
+		if entry.Provider == provider {
+			match = entry
+			break
+		}
+		if entry.Provider == "*" && match == nil {
+			match = entry
+		}
+	}
+	if match == nil {
+		return credentialPolicy{}, false
+	}
+	policy := credentialPolicy{
+		minInterval:     time.Duration(match.MinIntervalMS) * time.Millisecond,
+		maxInterval:     time.Duration(match.MaxIntervalMS) * time.Millisecond,
+		dailyMax:        match.DailyMaxRequests,
+		score:           match.ScoreSelection,
+		suspendCooldown: time.Duration(match.SuspendCooldownSeconds) * time.Second,
+	}
+	if policy.maxInterval < policy.minInterval {
+		policy.maxInterval = policy.minInterval
+	}
+	if policy.suspendCooldown <= 0 {
+		policy.suspendCooldown = defaultSuspendCooldown
+	}
+	return policy, true
+}
+
+func (m *Manager) hasCredentialPolicies() bool {
+	cfg, _ := m.runtimeConfig.Load().(*internalconfig.Config)
+	return cfg != nil && len(cfg.Routing.CredentialPolicies) > 0
+}
+
+// applyCredentialPolicies removes credentials that reached their daily cap or are suspended
+// and picks one, by health score when every remaining candidate's provider enables score
+// selection and with pick otherwise. The daily cap slot of the chosen credential is reserved
+// atomically with the cap check; a credential that lost the race is dropped and another is
+// picked. Callers return the slot with releaseCredential when the request is not sent.
+func (m *Manager) applyCredentialPolicies(candidates []*Auth, provider, model string, pick func([]*Auth) (*Auth, error)) (*Auth, error) {
+	if !m.hasCredentialPolicies() {
+		return pick(candidates)
+	}
+	t := m.policies
+	filtered := make([]*Auth, 0, len(candidates))
+	scoreAll := true
+	for _, candidate := range candidates {
+		policy, ok := m.credentialPolicyFor(candidate.Provider)
+		if !ok {
+			scoreAll = false
+			filtered = append(filtered, candidate)
+			continue
+		}
+		if t.cooldowns.IsInCooldown(candidate.ID) {
+			continue
+		}
+		if policy.dailyMax > 0 && !t.limiterFor(candidate.Provider, policy).IsTokenAvailable(candidate.ID) {
+			continue
+		}
+		if !policy.score {
+			scoreAll = false
+		}
+		filtered = append(filtered, candidate)
+	}
+	for len(filtered) > 0 {
+		var selected *Auth
+		var err error
+		if scoreAll {
+			selected, err = t.pickBest(filtered, provider, model)
+		} else {
+			selected, err = pick(filtered)
+		}
+		if err != nil || selected == nil {
+			return selected, err
+		}
+		if m.acquireCredential(selected) {
+			return selected, nil
+		}
+		remaining := filtered[:0:0]
+		for _, candidate := range filtered {
+			if candidate.ID != selected.ID {
+				remaining = append(remaining, candidate)
+			}
+		}
+		filtered = remaining
+	}
+	return nil, &Error{Code: "auth_unavailable", Message: "all credentials reached their daily request cap or are suspended", HTTPStatus: http.StatusTooManyRequests}
+}
+
+// pickBest returns the available candidate with the highest health score.
+func (t *policyTracker) pickBest(candidates []*Auth, provider, model string) (*Auth, error) {
+	available, err := getAvailableAuths(candidates, provider, model, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]string, len(available))
+	for i, candidate := range available {
+		ids[i] = candidate.ID
+	}
+	best := t.scorer.SelectBestToken(ids)
+	for _, candidate := range available {
+		if candidate.ID == best {
+			return candidate, nil
+		}
+	}
+	return nil, nil
+}
+
+// acquireCredential counts a request against the daily cap of auth, reporting false when
+// the cap was reached.
+func (m *Manager) acquireCredential(auth *Auth) bool {
+	policy, ok := m.credentialPolicyFor(auth.Provider)
+	if !ok || policy.dailyMax <= 0 {
+		return true
+	}
+	return m.policies.limiterFor(auth.Provider, policy).TryAcquire(auth.ID)
+}
+
+// releaseCredential returns the daily cap slot reserved for a request that was not sent.
+func (m *Manager) releaseCredential(auth *Auth) {
+	if auth == nil {
+		return
+	}
+	policy, ok := m.credentialPolicyFor(auth.Provider)
+	if !ok || policy.dailyMax <= 0 {
+		return
+	}
+	m.policies.limiterFor(auth.Provider, policy).Release(auth.ID)
+}
+
+// paceCredential waits until the credential may send its next request according to the
+// provider policy. Concurrent callers reserve consecutive slots so the configured gap holds
+// under load. When the slot falls after the context deadline the credential is refused and
+// its daily cap slot released, so the caller moves on to the next credential.
+func (m *Manager) paceCredential(ctx context.Context, auth *Auth) error {
+	if auth == nil {
+		return nil
+	}
+	policy, ok := m.credentialPolicyFor(auth.Provider)
+	if !ok || policy.maxInterval <= 0 {
+		return nil
+	}
+	deadline, _ := ctx.Deadline()
+	wait, reserved := m.policies.limiterFor(auth.Provider, policy).ReserveSlot(auth.ID, deadline)
+	if !reserved {
+		m.releaseCredential(auth)
+		return &Error{Code: "auth_unavailable", Message: "credential pacing delay exceeds the request deadline", HTTPStatus: http.StatusTooManyRequests}
+	}
+	if errWait := waitForCooldown(ctx, wait); errWait != nil {
+		m.releaseCredential(auth)
+		return errWait
+	}
+	return nil
+}
+
+// SetCredentialQuotaRemaining records the remaining quota fraction (0..1) reported for a
+// credential; it feeds the score used by score-based selection.
+func (m *Manager) SetCredentialQuotaRemaining(authID string, fraction float64) {
+	if m == nil || authID == "" {
+		return
+	}
+	m.policies.scorer.SetQuotaRemaining(authID, math.Max(0, math.Min(1, fraction)))
+}
+
+// CredentialHealth returns the health tracked for a credential by the credential policies.
+func (m *Manager) CredentialHealth(authID string) (CredentialHealth, bool) {
+	if m == nil {
+		return CredentialHealth{}, false
+	}
+	t := m.policies
+	metrics := t.scorer.GetMetrics(authID)
+	var state *cliproxypolicy.TokenState
+	m.mu.RLock()
+	auth := m.auths[authID]
+	m.mu.RUnlock()
+	if auth != nil {
+		if policy, ok := m.credentialPolicyFor(auth.Provider); ok {
+			state = t.limiterFor(auth.Provider, policy).GetTokenState(authID)
+		}
+	}
+	suspended := t.cooldowns.IsInCooldown(authID)
+	if metrics == nil && state == nil && !suspended {
+		return CredentialHealth{}, false
+	}
+	snapshot := CredentialHealth{
+		Score:          t.scorer.CalculateScore(authID),
+		SuccessRate:    1,
+		QuotaRemaining: 1,
+	}
+	if metrics != nil {
+		snapshot.SuccessRate = metrics.SuccessRate
+		snapshot.AvgLatency = time.Duration(metrics.AvgLatency * float64(time.Millisecond))
+		snapshot.FailStreak = metrics.FailCount
+		snapshot.TotalRequests = metrics.TotalRequests
+		snapshot.QuotaRemaining = metrics.QuotaRemaining
+	}
+	if state != nil && time.Now().Before(state.DailyResetTime) {
+		snapshot.DailyRequests = state.DailyRequests
+	}
+	if suspended {
+		snapshot.SuspendedUntil = time.Now().Add(t.cooldowns.GetRemainingCooldown(authID))
+		snapshot.SuspendReason = t.cooldowns.GetCooldownReason(authID)
+	}
+	return snapshot, true
+}
+
+// BeforeAttempt implements ExecutionHook.
+func (t *policyTracker) BeforeAttempt(_ context.Context, attempt *ExecutionAttempt) {
+	if attempt == nil || attempt.Auth == nil {
+		return
+	}
+	t.mu.Lock()
+	t.started[attempt] = time.Now()
+	t.mu.Unlock()
+}
+
+// OnAttemptChunk implements ExecutionHook. The first chunk of a stream records its latency.
+func (t *policyTracker) OnAttemptChunk(_ context.Context, attempt *ExecutionAttempt, chunk cliproxyexecutor.StreamChunk) {
+	if attempt == nil || chunk.Err != nil {
+		return
+	}
+	t.mu.Lock()
+	t.observeLatencyLocked(attempt)
+	t.mu.Unlock()
+}
+
+// AfterAttempt implements ExecutionHook. It records the outcome and detects suspension errors.
+func (t *policyTracker) AfterAttempt(ctx context.Context, attempt *ExecutionAttempt, _ cliproxyexecutor.Response, err error) {
+	if attempt == nil || attempt.Auth == nil {
+		return
+	}
+	t.mu.Lock()
+	t.observeLatencyLocked(attempt)
+	latency := t.latency[attempt]
+	delete(t.started, attempt)
+	delete(t.latency, attempt)
+	t.mu.Unlock()
+	if err != nil && ctx != nil && ctx.Err() != nil {
+		return
+	}
+	t.scorer.RecordRequest(attempt.Auth.ID, err == nil, latency)
+	if err != nil && isSuspensionError(err) {
+		cooldown := defaultSuspendCooldown
+		if t.policyFor != nil {
+			if policy, ok := t.policyFor(attempt.Auth.Provider); ok {
+				cooldown = policy.suspendCooldown
+			}
+		}
+		t.cooldowns.SetCooldown(attempt.Auth.ID, cooldown, err.Error())
+	}
+}
+
+func (t *policyTracker) observeLatencyLocked(attempt *ExecutionAttempt) {
+	if _, done := t.latency[attempt]; done {
+		return
+	}
+	started, ok := t.started[attempt]
+	if !ok {
+		return
+	}
+	latency := time.Since(started)
+	if latency < 0 {
+		latency = 0
+	}
+	t.latency[attempt] = latency
+}
+
+func isSuspensionError(err error) bool {
+	if err == nil {
+		return false
+	}
+	msg := strings.ToLower(err.Error())
+	for _, keyword := range suspendKeywords {
+		if strings.Contains(msg, keyword) {
+			return true
+		}
+	}
+	return false
+}
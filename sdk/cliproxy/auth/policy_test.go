@@ -0,0 +1,181 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+)
+
+type policyTestExecutor struct {
+	mu     sync.Mutex
+	errors map[string]string
+	calls  []string
+}
+
+func (e *policyTestExecutor) Identifier() string { return "policytest" }
+
+func (e *policyTestExecutor) Execute(_ context.Context, auth *Auth, _ cliproxyexecutor.Request, _ cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	e.mu.Lock()
+	e.calls = append(e.calls, auth.ID)
+	msg := e.errors[auth.ID]
+	e.mu.Unlock()
+	if msg != "" {
+		return cliproxyexecutor.Response{}, &Error{HTTPStatus: http.StatusForbidden, Message: msg}
+	}
+	return cliproxyexecutor.Response{Payload: []byte(auth.ID)}, nil
+}
+
+func (e *policyTestExecutor) ExecuteStream(context.Context, *Auth, cliproxyexecutor.Request, cliproxyexecutor.Options) (<-chan cliproxyexecutor.StreamChunk, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (e *policyTestExecutor) Refresh(_ context.Context, auth *Auth) (*Auth, error) {
+	return auth, nil
+}
+
+func (e *policyTestExecutor) CountTokens(ctx context.Context, auth *Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	return e.Execute(ctx, auth, req, opts)
+}
+
+func (e *policyTestExecutor) HttpRequest(context.Context, *Auth, *http.Request) (*http.Response, error) {
+	return nil, errors.New("not implemented")
+}
+
+func setupPolicyManager(t *testing.T, policy internalconfig.CredentialPolicy, ids ...string) (*Manager, *policyTestExecutor) {
+	t.Helper()
+	m := NewManager(nil, &FillFirstSelector{}, nil)
+	exec := &policyTestExecutor{errors: make(map[string]string)}
+	m.RegisterExecutor(exec)
+	for _, id := range ids {
+		if _, err := m.Register(context.Background(), &Auth{ID: id, Provider: "policytest"}); err != nil {
+			t.Fatalf("register %s: %v", id, err)
+		}
+	}
+	cfg := &internalconfig.Config{}
+	cfg.Routing.CredentialPolicies = []internalconfig.CredentialPolicy{policy}
+	m.SetConfig(cfg)
+	return m, exec
+}
+
+func TestCredentialPolicy_DailyCapSkipsExhaustedCredential(t *testing.T) {
+	m, exec := setupPolicyManager(t, internalconfig.CredentialPolicy{Provider: "policytest", DailyMaxRequests: 1}, "a", "b")
+
+	for i := 0; i < 2; i++ {
+		if _, err := m.Execute(context.Background(), []string{"policytest"}, cliproxyexecutor.Request{}, cliproxyexecutor.Options{}); err != nil {
+			t.Fatalf("Execute() #%d error = %v", i, err)
+		}
+	}
+	if got := exec.calls; len(got) != 2 || got[0] != "a" || got[1] != "b" {
+		t.Fatalf("calls = %v, want [a b]", got)
+	}
+	_, err := m.Execute(context.Background(), []string{"policytest"}, cliproxyexecutor.Request{}, cliproxyexecutor.Options{})
+	if statusCodeFromError(err) != http.StatusTooManyRequests {
+		t.Fatalf("expected 429 once every credential hit its cap, got %v", err)
+	}
+}
+
+func TestCredentialPolicy_ScoreSelectionAvoidsFailingCredential(t *testing.T) {
+	m, exec := setupPolicyManager(t, internalconfig.CredentialPolicy{Provider: "policytest", ScoreSelection: true}, "a", "b")
+	exec.errors["a"] = "upstream exploded"
+
+	if _, err := m.Execute(context.Background(), []string{"policytest"}, cliproxyexecutor.Request{}, cliproxyexecutor.Options{}); err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	delete(exec.errors, "a")
+	exec.calls = nil
+	if _, err := m.Execute(context.Background(), []string{"policytest"}, cliproxyexecutor.Request{}, cliproxyexecutor.Options{}); err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if got := exec.calls; len(got) != 1 || got[0] != "b" {
+		t.Fatalf("calls = %v, want the healthy credential b", got)
+	}
+	health, ok := m.CredentialHealth("a")
+	if !ok || health.FailStreak != 1 {
+		t.Fatalf("health(a) = %+v, want fail streak 1", health)
+	}
+}
+
+func TestCredentialPolicy_SuspensionSkipsCredential(t *testing.T) {
+	m, exec := setupPolicyManager(t, internalconfig.CredentialPolicy{Provider: "policytest", SuspendCooldownSeconds: 60}, "a", "b")
+	exec.errors["a"] = "Your account has been suspended"
+
+	if _, err := m.Execute(context.Background(), []string{"policytest"}, cliproxyexecutor.Request{}, cliproxyexecutor.Options{}); err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	health, _ := m.CredentialHealth("a")
+	if until := time.Until(health.SuspendedUntil); until <= 0 || until > time.Minute {
+		t.Fatalf("suspended until %v, want within the next minute", health.SuspendedUntil)
+	}
+	exec.calls = nil
+	if _, err := m.Execute(context.Background(), []string{"policytest"}, cliproxyexecutor.Request{}, cliproxyexecutor.Options{}); err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if got := exec.calls; len(got) != 1 || got[0] != "b" {
+		t.Fatalf("calls = %v, want suspended credential skipped", got)
+	}
+}
+
+func TestCredentialPolicy_PacingSpacesRequests(t *testing.T) {
+	m, _ := setupPolicyManager(t, internalconfig.CredentialPolicy{Provider: "policytest", MinIntervalMS: 50}, "a")
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		if _, err := m.Execute(context.Background(), []string{"policytest"}, cliproxyexecutor.Request{}, cliproxyexecutor.Options{}); err != nil {
+			t.Fatalf("Execute() #%d error = %v", i, err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 100*time.Millisecond {
+		t.Fatalf("three paced requests took %v, want at least 100ms", elapsed)
+	}
+}
+
+func TestCredentialPolicy_DailyCapHoldsUnderConcurrency(t *testing.T) {
+	m, exec := setupPolicyManager(t, internalconfig.CredentialPolicy{Provider: "policytest", DailyMaxRequests: 1}, "a", "b")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, _ = m.Execute(context.Background(), []string{"policytest"}, cliproxyexecutor.Request{}, cliproxyexecutor.Options{})
+		}()
+	}
+	wg.Wait()
+	if got := len(exec.calls); got != 2 {
+		t.Fatalf("calls = %v, want one per credential", exec.calls)
+	}
+	for _, id := range []string{"a", "b"} {
+		if health, _ := m.CredentialHealth(id); health.DailyRequests != 1 {
+			t.Fatalf("daily requests of %s = %d, want 1", id, health.DailyRequests)
+		}
+	}
+}
+
+func TestCredentialPolicy_PacingRefusalTriesNextCredential(t *testing.T) {
+	m, exec := setupPolicyManager(t, internalconfig.CredentialPolicy{Provider: "policytest", MinIntervalMS: 5000, DailyMaxRequests: 10}, "a", "b")
+
+	if _, err := m.Execute(context.Background(), []string{"policytest"}, cliproxyexecutor.Request{}, cliproxyexecutor.Options{}); err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	start := time.Now()
+	if _, err := m.Execute(ctx, []string{"policytest"}, cliproxyexecutor.Request{}, cliproxyexecutor.Options{}); err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Fatalf("paced request waited %v instead of moving on", elapsed)
+	}
+	if got := exec.calls; len(got) != 2 || got[0] != "a" || got[1] != "b" {
+		t.Fatalf("calls = %v, want [a b]", got)
+	}
+	if health, _ := m.CredentialHealth("a"); health.DailyRequests != 1 {
+		t.Fatalf("refused credential kept its daily slot: %d requests", health.DailyRequests)
+	}
+}
@@ -0,0 +1,256 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// defaultQuotaPollInterval applies when quota-polling.interval-seconds is unset.
+	defaultQuotaPollInterval = 5 * time.Minute
+	// quotaPollCheckInterval is how often the poller looks for credentials that are due.
+	quotaPollCheckInterval = 30 * time.Second
+	// quotaPollTimeout bounds a single provider quota request.
+	quotaPollTimeout = 30 * time.Second
+	// lowQuotaFraction is the share of quota below which a credential is only picked when
+	// no candidate with more headroom is available.
+	lowQuotaFraction = 0.1
+)
+
+// QuotaPoller is implemented by provider executors that can report a credential's remaining
+// quota without spending it. The manager polls them in the background so exhausted
+// credentials are skipped before they start returning 429s.
+type QuotaPoller interface {
+	PollQuota(ctx context.Context, auth *Auth) (*QuotaSnapshot, error)
+}
+
+// QuotaSnapshot is the quota a provider reported for a credential.
+type QuotaSnapshot struct {
+	// Remaining and Limit are expressed in provider units (requests, credits or percent).
+	// A zero Limit means the provider did not report a credential-wide quota.
+	Remaining float64
+	Limit     float64
+	// ResetAt is when the provider replenishes the quota; zero when unknown.
+	ResetAt time.Time
+	// Models carries per-model quotas for providers that meter models separately.
+	Models map[string]QuotaSnapshot
+}
+
+// quotaExhausted reports whether q records a depleted provider quota and, if so, until when
+// the credential should be skipped. Without a reset time the block lasts one poll interval.
+func quotaExhausted(q QuotaState, now time.Time) (bool, time.Time) {
+	if q.Limit <= 0 || q.Remaining > 0 {
+		return false, time.Time{}
+	}
+	until := q.ResetAt
+	if until.IsZero() && !q.CheckedAt.IsZero() {
+		until = q.CheckedAt.Add(defaultQuotaPollInterval)
+	}
+	if !until.After(now) {
+		return false, time.Time{}
+	}
+	return true, until
+}
+
+func applyQuotaSnapshot(q *QuotaState, snapshot QuotaSnapshot, now time.Time) {
+	q.Remaining = snapshot.Remaining
+	q.Limit = snapshot.Limit
+	q.ResetAt = snapshot.ResetAt
+	q.CheckedAt = now
+}
+
+// ApplyQuotaSnapshot records the quota reported for a credential, whether polled or captured
+// from a response. Exhausted quotas keep the credential (or model) out of selection until the
+// reported reset time, and the remaining fraction feeds score-based credential policies.
+func (m *Manager) ApplyQuotaSnapshot(authID string, snapshot QuotaSnapshot) {
+	if m == nil || authID == "" {
+		return
+	}
+	now := time.Now()
+	fraction := -1.0
+	m.mu.Lock()
+	auth, ok := m.auths[authID]
+	if !ok || auth == nil {
+		m.mu.Unlock()
+		return
+	}
+	if snapshot.Limit > 0 {
+		applyQuotaSnapshot(&auth.Quota, snapshot, now)
+		fraction = snapshot.Remaining / snapshot.Limit
+	}
+	for model, modelSnapshot := range snapshot.Models {
+		if model == "" || modelSnapshot.Limit <= 0 {
+			continue
+		}
+		applyQuotaSnapshot(&ensureModelState(auth, model).Quota, modelSnapshot, now)
+		if modelFraction := modelSnapshot.Remaining / modelSnapshot.Limit; fraction < 0 || modelFraction < fraction {
+			fraction = modelFraction
+		}
+	}
+	m.mu.Unlock()
+
+	if fraction >= 0 {
+		m.SetCredentialQuotaRemaining(authID, fraction)
+	}
+}
+
+// withRateLimitReporter routes the rate limits an executor reads from response headers into
+// the quota state of the routed model, or of the credential when no model is known.
+func (m *Manager) withRateLimitReporter(ctx context.Context, authID, model string) context.Context {
+	return cliproxyexecutor.WithRateLimitReporter(ctx, func(limit cliproxyexecutor.RateLimit) {
+		observed := QuotaSnapshot{Remaining: limit.Remaining, Limit: limit.Limit, ResetAt: limit.ResetAt}
+		if model == "" {
+			m.ApplyQuotaSnapshot(authID, observed)
+			return
+		}
+		m.ApplyQuotaSnapshot(authID, QuotaSnapshot{Models: map[string]QuotaSnapshot{model: observed}})
+	})
+}
+
+// quotaNearlyExhausted reports whether the last quota observed for auth (or its model state)
+// has less than lowQuotaFraction left in a window that has not reset yet.
+func quotaNearlyExhausted(auth *Auth, model string, now time.Time) bool {
+	low := func(q QuotaState) bool {
+		if q.Limit <= 0 || (!q.ResetAt.IsZero() && !q.ResetAt.After(now)) {
+			return false
+		}
+		return q.Remaining/q.Limit < lowQuotaFraction
+	}
+	if low(auth.Quota) {
+		return true
+	}
+	if model == "" {
+		return false
+	}
+	state, ok := auth.ModelStates[model]
+	return ok && state != nil && low(state.Quota)
+}
+
+// preferQuotaHeadroom drops nearly exhausted credentials from available while at least one
+// candidate still has headroom, so selectors spread load away from accounts about to fail.
+func preferQuotaHeadroom(available []*Auth, model string, now time.Time) []*Auth {
+	if len(available) < 2 {
+		return available
+	}
+	healthy := make([]*Auth, 0, len(available))
+	for _, auth := range available {
+		if !quotaNearlyExhausted(auth, model, now) {
+			healthy = append(healthy, auth)
+		}
+	}
+	if len(healthy) == 0 {
+		return available
+	}
+	return healthy
+}
+
+// StartQuotaPolling launches the background quota poller. Credentials whose executor
+// implements QuotaPoller are polled once per quota-polling.interval-seconds while
+// quota-polling.enabled is set; the setting is re-read on every check so it hot-reloads.
+// Starting a new poller cancels the previous one.
+func (m *Manager) StartQuotaPolling(parent context.Context) {
+	m.StopQuotaPolling()
+	ctx, cancel := context.WithCancel(parent)
+	m.quotaCancel = cancel
+	go func() {
+		ticker := time.NewTicker(quotaPollCheckInterval)
+		defer ticker.Stop()
+		m.checkQuotaPolls(ctx)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				m.checkQuotaPolls(ctx)
+			}
+		}
+	}()
+}
+
+// StopQuotaPolling cancels the background quota poller, if running.
+func (m *Manager) StopQuotaPolling() {
+	if m.quotaCancel != nil {
+		m.quotaCancel()
+		m.quotaCancel = nil
+	}
+}
+
+// PollQuotas polls every credential that supports it immediately and waits for the results.
+func (m *Manager) PollQuotas(ctx context.Context) {
+	now := time.Now()
+	var wg sync.WaitGroup
+	for _, auth := range m.quotaPollable() {
+		m.quotaPolls.Store(auth.ID, now)
+		wg.Add(1)
+		go func(auth *Auth) {
+			defer wg.Done()
+			m.pollQuota(ctx, auth)
+		}(auth)
+	}
+	wg.Wait()
+}
+
+func (m *Manager) quotaPollInterval() (time.Duration, bool) {
+	cfg, _ := m.runtimeConfig.Load().(*internalconfig.Config)
+	if cfg == nil || !cfg.QuotaPolling.Enabled {
+		return 0, false
+	}
+	if cfg.QuotaPolling.IntervalSeconds <= 0 {
+		return defaultQuotaPollInterval, true
+	}
+	return time.Duration(cfg.QuotaPolling.IntervalSeconds) * time.Second, true
+}
+
+func (m *Manager) checkQuotaPolls(ctx context.Context) {
+	interval, enabled := m.quotaPollInterval()
+	if !enabled {
+		return
+	}
+	now := time.Now()
+	for _, auth := range m.quotaPollable() {
+		if last, ok := m.quotaPolls.Load(auth.ID); ok && now.Sub(last.(time.Time)) < interval {
+			continue
+		}
+		m.quotaPolls.Store(auth.ID, now)
+		go m.pollQuota(ctx, auth)
+	}
+}
+
+// quotaPollable returns snapshots of the enabled credentials whose executor can poll quota.
+func (m *Manager) quotaPollable() []*Auth {
+	out := make([]*Auth, 0)
+	for _, auth := range m.snapshotAuths() {
+		if auth.Disabled || auth.Status == StatusDisabled {
+			continue
+		}
+		if _, ok := m.executorFor(auth.Provider).(QuotaPoller); ok {
+			out = append(out, auth)
+		}
+	}
+	return out
+}
+
+func (m *Manager) pollQuota(ctx context.Context, auth *Auth) {
+	poller, ok := m.executorFor(auth.Provider).(QuotaPoller)
+	if !ok {
+		return
+	}
+	pollCtx, cancel := context.WithTimeout(ctx, quotaPollTimeout)
+	defer cancel()
+	snapshot, err := poller.PollQuota(pollCtx, auth)
+	if err != nil {
+		if !errors.Is(err, context.Canceled) {
+			log.Debugf("quota poll failed for %s, %s: %v", auth.Provider, auth.ID, err)
+		}
+		return
+	}
+	if snapshot != nil {
+		m.ApplyQuotaSnapshot(auth.ID, *snapshot)
+	}
+}
@@ -0,0 +1,144 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+)
+
+type quotaTestExecutor struct {
+	snapshots map[string]*QuotaSnapshot
+	limits    map[string]cliproxyexecutor.RateLimit
+	calls     []string
+}
+
+func (e *quotaTestExecutor) Identifier() string { return "quotatest" }
+
+func (e *quotaTestExecutor) Execute(ctx context.Context, auth *Auth, _ cliproxyexecutor.Request, _ cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	e.calls = append(e.calls, auth.ID)
+	if limit, ok := e.limits[auth.ID]; ok {
+		cliproxyexecutor.ReportRateLimit(ctx, limit)
+	}
+	return cliproxyexecutor.Response{Payload: []byte(auth.ID)}, nil
+}
+
+func (e *quotaTestExecutor) ExecuteStream(context.Context, *Auth, cliproxyexecutor.Request, cliproxyexecutor.Options) (<-chan cliproxyexecutor.StreamChunk, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (e *quotaTestExecutor) Refresh(_ context.Context, auth *Auth) (*Auth, error) {
+	return auth, nil
+}
+
+func (e *quotaTestExecutor) CountTokens(ctx context.Context, auth *Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	return e.Execute(ctx, auth, req, opts)
+}
+
+func (e *quotaTestExecutor) HttpRequest(context.Context, *Auth, *http.Request) (*http.Response, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (e *quotaTestExecutor) PollQuota(_ context.Context, auth *Auth) (*QuotaSnapshot, error) {
+	return e.snapshots[auth.ID], nil
+}
+
+func setupQuotaManager(t *testing.T, snapshots map[string]*QuotaSnapshot) (*Manager, *quotaTestExecutor) {
+	t.Helper()
+	m := NewManager(nil, &FillFirstSelector{}, nil)
+	exec := &quotaTestExecutor{snapshots: snapshots}
+	m.RegisterExecutor(exec)
+	for _, id := range []string{"a", "b"} {
+		if _, err := m.Register(context.Background(), &Auth{ID: id, Provider: "quotatest"}); err != nil {
+			t.Fatalf("register %s: %v", id, err)
+		}
+	}
+	return m, exec
+}
+
+func TestPollQuotas_ExhaustedCredentialIsSkippedUntilReset(t *testing.T) {
+	resetAt := time.Now().Add(time.Hour)
+	m, exec := setupQuotaManager(t, map[string]*QuotaSnapshot{
+		"a": {Remaining: 0, Limit: 50, ResetAt: resetAt},
+		"b": {Remaining: 40, Limit: 50},
+	})
+
+	m.PollQuotas(context.Background())
+
+	auth, _ := m.GetByID("a")
+	if auth.Quota.Limit != 50 || !auth.Quota.ResetAt.Equal(resetAt) || auth.Quota.CheckedAt.IsZero() {
+		t.Fatalf("quota(a) = %+v, want polled snapshot", auth.Quota)
+	}
+	if _, err := m.Execute(context.Background(), []string{"quotatest"}, cliproxyexecutor.Request{}, cliproxyexecutor.Options{}); err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if got := exec.calls; len(got) != 1 || got[0] != "b" {
+		t.Fatalf("calls = %v, want exhausted credential skipped", got)
+	}
+	if health, _ := m.CredentialHealth("b"); health.QuotaRemaining != 0.8 {
+		t.Fatalf("quota remaining fraction = %v, want 0.8", health.QuotaRemaining)
+	}
+}
+
+func TestApplyQuotaSnapshot_ModelQuotaBlocksOnlyThatModel(t *testing.T) {
+	m, _ := setupQuotaManager(t, nil)
+	m.ApplyQuotaSnapshot("a", QuotaSnapshot{Models: map[string]QuotaSnapshot{
+		"quota-pro":   {Remaining: 0, Limit: 100, ResetAt: time.Now().Add(time.Hour)},
+		"quota-flash": {Remaining: 30, Limit: 100},
+	}})
+
+	auth, _ := m.GetByID("a")
+	now := time.Now()
+	if blocked, reason, _ := isAuthBlockedForModel(auth, "quota-pro", now); !blocked || reason != blockReasonCooldown {
+		t.Fatalf("quota-pro blocked = %v (%v), want cooldown", blocked, reason)
+	}
+	if blocked, _, _ := isAuthBlockedForModel(auth, "quota-flash", now); blocked {
+		t.Fatal("quota-flash must stay available")
+	}
+	if blocked, _, _ := isAuthBlockedForModel(auth, "quota-pro", now.Add(2*time.Hour)); blocked {
+		t.Fatal("quota-pro must recover after the reset time")
+	}
+}
+
+func TestManagerExecute_DeprioritizesCredentialNearRateLimit(t *testing.T) {
+	m, exec := setupQuotaManager(t, nil)
+	reg := registry.GetGlobalRegistry()
+	for _, id := range []string{"a", "b"} {
+		reg.RegisterClient(id, "quotatest", []*registry.ModelInfo{{ID: "quota-model"}})
+		clientID := id
+		t.Cleanup(func() { reg.UnregisterClient(clientID) })
+	}
+	exec.limits = map[string]cliproxyexecutor.RateLimit{
+		"a": {Remaining: 2, Limit: 100, ResetAt: time.Now().Add(time.Minute)},
+	}
+	req := cliproxyexecutor.Request{Model: "quota-model"}
+
+	for i := 0; i < 2; i++ {
+		if _, err := m.Execute(context.Background(), []string{"quotatest"}, req, cliproxyexecutor.Options{}); err != nil {
+			t.Fatalf("Execute() #%d error = %v", i, err)
+		}
+	}
+	if got := exec.calls; len(got) != 2 || got[0] != "a" || got[1] != "b" {
+		t.Fatalf("calls = %v, want [a b]", got)
+	}
+	auth, _ := m.GetByID("a")
+	if state := auth.ModelStates["quota-model"]; state == nil || state.Quota.Remaining != 2 || state.Quota.Limit != 100 {
+		t.Fatalf("model quota not captured: %+v", state)
+	}
+
+	exec.limits = nil
+	exec.calls = nil
+	m.mu.Lock()
+	m.auths["b"].ModelStates["quota-model"].Quota = QuotaState{Remaining: 1, Limit: 100}
+	m.mu.Unlock()
+	if _, err := m.Execute(context.Background(), []string{"quotatest"}, req, cliproxyexecutor.Options{}); err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if got := exec.calls; len(got) != 1 || got[0] != "a" {
+		t.Fatalf("calls = %v, want fill-first order when every credential is low", got)
+	}
+}
@@ -0,0 +1,270 @@
+package auth
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/cache"
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	coreusage "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
+	log "github.com/sirupsen/logrus"
+	"github.com/tidwall/gjson"
+)
+
+const (
+	// defaultResponseCacheTTL applies when response-cache.ttl-seconds is unset.
+	defaultResponseCacheTTL = time.Hour
+	// defaultResponseCacheMaxSizeMB applies when response-cache.max-size-mb is unset.
+	defaultResponseCacheMaxSizeMB = 256
+	// responseCacheDirName is the disk backend directory under the auth directory.
+	responseCacheDirName = "response-cache"
+)
+
+// responseCache pairs a store with the settings it was opened from, so config reloads that
+// change the backend reopen it.
+type responseCache struct {
+	settings internalconfig.ResponseCacheConfig
+	authDir  string
+	store    cache.ResponseStore
+	ttl      time.Duration
+}
+
+// responseCacheFor returns the response cache configured in cfg, opening or reopening the
+// store when the settings changed. It returns nil while caching is disabled.
+func (m *Manager) responseCacheFor(cfg *internalconfig.Config) *responseCache {
+	if cfg == nil || !cfg.ResponseCache.Enable {
+		return nil
+	}
+	m.responseCacheMu.Lock()
+	defer m.responseCacheMu.Unlock()
+	if current := m.responseCache; current != nil && current.settings == cfg.ResponseCache && current.authDir == cfg.AuthDir {
+		return current
+	}
+	settings := cfg.ResponseCache
+	ttl := defaultResponseCacheTTL
+	if settings.TTLSeconds > 0 {
+		ttl = time.Duration(settings.TTLSeconds) * time.Second
+	}
+	maxSizeMB := defaultResponseCacheMaxSizeMB
+	if settings.MaxSizeMB > 0 {
+		maxSizeMB = settings.MaxSizeMB
+	}
+	maxBytes := int64(maxSizeMB) << 20
+
+	var store cache.ResponseStore
+	if settings.Backend == "disk" {
+		dir := settings.Dir
+		if dir == "" {
+			authDir, err := util.ResolveAuthDir(cfg.AuthDir)
+			if err != nil || authDir == "" {
+				log.Warnf("response cache: resolve auth directory: %v", err)
+				return nil
+			}
+			dir = filepath.Join(authDir, responseCacheDirName)
+		}
+		diskStore, err := cache.NewDiskResponseStore(dir, maxBytes)
+		if err != nil {
+			log.Warnf("response cache: %v", err)
+			return nil
+		}
+		store = diskStore
+	} else {
+		store = cache.NewMemoryResponseStore(maxBytes)
+	}
+	m.responseCache = &responseCache{settings: settings, authDir: cfg.AuthDir, store: store, ttl: ttl}
+	return m.responseCache
+}
+
+// responseCacheKey derives the cache key for a non-streaming request, or reports that the
+// request must not be cached. The key covers the handler format, alternate endpoint, resolved
+// model, canonicalized payload and the payload rules, which executors apply to the payload
+// before it goes upstream. The providers and the calling key's allow-lists are part of the key
+// so that callers restricted to different providers or fallback models never share an entry.
+func responseCacheKey(cfg *internalconfig.Config, providers []string, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (string, bool) {
+	if opts.Stream || len(req.Payload) == 0 {
+		return "", false
+	}
+	if bypass, _ := opts.Metadata[cliproxyexecutor.ResponseCacheBypassMetadataKey].(bool); bypass {
+		return "", false
+	}
+	if !cfg.ResponseCache.IncludeNonDeterministic && !deterministicRequest(req.Payload) {
+		return "", false
+	}
+	decoder := json.NewDecoder(bytes.NewReader(req.Payload))
+	decoder.UseNumber()
+	var payload any
+	if err := decoder.Decode(&payload); err != nil {
+		return "", false
+	}
+	// encoding/json sorts object keys, which canonicalizes key order and whitespace.
+	canonical, err := json.Marshal(payload)
+	if err != nil {
+		return "", false
+	}
+	rules, err := json.Marshal(cfg.Payload)
+	if err != nil {
+		return "", false
+	}
+	h := sha256.New()
+	_, _ = fmt.Fprintf(h, "%s\x00%s\x00%s\x00", opts.SourceFormat, opts.Alt, req.Model)
+	for _, list := range [][]string{
+		providers,
+		metadataStrings(opts.Metadata, cliproxyexecutor.AllowedProvidersMetadataKey),
+		metadataStrings(opts.Metadata, cliproxyexecutor.AllowedModelsMetadataKey),
+	} {
+		sorted := append([]string(nil), list...)
+		sort.Strings(sorted)
+		_, _ = fmt.Fprintf(h, "%s\x00", strings.Join(sorted, ","))
+	}
+	h.Write(canonical)
+	h.Write([]byte{0})
+	h.Write(rules)
+	return hex.EncodeToString(h.Sum(nil)), true
+}
+
+// deterministicRequest reports whether the payload pins temperature to 0, either at the top
+// level (OpenAI, Claude) or in Gemini's generationConfig.
+func deterministicRequest(payload []byte) bool {
+	for _, path := range []string{"temperature", "generationConfig.temperature", "request.generationConfig.temperature"} {
+		if value := gjson.GetBytes(payload, path); value.Exists() {
+			return value.Type == gjson.Number && value.Float() == 0
+		}
+	}
+	return false
+}
+
+// responseCacheKeyFor returns the active response cache and the key for req, or false when
+// caching is disabled or the request is not cacheable.
+func (m *Manager) responseCacheKeyFor(providers []string, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (*responseCache, string, bool) {
+	cfg, _ := m.runtimeConfig.Load().(*internalconfig.Config)
+	rc := m.responseCacheFor(cfg)
+	if rc == nil {
+		return nil, "", false
+	}
+	key, ok := responseCacheKey(cfg, providers, req, opts)
+	return rc, key, ok
+}
+
+// executeCached serves req from the cache or executes it and stores a successful response.
+func (m *Manager) executeCached(ctx context.Context, rc *responseCache, key string, providers []string, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	if resp, ok := m.serveCachedResponse(ctx, rc, key, req); ok {
+		return resp, nil
+	}
+	recorder := &responseCacheRecorder{}
+	resp, err := m.execute(coreusage.WithRecordObserver(ctx, recorder.observe), providers, req, opts)
+	if err == nil && !isEmptyPayload(resp.Payload) {
+		recorder.store(rc, key, resp)
+	}
+	return resp, err
+}
+
+// serveCachedResponse answers req from the cache when an unexpired entry exists. The hit is
+// published as a usage record flagged as cached, carrying the usage of the original call.
+func (m *Manager) serveCachedResponse(ctx context.Context, rc *responseCache, key string, req cliproxyexecutor.Request) (cliproxyexecutor.Response, bool) {
+	entry, ok := rc.store.Get(key, time.Now())
+	if !ok || entry == nil {
+		return cliproxyexecutor.Response{}, false
+	}
+	logEntryWithRequestID(ctx).Debugf("response cache hit for model %s (stored %s)", req.Model, entry.StoredAt.Format(time.RFC3339))
+	coreusage.PublishRecord(ctx, coreusage.Record{
+		Provider:    entry.Provider,
+		Model:       entry.Model,
+		APIKey:      clientAPIKey(ctx),
+		AuthID:      entry.AuthID,
+		AuthIndex:   entry.AuthIndex,
+		Source:      entry.Source,
+		RequestedAt: time.Now(),
+		Detail: coreusage.Detail{
+			InputTokens:     entry.Usage.InputTokens,
+			OutputTokens:    entry.Usage.OutputTokens,
+			ReasoningTokens: entry.Usage.ReasoningTokens,
+			CachedTokens:    entry.Usage.CachedTokens,
+			TotalTokens:     entry.Usage.TotalTokens,
+		},
+		ResponseCached: true,
+	})
+	return cliproxyexecutor.Response{
+		Payload:  bytes.Clone(entry.Payload),
+		Metadata: map[string]any{cliproxyexecutor.ResponseCacheMetadataKey: "hit"},
+	}, true
+}
+
+// responseCacheRecorder captures the usage record of the upstream call that a cached
+// response is stored with.
+type responseCacheRecorder struct {
+	mu     sync.Mutex
+	record *coreusage.Record
+}
+
+func (r *responseCacheRecorder) observe(record coreusage.Record) {
+	if record.Failed {
+		return
+	}
+	r.mu.Lock()
+	r.record = &record
+	r.mu.Unlock()
+}
+
+// store saves a successful response under key together with the captured usage.
+func (r *responseCacheRecorder) store(rc *responseCache, key string, resp cliproxyexecutor.Response) {
+	now := time.Now()
+	entry := &cache.ResponseEntry{
+		Payload:   bytes.Clone(resp.Payload),
+		StoredAt:  now,
+		ExpiresAt: now.Add(rc.ttl),
+	}
+	r.mu.Lock()
+	if record := r.record; record != nil {
+		entry.Provider = record.Provider
+		entry.Model = record.Model
+		entry.AuthID = record.AuthID
+		entry.AuthIndex = record.AuthIndex
+		entry.Source = record.Source
+		entry.Usage = cache.ResponseUsage{
+			InputTokens:     record.Detail.InputTokens,
+			OutputTokens:    record.Detail.OutputTokens,
+			ReasoningTokens: record.Detail.ReasoningTokens,
+			CachedTokens:    record.Detail.CachedTokens,
+			TotalTokens:     record.Detail.TotalTokens,
+		}
+	}
+	r.mu.Unlock()
+	rc.store.Put(key, entry)
+}
+
+// clientAPIKey returns the authenticated client key stored on the request's gin context.
+func clientAPIKey(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	ginCtx, ok := ctx.Value("gin").(*gin.Context)
+	if !ok || ginCtx == nil {
+		return ""
+	}
+	if v, exists := ginCtx.Get("apiKey"); exists {
+		switch value := v.(type) {
+		case string:
+			return value
+		case fmt.Stringer:
+			return value.String()
+		}
+	}
+	return ""
+}
+
+// isEmptyPayload reports whether a response carries nothing worth caching.
+func isEmptyPayload(payload []byte) bool {
+	return len(bytes.TrimSpace(payload)) == 0 || strings.TrimSpace(string(payload)) == "null"
+}
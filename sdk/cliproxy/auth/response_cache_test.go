@@ -0,0 +1,175 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	coreusage "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
+)
+
+type cacheTestExecutor struct {
+	mu    sync.Mutex
+	calls int
+}
+
+func (e *cacheTestExecutor) Identifier() string { return "cachetest" }
+
+func (e *cacheTestExecutor) Execute(ctx context.Context, auth *Auth, req cliproxyexecutor.Request, _ cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	e.mu.Lock()
+	e.calls++
+	e.mu.Unlock()
+	coreusage.PublishRecord(ctx, coreusage.Record{
+		Provider: "cachetest",
+		Model:    req.Model,
+		AuthID:   auth.ID,
+		Detail:   coreusage.Detail{InputTokens: 10, OutputTokens: 5, TotalTokens: 15},
+	})
+	return cliproxyexecutor.Response{Payload: []byte(`{"answer":42}`)}, nil
+}
+
+func (e *cacheTestExecutor) ExecuteStream(context.Context, *Auth, cliproxyexecutor.Request, cliproxyexecutor.Options) (<-chan cliproxyexecutor.StreamChunk, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (e *cacheTestExecutor) Refresh(_ context.Context, auth *Auth) (*Auth, error) {
+	return auth, nil
+}
+
+func (e *cacheTestExecutor) CountTokens(ctx context.Context, auth *Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	return e.Execute(ctx, auth, req, opts)
+}
+
+func (e *cacheTestExecutor) HttpRequest(context.Context, *Auth, *http.Request) (*http.Response, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (e *cacheTestExecutor) Calls() int {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.calls
+}
+
+func setupCacheManager(t *testing.T, cfg internalconfig.ResponseCacheConfig) (*Manager, *cacheTestExecutor) {
+	t.Helper()
+	m := NewManager(nil, nil, nil)
+	exec := &cacheTestExecutor{}
+	m.RegisterExecutor(exec)
+	if _, err := m.Register(context.Background(), &Auth{ID: "cache-a", Provider: "cachetest"}); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+	cfg.Enable = true
+	m.SetConfig(&internalconfig.Config{ResponseCache: cfg})
+	return m, exec
+}
+
+func cacheTestRequest(payload string) (cliproxyexecutor.Request, cliproxyexecutor.Options) {
+	return cliproxyexecutor.Request{Payload: []byte(payload)}, cliproxyexecutor.Options{SourceFormat: "openai"}
+}
+
+func TestManagerExecute_ResponseCacheServesRepeatedDeterministicRequest(t *testing.T) {
+	m, exec := setupCacheManager(t, internalconfig.ResponseCacheConfig{})
+
+	req, opts := cacheTestRequest(`{"messages":[{"role":"user","content":"hi"}],"temperature":0}`)
+	if _, err := m.Execute(context.Background(), []string{"cachetest"}, req, opts); err != nil {
+		t.Fatalf("first Execute() error = %v", err)
+	}
+
+	var records []coreusage.Record
+	ctx := coreusage.WithRecordObserver(context.Background(), func(record coreusage.Record) {
+		records = append(records, record)
+	})
+	// Same request with different key order and whitespace.
+	req, opts = cacheTestRequest(`{"temperature": 0, "messages": [{"content":"hi","role":"user"}]}`)
+	resp, err := m.Execute(ctx, []string{"cachetest"}, req, opts)
+	if err != nil {
+		t.Fatalf("second Execute() error = %v", err)
+	}
+	if exec.Calls() != 1 {
+		t.Fatalf("executor calls = %d, want 1", exec.Calls())
+	}
+	if string(resp.Payload) != `{"answer":42}` || resp.Metadata[cliproxyexecutor.ResponseCacheMetadataKey] != "hit" {
+		t.Fatalf("unexpected cached response %s, metadata %v", resp.Payload, resp.Metadata)
+	}
+	if len(records) != 1 || !records[0].ResponseCached || records[0].Detail.TotalTokens != 15 || records[0].AuthID != "cache-a" {
+		t.Fatalf("expected one cached usage record carrying the original usage, got %+v", records)
+	}
+}
+
+func TestManagerExecute_ResponseCacheSkipsUncacheableRequests(t *testing.T) {
+	m, exec := setupCacheManager(t, internalconfig.ResponseCacheConfig{})
+
+	nonDeterministic, opts := cacheTestRequest(`{"messages":[{"role":"user","content":"hi"}],"temperature":0.7}`)
+	bypassed, bypassOpts := cacheTestRequest(`{"messages":[{"role":"user","content":"hi"}],"temperature":0}`)
+	bypassOpts.Metadata = map[string]any{cliproxyexecutor.ResponseCacheBypassMetadataKey: true}
+	for i := 0; i < 2; i++ {
+		if _, err := m.Execute(context.Background(), []string{"cachetest"}, nonDeterministic, opts); err != nil {
+			t.Fatalf("Execute() error = %v", err)
+		}
+		if _, err := m.Execute(context.Background(), []string{"cachetest"}, bypassed, bypassOpts); err != nil {
+			t.Fatalf("Execute() error = %v", err)
+		}
+	}
+	if exec.Calls() != 4 {
+		t.Fatalf("executor calls = %d, want 4", exec.Calls())
+	}
+}
+
+func TestManagerExecute_ResponseCacheKeyCoversPayloadRules(t *testing.T) {
+	m, exec := setupCacheManager(t, internalconfig.ResponseCacheConfig{})
+	req, opts := cacheTestRequest(`{"messages":[{"role":"user","content":"hi"}],"temperature":0}`)
+	if _, err := m.Execute(context.Background(), []string{"cachetest"}, req, opts); err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+
+	m.SetConfig(&internalconfig.Config{
+		ResponseCache: internalconfig.ResponseCacheConfig{Enable: true},
+		Payload: internalconfig.PayloadConfig{Override: []internalconfig.PayloadRule{{
+			Models: []internalconfig.PayloadModelRule{{Name: "*"}},
+			Params: map[string]any{"max_tokens": 100},
+		}}},
+	})
+	if _, err := m.Execute(context.Background(), []string{"cachetest"}, req, opts); err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if exec.Calls() != 2 {
+		t.Fatalf("executor calls = %d, want 2 after the payload rules changed", exec.Calls())
+	}
+}
+
+func TestManagerExecute_ResponseCacheKeyCoversProvidersAndAllowLists(t *testing.T) {
+	m, exec := setupCacheManager(t, internalconfig.ResponseCacheConfig{})
+	payload := `{"messages":[{"role":"user","content":"hi"}],"temperature":0}`
+
+	req, opts := cacheTestRequest(payload)
+	if _, err := m.Execute(context.Background(), []string{"cachetest", "other"}, req, opts); err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	// Provider order does not matter.
+	if _, err := m.Execute(context.Background(), []string{"other", "cachetest"}, req, opts); err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if exec.Calls() != 1 {
+		t.Fatalf("executor calls = %d, want 1 for reordered providers", exec.Calls())
+	}
+
+	if _, err := m.Execute(context.Background(), []string{"cachetest"}, req, opts); err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if exec.Calls() != 2 {
+		t.Fatalf("executor calls = %d, want 2 for a narrower provider set", exec.Calls())
+	}
+
+	restricted, restrictedOpts := cacheTestRequest(payload)
+	restrictedOpts.Metadata = map[string]any{cliproxyexecutor.AllowedModelsMetadataKey: []string{"gpt-*"}}
+	if _, err := m.Execute(context.Background(), []string{"cachetest"}, restricted, restrictedOpts); err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if exec.Calls() != 3 {
+		t.Fatalf("executor calls = %d, want 3 for a key with different allow-lists", exec.Calls())
+	}
+}
@@ -169,7 +169,7 @@ func getAvailableAuths(auths []*Auth, provider, model string, now time.Time) ([]
 		}
 	}
 
-	available := availableByPriority[bestPriority]
+	available := preferQuotaHeadroom(availableByPriority[bestPriority], model, now)
 	if len(available) > 1 {
 		sort.Slice(available, func(i, j int) bool { return available[i].ID < available[j].ID })
 	}
@@ -221,12 +221,18 @@ func isAuthBlockedForModel(auth *Auth, model string, now time.Time) (bool, block
 	if auth.Disabled || auth.Status == StatusDisabled {
 		return true, blockReasonDisabled, time.Time{}
 	}
+	if exhausted, resetAt := quotaExhausted(auth.Quota, now); exhausted {
+		return true, blockReasonCooldown, resetAt
+	}
 	if model != "" {
 		if len(auth.ModelStates) > 0 {
 			if state, ok := auth.ModelStates[model]; ok && state != nil {
 				if state.Status == StatusDisabled {
 					return true, blockReasonDisabled, time.Time{}
 				}
+				if exhausted, resetAt := quotaExhausted(state.Quota, now); exhausted {
+					return true, blockReasonCooldown, resetAt
+				}
 				if state.Unavailable {
 					if state.NextRetryAfter.IsZero() {
 						return false, blockReasonNone, time.Time{}
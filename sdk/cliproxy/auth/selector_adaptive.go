@@ -0,0 +1,307 @@
+package auth
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+)
+
+// Routing strategy names accepted by routing.strategy.
+const (
+	RoutingStrategyRoundRobin       = "round-robin"
+	RoutingStrategyFillFirst        = "fill-first"
+	RoutingStrategyLeastOutstanding = "least-outstanding"
+	RoutingStrategyLatency          = "latency"
+)
+
+// latencyEWMAAlpha weights the newest time-to-first-byte sample in the moving average.
+const latencyEWMAAlpha = 0.3
+
+// latencyFailurePenalty is added to the elapsed time of failed attempts, so credentials that
+// keep failing are measured as slow instead of staying unmeasured and being tried first.
+const latencyFailurePenalty = 10 * time.Second
+
+// NormalizeRoutingStrategy maps user supplied strategy names and aliases to their canonical form.
+// The boolean result reports whether the strategy is recognised; empty input maps to round-robin.
+func NormalizeRoutingStrategy(strategy string) (string, bool) {
+	switch strings.ToLower(strings.TrimSpace(strategy)) {
+	case "", "round-robin", "roundrobin", "rr":
+		return RoutingStrategyRoundRobin, true
+	case "fill-first", "fillfirst", "ff":
+		return RoutingStrategyFillFirst, true
+	case "least-outstanding", "leastoutstanding", "least-busy", "least-requests", "lor":
+		return RoutingStrategyLeastOutstanding, true
+	case "latency", "latency-aware", "lowest-latency", "ewma":
+		return RoutingStrategyLatency, true
+	default:
+		return "", false
+	}
+}
+
+// NewSelectorForStrategy returns a fresh selector for the given routing strategy.
+// Unknown strategies fall back to round-robin.
+func NewSelectorForStrategy(strategy string) Selector {
+	normalized, _ := NormalizeRoutingStrategy(strategy)
+	switch normalized {
+	case RoutingStrategyFillFirst:
+		return &FillFirstSelector{}
+	case RoutingStrategyLeastOutstanding:
+		return NewLeastOutstandingSelector()
+	case RoutingStrategyLatency:
+		return NewLatencySelector()
+	default:
+		return &RoundRobinSelector{}
+	}
+}
+
+// LeastOutstandingSelector picks the available credential with the fewest in-flight requests.
+// Ties are broken in a rotating order so idle credentials share load evenly.
+// It implements ExecutionHook to track in-flight attempts.
+type LeastOutstandingSelector struct {
+	mu       sync.Mutex
+	inflight map[string]int
+	cursors  map[string]int
+}
+
+// NewLeastOutstandingSelector constructs a LeastOutstandingSelector.
+func NewLeastOutstandingSelector() *LeastOutstandingSelector {
+	return &LeastOutstandingSelector{
+		inflight: make(map[string]int),
+		cursors:  make(map[string]int),
+	}
+}
+
+// Pick selects the available auth with the lowest outstanding request count.
+func (s *LeastOutstandingSelector) Pick(ctx context.Context, provider, model string, opts cliproxyexecutor.Options, auths []*Auth) (*Auth, error) {
+	_ = ctx
+	_ = opts
+	available, err := getAvailableAuths(auths, provider, model, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	key := provider + ":" + model
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.inflight == nil {
+		s.inflight = make(map[string]int)
+	}
+	if s.cursors == nil {
+		s.cursors = make(map[string]int)
+	}
+	start := s.cursors[key]
+	if start >= 2_147_483_640 {
+		start = 0
+	}
+	s.cursors[key] = start + 1
+	var selected *Auth
+	best := 0
+	for i := 0; i < len(available); i++ {
+		candidate := available[(start+i)%len(available)]
+		count := s.inflight[candidate.ID]
+		if selected == nil || count < best {
+			selected = candidate
+			best = count
+		}
+	}
+	return selected, nil
+}
+
+// Outstanding reports the number of in-flight attempts tracked for the auth.
+func (s *LeastOutstandingSelector) Outstanding(authID string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.inflight[authID]
+}
+
+// BeforeAttempt implements ExecutionHook.
+func (s *LeastOutstandingSelector) BeforeAttempt(_ context.Context, attempt *ExecutionAttempt) {
+	if attempt == nil || attempt.Auth == nil {
+		return
+	}
+	s.mu.Lock()
+	if s.inflight == nil {
+		s.inflight = make(map[string]int)
+	}
+	s.inflight[attempt.Auth.ID]++
+	s.mu.Unlock()
+}
+
+// AfterAttempt implements ExecutionHook.
+func (s *LeastOutstandingSelector) AfterAttempt(_ context.Context, attempt *ExecutionAttempt, _ cliproxyexecutor.Response, _ error) {
+	if attempt == nil || attempt.Auth == nil {
+		return
+	}
+	s.mu.Lock()
+	if count := s.inflight[attempt.Auth.ID]; count > 1 {
+		s.inflight[attempt.Auth.ID] = count - 1
+	} else {
+		delete(s.inflight, attempt.Auth.ID)
+	}
+	s.mu.Unlock()
+}
+
+// OnAttemptChunk implements ExecutionHook.
+func (s *LeastOutstandingSelector) OnAttemptChunk(context.Context, *ExecutionAttempt, cliproxyexecutor.StreamChunk) {
+}
+
+// LatencySelector picks the available credential with the lowest exponentially weighted
+// moving average time-to-first-byte for the provider and model. Credentials without
+// samples are tried first so every account gets measured.
+// It implements ExecutionHook to record latency samples.
+type LatencySelector struct {
+	mu       sync.Mutex
+	ewma     map[string]time.Duration
+	cursors  map[string]int
+	started  map[*ExecutionAttempt]time.Time
+	observed map[*ExecutionAttempt]struct{}
+	nowFunc  func() time.Time
+}
+
+// NewLatencySelector constructs a LatencySelector.
+func NewLatencySelector() *LatencySelector {
+	return &LatencySelector{
+		ewma:     make(map[string]time.Duration),
+		started:  make(map[*ExecutionAttempt]time.Time),
+		cursors:  make(map[string]int),
+		observed: make(map[*ExecutionAttempt]struct{}),
+	}
+}
+
+func latencyKey(provider, model, authID string) string {
+	return strings.ToLower(strings.TrimSpace(provider)) + "|" + model + "|" + authID
+}
+
+func (s *LatencySelector) now() time.Time {
+	if s.nowFunc != nil {
+		return s.nowFunc()
+	}
+	return time.Now()
+}
+
+func (s *LatencySelector) ensureLocked() {
+	if s.ewma == nil {
+		s.ewma = make(map[string]time.Duration)
+	}
+	if s.started == nil {
+		s.started = make(map[*ExecutionAttempt]time.Time)
+	}
+	if s.cursors == nil {
+		s.cursors = make(map[string]int)
+	}
+	if s.observed == nil {
+		s.observed = make(map[*ExecutionAttempt]struct{})
+	}
+}
+
+// Pick selects the available auth with the lowest average time-to-first-byte.
+func (s *LatencySelector) Pick(ctx context.Context, provider, model string, opts cliproxyexecutor.Options, auths []*Auth) (*Auth, error) {
+	_ = ctx
+	_ = opts
+	available, err := getAvailableAuths(auths, provider, model, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	key := provider + ":" + model
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ensureLocked()
+	start := s.cursors[key]
+	if start >= 2_147_483_640 {
+		start = 0
+	}
+	s.cursors[key] = start + 1
+	var selected *Auth
+	var best time.Duration
+	bestMeasured := true
+	for i := 0; i < len(available); i++ {
+		candidate := available[(start+i)%len(available)]
+		avg, measured := s.ewma[latencyKey(candidate.Provider, model, candidate.ID)]
+		switch {
+		case selected == nil:
+		case !measured && bestMeasured:
+		case measured && bestMeasured && avg < best:
+		default:
+			continue
+		}
+		selected = candidate
+		best = avg
+		bestMeasured = measured
+	}
+	return selected, nil
+}
+
+// AverageLatency returns the recorded time-to-first-byte average for the auth, provider and model.
+func (s *LatencySelector) AverageLatency(provider, model, authID string) (time.Duration, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	avg, ok := s.ewma[latencyKey(provider, model, authID)]
+	return avg, ok
+}
+
+// BeforeAttempt implements ExecutionHook.
+func (s *LatencySelector) BeforeAttempt(_ context.Context, attempt *ExecutionAttempt) {
+	if attempt == nil || attempt.Auth == nil {
+		return
+	}
+	s.mu.Lock()
+	s.ensureLocked()
+	s.started[attempt] = s.now()
+	s.mu.Unlock()
+}
+
+// OnAttemptChunk implements ExecutionHook. The first successful chunk of a stream marks its first byte.
+func (s *LatencySelector) OnAttemptChunk(_ context.Context, attempt *ExecutionAttempt, chunk cliproxyexecutor.StreamChunk) {
+	if attempt == nil || chunk.Err != nil {
+		return
+	}
+	s.mu.Lock()
+	s.observeLocked(attempt, 0)
+	s.mu.Unlock()
+}
+
+// AfterAttempt implements ExecutionHook. Successful non-streaming attempts record their full
+// latency; attempts failing before their first byte record it plus latencyFailurePenalty.
+func (s *LatencySelector) AfterAttempt(_ context.Context, attempt *ExecutionAttempt, _ cliproxyexecutor.Response, err error) {
+	if attempt == nil {
+		return
+	}
+	s.mu.Lock()
+	if err == nil {
+		s.observeLocked(attempt, 0)
+	} else {
+		s.observeLocked(attempt, latencyFailurePenalty)
+	}
+	delete(s.started, attempt)
+	delete(s.observed, attempt)
+	s.mu.Unlock()
+}
+
+func (s *LatencySelector) observeLocked(attempt *ExecutionAttempt, penalty time.Duration) {
+	if attempt.Auth == nil {
+		return
+	}
+	s.ensureLocked()
+	if _, done := s.observed[attempt]; done {
+		return
+	}
+	started, ok := s.started[attempt]
+	if !ok {
+		return
+	}
+	s.observed[attempt] = struct{}{}
+	sample := s.now().Sub(started)
+	if sample < 0 {
+		sample = 0
+	}
+	sample += penalty
+	key := latencyKey(attempt.Auth.Provider, attempt.Model, attempt.Auth.ID)
+	prev, ok := s.ewma[key]
+	if !ok {
+		s.ewma[key] = sample
+		return
+	}
+	s.ewma[key] = time.Duration(latencyEWMAAlpha*float64(sample) + (1-latencyEWMAAlpha)*float64(prev))
+}
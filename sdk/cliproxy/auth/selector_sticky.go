@@ -0,0 +1,297 @@
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	"github.com/tidwall/gjson"
+)
+
+const defaultSessionAffinityTTL = time.Hour
+
+// SessionAffinity describes a conversation currently pinned to a credential.
+type SessionAffinity struct {
+	// Key is the derived affinity key (model scoped).
+	Key string `json:"key"`
+	// Source reports where the key was derived from (header, user_id, prompt_cache_key, prompt_hash).
+	Source string `json:"source"`
+	// AuthID references the pinned credential.
+	AuthID string `json:"auth_id"`
+	// Hits counts how many selections reused the affinity.
+	Hits int64 `json:"hits"`
+	// CreatedAt records when the affinity was first established.
+	CreatedAt time.Time `json:"created_at"`
+	// ExpiresAt is when the affinity is dropped unless it is used again.
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// SessionAffinityStore is implemented by selectors that keep session affinities.
+type SessionAffinityStore interface {
+	// SessionAffinities lists the currently active affinities.
+	SessionAffinities() []SessionAffinity
+	// ClearSessionAffinities removes the affinity with the given key, or all affinities when key is empty.
+	// It returns the number of removed entries.
+	ClearSessionAffinities(key string) int
+}
+
+// StickySelector pins conversations to a credential so consecutive turns land on the
+// same account. Requests without a derivable affinity key, or whose pinned credential
+// is unavailable, fall back to the wrapped selector.
+type StickySelector struct {
+	base   Selector
+	ttl    time.Duration
+	header string
+
+	mu        sync.Mutex
+	entries   map[string]*SessionAffinity
+	nextSweep time.Time
+	nowFunc   func() time.Time
+}
+
+// NewStickySelector wraps base with session affinity. A non-positive ttl uses one hour;
+// header optionally names a client request header carrying an explicit session key.
+func NewStickySelector(base Selector, ttl time.Duration, header string) *StickySelector {
+	if base == nil {
+		base = &RoundRobinSelector{}
+	}
+	if ttl <= 0 {
+		ttl = defaultSessionAffinityTTL
+	}
+	return &StickySelector{
+		base:    base,
+		ttl:     ttl,
+		header:  strings.TrimSpace(header),
+		entries: make(map[string]*SessionAffinity),
+	}
+}
+
+// NewSelectorFromRouting builds the selector described by the routing configuration.
+func NewSelectorFromRouting(cfg internalconfig.RoutingConfig) Selector {
+	selector := NewSelectorForStrategy(cfg.Strategy)
+	if !cfg.SessionAffinity.Enable {
+		return selector
+	}
+	ttl := time.Duration(cfg.SessionAffinity.TTLSeconds) * time.Second
+	return NewStickySelector(selector, ttl, cfg.SessionAffinity.Header)
+}
+
+func (s *StickySelector) now() time.Time {
+	if s.nowFunc != nil {
+		return s.nowFunc()
+	}
+	return time.Now()
+}
+
+// Pick returns the pinned auth for the request's affinity key when it is still usable,
+// otherwise delegates to the wrapped selector and pins the result.
+func (s *StickySelector) Pick(ctx context.Context, provider, model string, opts cliproxyexecutor.Options, auths []*Auth) (*Auth, error) {
+	key, source := s.affinityKey(ctx, model, opts)
+	if key == "" {
+		return s.base.Pick(ctx, provider, model, opts, auths)
+	}
+	now := s.now()
+	s.mu.Lock()
+	entry := s.entries[key]
+	if entry != nil && now.After(entry.ExpiresAt) {
+		delete(s.entries, key)
+		entry = nil
+	}
+	if entry != nil {
+		for _, candidate := range auths {
+			if candidate == nil || candidate.ID != entry.AuthID {
+				continue
+			}
+			if blocked, _, _ := isAuthBlockedForModel(candidate, model, now); blocked {
+				break
+			}
+			entry.Hits++
+			entry.ExpiresAt = now.Add(s.ttl)
+			s.mu.Unlock()
+			return candidate, nil
+		}
+	}
+	s.mu.Unlock()
+
+	selected, err := s.base.Pick(ctx, provider, model, opts, auths)
+	if err != nil || selected == nil {
+		return selected, err
+	}
+
+	s.mu.Lock()
+	if s.entries == nil {
+		s.entries = make(map[string]*SessionAffinity)
+	}
+	if now.After(s.nextSweep) {
+		for k, e := range s.entries {
+			if now.After(e.ExpiresAt) {
+				delete(s.entries, k)
+			}
+		}
+		s.nextSweep = now.Add(s.ttl)
+	}
+	s.entries[key] = &SessionAffinity{
+		Key:       key,
+		Source:    source,
+		AuthID:    selected.ID,
+		CreatedAt: now,
+		ExpiresAt: now.Add(s.ttl),
+	}
+	s.mu.Unlock()
+	return selected, nil
+}
+
+// SessionAffinities implements SessionAffinityStore.
+func (s *StickySelector) SessionAffinities() []SessionAffinity {
+	now := s.now()
+	s.mu.Lock()
+	out := make([]SessionAffinity, 0, len(s.entries))
+	for _, entry := range s.entries {
+		if entry == nil || now.After(entry.ExpiresAt) {
+			continue
+		}
+		out = append(out, *entry)
+	}
+	s.mu.Unlock()
+	sort.Slice(out, func(i, j int) bool { return out[i].Key < out[j].Key })
+	return out
+}
+
+// ClearSessionAffinities implements SessionAffinityStore.
+func (s *StickySelector) ClearSessionAffinities(key string) int {
+	key = strings.TrimSpace(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if key == "" {
+		removed := len(s.entries)
+		s.entries = make(map[string]*SessionAffinity)
+		return removed
+	}
+	if _, ok := s.entries[key]; !ok {
+		return 0
+	}
+	delete(s.entries, key)
+	return 1
+}
+
+// BeforeAttempt implements ExecutionHook by forwarding to the wrapped selector.
+func (s *StickySelector) BeforeAttempt(ctx context.Context, attempt *ExecutionAttempt) {
+	if hook, ok := s.base.(ExecutionHook); ok {
+		hook.BeforeAttempt(ctx, attempt)
+	}
+}
+
+// AfterAttempt implements ExecutionHook by forwarding to the wrapped selector.
+func (s *StickySelector) AfterAttempt(ctx context.Context, attempt *ExecutionAttempt, resp cliproxyexecutor.Response, err error) {
+	if hook, ok := s.base.(ExecutionHook); ok {
+		hook.AfterAttempt(ctx, attempt, resp, err)
+	}
+}
+
+// OnAttemptChunk implements ExecutionHook by forwarding to the wrapped selector.
+func (s *StickySelector) OnAttemptChunk(ctx context.Context, attempt *ExecutionAttempt, chunk cliproxyexecutor.StreamChunk) {
+	if hook, ok := s.base.(ExecutionHook); ok {
+		hook.OnAttemptChunk(ctx, attempt, chunk)
+	}
+}
+
+// affinityKey derives a model scoped affinity key from, in order: the configured header,
+// Claude metadata.user_id, OpenAI/Codex prompt_cache_key, or a hash of the system prompt
+// and the first conversation message.
+func (s *StickySelector) affinityKey(ctx context.Context, model string, opts cliproxyexecutor.Options) (string, string) {
+	value, source := "", ""
+	if s.header != "" {
+		if v := requestHeaderValue(ctx, opts.Headers, s.header); v != "" {
+			value, source = v, "header"
+		}
+	}
+	raw := opts.OriginalRequest
+	if value == "" && len(raw) > 0 {
+		if v := strings.TrimSpace(gjson.GetBytes(raw, "metadata.user_id").String()); v != "" {
+			value, source = v, "user_id"
+		} else if v = strings.TrimSpace(gjson.GetBytes(raw, "prompt_cache_key").String()); v != "" {
+			value, source = v, "prompt_cache_key"
+		} else if v = promptFingerprint(raw); v != "" {
+			value, source = v, "prompt_hash"
+		}
+	}
+	if value == "" {
+		return "", ""
+	}
+	return model + "|" + source + ":" + value, source
+}
+
+// requestHeaderValue reads a header from explicit options or the inbound gin request.
+func requestHeaderValue(ctx context.Context, headers http.Header, name string) string {
+	if headers != nil {
+		if v := strings.TrimSpace(headers.Get(name)); v != "" {
+			return v
+		}
+	}
+	if ctx == nil {
+		return ""
+	}
+	if getter, ok := ctx.Value("gin").(interface{ GetHeader(string) string }); ok && getter != nil {
+		return strings.TrimSpace(getter.GetHeader(name))
+	}
+	return ""
+}
+
+// promptFingerprint hashes the system prompt and first message across Claude, OpenAI,
+// Responses and Gemini request shapes.
+func promptFingerprint(raw []byte) string {
+	paths := []string{"system", "instructions", "systemInstruction", "request.systemInstruction", "messages.0", "input.0", "contents.0", "request.contents.0"}
+	hasher := sha256.New()
+	found := false
+	for _, path := range paths {
+		result := gjson.GetBytes(raw, path)
+		if !result.Exists() {
+			continue
+		}
+		found = true
+		hasher.Write([]byte(path))
+		hasher.Write([]byte{0})
+		hasher.Write([]byte(result.Raw))
+		hasher.Write([]byte{0})
+	}
+	if !found {
+		return ""
+	}
+	return hex.EncodeToString(hasher.Sum(nil)[:16])
+}
+
+// SessionAffinities lists the active session affinities when the selector keeps them.
+func (m *Manager) SessionAffinities() ([]SessionAffinity, bool) {
+	if m == nil {
+		return nil, false
+	}
+	m.mu.RLock()
+	store, ok := m.selector.(SessionAffinityStore)
+	m.mu.RUnlock()
+	if !ok || store == nil {
+		return nil, false
+	}
+	return store.SessionAffinities(), true
+}
+
+// ClearSessionAffinities drops one (or, with an empty key, every) session affinity.
+// The boolean result is false when the active selector does not keep affinities.
+func (m *Manager) ClearSessionAffinities(key string) (int, bool) {
+	if m == nil {
+		return 0, false
+	}
+	m.mu.RLock()
+	store, ok := m.selector.(SessionAffinityStore)
+	m.mu.RUnlock()
+	if !ok || store == nil {
+		return 0, false
+	}
+	return store.ClearSessionAffinities(key), true
+}
@@ -175,3 +175,216 @@ func TestRoundRobinSelectorPick_Concurrent(t *testing.T) {
 	default:
 	}
 }
+
+func TestLeastOutstandingSelectorPick_PrefersIdleAuth(t *testing.T) {
+	t.Parallel()
+
+	selector := NewLeastOutstandingSelector()
+	auths := []*Auth{{ID: "a"}, {ID: "b"}, {ID: "c"}}
+	busy := &ExecutionAttempt{Auth: &Auth{ID: "a"}}
+	selector.BeforeAttempt(context.Background(), busy)
+	selector.BeforeAttempt(context.Background(), &ExecutionAttempt{Auth: &Auth{ID: "b"}})
+
+	got, err := selector.Pick(context.Background(), "claude", "", cliproxyexecutor.Options{}, auths)
+	if err != nil {
+		t.Fatalf("Pick() error = %v", err)
+	}
+	if got.ID != "c" {
+		t.Fatalf("Pick() auth.ID = %q, want %q", got.ID, "c")
+	}
+
+	selector.AfterAttempt(context.Background(), busy, cliproxyexecutor.Response{}, nil)
+	if n := selector.Outstanding("a"); n != 0 {
+		t.Fatalf("Outstanding(a) = %d, want 0", n)
+	}
+}
+
+func TestLeastOutstandingSelectorPick_RespectsPriority(t *testing.T) {
+	t.Parallel()
+
+	selector := NewLeastOutstandingSelector()
+	high := &Auth{ID: "high", Attributes: map[string]string{"priority": "10"}}
+	low := &Auth{ID: "low"}
+	selector.BeforeAttempt(context.Background(), &ExecutionAttempt{Auth: high})
+
+	got, err := selector.Pick(context.Background(), "claude", "", cliproxyexecutor.Options{}, []*Auth{low, high})
+	if err != nil {
+		t.Fatalf("Pick() error = %v", err)
+	}
+	if got.ID != "high" {
+		t.Fatalf("Pick() auth.ID = %q, want %q", got.ID, "high")
+	}
+}
+
+func TestLatencySelectorPick_PrefersLowestEWMA(t *testing.T) {
+	t.Parallel()
+
+	now := time.Unix(0, 0)
+	selector := NewLatencySelector()
+	selector.nowFunc = func() time.Time { return now }
+	auths := []*Auth{{ID: "fast", Provider: "codex"}, {ID: "slow", Provider: "codex"}, {ID: "new", Provider: "codex"}}
+
+	record := func(auth *Auth, latency time.Duration) {
+		attempt := &ExecutionAttempt{Auth: auth, Model: "gpt-5"}
+		selector.BeforeAttempt(context.Background(), attempt)
+		now = now.Add(latency)
+		selector.OnAttemptChunk(context.Background(), attempt, cliproxyexecutor.StreamChunk{Payload: []byte("x")})
+		selector.AfterAttempt(context.Background(), attempt, cliproxyexecutor.Response{}, nil)
+	}
+	record(auths[0], 100*time.Millisecond)
+	record(auths[1], 900*time.Millisecond)
+
+	got, err := selector.Pick(context.Background(), "mixed", "gpt-5", cliproxyexecutor.Options{}, auths)
+	if err != nil {
+		t.Fatalf("Pick() error = %v", err)
+	}
+	if got.ID != "new" {
+		t.Fatalf("Pick() auth.ID = %q, want unmeasured auth %q", got.ID, "new")
+	}
+
+	record(auths[2], 500*time.Millisecond)
+	for i := 0; i < 3; i++ {
+		got, err = selector.Pick(context.Background(), "mixed", "gpt-5", cliproxyexecutor.Options{}, auths)
+		if err != nil {
+			t.Fatalf("Pick() #%d error = %v", i, err)
+		}
+		if got.ID != "fast" {
+			t.Fatalf("Pick() #%d auth.ID = %q, want %q", i, got.ID, "fast")
+		}
+	}
+	if avg, ok := selector.AverageLatency("codex", "gpt-5", "fast"); !ok || avg != 100*time.Millisecond {
+		t.Fatalf("AverageLatency(fast) = %v, %v", avg, ok)
+	}
+}
+
+func TestLatencySelectorPick_PenalizesFailures(t *testing.T) {
+	t.Parallel()
+
+	now := time.Unix(0, 0)
+	selector := NewLatencySelector()
+	selector.nowFunc = func() time.Time { return now }
+	auths := []*Auth{{ID: "broken", Provider: "codex"}, {ID: "slow", Provider: "codex"}}
+
+	failing := &ExecutionAttempt{Auth: auths[0], Model: "gpt-5"}
+	selector.BeforeAttempt(context.Background(), failing)
+	now = now.Add(50 * time.Millisecond)
+	selector.AfterAttempt(context.Background(), failing, cliproxyexecutor.Response{}, errors.New("upstream error"))
+
+	if avg, ok := selector.AverageLatency("codex", "gpt-5", "broken"); !ok || avg < latencyFailurePenalty {
+		t.Fatalf("AverageLatency(broken) = %v, %v; want a failure penalty", avg, ok)
+	}
+
+	ok := &ExecutionAttempt{Auth: auths[1], Model: "gpt-5"}
+	selector.BeforeAttempt(context.Background(), ok)
+	now = now.Add(2 * time.Second)
+	selector.AfterAttempt(context.Background(), ok, cliproxyexecutor.Response{}, nil)
+
+	for i := 0; i < 2; i++ {
+		got, err := selector.Pick(context.Background(), "mixed", "gpt-5", cliproxyexecutor.Options{}, auths)
+		if err != nil {
+			t.Fatalf("Pick() #%d error = %v", i, err)
+		}
+		if got.ID != "slow" {
+			t.Fatalf("Pick() #%d auth.ID = %q, want the working credential", i, got.ID)
+		}
+	}
+}
+
+func TestNormalizeRoutingStrategy(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]string{
+		"":                  RoutingStrategyRoundRobin,
+		"FF":                RoutingStrategyFillFirst,
+		"least-busy":        RoutingStrategyLeastOutstanding,
+		"least-outstanding": RoutingStrategyLeastOutstanding,
+		"latency-aware":     RoutingStrategyLatency,
+	}
+	for input, want := range cases {
+		got, ok := NormalizeRoutingStrategy(input)
+		if !ok || got != want {
+			t.Fatalf("NormalizeRoutingStrategy(%q) = %q, %v; want %q", input, got, ok, want)
+		}
+	}
+	if _, ok := NormalizeRoutingStrategy("random"); ok {
+		t.Fatalf("NormalizeRoutingStrategy(random) should be rejected")
+	}
+}
+
+func TestStickySelectorPick_PinsConversation(t *testing.T) {
+	t.Parallel()
+
+	selector := NewStickySelector(&RoundRobinSelector{}, time.Minute, "")
+	auths := []*Auth{{ID: "a"}, {ID: "b"}, {ID: "c"}}
+	claude := cliproxyexecutor.Options{OriginalRequest: []byte(`{"metadata":{"user_id":"user-1"},"messages":[{"role":"user","content":"hi"}]}`)}
+
+	first, err := selector.Pick(context.Background(), "claude", "claude-sonnet", claude, auths)
+	if err != nil {
+		t.Fatalf("Pick() error = %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		got, errPick := selector.Pick(context.Background(), "claude", "claude-sonnet", claude, auths)
+		if errPick != nil {
+			t.Fatalf("Pick() #%d error = %v", i, errPick)
+		}
+		if got.ID != first.ID {
+			t.Fatalf("Pick() #%d auth.ID = %q, want pinned %q", i, got.ID, first.ID)
+		}
+	}
+
+	affinities := selector.SessionAffinities()
+	if len(affinities) != 1 || affinities[0].Source != "user_id" || affinities[0].Hits != 3 {
+		t.Fatalf("SessionAffinities() = %+v", affinities)
+	}
+}
+
+func TestStickySelectorPick_FallsBackWhenPinnedAuthCoolingDown(t *testing.T) {
+	t.Parallel()
+
+	selector := NewStickySelector(&FillFirstSelector{}, time.Minute, "")
+	opts := cliproxyexecutor.Options{OriginalRequest: []byte(`{"prompt_cache_key":"conv-1","input":[]}`)}
+	a := &Auth{ID: "a"}
+	b := &Auth{ID: "b"}
+
+	got, err := selector.Pick(context.Background(), "codex", "gpt-5", opts, []*Auth{a, b})
+	if err != nil || got.ID != "a" {
+		t.Fatalf("Pick() = %v, %v; want a", got, err)
+	}
+
+	a.ModelStates = map[string]*ModelState{"gpt-5": {
+		Unavailable:    true,
+		Status:         StatusError,
+		NextRetryAfter: time.Now().Add(time.Minute),
+		Quota:          QuotaState{Exceeded: true, NextRecoverAt: time.Now().Add(time.Minute)},
+	}}
+	got, err = selector.Pick(context.Background(), "codex", "gpt-5", opts, []*Auth{a, b})
+	if err != nil || got.ID != "b" {
+		t.Fatalf("Pick() after cooldown = %v, %v; want b", got, err)
+	}
+	if affinities := selector.SessionAffinities(); len(affinities) != 1 || affinities[0].AuthID != "b" {
+		t.Fatalf("SessionAffinities() = %+v, want repinned to b", affinities)
+	}
+	if removed := selector.ClearSessionAffinities(""); removed != 1 {
+		t.Fatalf("ClearSessionAffinities() = %d, want 1", removed)
+	}
+}
+
+func TestStickySelectorPick_PromptHashAndExpiry(t *testing.T) {
+	t.Parallel()
+
+	now := time.Unix(1000, 0)
+	selector := NewStickySelector(&RoundRobinSelector{}, time.Minute, "")
+	selector.nowFunc = func() time.Time { return now }
+	auths := []*Auth{{ID: "a"}, {ID: "b"}}
+	opts := cliproxyexecutor.Options{OriginalRequest: []byte(`{"messages":[{"role":"system","content":"be brief"}]}`)}
+
+	first, _ := selector.Pick(context.Background(), "openai", "m", opts, auths)
+	second, _ := selector.Pick(context.Background(), "openai", "m", opts, auths)
+	if first.ID != second.ID {
+		t.Fatalf("prompt hash affinity not applied: %q vs %q", first.ID, second.ID)
+	}
+	now = now.Add(2 * time.Minute)
+	if affinities := selector.SessionAffinities(); len(affinities) != 0 {
+		t.Fatalf("SessionAffinities() after ttl = %+v, want empty", affinities)
+	}
+}
@@ -0,0 +1,63 @@
+package auth
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// startSelectSpan opens the credential selection span for pickNext/pickNextMixed.
+func startSelectSpan(ctx context.Context, model string, providers ...string) trace.Span {
+	_, span := tracing.Start(ctx, "auth.select",
+		tracing.AttrModel.String(model),
+		attribute.StringSlice("cliproxy.providers", providers),
+	)
+	return span
+}
+
+// endSelectSpan records the selected credential on span and ends it. Only the provider and
+// the opaque auth index are recorded so no credential material reaches the trace backend.
+func endSelectSpan(span trace.Span, auth *Auth, provider string, err error) {
+	if auth != nil && span.IsRecording() {
+		span.SetAttributes(
+			tracing.AttrProvider.String(provider),
+			tracing.AttrAuthIndex.String(auth.Index),
+		)
+	}
+	tracing.End(span, err)
+}
+
+// startAttemptSpan opens the span covering a single executor invocation.
+func startAttemptSpan(ctx context.Context, auth *Auth, provider, model string, attempt int, stream bool) (context.Context, trace.Span) {
+	attrs := []attribute.KeyValue{
+		tracing.AttrProvider.String(provider),
+		tracing.AttrModel.String(model),
+		tracing.AttrAttempt.Int(attempt),
+		tracing.AttrStream.Bool(stream),
+	}
+	if auth != nil {
+		attrs = append(attrs, tracing.AttrAuthIndex.String(auth.Index))
+	}
+	return tracing.Start(ctx, "executor."+strings.ToLower(strings.TrimSpace(provider)), attrs...)
+}
+
+// recordRetryEvent annotates the current span with a scheduled retry round.
+func recordRetryEvent(ctx context.Context, model string, attempt int, wait time.Duration, err error) {
+	span := trace.SpanFromContext(ctx)
+	if !span.IsRecording() {
+		return
+	}
+	attrs := []attribute.KeyValue{
+		tracing.AttrModel.String(model),
+		tracing.AttrAttempt.Int(attempt),
+		attribute.String("cliproxy.retry.wait", wait.String()),
+	}
+	if err != nil {
+		attrs = append(attrs, attribute.String("cliproxy.retry.error", err.Error()))
+	}
+	span.AddEvent("retry", trace.WithAttributes(attrs...))
+}
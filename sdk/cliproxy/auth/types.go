@@ -75,6 +75,14 @@ type QuotaState struct {
 	NextRecoverAt time.Time `json:"next_recover_at"`
 	// BackoffLevel stores the progressive cooldown exponent used for rate limits.
 	BackoffLevel int `json:"backoff_level,omitempty"`
+	// Remaining and Limit hold the last quota reported by the provider, in provider
+	// units (requests, credits or percent). Limit is zero when the quota is unknown.
+	Remaining float64 `json:"remaining,omitempty"`
+	Limit     float64 `json:"limit,omitempty"`
+	// ResetAt is when the provider replenishes the reported quota.
+	ResetAt time.Time `json:"reset_at,omitempty"`
+	// CheckedAt records when Remaining and Limit were last observed.
+	CheckedAt time.Time `json:"checked_at,omitempty"`
 }
 
 // ModelState captures the execution state for a specific model under an auth entry.
@@ -5,12 +5,13 @@ package cliproxy
 
 import (
 	"fmt"
-	"strings"
 
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/api"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/metrics"
 	sdkaccess "github.com/router-for-me/CLIProxyAPI/v6/sdk/access"
 	sdkAuth "github.com/router-for-me/CLIProxyAPI/v6/sdk/auth"
 	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/pipeline"
 	"github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
 )
 
@@ -47,6 +48,9 @@ type Builder struct {
 
 	// serverOptions contains additional server configuration options.
 	serverOptions []api.ServerOption
+
+	// pipelineChains holds ordered hook chains invoked around every execution attempt.
+	pipelineChains []*pipeline.Chain
 }
 
 // Hooks allows callers to plug into service lifecycle stages.
@@ -143,6 +147,18 @@ func (b *Builder) WithServerOptions(opts ...api.ServerOption) *Builder {
 	return b
 }
 
+// WithPipelineHooks registers an ordered hook chain invoked around every executor attempt,
+// including retries onto a different credential. Hooks within a chain run in the given order,
+// and chains run in the order they were registered.
+func (b *Builder) WithPipelineHooks(hooks ...pipeline.Hook) *Builder {
+	chain := pipeline.NewChain(hooks...)
+	if chain.Len() == 0 {
+		return b
+	}
+	b.pipelineChains = append(b.pipelineChains, chain)
+	return b
+}
+
 // WithLocalManagementPassword configures a password that is only accepted from localhost management requests.
 func (b *Builder) WithLocalManagementPassword(password string) *Builder {
 	if password == "" {
@@ -199,17 +215,7 @@ func (b *Builder) Build() (*Service, error) {
 			dirSetter.SetBaseDir(b.cfg.AuthDir)
 		}
 
-		strategy := ""
-		if b.cfg != nil {
-			strategy = strings.ToLower(strings.TrimSpace(b.cfg.Routing.Strategy))
-		}
-		var selector coreauth.Selector
-		switch strategy {
-		case "fill-first", "fillfirst", "ff":
-			selector = &coreauth.FillFirstSelector{}
-		default:
-			selector = &coreauth.RoundRobinSelector{}
-		}
+		selector := coreauth.NewSelectorFromRouting(b.cfg.Routing)
 
 		coreManager = coreauth.NewManager(tokenStore, selector, nil)
 	}
@@ -217,6 +223,11 @@ func (b *Builder) Build() (*Service, error) {
 	coreManager.SetRoundTripperProvider(newDefaultRoundTripperProvider())
 	coreManager.SetConfig(b.cfg)
 	coreManager.SetOAuthModelAlias(b.cfg.OAuthModelAlias)
+	// Metrics observe every attempt; the /metrics endpoint decides whether they are exposed.
+	coreManager.AddExecutionHook(metrics.Default())
+	for _, chain := range b.pipelineChains {
+		coreManager.AddExecutionHook(chain)
+	}
 
 	service := &Service{
 		cfg:            b.cfg,
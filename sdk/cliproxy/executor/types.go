@@ -1,8 +1,11 @@
 package executor
 
 import (
+	"context"
+	"errors"
 	"net/http"
 	"net/url"
+	"time"
 
 	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
 )
@@ -10,6 +13,133 @@ import (
 // RequestedModelMetadataKey stores the client-requested model name in Options.Metadata.
 const RequestedModelMetadataKey = "requested_model"
 
+// ResponseCacheBypassMetadataKey in Options.Metadata keeps a request out of the response cache.
+const ResponseCacheBypassMetadataKey = "response_cache_bypass"
+
+// ResponseCacheMetadataKey is set to "hit" in Response.Metadata when the response cache
+// answered the request.
+const ResponseCacheMetadataKey = "response_cache"
+
+// AllowedModelsMetadataKey in Options.Metadata holds the model globs ([]string) the calling
+// client key may use. Fallback models outside the list are skipped.
+const AllowedModelsMetadataKey = "allowed_models"
+
+// AllowedProvidersMetadataKey in Options.Metadata holds the providers ([]string) the calling
+// client key may be routed to. Fallback targets are limited to these providers.
+const AllowedProvidersMetadataKey = "allowed_providers"
+
+type fallbackFromKey struct{}
+
+// WithFallbackFrom marks ctx as serving a fallback model on behalf of the originally requested model.
+func WithFallbackFrom(ctx context.Context, model string) context.Context {
+	return context.WithValue(ctx, fallbackFromKey{}, model)
+}
+
+// FallbackFrom returns the originally requested model when ctx serves a fallback model.
+func FallbackFrom(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	model, _ := ctx.Value(fallbackFromKey{}).(string)
+	return model
+}
+
+// RateLimit is the remaining upstream allowance reported in a response's rate-limit headers.
+type RateLimit struct {
+	// Remaining and Limit describe the most depleted window, in the provider's units.
+	Remaining float64
+	Limit     float64
+	// ResetAt is when the window replenishes; zero when unknown.
+	ResetAt time.Time
+}
+
+type rateLimitReporterKey struct{}
+
+// WithRateLimitReporter installs fn to receive the rate limits executors observe while serving ctx.
+func WithRateLimitReporter(ctx context.Context, fn func(RateLimit)) context.Context {
+	return context.WithValue(ctx, rateLimitReporterKey{}, fn)
+}
+
+// ReportRateLimit forwards limit to the reporter installed on ctx, if any.
+func ReportRateLimit(ctx context.Context, limit RateLimit) {
+	if ctx == nil {
+		return
+	}
+	if fn, ok := ctx.Value(rateLimitReporterKey{}).(func(RateLimit)); ok && fn != nil {
+		fn(limit)
+	}
+}
+
+type usageGateKey struct{}
+
+// WithUsageGate installs gate to decide when the usage records of requests served under ctx
+// are published. Executors hand gate the callback that publishes a record; gate may run it
+// right away, hold it back and run it later, or drop it.
+func WithUsageGate(ctx context.Context, gate func(publish func())) context.Context {
+	return context.WithValue(ctx, usageGateKey{}, gate)
+}
+
+// PublishUsage runs publish through the usage gate of ctx, or right away when there is none.
+func PublishUsage(ctx context.Context, publish func()) {
+	if ctx != nil {
+		if gate, ok := ctx.Value(usageGateKey{}).(func(func())); ok && gate != nil {
+			gate(publish)
+			return
+		}
+	}
+	publish()
+}
+
+type streamContinuationKey struct{}
+
+// WithStreamContinuation marks ctx as able to resume streams that break midway, so executors
+// surface truncated output as an error instead of patching it up in-band.
+func WithStreamContinuation(ctx context.Context) context.Context {
+	return context.WithValue(ctx, streamContinuationKey{}, true)
+}
+
+// StreamContinuation reports whether interrupted streams served under ctx will be resumed.
+func StreamContinuation(ctx context.Context) bool {
+	if ctx == nil {
+		return false
+	}
+	enabled, _ := ctx.Value(streamContinuationKey{}).(bool)
+	return enabled
+}
+
+type authObserverKey struct{}
+
+// WithAuthObserver installs fn to learn which credential serves each streaming attempt under ctx.
+func WithAuthObserver(ctx context.Context, fn func(authID string)) context.Context {
+	return context.WithValue(ctx, authObserverKey{}, fn)
+}
+
+// ObserveAuth reports authID to the observer installed on ctx, if any.
+func ObserveAuth(ctx context.Context, authID string) {
+	if ctx == nil {
+		return
+	}
+	if fn, ok := ctx.Value(authObserverKey{}).(func(string)); ok && fn != nil {
+		fn(authID)
+	}
+}
+
+type avoidedAuthsKey struct{}
+
+// WithAvoidedAuths asks the manager to pick credentials other than ids while any remain available.
+func WithAvoidedAuths(ctx context.Context, ids []string) context.Context {
+	return context.WithValue(ctx, avoidedAuthsKey{}, ids)
+}
+
+// AvoidedAuths returns the credentials ctx asks the manager to avoid.
+func AvoidedAuths(ctx context.Context) []string {
+	if ctx == nil {
+		return nil
+	}
+	ids, _ := ctx.Value(avoidedAuthsKey{}).([]string)
+	return ids
+}
+
 // Request encapsulates the translated payload that will be sent to a provider executor.
 type Request struct {
 	// Model is the upstream model identifier after translation.
@@ -56,6 +186,23 @@ type StreamChunk struct {
 	Err error
 }
 
+// ErrLiveUnsupported is returned by executors that cannot open a live session with a
+// credential; the manager then moves on to the next credential without penalising this one.
+var ErrLiveUnsupported = errors.New("live sessions are not supported by this credential")
+
+// LiveSession is a bidirectional session opened by an executor, such as a Gemini Live
+// BidiGenerateContent websocket. Messages are relayed with their websocket message type.
+// Sessions publish the usage reported by upstream messages themselves.
+type LiveSession interface {
+	// Send forwards a client message upstream.
+	Send(messageType int, data []byte) error
+	// Receive returns the next upstream message. When upstream closes the session, the error
+	// is a *websocket.CloseError carrying its close code.
+	Receive() (int, []byte, error)
+	// Close ends the session.
+	Close() error
+}
+
 // StatusError represents an error that carries an HTTP-like status code.
 // Provider executors should implement this when possible to enable
 // better auth state updates on failures (e.g., 401/402/429).
@@ -5,6 +5,9 @@ import "github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
 // ModelInfo re-exports the registry model info structure.
 type ModelInfo = registry.ModelInfo
 
+// ModelCapabilities re-exports the registry model capabilities type.
+type ModelCapabilities = registry.ModelCapabilities
+
 // ModelRegistryHook re-exports the registry hook interface for external integrations.
 type ModelRegistryHook = registry.ModelRegistryHook
 
@@ -0,0 +1,99 @@
+package pipeline
+
+import (
+	"context"
+	"net/http"
+	"sync"
+
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+)
+
+// Chain runs an ordered list of hooks around every execution attempt performed by
+// the core auth manager. It implements cliproxyauth.ExecutionHook so it can be
+// registered through Manager.SetExecutionHooks.
+type Chain struct {
+	hooks    []Hook
+	contexts sync.Map // *cliproxyauth.ExecutionAttempt -> *Context
+}
+
+// NewChain creates a chain invoking hooks in the given order. Nil hooks are ignored.
+func NewChain(hooks ...Hook) *Chain {
+	filtered := make([]Hook, 0, len(hooks))
+	for _, hook := range hooks {
+		if hook != nil {
+			filtered = append(filtered, hook)
+		}
+	}
+	return &Chain{hooks: filtered}
+}
+
+// Len reports the number of hooks in the chain.
+func (c *Chain) Len() int {
+	if c == nil {
+		return 0
+	}
+	return len(c.hooks)
+}
+
+// BeforeAttempt implements cliproxyauth.ExecutionHook.
+// Changes hooks make to Context.Request and Context.Options are applied to the attempt, and
+// the transport of Context.HTTPClient, when set, carries the attempt's upstream requests.
+func (c *Chain) BeforeAttempt(ctx context.Context, attempt *cliproxyauth.ExecutionAttempt) {
+	if c == nil || attempt == nil || len(c.hooks) == 0 {
+		return
+	}
+	execCtx := &Context{
+		Request: attempt.Request,
+		Options: attempt.Options,
+		Auth:    attempt.Auth,
+	}
+	for _, hook := range c.hooks {
+		hook.BeforeExecute(ctx, execCtx)
+	}
+	attempt.Request = execCtx.Request
+	attempt.Options = execCtx.Options
+	if execCtx.HTTPClient != nil {
+		attempt.RoundTripper = execCtx.HTTPClient.Transport
+		if attempt.RoundTripper == nil {
+			attempt.RoundTripper = http.DefaultTransport
+		}
+	}
+	c.contexts.Store(attempt, execCtx)
+}
+
+// AfterAttempt implements cliproxyauth.ExecutionHook.
+func (c *Chain) AfterAttempt(ctx context.Context, attempt *cliproxyauth.ExecutionAttempt, resp cliproxyexecutor.Response, err error) {
+	if c == nil || attempt == nil || len(c.hooks) == 0 {
+		return
+	}
+	execCtx := c.contextFor(attempt)
+	c.contexts.Delete(attempt)
+	for _, hook := range c.hooks {
+		hook.AfterExecute(ctx, execCtx, resp, err)
+	}
+}
+
+// OnAttemptChunk implements cliproxyauth.ExecutionHook.
+func (c *Chain) OnAttemptChunk(ctx context.Context, attempt *cliproxyauth.ExecutionAttempt, chunk cliproxyexecutor.StreamChunk) {
+	if c == nil || attempt == nil || len(c.hooks) == 0 {
+		return
+	}
+	execCtx := c.contextFor(attempt)
+	for _, hook := range c.hooks {
+		hook.OnStreamChunk(ctx, execCtx, chunk)
+	}
+}
+
+func (c *Chain) contextFor(attempt *cliproxyauth.ExecutionAttempt) *Context {
+	if value, ok := c.contexts.Load(attempt); ok {
+		if execCtx, okCtx := value.(*Context); okCtx && execCtx != nil {
+			return execCtx
+		}
+	}
+	return &Context{
+		Request: attempt.Request,
+		Options: attempt.Options,
+		Auth:    attempt.Auth,
+	}
+}
@@ -15,11 +15,15 @@ type Context struct {
 	Request cliproxyexecutor.Request
 	// Options carries execution flags (streaming, headers, etc.).
 	Options cliproxyexecutor.Options
-	// Auth references the credential selected for execution.
+	// Auth is a copy of the credential selected for execution; changes to it are not persisted.
 	Auth *cliproxyauth.Auth
 	// Translator represents the pipeline responsible for schema adaptation.
+	//
+	// Deprecated: Chain does not populate it; executors translate payloads themselves, and
+	// hooks see the provider facing Request.
 	Translator *sdktranslator.Pipeline
-	// HTTPClient allows middleware to customise the outbound transport per request.
+	// HTTPClient allows middleware to customise the outbound transport per request. Only its
+	// Transport is used; executors keep their own timeouts.
 	HTTPClient *http.Client
 }
 
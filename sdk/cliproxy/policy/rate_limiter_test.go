@@ -1,4 +1,4 @@
-package kiro
+package policy
 
 import (
 	"sync"
@@ -302,3 +302,38 @@ func TestCalculateInterval_WithinRange(t *testing.T) {
 		}
 	}
 }
+
+func TestTryAcquire_DailyCap(t *testing.T) {
+	rl := NewRateLimiterWithConfig(RateLimiterConfig{DailyMaxRequests: 2})
+	if !rl.TryAcquire("token1") || !rl.TryAcquire("token1") {
+		t.Fatal("expected two requests within the daily cap")
+	}
+	if rl.TryAcquire("token1") {
+		t.Fatal("expected the third request to exceed the daily cap")
+	}
+	rl.Release("token1")
+	if !rl.TryAcquire("token1") {
+		t.Fatal("expected a released slot to be reusable")
+	}
+}
+
+func TestReserveSlot_ConsecutiveSlots(t *testing.T) {
+	rl := NewRateLimiterWithConfig(RateLimiterConfig{
+		MinTokenInterval: time.Second,
+		MaxTokenInterval: time.Second,
+		NoJitter:         true,
+	})
+	if wait, ok := rl.ReserveSlot("token1", time.Time{}); !ok || wait != 0 {
+		t.Fatalf("first slot = %v, %v; want immediate", wait, ok)
+	}
+	wait, ok := rl.ReserveSlot("token1", time.Time{})
+	if !ok || wait < 900*time.Millisecond || wait > time.Second {
+		t.Fatalf("second slot = %v, %v; want about one interval", wait, ok)
+	}
+	if _, ok = rl.ReserveSlot("token1", time.Now().Add(time.Second)); ok {
+		t.Fatal("expected a slot after the deadline to be refused")
+	}
+	if wait, ok = rl.ReserveSlot("token1", time.Time{}); !ok || wait < 1900*time.Millisecond {
+		t.Fatalf("slot after refusal = %v, %v; want the refused slot not reserved twice", wait, ok)
+	}
+}
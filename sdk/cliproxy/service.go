@@ -347,6 +347,21 @@ func (s *Service) applyRetryConfig(cfg *config.Config) {
 	s.coreManager.SetRetryConfig(cfg.RequestRetry, maxInterval)
 }
 
+// routingChanged reports whether the selector must be rebuilt for a routing config change.
+func routingChanged(previous, next config.RoutingConfig) bool {
+	normalize := func(strategy string) string {
+		normalized, ok := coreauth.NormalizeRoutingStrategy(strategy)
+		if !ok {
+			return coreauth.RoutingStrategyRoundRobin
+		}
+		return normalized
+	}
+	if normalize(previous.Strategy) != normalize(next.Strategy) {
+		return true
+	}
+	return previous.SessionAffinity != next.SessionAffinity
+}
+
 func openAICompatInfoFromAuth(a *coreauth.Auth) (providerKey string, compatName string, ok bool) {
 	if a == nil {
 		return "", "", false
@@ -544,10 +559,10 @@ func (s *Service) Run(ctx context.Context) error {
 
 	var watcherWrapper *WatcherWrapper
 	reloadCallback := func(newCfg *config.Config) {
-		previousStrategy := ""
+		var previousRouting config.RoutingConfig
 		s.cfgMu.RLock()
 		if s.cfg != nil {
-			previousStrategy = strings.ToLower(strings.TrimSpace(s.cfg.Routing.Strategy))
+			previousRouting = s.cfg.Routing
 		}
 		s.cfgMu.RUnlock()
 
@@ -560,26 +575,8 @@ func (s *Service) Run(ctx context.Context) error {
 			return
 		}
 
-		nextStrategy := strings.ToLower(strings.TrimSpace(newCfg.Routing.Strategy))
-		normalizeStrategy := func(strategy string) string {
-			switch strategy {
-			case "fill-first", "fillfirst", "ff":
-				return "fill-first"
-			default:
-				return "round-robin"
-			}
-		}
-		previousStrategy = normalizeStrategy(previousStrategy)
-		nextStrategy = normalizeStrategy(nextStrategy)
-		if s.coreManager != nil && previousStrategy != nextStrategy {
-			var selector coreauth.Selector
-			switch nextStrategy {
-			case "fill-first":
-				selector = &coreauth.FillFirstSelector{}
-			default:
-				selector = &coreauth.RoundRobinSelector{}
-			}
-			s.coreManager.SetSelector(selector)
+		if s.coreManager != nil && routingChanged(previousRouting, newCfg.Routing) {
+			s.coreManager.SetSelector(coreauth.NewSelectorFromRouting(newCfg.Routing))
 		}
 
 		s.applyRetryConfig(newCfg)
@@ -632,6 +629,7 @@ func (s *Service) Run(ctx context.Context) error {
 		interval := 15 * time.Minute
 		s.coreManager.StartAutoRefresh(context.Background(), interval)
 		log.Infof("core auth auto-refresh started (interval=%s)", interval)
+		s.coreManager.StartQuotaPolling(context.Background())
 	}
 
 	select {
@@ -669,6 +667,7 @@ func (s *Service) Shutdown(ctx context.Context) error {
 		}
 		if s.coreManager != nil {
 			s.coreManager.StopAutoRefresh()
+			s.coreManager.StopQuotaPolling()
 		}
 		if s.watcher != nil {
 			if err := s.watcher.Stop(); err != nil {
@@ -896,7 +895,7 @@ func (s *Service) registerModelsForAuth(a *coreauth.Auth) {
 						if modelID == "" {
 							modelID = m.Name
 						}
-						ms = append(ms, &ModelInfo{
+						info := &ModelInfo{
 							ID:          modelID,
 							Object:      "model",
 							Created:     time.Now().Unix(),
@@ -904,7 +903,9 @@ func (s *Service) registerModelsForAuth(a *coreauth.Auth) {
 							Type:        "openai-compatibility",
 							DisplayName: modelID,
 							UserDefined: true,
-						})
+						}
+						applyModelCapabilities(info, nil, m.GetCapabilities())
+						ms = append(ms, info)
 					}
 					// Register and return
 					if len(ms) > 0 {
@@ -1237,6 +1238,7 @@ func matchWildcard(pattern, value string) bool {
 type modelEntry interface {
 	GetName() string
 	GetAlias() string
+	GetCapabilities() config.ModelCapabilityConfig
 }
 
 func buildConfigModels[T modelEntry](models []T, ownedBy, modelType string) []*ModelInfo {
@@ -1274,16 +1276,66 @@ func buildConfigModels[T modelEntry](models []T, ownedBy, modelType string) []*M
 			DisplayName: display,
 			UserDefined: true,
 		}
+		var upstream *ModelInfo
 		if name != "" {
-			if upstream := registry.LookupStaticModelInfo(name); upstream != nil && upstream.Thinking != nil {
+			upstream = registry.LookupStaticModelInfo(name)
+			if upstream != nil && upstream.Thinking != nil {
 				info.Thinking = upstream.Thinking
 			}
 		}
+		applyModelCapabilities(info, upstream, model.GetCapabilities())
 		out = append(out, info)
 	}
 	return out
 }
 
+// applyModelCapabilities fills the limits, capabilities and tokenizer of a configured model from the
+// static catalog entry of its upstream model, when known, and then from its declaration.
+// Capabilities stay nil, disabling pre-dispatch validation, unless one of them is known;
+// features neither declared nor known from the catalog are assumed to be supported.
+func applyModelCapabilities(info, upstream *ModelInfo, declared config.ModelCapabilityConfig) {
+	if info == nil {
+		return
+	}
+	capabilities := registry.ModelCapabilities{Vision: true, Audio: true, ToolUse: true, JSONSchema: true}
+	known := false
+	if upstream != nil {
+		info.ContextLength = upstream.ContextWindow()
+		info.MaxCompletionTokens = upstream.MaxOutputTokens()
+		info.Tokenizer = upstream.Tokenizer
+		if upstream.Capabilities != nil {
+			capabilities = *upstream.Capabilities
+			known = true
+		}
+	}
+	if declared.ContextLength > 0 {
+		info.ContextLength = declared.ContextLength
+	}
+	if declared.MaxOutputTokens > 0 {
+		info.MaxCompletionTokens = declared.MaxOutputTokens
+	}
+	if tokenizer := strings.TrimSpace(declared.Tokenizer); tokenizer != "" {
+		info.Tokenizer = tokenizer
+	}
+	for _, flag := range []struct {
+		declared *bool
+		target   *bool
+	}{
+		{declared.Vision, &capabilities.Vision},
+		{declared.Audio, &capabilities.Audio},
+		{declared.ToolUse, &capabilities.ToolUse},
+		{declared.JSONSchema, &capabilities.JSONSchema},
+	} {
+		if flag.declared != nil {
+			*flag.target = *flag.declared
+			known = true
+		}
+	}
+	if known {
+		info.Capabilities = &capabilities
+	}
+}
+
 func buildVertexCompatConfigModels(entry *config.VertexCompatKey) []*ModelInfo {
 	if entry == nil {
 		return nil
@@ -19,6 +19,20 @@ type Record struct {
 	RequestedAt time.Time
 	Failed      bool
 	Detail      Detail
+	// FallbackFrom is the originally requested model when a fallback chain served the request.
+	FallbackFrom string
+	// ResponseCached marks requests answered from the response cache; Detail then carries the
+	// usage of the upstream call that produced the cached response.
+	ResponseCached bool
+	// Estimated marks input tokens counted locally because the upstream response carried no usage.
+	Estimated bool
+}
+
+type recordObserverKey struct{}
+
+// WithRecordObserver installs fn to receive the usage records published while serving ctx.
+func WithRecordObserver(ctx context.Context, fn func(Record)) context.Context {
+	return context.WithValue(ctx, recordObserverKey{}, fn)
 }
 
 // Detail holds the token usage breakdown.
@@ -109,6 +123,11 @@ func (m *Manager) Publish(ctx context.Context, record Record) {
 	if m == nil {
 		return
 	}
+	if ctx != nil {
+		if observe, ok := ctx.Value(recordObserverKey{}).(func(Record)); ok && observe != nil {
+			observe(record)
+		}
+	}
 	// ensure worker is running even if Start was not called explicitly
 	m.Start(context.Background())
 	m.mu.Lock()
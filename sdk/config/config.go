@@ -21,6 +21,9 @@ type PayloadConfig = internalconfig.PayloadConfig
 type PayloadRule = internalconfig.PayloadRule
 type PayloadFilterRule = internalconfig.PayloadFilterRule
 type PayloadModelRule = internalconfig.PayloadModelRule
+type RoutingConfig = internalconfig.RoutingConfig
+type SessionAffinityConfig = internalconfig.SessionAffinityConfig
+type APIKeyEntry = internalconfig.APIKeyEntry
 
 type GeminiKey = internalconfig.GeminiKey
 type CodexKey = internalconfig.CodexKey
@@ -30,6 +33,7 @@ type VertexCompatModel = internalconfig.VertexCompatModel
 type OpenAICompatibility = internalconfig.OpenAICompatibility
 type OpenAICompatibilityAPIKey = internalconfig.OpenAICompatibilityAPIKey
 type OpenAICompatibilityModel = internalconfig.OpenAICompatibilityModel
+type ModelCapabilityConfig = internalconfig.ModelCapabilityConfig
 
 type TLS = internalconfig.TLSConfig
 
@@ -37,12 +41,19 @@ const (
 	AccessProviderTypeConfigAPIKey = internalconfig.AccessProviderTypeConfigAPIKey
 	DefaultAccessProviderName      = internalconfig.DefaultAccessProviderName
 	DefaultPanelGitHubRepository   = internalconfig.DefaultPanelGitHubRepository
+	TokenCountingUpstream          = internalconfig.TokenCountingUpstream
+	TokenCountingLocal             = internalconfig.TokenCountingLocal
+	TokenCountingFallback          = internalconfig.TokenCountingFallback
 )
 
 func MakeInlineAPIKeyProvider(keys []string) *AccessProvider {
 	return internalconfig.MakeInlineAPIKeyProvider(keys)
 }
 
+func APIKeyEntries(keys ...string) []APIKeyEntry {
+	return internalconfig.APIKeyEntries(keys...)
+}
+
 func LoadConfig(configFile string) (*Config, error) { return internalconfig.LoadConfig(configFile) }
 
 func LoadConfigOptional(configFile string, optional bool) (*Config, error) {
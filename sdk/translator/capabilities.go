@@ -0,0 +1,149 @@
+package translator
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/tidwall/gjson"
+)
+
+// Capability flags a request feature that a translator pair carries from its source format
+// to its target format. Plain text is always assumed to survive a translation.
+type Capability uint32
+
+const (
+	// CapabilityTools covers tool declarations, tool calls and tool results.
+	CapabilityTools Capability = 1 << iota
+	// CapabilityImages covers inline images in message content.
+	CapabilityImages
+	// CapabilityThinking covers enabling reasoning and reasoning content.
+	CapabilityThinking
+	// CapabilityThinkingSignature covers signatures attached to reasoning blocks, such as
+	// Claude thinking signatures and Gemini thought signatures.
+	CapabilityThinkingSignature
+	// CapabilityCacheControl covers prompt caching markers such as Claude cache_control.
+	CapabilityCacheControl
+)
+
+// DefaultCapabilities is assumed for pairs that do not declare their capabilities.
+const DefaultCapabilities = CapabilityTools | CapabilityImages | CapabilityThinking
+
+// AllCapabilities carries every feature.
+const AllCapabilities = DefaultCapabilities | CapabilityThinkingSignature | CapabilityCacheControl
+
+// LossyCapabilities are features that a chained route may drop with a log entry; the request
+// still works without them. Any other missing feature rejects the request.
+const LossyCapabilities = CapabilityThinkingSignature | CapabilityCacheControl
+
+var capabilityNames = []struct {
+	flag Capability
+	name string
+}{
+	{CapabilityTools, "tools"},
+	{CapabilityImages, "images"},
+	{CapabilityThinking, "thinking"},
+	{CapabilityThinkingSignature, "thinking_signature"},
+	{CapabilityCacheControl, "cache_control"},
+}
+
+// Has reports whether every flag of other is set in c.
+func (c Capability) Has(other Capability) bool {
+	return c&other == other
+}
+
+// String lists the flags set in c, e.g. "tools,images".
+func (c Capability) String() string {
+	var names []string
+	for _, entry := range capabilityNames {
+		if c&entry.flag != 0 {
+			names = append(names, entry.name)
+		}
+	}
+	if len(names) == 0 {
+		return "none"
+	}
+	return strings.Join(names, ",")
+}
+
+// RequestFeatures reports the features used by a request payload. Detection is schema
+// agnostic: it looks for the field names the supported formats use for each feature.
+func RequestFeatures(rawJSON []byte) Capability {
+	if !gjson.ValidBytes(rawJSON) {
+		return 0
+	}
+	var features Capability
+	root := gjson.ParseBytes(rawJSON)
+	for _, path := range []string{"tools", "request.tools"} {
+		if tools := root.Get(path); tools.IsArray() && len(tools.Array()) > 0 {
+			features |= CapabilityTools
+		}
+	}
+	walkFeatures(root, &features)
+	return features
+}
+
+func walkFeatures(value gjson.Result, features *Capability) {
+	switch {
+	case value.IsArray():
+		value.ForEach(func(_, item gjson.Result) bool {
+			walkFeatures(item, features)
+			return true
+		})
+	case value.IsObject():
+		switch value.Get("type").String() {
+		case "image", "input_image", "image_url":
+			*features |= CapabilityImages
+		case "tool_use", "tool_result", "function_call", "function_call_output":
+			*features |= CapabilityTools
+		case "thinking", "redacted_thinking":
+			if value.Get("signature").String() != "" || value.Get("data").String() != "" {
+				*features |= CapabilityThinkingSignature
+			}
+		}
+		value.ForEach(func(key, item gjson.Result) bool {
+			if item.Type == gjson.Null || item.Type == gjson.False {
+				return true
+			}
+			switch key.String() {
+			case "image_url", "inlineData", "inline_data":
+				*features |= CapabilityImages
+			case "tool_calls", "functionCall", "functionResponse":
+				*features |= CapabilityTools
+			case "thinking", "thinkingConfig", "thinking_config", "reasoning_effort", "reasoning":
+				*features |= CapabilityThinking
+			case "thoughtSignature", "thought_signature":
+				*features |= CapabilityThinkingSignature
+			case "cache_control":
+				*features |= CapabilityCacheControl
+			}
+			if item.IsObject() || item.IsArray() {
+				walkFeatures(item, features)
+			}
+			return true
+		})
+	}
+}
+
+// UnsupportedFeatureError reports a request that uses features a translation route cannot carry.
+type UnsupportedFeatureError struct {
+	From     Format
+	To       Format
+	Route    []Format
+	Features Capability
+}
+
+// Error implements error.
+func (e *UnsupportedFeatureError) Error() string {
+	route := make([]string, len(e.Route))
+	for i, format := range e.Route {
+		route[i] = format.String()
+	}
+	return fmt.Sprintf("translator: %s requests are not supported by %s upstreams: translation route %s cannot carry %s",
+		e.From, e.To, strings.Join(route, " -> "), e.Features)
+}
+
+// StatusCode maps the error to 400 Bad Request.
+func (e *UnsupportedFeatureError) StatusCode() int {
+	return http.StatusBadRequest
+}
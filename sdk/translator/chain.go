@@ -0,0 +1,294 @@
+package translator
+
+import (
+	"context"
+	"math/bits"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/tidwall/gjson"
+)
+
+// Hub is an intermediate format that chained routes may pass through when no direct
+// translator pair exists between a client format and a provider format.
+type Hub struct {
+	Format Format
+	// DataPrefix frames bare JSON chunks as SSE "data:" lines before handing them to the
+	// second hop, matching what executors of the hub format hand to response translators.
+	DataPrefix bool
+	// Done is handed to the second hop once the upstream stream ends, when not empty.
+	Done string
+}
+
+// DefaultHubs returns the hubs of a new registry: OpenAI Chat Completions first, then Gemini.
+func DefaultHubs() []Hub {
+	return []Hub{
+		{Format: FormatOpenAI, DataPrefix: true, Done: "data: [DONE]"},
+		{Format: FormatGemini, Done: "[DONE]"},
+	}
+}
+
+// Route is the translation path from a client format to a provider format. Direct routes have
+// two formats; chained routes list the hub in between.
+type Route struct {
+	Formats []Format
+	// Capabilities are the features carried along the whole route.
+	Capabilities Capability
+}
+
+// Chained reports whether the route passes through a hub.
+func (r Route) Chained() bool {
+	return len(r.Formats) > 2
+}
+
+// Via returns the format fed to the last hop, i.e. the format of the request the provider
+// translator receives. It is the client format for direct routes and the hub otherwise.
+func (r Route) Via() Format {
+	if len(r.Formats) < 2 {
+		return ""
+	}
+	return r.Formats[len(r.Formats)-2]
+}
+
+// String renders the route as "a -> hub -> b".
+func (r Route) String() string {
+	names := make([]string, len(r.Formats))
+	for i, format := range r.Formats {
+		names[i] = format.String()
+	}
+	return strings.Join(names, " -> ")
+}
+
+// chain holds the four transforms of a route through a hub.
+type chain struct {
+	route Route
+	hub   Hub
+	// toHub and fromHub translate requests from the client to the hub and on to the provider.
+	toHub   RequestTransform
+	fromHub RequestTransform
+	// providerToHub converts provider responses into hub responses; hubToClient converts
+	// those into client responses.
+	providerToHub ResponseTransform
+	hubToClient   ResponseTransform
+}
+
+// chainState is stored in the caller's param for the lifetime of one response.
+type chainState struct {
+	hubRequest []byte
+	first      any
+	second     any
+	done       bool
+}
+
+// SetCapabilities declares the features the pair from one format to another carries.
+// Pairs without a declaration are assumed to carry DefaultCapabilities.
+func (r *Registry) SetCapabilities(from, to Format, capabilities Capability) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.capabilities[[2]Format{from, to}] = capabilities
+}
+
+// SetHubs replaces the hubs chained routes may pass through, in order of preference.
+// Calling it without hubs disables chaining.
+func (r *Registry) SetHubs(hubs ...Hub) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.hubs = append([]Hub(nil), hubs...)
+}
+
+// ResolveRoute returns the route used to translate from a client format to a provider format.
+// Direct pairs and identical formats win; otherwise the hub route carrying the most features
+// is chosen, with ties going to the earlier hub.
+func (r *Registry) ResolveRoute(from, to Format) (Route, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if from == to {
+		return Route{Formats: []Format{from, to}, Capabilities: AllCapabilities}, true
+	}
+	if r.hasPairLocked(from, to) {
+		return Route{Formats: []Format{from, to}, Capabilities: r.capabilitiesLocked(from, to)}, true
+	}
+	if c, ok := r.chainLocked(from, to); ok {
+		return c.route, true
+	}
+	return Route{}, false
+}
+
+// ValidateRequest rejects requests whose chained route cannot carry the features they use.
+// Features in LossyCapabilities are dropped instead and only logged. Direct routes are never
+// rejected, since their translators handle every feature of their formats.
+func (r *Registry) ValidateRequest(from, to Format, rawJSON []byte) error {
+	route, ok := r.ResolveRoute(from, to)
+	if !ok || !route.Chained() {
+		return nil
+	}
+	missing := RequestFeatures(rawJSON) &^ route.Capabilities &^ LossyCapabilities
+	if missing == 0 {
+		return nil
+	}
+	return &UnsupportedFeatureError{From: from, To: to, Route: route.Formats, Features: missing}
+}
+
+func (r *Registry) hasPairLocked(from, to Format) bool {
+	if _, ok := r.requests[from][to]; ok {
+		return true
+	}
+	_, ok := r.responses[from][to]
+	return ok
+}
+
+func (r *Registry) capabilitiesLocked(from, to Format) Capability {
+	if capabilities, ok := r.capabilities[[2]Format{from, to}]; ok {
+		return capabilities
+	}
+	return DefaultCapabilities
+}
+
+// chainLocked finds the best hub route from a client format to a provider format. Both hops
+// need request and response transforms.
+func (r *Registry) chainLocked(from, to Format) (*chain, bool) {
+	if from == to {
+		return nil, false
+	}
+	var best *chain
+	for _, hub := range r.hubs {
+		if hub.Format == from || hub.Format == to {
+			continue
+		}
+		toHub := r.requests[from][hub.Format]
+		fromHub := r.requests[hub.Format][to]
+		hubToClient, okClient := r.responses[from][hub.Format]
+		providerToHub, okProvider := r.responses[hub.Format][to]
+		if toHub == nil || fromHub == nil || !okClient || !okProvider {
+			continue
+		}
+		capabilities := r.capabilitiesLocked(from, hub.Format) & r.capabilitiesLocked(hub.Format, to)
+		if best != nil && bits.OnesCount32(uint32(capabilities)) <= bits.OnesCount32(uint32(best.route.Capabilities)) {
+			continue
+		}
+		best = &chain{
+			route:         Route{Formats: []Format{from, hub.Format, to}, Capabilities: capabilities},
+			hub:           hub,
+			toHub:         toHub,
+			fromHub:       fromHub,
+			providerToHub: providerToHub,
+			hubToClient:   hubToClient,
+		}
+	}
+	return best, best != nil
+}
+
+// logLossy logs once per route the lossy features a chained translation drops.
+func (r *Registry) logLossy(c *chain, rawJSON []byte) {
+	lost := RequestFeatures(rawJSON) & LossyCapabilities &^ c.route.Capabilities
+	if lost == 0 {
+		return
+	}
+	key := c.route.String() + "|" + lost.String()
+	if _, seen := r.lossyLogged.LoadOrStore(key, struct{}{}); seen {
+		return
+	}
+	log.Warnf("translator: route %s drops %s", c.route, lost)
+}
+
+func (c *chain) translateRequest(model string, rawJSON []byte, stream bool) []byte {
+	return c.fromHub(model, c.toHub(model, rawJSON, stream), stream)
+}
+
+// state returns the chain state kept in param, computing the hub request on first use. The
+// hub request plays the original request for the first hop and the translated request for
+// the second one.
+func (c *chain) state(param *any, model string, originalRequestRawJSON []byte, stream bool) *chainState {
+	if param == nil {
+		var local any
+		param = &local
+	}
+	if state, ok := (*param).(*chainState); ok {
+		return state
+	}
+	state := &chainState{}
+	if len(originalRequestRawJSON) > 0 {
+		state.hubRequest = c.toHub(model, originalRequestRawJSON, stream)
+	}
+	*param = state
+	return state
+}
+
+func (c *chain) translateStream(ctx context.Context, model string, originalRequestRawJSON, requestRawJSON, rawJSON []byte, param *any) []string {
+	if c.providerToHub.Stream == nil || c.hubToClient.Stream == nil {
+		return []string{string(rawJSON)}
+	}
+	state := c.state(param, model, originalRequestRawJSON, true)
+	var out []string
+	for _, chunk := range c.providerToHub.Stream(ctx, model, state.hubRequest, requestRawJSON, rawJSON, &state.first) {
+		for _, framed := range c.frame(chunk, state) {
+			out = append(out, c.hubToClient.Stream(ctx, model, originalRequestRawJSON, state.hubRequest, framed, &state.second)...)
+		}
+	}
+	if !state.done && c.hub.Done != "" && streamEnded(rawJSON) {
+		state.done = true
+		out = append(out, c.hubToClient.Stream(ctx, model, originalRequestRawJSON, state.hubRequest, []byte(c.hub.Done), &state.second)...)
+	}
+	return out
+}
+
+func (c *chain) translateNonStream(ctx context.Context, model string, originalRequestRawJSON, requestRawJSON, rawJSON []byte, param *any) string {
+	if c.providerToHub.NonStream == nil || c.hubToClient.NonStream == nil {
+		return string(rawJSON)
+	}
+	state := c.state(param, model, originalRequestRawJSON, false)
+	hubResponse := c.providerToHub.NonStream(ctx, model, state.hubRequest, requestRawJSON, rawJSON, &state.first)
+	return c.hubToClient.NonStream(ctx, model, originalRequestRawJSON, state.hubRequest, []byte(hubResponse), &state.second)
+}
+
+// frame turns a chunk emitted for hub clients into the lines an executor of the hub format
+// hands to response translators: SSE frames are split into lines and bare JSON is prefixed
+// when the hub streams "data:" lines.
+func (c *chain) frame(chunk string, state *chainState) [][]byte {
+	trimmed := strings.TrimSpace(chunk)
+	switch {
+	case trimmed == "":
+		return nil
+	case trimmed == "[DONE]" || trimmed == "data: [DONE]":
+		if state.done || c.hub.Done == "" {
+			return nil
+		}
+		state.done = true
+		return [][]byte{[]byte(c.hub.Done)}
+	case strings.HasPrefix(trimmed, "event:") || strings.HasPrefix(trimmed, "data:"):
+		var lines [][]byte
+		for _, line := range strings.Split(trimmed, "\n") {
+			if line = strings.TrimSpace(line); line != "" {
+				lines = append(lines, []byte(line))
+			}
+		}
+		return lines
+	case c.hub.DataPrefix:
+		return [][]byte{[]byte("data: " + trimmed)}
+	default:
+		return [][]byte{[]byte(trimmed)}
+	}
+}
+
+// streamEnded reports whether a raw upstream chunk ends the stream: a "[DONE]" marker, or the
+// terminal event of the Claude Messages and OpenAI Responses streams.
+func streamEnded(rawJSON []byte) bool {
+	var payload string
+	for _, line := range strings.Split(string(rawJSON), "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "data:") {
+			payload = strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+		} else if payload == "" && line != "" && !strings.HasPrefix(line, "event:") {
+			payload = line
+		}
+	}
+	if payload == "[DONE]" {
+		return true
+	}
+	switch gjson.Get(payload, "type").String() {
+	case "message_stop", "response.completed", "response.done":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,109 @@
+package translator
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// tagRequest appends the target format to a plain-text request so that the hops are visible.
+func tagRequest(to Format) RequestTransform {
+	return func(_ string, rawJSON []byte, _ bool) []byte {
+		return []byte(string(rawJSON) + ">" + to.String())
+	}
+}
+
+// tagResponse appends the client format to each chunk and records the input it received.
+func tagResponse(client Format, seen *[]string) ResponseTransform {
+	return ResponseTransform{
+		Stream: func(_ context.Context, _ string, _, _, rawJSON []byte, _ *any) []string {
+			*seen = append(*seen, string(rawJSON))
+			if strings.Contains(string(rawJSON), "[DONE]") {
+				return nil
+			}
+			return []string{strings.TrimPrefix(string(rawJSON), "data: ") + ">" + client.String()}
+		},
+		NonStream: func(_ context.Context, _ string, _, _, rawJSON []byte, _ *any) string {
+			return string(rawJSON) + ">" + client.String()
+		},
+	}
+}
+
+func TestChainedRouteThroughHub(t *testing.T) {
+	var hubSeen, clientSeen []string
+	r := NewRegistry()
+	r.Register("src", FormatOpenAI, tagRequest(FormatOpenAI), tagResponse("src", &clientSeen))
+	r.Register(FormatOpenAI, "dst", tagRequest("dst"), tagResponse(FormatOpenAI, &hubSeen))
+
+	route, ok := r.ResolveRoute("src", "dst")
+	if !ok || !route.Chained() || route.Via() != FormatOpenAI {
+		t.Fatalf("expected a route through openai, got %v (found %v)", route, ok)
+	}
+	if got := string(r.TranslateRequest("src", "dst", "m", []byte("req"), true)); got != "req>openai>dst" {
+		t.Fatalf("TranslateRequest = %q", got)
+	}
+	if got := r.TranslateNonStream(context.Background(), "dst", "src", "m", []byte("req"), nil, []byte("resp"), nil); got != "resp>openai>src" {
+		t.Fatalf("TranslateNonStream = %q", got)
+	}
+
+	var param any
+	out := r.TranslateStream(context.Background(), "dst", "src", "m", []byte("req"), nil, []byte("chunk"), &param)
+	out = append(out, r.TranslateStream(context.Background(), "dst", "src", "m", []byte("req"), nil, []byte("[DONE]"), &param)...)
+	if strings.Join(out, "|") != "chunk>openai>src" {
+		t.Fatalf("TranslateStream = %q", out)
+	}
+	// The OpenAI hub hands "data:" lines to the second hop and finishes it exactly once.
+	if strings.Join(clientSeen, "|") != "data: chunk>openai|data: [DONE]" {
+		t.Fatalf("second hop received %q", clientSeen)
+	}
+}
+
+func TestResolveRoutePrefersCapabilities(t *testing.T) {
+	var seen []string
+	r := NewRegistry()
+	for _, hub := range []Format{FormatOpenAI, FormatGemini} {
+		r.Register("src", hub, tagRequest(hub), tagResponse("src", &seen))
+		r.Register(hub, "dst", tagRequest("dst"), tagResponse(hub, &seen))
+	}
+	if route, _ := r.ResolveRoute("src", "dst"); route.Via() != FormatOpenAI {
+		t.Fatalf("expected the first hub on a tie, got %v", route)
+	}
+
+	r.SetCapabilities("src", FormatGemini, AllCapabilities)
+	r.SetCapabilities(FormatGemini, "dst", AllCapabilities)
+	if route, _ := r.ResolveRoute("src", "dst"); route.Via() != FormatGemini || route.Capabilities != AllCapabilities {
+		t.Fatalf("expected the lossless hub, got %v", route)
+	}
+
+	r.SetHubs()
+	if _, ok := r.ResolveRoute("src", "dst"); ok {
+		t.Fatal("expected no route without hubs")
+	}
+}
+
+func TestValidateRequestOnChainedRoute(t *testing.T) {
+	var seen []string
+	r := NewRegistry()
+	r.Register("src", FormatOpenAI, tagRequest(FormatOpenAI), tagResponse("src", &seen))
+	r.Register(FormatOpenAI, "dst", tagRequest("dst"), tagResponse(FormatOpenAI, &seen))
+	r.SetCapabilities(FormatOpenAI, "dst", CapabilityTools)
+
+	cached := []byte(`{"messages":[{"role":"user","content":[{"type":"text","text":"hi","cache_control":{"type":"ephemeral"}}]}]}`)
+	if err := r.ValidateRequest("src", "dst", cached); err != nil {
+		t.Fatalf("lossy features must not be rejected: %v", err)
+	}
+
+	image := []byte(`{"messages":[{"role":"user","content":[{"type":"image","source":{"type":"base64","media_type":"image/png","data":"AA=="}}]}]}`)
+	err := r.ValidateRequest("src", "dst", image)
+	var unsupported *UnsupportedFeatureError
+	if !errors.As(err, &unsupported) || unsupported.Features != CapabilityImages || unsupported.StatusCode() != http.StatusBadRequest {
+		t.Fatalf("expected images to be rejected, got %v", err)
+	}
+
+	// Direct pairs are trusted with every feature.
+	if err := r.ValidateRequest("src", FormatOpenAI, image); err != nil {
+		t.Fatalf("direct pair rejected: %v", err)
+	}
+}
@@ -0,0 +1,361 @@
+// Package conformance is a reusable test kit for translator pairs. Each wire format is
+// described by a Profile holding golden requests (when the format is a client format), golden
+// upstream responses (when it is a provider format) and inspectors that reduce a payload of
+// that format to format-independent facts. Run translates the golden fixtures of one profile
+// through a registered pair and checks that the facts survive in the other format: prompt and
+// system text, tool declarations and tool call round trips, inline images, thinking, and for
+// responses text, tool calls, reasoning and usage in both non-streaming and streaming mode.
+//
+// Third-party translators can be checked by describing their format with a Profile and calling
+// Run against the built-in profiles:
+//
+//	func TestMyFormat(t *testing.T) {
+//		conformance.Run(t, sdktranslator.Default(), conformance.OpenAI(), myProfile)
+//	}
+package conformance
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+	"github.com/tidwall/gjson"
+)
+
+// Feature names one behaviour covered by the golden fixtures.
+type Feature string
+
+const (
+	// FeatureText covers the system instruction and the user prompt, and a plain text reply.
+	FeatureText Feature = "text"
+	// FeatureToolCall covers tool declarations, a tool call with its result, and a tool call reply.
+	FeatureToolCall Feature = "tool_call"
+	// FeatureImage covers an inline base64 image in the user prompt.
+	FeatureImage Feature = "image"
+	// FeatureThinking covers enabling reasoning in requests and reasoning text in replies.
+	FeatureThinking Feature = "thinking"
+	// FeatureUsage covers prompt and completion token counts in replies.
+	FeatureUsage Feature = "usage"
+)
+
+// Features lists every feature in the order Run checks them.
+var Features = []Feature{FeatureText, FeatureToolCall, FeatureImage, FeatureThinking, FeatureUsage}
+
+// Golden values shared by every profile's fixtures.
+const (
+	Model            = "conformance-model"
+	SystemText       = "You are a conformance test assistant."
+	PromptText       = "What is the weather in Paris?"
+	ToolName         = "get_weather"
+	ToolDescription  = "Look up the current weather for a city."
+	ToolCallID       = "call_conformance_1"
+	ToolResultText   = "sunny, 21C"
+	ImageMIMEType    = "image/png"
+	ImageData        = "iVBORw0KGgoAAAANSUhEUgAAAAEAAAABCAYAAAAfFcSJAAAADUlEQVR42mNk+M9QDwADhgGAWjR9awAAAABJRU5ErkJggg=="
+	ReplyText        = "It is sunny in Paris."
+	ReasoningText    = "The user wants the weather in Paris."
+	PromptTokens     = 11
+	CompletionTokens = 7
+)
+
+// ToolArguments is the JSON argument object of the golden tool call.
+const ToolArguments = `{"city":"Paris"}`
+
+// ToolCall is a tool invocation reduced to its name and JSON arguments.
+type ToolCall struct {
+	Name      string
+	Arguments string
+}
+
+// RequestFacts is the format-independent content of a request.
+type RequestFacts struct {
+	System      []string
+	Texts       []string
+	Images      []string
+	Tools       []string
+	ToolCalls   []ToolCall
+	ToolResults []string
+	Thinking    bool
+}
+
+// ResponseFacts is the format-independent content of a complete (or fully streamed) response.
+type ResponseFacts struct {
+	Text             string
+	Reasoning        string
+	ToolCalls        []ToolCall
+	PromptTokens     int64
+	CompletionTokens int64
+}
+
+// Response is a golden upstream response in both delivery modes. Stream holds the raw
+// chunks exactly as the provider executor hands them to the stream translator.
+type Response struct {
+	Body   string
+	Stream []string
+}
+
+// Profile describes one wire format to the kit. Client formats provide Requests and the
+// response inspectors; provider formats provide Responses and InspectRequest. A format used
+// in both roles provides all of them. Missing fixtures or inspectors skip the related checks.
+type Profile struct {
+	Format sdktranslator.Format
+
+	// Requests holds golden client requests keyed by feature.
+	Requests map[Feature]string
+	// Responses holds golden upstream responses keyed by feature.
+	Responses map[Feature]Response
+
+	// InspectRequest reduces a request in this format to facts.
+	InspectRequest func(body []byte) RequestFacts
+	// InspectResponse reduces a non-streaming response in this format to facts.
+	InspectResponse func(body []byte) ResponseFacts
+	// InspectStream reduces the translated stream chunks in this format to facts.
+	InspectStream func(chunks []string) ResponseFacts
+
+	// StreamRequest marks a client request as streaming, for formats that carry the flag in
+	// the body rather than in the endpoint. Stream translators may read it from the original
+	// request.
+	StreamRequest func(request string) string
+}
+
+// Option adjusts a Run.
+type Option func(*runConfig)
+
+type runConfig struct {
+	skip map[string]string
+}
+
+// Skip disables a check with a reason, for known gaps of a pair. Check names have the form
+// "request/<feature>", "response/non_stream/<feature>" or "response/stream/<feature>".
+func Skip(check, reason string) Option {
+	return func(cfg *runConfig) {
+		if cfg.skip == nil {
+			cfg.skip = make(map[string]string)
+		}
+		cfg.skip[check] = reason
+	}
+}
+
+// Run checks the route registry resolves from client.Format to provider.Format, a registered
+// pair or a chain through a hub, against the golden fixtures of both profiles. Each check runs
+// as a subtest named after the check.
+func Run(t *testing.T, registry *sdktranslator.Registry, client, provider Profile, opts ...Option) {
+	t.Helper()
+	if registry == nil {
+		registry = sdktranslator.Default()
+	}
+	cfg := &runConfig{}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	if _, ok := registry.ResolveRoute(client.Format, provider.Format); !ok {
+		t.Fatalf("no translator route from %s to %s", client.Format, provider.Format)
+	}
+
+	// Executors pass the client's "alt" query parameter to response translators through the
+	// context; an empty value selects the default SSE framing.
+	ctx := context.WithValue(context.Background(), "alt", "")
+
+	check := func(name string, fn func(t *testing.T)) {
+		t.Run(name, func(t *testing.T) {
+			if reason, ok := cfg.skip[name]; ok {
+				t.Skip(reason)
+			}
+			fn(t)
+		})
+	}
+
+	for _, feature := range Features {
+		feature := feature
+		request, ok := client.Requests[feature]
+		if !ok || provider.InspectRequest == nil {
+			continue
+		}
+		check("request/"+string(feature), func(t *testing.T) {
+			translated := registry.TranslateRequest(client.Format, provider.Format, Model, []byte(request), false)
+			if !gjson.ValidBytes(translated) {
+				t.Fatalf("translated request is not valid JSON: %s", translated)
+			}
+			for _, problem := range checkRequest(feature, provider.InspectRequest(translated)) {
+				t.Errorf("%s\ntranslated request: %s", problem, translated)
+			}
+		})
+	}
+
+	for _, feature := range Features {
+		feature := feature
+		response, ok := provider.Responses[feature]
+		if !ok && feature == FeatureUsage {
+			// Usage is checked on the text reply unless the profile has a dedicated fixture.
+			response, ok = provider.Responses[FeatureText]
+		}
+		if !ok {
+			continue
+		}
+		original := client.Requests[feature]
+		if original == "" {
+			original = client.Requests[FeatureText]
+		}
+		if response.Body != "" && client.InspectResponse != nil {
+			check("response/non_stream/"+string(feature), func(t *testing.T) {
+				translatedReq := registry.TranslateRequest(client.Format, provider.Format, Model, []byte(original), false)
+				var param any
+				out := registry.TranslateNonStream(ctx, provider.Format, client.Format, Model, []byte(original), translatedReq, []byte(response.Body), &param)
+				for _, problem := range checkResponse(feature, client.InspectResponse([]byte(out))) {
+					t.Errorf("%s\ntranslated response: %s", problem, out)
+				}
+			})
+		}
+		if len(response.Stream) > 0 && client.InspectStream != nil {
+			check("response/stream/"+string(feature), func(t *testing.T) {
+				original := original
+				if client.StreamRequest != nil {
+					original = client.StreamRequest(original)
+				}
+				translatedReq := registry.TranslateRequest(client.Format, provider.Format, Model, []byte(original), true)
+				var param any
+				var chunks []string
+				for _, chunk := range response.Stream {
+					chunks = append(chunks, registry.TranslateStream(ctx, provider.Format, client.Format, Model, []byte(original), translatedReq, []byte(chunk), &param)...)
+				}
+				for _, problem := range checkResponse(feature, client.InspectStream(chunks)) {
+					t.Errorf("%s\ntranslated stream:\n%s", problem, strings.Join(chunks, "\n"))
+				}
+			})
+		}
+	}
+}
+
+// RunAll runs the kit on every pair in registry whose formats both have a profile.
+func RunAll(t *testing.T, registry *sdktranslator.Registry, profiles []Profile, opts map[string][]Option) {
+	t.Helper()
+	if registry == nil {
+		registry = sdktranslator.Default()
+	}
+	byFormat := make(map[sdktranslator.Format]Profile, len(profiles))
+	for _, profile := range profiles {
+		byFormat[profile.Format] = profile
+	}
+	for _, pair := range registry.Pairs() {
+		client, okClient := byFormat[pair.From]
+		provider, okProvider := byFormat[pair.To]
+		if !okClient || !okProvider || pair.From == pair.To {
+			continue
+		}
+		name := PairName(pair.From, pair.To)
+		t.Run(name, func(t *testing.T) {
+			Run(t, registry, client, provider, opts[name]...)
+		})
+	}
+}
+
+// PairName is the subtest name RunAll uses for a pair, e.g. "openai->claude".
+func PairName(from, to sdktranslator.Format) string {
+	return from.String() + "->" + to.String()
+}
+
+func checkRequest(feature Feature, facts RequestFacts) []string {
+	var problems []string
+	switch feature {
+	case FeatureText:
+		if !containsText(facts.Texts, PromptText) {
+			problems = append(problems, fmt.Sprintf("prompt %q not found in texts %q", PromptText, facts.Texts))
+		}
+		if !containsText(facts.System, SystemText) && !containsText(facts.Texts, SystemText) {
+			problems = append(problems, fmt.Sprintf("system instruction %q not found", SystemText))
+		}
+	case FeatureToolCall:
+		if !containsString(facts.Tools, ToolName) {
+			problems = append(problems, fmt.Sprintf("tool %q not declared, got %q", ToolName, facts.Tools))
+		}
+		if !containsToolCall(facts.ToolCalls) {
+			problems = append(problems, fmt.Sprintf("tool call %s(%s) not found, got %+v", ToolName, ToolArguments, facts.ToolCalls))
+		}
+		if !containsText(facts.ToolResults, ToolResultText) {
+			problems = append(problems, fmt.Sprintf("tool result %q not found, got %q", ToolResultText, facts.ToolResults))
+		}
+	case FeatureImage:
+		if !containsString(facts.Images, ImageData) {
+			problems = append(problems, fmt.Sprintf("inline image not found, got %d images", len(facts.Images)))
+		}
+	case FeatureThinking:
+		if !facts.Thinking {
+			problems = append(problems, "thinking is not enabled")
+		}
+	}
+	return problems
+}
+
+func checkResponse(feature Feature, facts ResponseFacts) []string {
+	var problems []string
+	switch feature {
+	case FeatureText:
+		if strings.TrimSpace(facts.Text) != ReplyText {
+			problems = append(problems, fmt.Sprintf("reply text = %q, want %q", facts.Text, ReplyText))
+		}
+	case FeatureToolCall:
+		if !containsToolCall(facts.ToolCalls) {
+			problems = append(problems, fmt.Sprintf("tool call %s(%s) not found, got %+v", ToolName, ToolArguments, facts.ToolCalls))
+		}
+	case FeatureThinking:
+		if strings.TrimSpace(facts.Reasoning) != ReasoningText {
+			problems = append(problems, fmt.Sprintf("reasoning = %q, want %q", facts.Reasoning, ReasoningText))
+		}
+		if strings.TrimSpace(facts.Text) != ReplyText {
+			problems = append(problems, fmt.Sprintf("reply text = %q, want %q", facts.Text, ReplyText))
+		}
+	case FeatureUsage:
+		if facts.PromptTokens != PromptTokens || facts.CompletionTokens != CompletionTokens {
+			problems = append(problems, fmt.Sprintf("usage = %d prompt / %d completion tokens, want %d / %d", facts.PromptTokens, facts.CompletionTokens, PromptTokens, CompletionTokens))
+		}
+	}
+	return problems
+}
+
+func containsText(values []string, want string) bool {
+	for _, value := range values {
+		if strings.Contains(value, want) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsString(values []string, want string) bool {
+	for _, value := range values {
+		if value == want {
+			return true
+		}
+	}
+	return false
+}
+
+func containsToolCall(calls []ToolCall) bool {
+	for _, call := range calls {
+		if call.Name == ToolName && sameJSON(call.Arguments, ToolArguments) {
+			return true
+		}
+	}
+	return false
+}
+
+// sameJSON reports whether two JSON objects have the same top-level members.
+func sameJSON(a, b string) bool {
+	left, right := gjson.Parse(a), gjson.Parse(b)
+	if !left.IsObject() || !right.IsObject() {
+		return false
+	}
+	flatten := func(value gjson.Result) []string {
+		var members []string
+		value.ForEach(func(key, member gjson.Result) bool {
+			members = append(members, key.String()+"="+member.String())
+			return true
+		})
+		sort.Strings(members)
+		return members
+	}
+	return strings.Join(flatten(left), "\x00") == strings.Join(flatten(right), "\x00")
+}
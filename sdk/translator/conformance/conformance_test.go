@@ -0,0 +1,36 @@
+package conformance
+
+import (
+	"testing"
+
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+	_ "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator/builtin"
+)
+
+func TestBuiltinTranslatorPairs(t *testing.T) {
+	RunAll(t, sdktranslator.Default(), Builtin(), nil)
+}
+
+// TestChainedRouteThroughOpenAI rebuilds a registry with only the Claude->OpenAI and
+// OpenAI->Gemini pairs, so Claude clients reach Gemini through the OpenAI hub.
+func TestChainedRouteThroughOpenAI(t *testing.T) {
+	registry := sdktranslator.NewRegistry()
+	for _, formats := range [][2]sdktranslator.Format{
+		{sdktranslator.FormatClaude, sdktranslator.FormatOpenAI},
+		{sdktranslator.FormatOpenAI, sdktranslator.FormatGemini},
+	} {
+		pair, ok := sdktranslator.LookupPair(formats[0], formats[1])
+		if !ok {
+			t.Fatalf("built-in pair %s missing", PairName(formats[0], formats[1]))
+		}
+		if err := registry.RegisterPair(pair); err != nil {
+			t.Fatalf("RegisterPair: %v", err)
+		}
+	}
+
+	route, ok := registry.ResolveRoute(sdktranslator.FormatClaude, sdktranslator.FormatGemini)
+	if !ok || route.String() != "claude -> openai -> gemini" {
+		t.Fatalf("unexpected route %v (found %v)", route, ok)
+	}
+	Run(t, registry, Claude(), Gemini())
+}
@@ -0,0 +1,110 @@
+package conformance
+
+import (
+	"strings"
+
+	"github.com/tidwall/gjson"
+)
+
+// streamEvents parses translated stream chunks into JSON events. Chunks may be bare JSON
+// objects or SSE frames with "event:" and "data:" lines; "[DONE]" markers are dropped.
+func streamEvents(chunks []string) []gjson.Result {
+	var events []gjson.Result
+	for _, chunk := range chunks {
+		trimmed := strings.TrimSpace(chunk)
+		if strings.HasPrefix(trimmed, "{") && gjson.Valid(trimmed) {
+			events = append(events, gjson.Parse(trimmed))
+			continue
+		}
+		for _, line := range strings.Split(chunk, "\n") {
+			line = strings.TrimSpace(line)
+			if !strings.HasPrefix(line, "data:") {
+				continue
+			}
+			data := strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+			if data == "" || data == "[DONE]" || !gjson.Valid(data) {
+				continue
+			}
+			events = append(events, gjson.Parse(data))
+		}
+	}
+	return events
+}
+
+// contentTexts returns the text of a string content or of the text items of a content array.
+func contentTexts(content gjson.Result) []string {
+	if content.Type == gjson.String {
+		return []string{content.String()}
+	}
+	var texts []string
+	content.ForEach(func(_, item gjson.Result) bool {
+		switch item.Get("type").String() {
+		case "", "text", "input_text", "output_text":
+			if text := item.Get("text"); text.Exists() {
+				texts = append(texts, text.String())
+			}
+		}
+		return true
+	})
+	return texts
+}
+
+// dataURLPayload returns the base64 payload of a data URL.
+func dataURLPayload(url string) string {
+	if idx := strings.Index(url, "base64,"); idx >= 0 {
+		return url[idx+len("base64,"):]
+	}
+	return url
+}
+
+// firstOf returns the first existing path of value.
+func firstOf(value gjson.Result, paths ...string) gjson.Result {
+	for _, path := range paths {
+		if result := value.Get(path); result.Exists() {
+			return result
+		}
+	}
+	return gjson.Result{}
+}
+
+// streamedToolCalls assembles tool calls streamed as a name followed by argument fragments.
+type streamedToolCalls struct {
+	order []string
+	names map[string]string
+	args  map[string]*strings.Builder
+}
+
+func (s *streamedToolCalls) start(key, name string) {
+	if s.names == nil {
+		s.names = make(map[string]string)
+		s.args = make(map[string]*strings.Builder)
+	}
+	if _, ok := s.args[key]; !ok {
+		s.order = append(s.order, key)
+		s.args[key] = &strings.Builder{}
+	}
+	if name != "" {
+		s.names[key] = name
+	}
+}
+
+func (s *streamedToolCalls) appendArgs(key, fragment string) {
+	s.start(key, "")
+	s.args[key].WriteString(fragment)
+}
+
+func (s *streamedToolCalls) calls() []ToolCall {
+	var calls []ToolCall
+	for _, key := range s.order {
+		calls = append(calls, ToolCall{Name: s.names[key], Arguments: s.args[key].String()})
+	}
+	return calls
+}
+
+// maxInt64 returns the larger value, so that cumulative usage reports keep their final count.
+func maxInt64(a, b int64) int64 {
+	if b > a {
+		return b
+	}
+	return a
+}
@@ -0,0 +1,671 @@
+package conformance
+
+import (
+	"strconv"
+	"strings"
+
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// fixtureValues expands the placeholders used by the golden fixtures below.
+// Longer placeholders come first so that they win over their prefixes.
+var fixtureValues = strings.NewReplacer(
+	"$PROMPT_TOKENS", strconv.Itoa(PromptTokens),
+	"$COMPLETION_TOKENS", strconv.Itoa(CompletionTokens),
+	"$TOTAL_TOKENS", strconv.Itoa(PromptTokens+CompletionTokens),
+	"$MODEL", Model,
+	"$SYSTEM", SystemText,
+	"$PROMPT", PromptText,
+	"$TOOL", ToolName,
+	"$DESC", ToolDescription,
+	"$CALLID", ToolCallID,
+	"$RESULT", ToolResultText,
+	"$MIME", ImageMIMEType,
+	"$IMAGE", ImageData,
+	"$REPLY_A", ReplyText[:6],
+	"$REPLY_B", ReplyText[6:],
+	"$REPLY", ReplyText,
+	"$REASONING", ReasoningText,
+	"$ARGS_OBJECT", ToolArguments,
+	"$ARGS_STRING", strconv.Quote(ToolArguments)[1:len(strconv.Quote(ToolArguments))-1],
+	"$ARGS_A", strconv.Quote(ToolArguments[:8])[1:len(strconv.Quote(ToolArguments[:8]))-1],
+	"$ARGS_B", strconv.Quote(ToolArguments[8:])[1:len(strconv.Quote(ToolArguments[8:]))-1],
+)
+
+func fill(s string) string { return fixtureValues.Replace(s) }
+
+func fillAll(chunks ...string) []string {
+	out := make([]string, len(chunks))
+	for i, chunk := range chunks {
+		out[i] = fill(chunk)
+	}
+	return out
+}
+
+// sseLines renders events as the line sequence that line-scanning executors hand to stream
+// translators: "event: <type>", "data: <json>" and a blank separator per event.
+func sseLines(events ...string) []string {
+	var lines []string
+	for _, event := range events {
+		event = fill(event)
+		lines = append(lines, "event: "+gjson.Get(event, "type").String(), "data: "+event, "")
+	}
+	return lines
+}
+
+// claudeResponse builds a Claude fixture from stream events. The Claude executor always
+// streams from upstream when it translates, so non-stream translators receive the whole
+// SSE body rather than a Messages JSON object.
+func claudeResponse(events ...string) Response {
+	lines := sseLines(events...)
+	return Response{Body: strings.Join(lines, "\n"), Stream: lines}
+}
+
+// streamFlag sets "stream": true on a request body.
+func streamFlag(request string) string {
+	out, _ := sjson.Set(request, "stream", true)
+	return out
+}
+
+// Builtin returns the profiles of the formats built into the proxy.
+func Builtin() []Profile {
+	return []Profile{OpenAI(), OpenAIResponses(), Claude(), Gemini(), GeminiCLI(), Codex()}
+}
+
+// OpenAI returns the profile of the OpenAI Chat Completions format.
+func OpenAI() Profile {
+	return Profile{
+		Format: sdktranslator.FormatOpenAI,
+		Requests: map[Feature]string{
+			FeatureText: fill(`{"model":"$MODEL","messages":[{"role":"system","content":"$SYSTEM"},{"role":"user","content":"$PROMPT"}]}`),
+			FeatureToolCall: fill(`{"model":"$MODEL","messages":[{"role":"user","content":"$PROMPT"},` +
+				`{"role":"assistant","content":null,"tool_calls":[{"id":"$CALLID","type":"function","function":{"name":"$TOOL","arguments":"$ARGS_STRING"}}]},` +
+				`{"role":"tool","tool_call_id":"$CALLID","content":"$RESULT"}],` +
+				`"tools":[{"type":"function","function":{"name":"$TOOL","description":"$DESC","parameters":{"type":"object","properties":{"city":{"type":"string"}},"required":["city"]}}}]}`),
+			FeatureImage:    fill(`{"model":"$MODEL","messages":[{"role":"user","content":[{"type":"text","text":"$PROMPT"},{"type":"image_url","image_url":{"url":"data:$MIME;base64,$IMAGE"}}]}]}`),
+			FeatureThinking: fill(`{"model":"$MODEL","reasoning_effort":"high","messages":[{"role":"user","content":"$PROMPT"}]}`),
+		},
+		Responses: map[Feature]Response{
+			FeatureText: {
+				Body: fill(`{"id":"chatcmpl-1","object":"chat.completion","created":1700000000,"model":"$MODEL","choices":[{"index":0,"message":{"role":"assistant","content":"$REPLY"},"finish_reason":"stop"}],"usage":{"prompt_tokens":$PROMPT_TOKENS,"completion_tokens":$COMPLETION_TOKENS,"total_tokens":$TOTAL_TOKENS}}`),
+				Stream: fillAll(
+					`data: {"id":"chatcmpl-1","object":"chat.completion.chunk","created":1700000000,"model":"$MODEL","choices":[{"index":0,"delta":{"role":"assistant","content":"$REPLY_A"},"finish_reason":null}]}`,
+					`data: {"id":"chatcmpl-1","object":"chat.completion.chunk","created":1700000000,"model":"$MODEL","choices":[{"index":0,"delta":{"content":"$REPLY_B"},"finish_reason":null}]}`,
+					`data: {"id":"chatcmpl-1","object":"chat.completion.chunk","created":1700000000,"model":"$MODEL","choices":[{"index":0,"delta":{},"finish_reason":"stop"}],"usage":{"prompt_tokens":$PROMPT_TOKENS,"completion_tokens":$COMPLETION_TOKENS,"total_tokens":$TOTAL_TOKENS}}`,
+					`data: [DONE]`,
+				),
+			},
+			FeatureToolCall: {
+				Body: fill(`{"id":"chatcmpl-1","object":"chat.completion","created":1700000000,"model":"$MODEL","choices":[{"index":0,"message":{"role":"assistant","content":null,"tool_calls":[{"id":"$CALLID","type":"function","function":{"name":"$TOOL","arguments":"$ARGS_STRING"}}]},"finish_reason":"tool_calls"}],"usage":{"prompt_tokens":$PROMPT_TOKENS,"completion_tokens":$COMPLETION_TOKENS,"total_tokens":$TOTAL_TOKENS}}`),
+				Stream: fillAll(
+					`data: {"id":"chatcmpl-1","object":"chat.completion.chunk","created":1700000000,"model":"$MODEL","choices":[{"index":0,"delta":{"role":"assistant","tool_calls":[{"index":0,"id":"$CALLID","type":"function","function":{"name":"$TOOL","arguments":""}}]},"finish_reason":null}]}`,
+					`data: {"id":"chatcmpl-1","object":"chat.completion.chunk","created":1700000000,"model":"$MODEL","choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"function":{"arguments":"$ARGS_A"}}]},"finish_reason":null}]}`,
+					`data: {"id":"chatcmpl-1","object":"chat.completion.chunk","created":1700000000,"model":"$MODEL","choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"function":{"arguments":"$ARGS_B"}}]},"finish_reason":null}]}`,
+					`data: {"id":"chatcmpl-1","object":"chat.completion.chunk","created":1700000000,"model":"$MODEL","choices":[{"index":0,"delta":{},"finish_reason":"tool_calls"}],"usage":{"prompt_tokens":$PROMPT_TOKENS,"completion_tokens":$COMPLETION_TOKENS,"total_tokens":$TOTAL_TOKENS}}`,
+					`data: [DONE]`,
+				),
+			},
+			FeatureThinking: {
+				Body: fill(`{"id":"chatcmpl-1","object":"chat.completion","created":1700000000,"model":"$MODEL","choices":[{"index":0,"message":{"role":"assistant","content":"$REPLY","reasoning_content":"$REASONING"},"finish_reason":"stop"}],"usage":{"prompt_tokens":$PROMPT_TOKENS,"completion_tokens":$COMPLETION_TOKENS,"total_tokens":$TOTAL_TOKENS}}`),
+				Stream: fillAll(
+					`data: {"id":"chatcmpl-1","object":"chat.completion.chunk","created":1700000000,"model":"$MODEL","choices":[{"index":0,"delta":{"role":"assistant","reasoning_content":"$REASONING"},"finish_reason":null}]}`,
+					`data: {"id":"chatcmpl-1","object":"chat.completion.chunk","created":1700000000,"model":"$MODEL","choices":[{"index":0,"delta":{"content":"$REPLY"},"finish_reason":null}]}`,
+					`data: {"id":"chatcmpl-1","object":"chat.completion.chunk","created":1700000000,"model":"$MODEL","choices":[{"index":0,"delta":{},"finish_reason":"stop"}],"usage":{"prompt_tokens":$PROMPT_TOKENS,"completion_tokens":$COMPLETION_TOKENS,"total_tokens":$TOTAL_TOKENS}}`,
+					`data: [DONE]`,
+				),
+			},
+		},
+		InspectRequest:  inspectOpenAIRequest,
+		InspectResponse: inspectOpenAIResponse,
+		InspectStream:   inspectOpenAIStream,
+		StreamRequest:   streamFlag,
+	}
+}
+
+// OpenAIResponses returns the profile of the OpenAI Responses format used by clients.
+func OpenAIResponses() Profile {
+	profile := responsesProfile()
+	profile.Format = sdktranslator.FormatOpenAIResponse
+	return profile
+}
+
+// Codex returns the profile of the Codex upstream format, which speaks the Responses API.
+func Codex() Profile {
+	profile := responsesProfile()
+	profile.Format = sdktranslator.FormatCodex
+	return profile
+}
+
+func responsesProfile() Profile {
+	return Profile{
+		Requests: map[Feature]string{
+			FeatureText: fill(`{"model":"$MODEL","instructions":"$SYSTEM","input":[{"type":"message","role":"user","content":[{"type":"input_text","text":"$PROMPT"}]}]}`),
+			FeatureToolCall: fill(`{"model":"$MODEL","input":[{"type":"message","role":"user","content":[{"type":"input_text","text":"$PROMPT"}]},` +
+				`{"type":"function_call","call_id":"$CALLID","name":"$TOOL","arguments":"$ARGS_STRING"},` +
+				`{"type":"function_call_output","call_id":"$CALLID","output":"$RESULT"}],` +
+				`"tools":[{"type":"function","name":"$TOOL","description":"$DESC","parameters":{"type":"object","properties":{"city":{"type":"string"}},"required":["city"]}}]}`),
+			FeatureImage:    fill(`{"model":"$MODEL","input":[{"type":"message","role":"user","content":[{"type":"input_text","text":"$PROMPT"},{"type":"input_image","image_url":"data:$MIME;base64,$IMAGE"}]}]}`),
+			FeatureThinking: fill(`{"model":"$MODEL","reasoning":{"effort":"high"},"input":[{"type":"message","role":"user","content":[{"type":"input_text","text":"$PROMPT"}]}]}`),
+		},
+		Responses: map[Feature]Response{
+			FeatureText: {
+				Body: fill(`{"type":"response.completed","response":{"id":"resp_1","object":"response","created_at":1700000000,"status":"completed","model":"$MODEL","output":[{"type":"message","id":"msg_1","status":"completed","role":"assistant","content":[{"type":"output_text","text":"$REPLY","annotations":[]}]}],"usage":{"input_tokens":$PROMPT_TOKENS,"output_tokens":$COMPLETION_TOKENS,"total_tokens":$TOTAL_TOKENS}}}`),
+				Stream: sseLines(
+					`{"type":"response.created","sequence_number":0,"response":{"id":"resp_1","object":"response","created_at":1700000000,"status":"in_progress","model":"$MODEL","output":[]}}`,
+					`{"type":"response.output_item.added","sequence_number":1,"output_index":0,"item":{"type":"message","id":"msg_1","status":"in_progress","role":"assistant","content":[]}}`,
+					`{"type":"response.content_part.added","sequence_number":2,"item_id":"msg_1","output_index":0,"content_index":0,"part":{"type":"output_text","text":"","annotations":[]}}`,
+					`{"type":"response.output_text.delta","sequence_number":3,"item_id":"msg_1","output_index":0,"content_index":0,"delta":"$REPLY_A"}`,
+					`{"type":"response.output_text.delta","sequence_number":4,"item_id":"msg_1","output_index":0,"content_index":0,"delta":"$REPLY_B"}`,
+					`{"type":"response.output_text.done","sequence_number":5,"item_id":"msg_1","output_index":0,"content_index":0,"text":"$REPLY"}`,
+					`{"type":"response.output_item.done","sequence_number":6,"output_index":0,"item":{"type":"message","id":"msg_1","status":"completed","role":"assistant","content":[{"type":"output_text","text":"$REPLY","annotations":[]}]}}`,
+					`{"type":"response.completed","sequence_number":7,"response":{"id":"resp_1","object":"response","created_at":1700000000,"status":"completed","model":"$MODEL","output":[{"type":"message","id":"msg_1","status":"completed","role":"assistant","content":[{"type":"output_text","text":"$REPLY","annotations":[]}]}],"usage":{"input_tokens":$PROMPT_TOKENS,"output_tokens":$COMPLETION_TOKENS,"total_tokens":$TOTAL_TOKENS}}}`,
+				),
+			},
+			FeatureToolCall: {
+				Body: fill(`{"type":"response.completed","response":{"id":"resp_1","object":"response","created_at":1700000000,"status":"completed","model":"$MODEL","output":[{"type":"function_call","id":"fc_1","call_id":"$CALLID","name":"$TOOL","arguments":"$ARGS_STRING","status":"completed"}],"usage":{"input_tokens":$PROMPT_TOKENS,"output_tokens":$COMPLETION_TOKENS,"total_tokens":$TOTAL_TOKENS}}}`),
+				Stream: sseLines(
+					`{"type":"response.created","sequence_number":0,"response":{"id":"resp_1","object":"response","created_at":1700000000,"status":"in_progress","model":"$MODEL","output":[]}}`,
+					`{"type":"response.output_item.added","sequence_number":1,"output_index":0,"item":{"type":"function_call","id":"fc_1","call_id":"$CALLID","name":"$TOOL","arguments":"","status":"in_progress"}}`,
+					`{"type":"response.function_call_arguments.delta","sequence_number":2,"item_id":"fc_1","output_index":0,"delta":"$ARGS_A"}`,
+					`{"type":"response.function_call_arguments.delta","sequence_number":3,"item_id":"fc_1","output_index":0,"delta":"$ARGS_B"}`,
+					`{"type":"response.function_call_arguments.done","sequence_number":4,"item_id":"fc_1","output_index":0,"arguments":"$ARGS_STRING"}`,
+					`{"type":"response.output_item.done","sequence_number":5,"output_index":0,"item":{"type":"function_call","id":"fc_1","call_id":"$CALLID","name":"$TOOL","arguments":"$ARGS_STRING","status":"completed"}}`,
+					`{"type":"response.completed","sequence_number":6,"response":{"id":"resp_1","object":"response","created_at":1700000000,"status":"completed","model":"$MODEL","output":[{"type":"function_call","id":"fc_1","call_id":"$CALLID","name":"$TOOL","arguments":"$ARGS_STRING","status":"completed"}],"usage":{"input_tokens":$PROMPT_TOKENS,"output_tokens":$COMPLETION_TOKENS,"total_tokens":$TOTAL_TOKENS}}}`,
+				),
+			},
+			FeatureThinking: {
+				Body: fill(`{"type":"response.completed","response":{"id":"resp_1","object":"response","created_at":1700000000,"status":"completed","model":"$MODEL","output":[{"type":"reasoning","id":"rs_1","summary":[{"type":"summary_text","text":"$REASONING"}]},{"type":"message","id":"msg_1","status":"completed","role":"assistant","content":[{"type":"output_text","text":"$REPLY","annotations":[]}]}],"usage":{"input_tokens":$PROMPT_TOKENS,"output_tokens":$COMPLETION_TOKENS,"total_tokens":$TOTAL_TOKENS}}}`),
+				Stream: sseLines(
+					`{"type":"response.created","sequence_number":0,"response":{"id":"resp_1","object":"response","created_at":1700000000,"status":"in_progress","model":"$MODEL","output":[]}}`,
+					`{"type":"response.output_item.added","sequence_number":1,"output_index":0,"item":{"type":"reasoning","id":"rs_1","summary":[]}}`,
+					`{"type":"response.reasoning_summary_part.added","sequence_number":2,"item_id":"rs_1","output_index":0,"summary_index":0,"part":{"type":"summary_text","text":""}}`,
+					`{"type":"response.reasoning_summary_text.delta","sequence_number":3,"item_id":"rs_1","output_index":0,"summary_index":0,"delta":"$REASONING"}`,
+					`{"type":"response.reasoning_summary_text.done","sequence_number":4,"item_id":"rs_1","output_index":0,"summary_index":0,"text":"$REASONING"}`,
+					`{"type":"response.reasoning_summary_part.done","sequence_number":5,"item_id":"rs_1","output_index":0,"summary_index":0,"part":{"type":"summary_text","text":"$REASONING"}}`,
+					`{"type":"response.output_item.done","sequence_number":6,"output_index":0,"item":{"type":"reasoning","id":"rs_1","summary":[{"type":"summary_text","text":"$REASONING"}]}}`,
+					`{"type":"response.output_item.added","sequence_number":7,"output_index":1,"item":{"type":"message","id":"msg_1","status":"in_progress","role":"assistant","content":[]}}`,
+					`{"type":"response.content_part.added","sequence_number":8,"item_id":"msg_1","output_index":1,"content_index":0,"part":{"type":"output_text","text":"","annotations":[]}}`,
+					`{"type":"response.output_text.delta","sequence_number":9,"item_id":"msg_1","output_index":1,"content_index":0,"delta":"$REPLY"}`,
+					`{"type":"response.output_text.done","sequence_number":10,"item_id":"msg_1","output_index":1,"content_index":0,"text":"$REPLY"}`,
+					`{"type":"response.output_item.done","sequence_number":11,"output_index":1,"item":{"type":"message","id":"msg_1","status":"completed","role":"assistant","content":[{"type":"output_text","text":"$REPLY","annotations":[]}]}}`,
+					`{"type":"response.completed","sequence_number":12,"response":{"id":"resp_1","object":"response","created_at":1700000000,"status":"completed","model":"$MODEL","output":[{"type":"reasoning","id":"rs_1","summary":[{"type":"summary_text","text":"$REASONING"}]},{"type":"message","id":"msg_1","status":"completed","role":"assistant","content":[{"type":"output_text","text":"$REPLY","annotations":[]}]}],"usage":{"input_tokens":$PROMPT_TOKENS,"output_tokens":$COMPLETION_TOKENS,"total_tokens":$TOTAL_TOKENS}}}`,
+				),
+			},
+		},
+		InspectRequest:  inspectResponsesRequest,
+		InspectResponse: inspectResponsesResponse,
+		InspectStream:   inspectResponsesStream,
+		StreamRequest:   streamFlag,
+	}
+}
+
+// Claude returns the profile of the Anthropic Messages format.
+func Claude() Profile {
+	return Profile{
+		Format: sdktranslator.FormatClaude,
+		Requests: map[Feature]string{
+			FeatureText: fill(`{"model":"$MODEL","max_tokens":1024,"system":"$SYSTEM","messages":[{"role":"user","content":"$PROMPT"}]}`),
+			FeatureToolCall: fill(`{"model":"$MODEL","max_tokens":1024,"messages":[{"role":"user","content":"$PROMPT"},` +
+				`{"role":"assistant","content":[{"type":"tool_use","id":"$CALLID","name":"$TOOL","input":$ARGS_OBJECT}]},` +
+				`{"role":"user","content":[{"type":"tool_result","tool_use_id":"$CALLID","content":"$RESULT"}]}],` +
+				`"tools":[{"name":"$TOOL","description":"$DESC","input_schema":{"type":"object","properties":{"city":{"type":"string"}},"required":["city"]}}]}`),
+			FeatureImage:    fill(`{"model":"$MODEL","max_tokens":1024,"messages":[{"role":"user","content":[{"type":"text","text":"$PROMPT"},{"type":"image","source":{"type":"base64","media_type":"$MIME","data":"$IMAGE"}}]}]}`),
+			FeatureThinking: fill(`{"model":"$MODEL","max_tokens":4096,"thinking":{"type":"enabled","budget_tokens":2048},"messages":[{"role":"user","content":"$PROMPT"}]}`),
+		},
+		Responses: map[Feature]Response{
+			FeatureText: claudeResponse(
+				`{"type":"message_start","message":{"id":"msg_1","type":"message","role":"assistant","model":"$MODEL","content":[],"stop_reason":null,"stop_sequence":null,"usage":{"input_tokens":$PROMPT_TOKENS,"output_tokens":1}}}`,
+				`{"type":"content_block_start","index":0,"content_block":{"type":"text","text":""}}`,
+				`{"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"$REPLY_A"}}`,
+				`{"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"$REPLY_B"}}`,
+				`{"type":"content_block_stop","index":0}`,
+				`{"type":"message_delta","delta":{"stop_reason":"end_turn","stop_sequence":null},"usage":{"input_tokens":$PROMPT_TOKENS,"output_tokens":$COMPLETION_TOKENS}}`,
+				`{"type":"message_stop"}`,
+			),
+			FeatureToolCall: claudeResponse(
+				`{"type":"message_start","message":{"id":"msg_1","type":"message","role":"assistant","model":"$MODEL","content":[],"stop_reason":null,"stop_sequence":null,"usage":{"input_tokens":$PROMPT_TOKENS,"output_tokens":1}}}`,
+				`{"type":"content_block_start","index":0,"content_block":{"type":"tool_use","id":"$CALLID","name":"$TOOL","input":{}}}`,
+				`{"type":"content_block_delta","index":0,"delta":{"type":"input_json_delta","partial_json":"$ARGS_A"}}`,
+				`{"type":"content_block_delta","index":0,"delta":{"type":"input_json_delta","partial_json":"$ARGS_B"}}`,
+				`{"type":"content_block_stop","index":0}`,
+				`{"type":"message_delta","delta":{"stop_reason":"tool_use","stop_sequence":null},"usage":{"input_tokens":$PROMPT_TOKENS,"output_tokens":$COMPLETION_TOKENS}}`,
+				`{"type":"message_stop"}`,
+			),
+			FeatureThinking: claudeResponse(
+				`{"type":"message_start","message":{"id":"msg_1","type":"message","role":"assistant","model":"$MODEL","content":[],"stop_reason":null,"stop_sequence":null,"usage":{"input_tokens":$PROMPT_TOKENS,"output_tokens":1}}}`,
+				`{"type":"content_block_start","index":0,"content_block":{"type":"thinking","thinking":""}}`,
+				`{"type":"content_block_delta","index":0,"delta":{"type":"thinking_delta","thinking":"$REASONING"}}`,
+				`{"type":"content_block_delta","index":0,"delta":{"type":"signature_delta","signature":"c2lnbmF0dXJl"}}`,
+				`{"type":"content_block_stop","index":0}`,
+				`{"type":"content_block_start","index":1,"content_block":{"type":"text","text":""}}`,
+				`{"type":"content_block_delta","index":1,"delta":{"type":"text_delta","text":"$REPLY"}}`,
+				`{"type":"content_block_stop","index":1}`,
+				`{"type":"message_delta","delta":{"stop_reason":"end_turn","stop_sequence":null},"usage":{"input_tokens":$PROMPT_TOKENS,"output_tokens":$COMPLETION_TOKENS}}`,
+				`{"type":"message_stop"}`,
+			),
+		},
+		InspectRequest:  inspectClaudeRequest,
+		InspectResponse: inspectClaudeResponse,
+		InspectStream:   inspectClaudeStream,
+		StreamRequest:   streamFlag,
+	}
+}
+
+// Gemini returns the profile of the Gemini generateContent format.
+func Gemini() Profile {
+	return Profile{
+		Format:   sdktranslator.FormatGemini,
+		Requests: geminiRequests(),
+		Responses: map[Feature]Response{
+			FeatureText: {
+				Body: fill(`{"candidates":[{"content":{"role":"model","parts":[{"text":"$REPLY"}]},"finishReason":"STOP","index":0}],"usageMetadata":{"promptTokenCount":$PROMPT_TOKENS,"candidatesTokenCount":$COMPLETION_TOKENS,"totalTokenCount":$TOTAL_TOKENS},"modelVersion":"$MODEL","responseId":"resp_1"}`),
+				Stream: fillAll(
+					`{"candidates":[{"content":{"role":"model","parts":[{"text":"$REPLY_A"}]},"index":0}],"modelVersion":"$MODEL","responseId":"resp_1"}`,
+					`{"candidates":[{"content":{"role":"model","parts":[{"text":"$REPLY_B"}]},"finishReason":"STOP","index":0}],"usageMetadata":{"promptTokenCount":$PROMPT_TOKENS,"candidatesTokenCount":$COMPLETION_TOKENS,"totalTokenCount":$TOTAL_TOKENS},"modelVersion":"$MODEL","responseId":"resp_1"}`,
+					`[DONE]`,
+				),
+			},
+			FeatureToolCall: {
+				Body: fill(`{"candidates":[{"content":{"role":"model","parts":[{"functionCall":{"name":"$TOOL","args":$ARGS_OBJECT}}]},"finishReason":"STOP","index":0}],"usageMetadata":{"promptTokenCount":$PROMPT_TOKENS,"candidatesTokenCount":$COMPLETION_TOKENS,"totalTokenCount":$TOTAL_TOKENS},"modelVersion":"$MODEL","responseId":"resp_1"}`),
+				Stream: fillAll(
+					`{"candidates":[{"content":{"role":"model","parts":[{"functionCall":{"name":"$TOOL","args":$ARGS_OBJECT}}]},"finishReason":"STOP","index":0}],"usageMetadata":{"promptTokenCount":$PROMPT_TOKENS,"candidatesTokenCount":$COMPLETION_TOKENS,"totalTokenCount":$TOTAL_TOKENS},"modelVersion":"$MODEL","responseId":"resp_1"}`,
+					`[DONE]`,
+				),
+			},
+			FeatureThinking: {
+				Body: fill(`{"candidates":[{"content":{"role":"model","parts":[{"text":"$REASONING","thought":true},{"text":"$REPLY"}]},"finishReason":"STOP","index":0}],"usageMetadata":{"promptTokenCount":$PROMPT_TOKENS,"candidatesTokenCount":$COMPLETION_TOKENS,"totalTokenCount":$TOTAL_TOKENS},"modelVersion":"$MODEL","responseId":"resp_1"}`),
+				Stream: fillAll(
+					`{"candidates":[{"content":{"role":"model","parts":[{"text":"$REASONING","thought":true}]},"index":0}],"modelVersion":"$MODEL","responseId":"resp_1"}`,
+					`{"candidates":[{"content":{"role":"model","parts":[{"text":"$REPLY"}]},"finishReason":"STOP","index":0}],"usageMetadata":{"promptTokenCount":$PROMPT_TOKENS,"candidatesTokenCount":$COMPLETION_TOKENS,"totalTokenCount":$TOTAL_TOKENS},"modelVersion":"$MODEL","responseId":"resp_1"}`,
+					`[DONE]`,
+				),
+			},
+		},
+		InspectRequest:  inspectGeminiRequest,
+		InspectResponse: inspectGeminiResponse,
+		InspectStream:   inspectGeminiStream,
+	}
+}
+
+// GeminiCLI returns the profile of the Gemini CLI (Cloud Code Assist) envelope format.
+func GeminiCLI() Profile {
+	gemini := Gemini()
+	profile := Profile{
+		Format:    sdktranslator.FormatGeminiCLI,
+		Requests:  make(map[Feature]string, len(gemini.Requests)),
+		Responses: make(map[Feature]Response, len(gemini.Responses)),
+		InspectRequest: func(body []byte) RequestFacts {
+			return inspectGeminiRequest([]byte(gjson.GetBytes(body, "request").Raw))
+		},
+		InspectResponse: func(body []byte) ResponseFacts {
+			return inspectGeminiResponse([]byte(gjson.GetBytes(body, "response").Raw))
+		},
+		InspectStream: func(chunks []string) ResponseFacts {
+			var unwrapped []string
+			for _, event := range streamEvents(chunks) {
+				unwrapped = append(unwrapped, event.Get("response").Raw)
+			}
+			return inspectGeminiStream(unwrapped)
+		},
+	}
+	for feature, request := range gemini.Requests {
+		envelope, _ := sjson.Set(`{"project":"conformance-project"}`, "model", Model)
+		envelope, _ = sjson.SetRaw(envelope, "request", request)
+		profile.Requests[feature] = envelope
+	}
+	for feature, response := range gemini.Responses {
+		wrapped := Response{Body: `{"response":` + response.Body + `}`}
+		for _, chunk := range response.Stream {
+			if chunk == "[DONE]" {
+				wrapped.Stream = append(wrapped.Stream, chunk)
+				continue
+			}
+			wrapped.Stream = append(wrapped.Stream, `data: {"response":`+chunk+`}`)
+		}
+		profile.Responses[feature] = wrapped
+	}
+	return profile
+}
+
+func geminiRequests() map[Feature]string {
+	return map[Feature]string{
+		FeatureText: fill(`{"systemInstruction":{"parts":[{"text":"$SYSTEM"}]},"contents":[{"role":"user","parts":[{"text":"$PROMPT"}]}]}`),
+		FeatureToolCall: fill(`{"contents":[{"role":"user","parts":[{"text":"$PROMPT"}]},` +
+			`{"role":"model","parts":[{"functionCall":{"name":"$TOOL","args":$ARGS_OBJECT}}]},` +
+			`{"role":"user","parts":[{"functionResponse":{"name":"$TOOL","response":{"result":"$RESULT"}}}]}],` +
+			`"tools":[{"functionDeclarations":[{"name":"$TOOL","description":"$DESC","parameters":{"type":"object","properties":{"city":{"type":"string"}},"required":["city"]}}]}]}`),
+		FeatureImage:    fill(`{"contents":[{"role":"user","parts":[{"text":"$PROMPT"},{"inlineData":{"mimeType":"$MIME","data":"$IMAGE"}}]}]}`),
+		FeatureThinking: fill(`{"contents":[{"role":"user","parts":[{"text":"$PROMPT"}]}],"generationConfig":{"thinkingConfig":{"thinkingBudget":2048,"includeThoughts":true}}}`),
+	}
+}
+
+func inspectOpenAIRequest(body []byte) RequestFacts {
+	var facts RequestFacts
+	root := gjson.ParseBytes(body)
+	root.Get("messages").ForEach(func(_, msg gjson.Result) bool {
+		switch msg.Get("role").String() {
+		case "system", "developer":
+			facts.System = append(facts.System, contentTexts(msg.Get("content"))...)
+		case "user":
+			facts.Texts = append(facts.Texts, contentTexts(msg.Get("content"))...)
+			msg.Get("content").ForEach(func(_, part gjson.Result) bool {
+				if part.Get("type").String() == "image_url" {
+					facts.Images = append(facts.Images, dataURLPayload(part.Get("image_url.url").String()))
+				}
+				return true
+			})
+		case "assistant":
+			msg.Get("tool_calls").ForEach(func(_, call gjson.Result) bool {
+				facts.ToolCalls = append(facts.ToolCalls, ToolCall{Name: call.Get("function.name").String(), Arguments: call.Get("function.arguments").String()})
+				return true
+			})
+		case "tool":
+			facts.ToolResults = append(facts.ToolResults, strings.Join(contentTexts(msg.Get("content")), ""))
+		}
+		return true
+	})
+	root.Get("tools").ForEach(func(_, tool gjson.Result) bool {
+		facts.Tools = append(facts.Tools, tool.Get("function.name").String())
+		return true
+	})
+	if effort := root.Get("reasoning_effort").String(); effort != "" && effort != "none" {
+		facts.Thinking = true
+	}
+	return facts
+}
+
+func inspectOpenAIResponse(body []byte) ResponseFacts {
+	root := gjson.ParseBytes(body)
+	message := root.Get("choices.0.message")
+	facts := ResponseFacts{
+		Text:             message.Get("content").String(),
+		Reasoning:        message.Get("reasoning_content").String(),
+		PromptTokens:     root.Get("usage.prompt_tokens").Int(),
+		CompletionTokens: root.Get("usage.completion_tokens").Int(),
+	}
+	message.Get("tool_calls").ForEach(func(_, call gjson.Result) bool {
+		facts.ToolCalls = append(facts.ToolCalls, ToolCall{Name: call.Get("function.name").String(), Arguments: call.Get("function.arguments").String()})
+		return true
+	})
+	return facts
+}
+
+func inspectOpenAIStream(chunks []string) ResponseFacts {
+	var facts ResponseFacts
+	var text, reasoning strings.Builder
+	var calls streamedToolCalls
+	for _, event := range streamEvents(chunks) {
+		delta := event.Get("choices.0.delta")
+		text.WriteString(delta.Get("content").String())
+		reasoning.WriteString(delta.Get("reasoning_content").String())
+		delta.Get("tool_calls").ForEach(func(_, call gjson.Result) bool {
+			key := call.Get("index").String()
+			calls.start(key, call.Get("function.name").String())
+			calls.appendArgs(key, call.Get("function.arguments").String())
+			return true
+		})
+		facts.PromptTokens = maxInt64(facts.PromptTokens, event.Get("usage.prompt_tokens").Int())
+		facts.CompletionTokens = maxInt64(facts.CompletionTokens, event.Get("usage.completion_tokens").Int())
+	}
+	facts.Text, facts.Reasoning, facts.ToolCalls = text.String(), reasoning.String(), calls.calls()
+	return facts
+}
+
+func inspectResponsesRequest(body []byte) RequestFacts {
+	var facts RequestFacts
+	root := gjson.ParseBytes(body)
+	if instructions := root.Get("instructions").String(); instructions != "" {
+		facts.System = append(facts.System, instructions)
+	}
+	input := root.Get("input")
+	if input.Type == gjson.String {
+		facts.Texts = append(facts.Texts, input.String())
+	}
+	input.ForEach(func(_, item gjson.Result) bool {
+		switch item.Get("type").String() {
+		case "", "message":
+			texts := contentTexts(item.Get("content"))
+			switch item.Get("role").String() {
+			case "system", "developer":
+				facts.System = append(facts.System, texts...)
+			case "user":
+				facts.Texts = append(facts.Texts, texts...)
+				item.Get("content").ForEach(func(_, part gjson.Result) bool {
+					if part.Get("type").String() == "input_image" {
+						facts.Images = append(facts.Images, dataURLPayload(firstOf(part, "image_url.url", "image_url").String()))
+					}
+					return true
+				})
+			}
+		case "function_call":
+			facts.ToolCalls = append(facts.ToolCalls, ToolCall{Name: item.Get("name").String(), Arguments: item.Get("arguments").String()})
+		case "function_call_output":
+			output := item.Get("output")
+			if output.Type == gjson.String {
+				facts.ToolResults = append(facts.ToolResults, output.String())
+			} else {
+				facts.ToolResults = append(facts.ToolResults, strings.Join(contentTexts(output), ""))
+			}
+		}
+		return true
+	})
+	root.Get("tools").ForEach(func(_, tool gjson.Result) bool {
+		facts.Tools = append(facts.Tools, firstOf(tool, "name", "function.name").String())
+		return true
+	})
+	if effort := root.Get("reasoning.effort").String(); effort != "" && effort != "none" {
+		facts.Thinking = true
+	}
+	return facts
+}
+
+func inspectResponsesResponse(body []byte) ResponseFacts {
+	root := gjson.ParseBytes(body)
+	if response := root.Get("response"); response.IsObject() {
+		root = response
+	}
+	facts := ResponseFacts{
+		PromptTokens:     root.Get("usage.input_tokens").Int(),
+		CompletionTokens: root.Get("usage.output_tokens").Int(),
+	}
+	var text, reasoning strings.Builder
+	root.Get("output").ForEach(func(_, item gjson.Result) bool {
+		switch item.Get("type").String() {
+		case "message":
+			text.WriteString(strings.Join(contentTexts(item.Get("content")), ""))
+		case "reasoning":
+			item.Get("summary").ForEach(func(_, summary gjson.Result) bool {
+				reasoning.WriteString(summary.Get("text").String())
+				return true
+			})
+		case "function_call":
+			facts.ToolCalls = append(facts.ToolCalls, ToolCall{Name: item.Get("name").String(), Arguments: item.Get("arguments").String()})
+		}
+		return true
+	})
+	facts.Text, facts.Reasoning = text.String(), reasoning.String()
+	return facts
+}
+
+func inspectResponsesStream(chunks []string) ResponseFacts {
+	var facts ResponseFacts
+	var text, reasoning strings.Builder
+	for _, event := range streamEvents(chunks) {
+		switch event.Get("type").String() {
+		case "response.output_text.delta":
+			text.WriteString(event.Get("delta").String())
+		case "response.reasoning_summary_text.delta", "response.reasoning_text.delta":
+			reasoning.WriteString(event.Get("delta").String())
+		case "response.output_item.done":
+			if item := event.Get("item"); item.Get("type").String() == "function_call" {
+				facts.ToolCalls = append(facts.ToolCalls, ToolCall{Name: item.Get("name").String(), Arguments: item.Get("arguments").String()})
+			}
+		case "response.completed":
+			facts.PromptTokens = event.Get("response.usage.input_tokens").Int()
+			facts.CompletionTokens = event.Get("response.usage.output_tokens").Int()
+		}
+	}
+	facts.Text, facts.Reasoning = text.String(), reasoning.String()
+	return facts
+}
+
+func inspectClaudeRequest(body []byte) RequestFacts {
+	var facts RequestFacts
+	root := gjson.ParseBytes(body)
+	facts.System = contentTexts(root.Get("system"))
+	root.Get("messages").ForEach(func(_, msg gjson.Result) bool {
+		content := msg.Get("content")
+		if msg.Get("role").String() == "user" {
+			facts.Texts = append(facts.Texts, contentTexts(content)...)
+		}
+		content.ForEach(func(_, block gjson.Result) bool {
+			switch block.Get("type").String() {
+			case "image":
+				facts.Images = append(facts.Images, block.Get("source.data").String())
+			case "tool_use":
+				facts.ToolCalls = append(facts.ToolCalls, ToolCall{Name: block.Get("name").String(), Arguments: block.Get("input").Raw})
+			case "tool_result":
+				facts.ToolResults = append(facts.ToolResults, strings.Join(contentTexts(block.Get("content")), ""))
+			}
+			return true
+		})
+		return true
+	})
+	root.Get("tools").ForEach(func(_, tool gjson.Result) bool {
+		facts.Tools = append(facts.Tools, tool.Get("name").String())
+		return true
+	})
+	switch root.Get("thinking.type").String() {
+	case "enabled", "adaptive":
+		facts.Thinking = true
+	}
+	return facts
+}
+
+func inspectClaudeResponse(body []byte) ResponseFacts {
+	root := gjson.ParseBytes(body)
+	facts := ResponseFacts{
+		PromptTokens:     root.Get("usage.input_tokens").Int(),
+		CompletionTokens: root.Get("usage.output_tokens").Int(),
+	}
+	var text, reasoning strings.Builder
+	root.Get("content").ForEach(func(_, block gjson.Result) bool {
+		switch block.Get("type").String() {
+		case "text":
+			text.WriteString(block.Get("text").String())
+		case "thinking":
+			reasoning.WriteString(block.Get("thinking").String())
+		case "tool_use":
+			facts.ToolCalls = append(facts.ToolCalls, ToolCall{Name: block.Get("name").String(), Arguments: block.Get("input").Raw})
+		}
+		return true
+	})
+	facts.Text, facts.Reasoning = text.String(), reasoning.String()
+	return facts
+}
+
+func inspectClaudeStream(chunks []string) ResponseFacts {
+	var facts ResponseFacts
+	var text, reasoning strings.Builder
+	var calls streamedToolCalls
+	for _, event := range streamEvents(chunks) {
+		key := event.Get("index").String()
+		switch event.Get("type").String() {
+		case "message_start":
+			facts.PromptTokens = maxInt64(facts.PromptTokens, event.Get("message.usage.input_tokens").Int())
+		case "content_block_start":
+			if block := event.Get("content_block"); block.Get("type").String() == "tool_use" {
+				calls.start(key, block.Get("name").String())
+			}
+		case "content_block_delta":
+			delta := event.Get("delta")
+			switch delta.Get("type").String() {
+			case "text_delta":
+				text.WriteString(delta.Get("text").String())
+			case "thinking_delta":
+				reasoning.WriteString(delta.Get("thinking").String())
+			case "input_json_delta":
+				calls.appendArgs(key, delta.Get("partial_json").String())
+			}
+		case "message_delta":
+			facts.PromptTokens = maxInt64(facts.PromptTokens, event.Get("usage.input_tokens").Int())
+			facts.CompletionTokens = maxInt64(facts.CompletionTokens, event.Get("usage.output_tokens").Int())
+		}
+	}
+	facts.Text, facts.Reasoning, facts.ToolCalls = text.String(), reasoning.String(), calls.calls()
+	return facts
+}
+
+func inspectGeminiRequest(body []byte) RequestFacts {
+	var facts RequestFacts
+	root := gjson.ParseBytes(body)
+	firstOf(root, "systemInstruction.parts", "system_instruction.parts").ForEach(func(_, part gjson.Result) bool {
+		facts.System = append(facts.System, part.Get("text").String())
+		return true
+	})
+	root.Get("contents").ForEach(func(_, content gjson.Result) bool {
+		isModel := content.Get("role").String() == "model"
+		content.Get("parts").ForEach(func(_, part gjson.Result) bool {
+			switch {
+			case part.Get("functionCall").Exists():
+				facts.ToolCalls = append(facts.ToolCalls, ToolCall{Name: part.Get("functionCall.name").String(), Arguments: part.Get("functionCall.args").Raw})
+			case part.Get("functionResponse").Exists():
+				facts.ToolResults = append(facts.ToolResults, part.Get("functionResponse.response").Raw)
+			case firstOf(part, "inlineData", "inline_data").Exists():
+				facts.Images = append(facts.Images, firstOf(part, "inlineData.data", "inline_data.data").String())
+			case part.Get("text").Exists() && !isModel && !part.Get("thought").Bool():
+				facts.Texts = append(facts.Texts, part.Get("text").String())
+			}
+			return true
+		})
+		return true
+	})
+	root.Get("tools").ForEach(func(_, tool gjson.Result) bool {
+		firstOf(tool, "functionDeclarations", "function_declarations").ForEach(func(_, decl gjson.Result) bool {
+			facts.Tools = append(facts.Tools, decl.Get("name").String())
+			return true
+		})
+		return true
+	})
+	thinking := firstOf(root, "generationConfig.thinkingConfig", "generation_config.thinking_config")
+	if budget := firstOf(thinking, "thinkingBudget", "thinking_budget"); budget.Exists() {
+		facts.Thinking = budget.Int() != 0
+	} else if level := firstOf(thinking, "thinkingLevel", "thinking_level").String(); level != "" {
+		facts.Thinking = level != "none"
+	} else {
+		facts.Thinking = firstOf(thinking, "includeThoughts", "include_thoughts").Bool()
+	}
+	return facts
+}
+
+func inspectGeminiResponse(body []byte) ResponseFacts {
+	return foldGemini([]gjson.Result{gjson.ParseBytes(body)})
+}
+
+func inspectGeminiStream(chunks []string) ResponseFacts {
+	return foldGemini(streamEvents(chunks))
+}
+
+func foldGemini(events []gjson.Result) ResponseFacts {
+	var facts ResponseFacts
+	var text, reasoning strings.Builder
+	for _, event := range events {
+		event.Get("candidates.0.content.parts").ForEach(func(_, part gjson.Result) bool {
+			switch {
+			case part.Get("functionCall").Exists():
+				facts.ToolCalls = append(facts.ToolCalls, ToolCall{Name: part.Get("functionCall.name").String(), Arguments: part.Get("functionCall.args").Raw})
+			case part.Get("thought").Bool():
+				reasoning.WriteString(part.Get("text").String())
+			default:
+				text.WriteString(part.Get("text").String())
+			}
+			return true
+		})
+		facts.PromptTokens = maxInt64(facts.PromptTokens, event.Get("usageMetadata.promptTokenCount").Int())
+		facts.CompletionTokens = maxInt64(facts.CompletionTokens, event.Get("usageMetadata.candidatesTokenCount").Int())
+	}
+	facts.Text, facts.Reasoning = text.String(), reasoning.String()
+	return facts
+}
@@ -0,0 +1,112 @@
+package translator
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Pair describes the translators registered between a client format and a provider format.
+// Request converts client requests into the provider format; Response converts provider
+// responses back into the client format. Capabilities declares the features the pair
+// carries; zero means DefaultCapabilities.
+type Pair struct {
+	From         Format
+	To           Format
+	Request      RequestTransform
+	Response     ResponseTransform
+	Capabilities Capability
+}
+
+// ErrInvalidPair is returned by RegisterPair for incomplete pair definitions.
+var ErrInvalidPair = errors.New("translator: invalid translator pair")
+
+// RegisterPair validates p and stores its transforms, replacing any existing registration
+// for the same formats. External modules should call it from an init function so that the
+// pair is in place before the service starts routing requests.
+func (r *Registry) RegisterPair(p Pair) error {
+	if strings.TrimSpace(p.From.String()) == "" || strings.TrimSpace(p.To.String()) == "" {
+		return fmt.Errorf("%w: both formats are required", ErrInvalidPair)
+	}
+	if p.Request == nil && p.Response.Stream == nil && p.Response.NonStream == nil && p.Response.TokenCount == nil {
+		return fmt.Errorf("%w: %s -> %s has no transforms", ErrInvalidPair, p.From, p.To)
+	}
+	r.Register(p.From, p.To, p.Request, p.Response)
+	if p.Capabilities != 0 {
+		r.SetCapabilities(p.From, p.To, p.Capabilities)
+	}
+	return nil
+}
+
+// Lookup returns the pair registered from one format to another.
+func (r *Registry) Lookup(from, to Format) (Pair, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	pair := Pair{From: from, To: to}
+	request, hasRequest := r.requests[from][to]
+	response, hasResponse := r.responses[from][to]
+	if !hasRequest && !hasResponse {
+		return Pair{}, false
+	}
+	pair.Request = request
+	pair.Response = response
+	pair.Capabilities = r.capabilitiesLocked(from, to)
+	return pair, true
+}
+
+// Pairs lists every registered pair ordered by source and target format.
+func (r *Registry) Pairs() []Pair {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	seen := make(map[[2]Format]struct{})
+	var pairs []Pair
+	add := func(from, to Format) {
+		key := [2]Format{from, to}
+		if _, ok := seen[key]; ok {
+			return
+		}
+		seen[key] = struct{}{}
+		pairs = append(pairs, Pair{
+			From:         from,
+			To:           to,
+			Request:      r.requests[from][to],
+			Response:     r.responses[from][to],
+			Capabilities: r.capabilitiesLocked(from, to),
+		})
+	}
+	for from, byTarget := range r.requests {
+		for to := range byTarget {
+			add(from, to)
+		}
+	}
+	for from, byTarget := range r.responses {
+		for to := range byTarget {
+			add(from, to)
+		}
+	}
+	sort.Slice(pairs, func(i, j int) bool {
+		if pairs[i].From != pairs[j].From {
+			return pairs[i].From < pairs[j].From
+		}
+		return pairs[i].To < pairs[j].To
+	})
+	return pairs
+}
+
+// RegisterPair validates and attaches a pair to the default registry.
+func RegisterPair(p Pair) error {
+	return defaultRegistry.RegisterPair(p)
+}
+
+// LookupPair returns a pair from the default registry.
+func LookupPair(from, to Format) (Pair, bool) {
+	return defaultRegistry.Lookup(from, to)
+}
+
+// Pairs lists the pairs registered in the default registry.
+func Pairs() []Pair {
+	return defaultRegistry.Pairs()
+}
@@ -5,18 +5,24 @@ import (
 	"sync"
 )
 
-// Registry manages translation functions across schemas.
+// Registry manages translation functions across schemas. When no pair is registered between
+// two formats, translations are chained through a hub format (see ResolveRoute).
 type Registry struct {
-	mu        sync.RWMutex
-	requests  map[Format]map[Format]RequestTransform
-	responses map[Format]map[Format]ResponseTransform
+	mu           sync.RWMutex
+	requests     map[Format]map[Format]RequestTransform
+	responses    map[Format]map[Format]ResponseTransform
+	capabilities map[[2]Format]Capability
+	hubs         []Hub
+	lossyLogged  sync.Map
 }
 
-// NewRegistry constructs an empty translator registry.
+// NewRegistry constructs an empty translator registry using DefaultHubs.
 func NewRegistry() *Registry {
 	return &Registry{
-		requests:  make(map[Format]map[Format]RequestTransform),
-		responses: make(map[Format]map[Format]ResponseTransform),
+		requests:     make(map[Format]map[Format]RequestTransform),
+		responses:    make(map[Format]map[Format]ResponseTransform),
+		capabilities: make(map[[2]Format]Capability),
+		hubs:         DefaultHubs(),
 	}
 }
 
@@ -38,21 +44,64 @@ func (r *Registry) Register(from, to Format, request RequestTransform, response
 	r.responses[from][to] = response
 }
 
-// TranslateRequest converts a payload between schemas, returning the original payload
-// if no translator is registered.
+// TranslateRequest converts a payload between schemas, chaining through a hub when no
+// direct translator is registered and returning the original payload if neither exists.
 func (r *Registry) TranslateRequest(from, to Format, model string, rawJSON []byte, stream bool) []byte {
+	fn, c := r.requestTransform(from, to)
+	if fn != nil {
+		return fn(model, rawJSON, stream)
+	}
+	if c != nil {
+		r.logLossy(c, rawJSON)
+		return c.translateRequest(model, rawJSON, stream)
+	}
+	return rawJSON
+}
+
+func (r *Registry) requestTransform(from, to Format) (RequestTransform, *chain) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
 	if byTarget, ok := r.requests[from]; ok {
 		if fn, isOk := byTarget[to]; isOk && fn != nil {
-			return fn(model, rawJSON, stream)
+			return fn, nil
 		}
 	}
-	return rawJSON
+	if r.hasPairLocked(from, to) {
+		return nil, nil
+	}
+	c, _ := r.chainLocked(from, to)
+	return nil, c
+}
+
+// responseTransform returns the response transform converting provider responses into client
+// responses, or the chain to use instead.
+func (r *Registry) responseTransform(provider, client Format) (ResponseTransform, bool, *chain) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if byTarget, ok := r.responses[client]; ok {
+		if fn, isOk := byTarget[provider]; isOk {
+			return fn, true, nil
+		}
+	}
+	if r.hasPairLocked(client, provider) {
+		return ResponseTransform{}, false, nil
+	}
+	c, _ := r.chainLocked(client, provider)
+	return ResponseTransform{}, false, c
 }
 
-// HasResponseTransformer indicates whether a response translator exists.
+// TranslateRequestWithContext behaves like TranslateRequest and records the translation
+// as a child span of the trace carried by ctx.
+func (r *Registry) TranslateRequestWithContext(ctx context.Context, from, to Format, model string, rawJSON []byte, stream bool) []byte {
+	span := startSpan(ctx, "translator.request", from, to, model)
+	defer endSpan(span)
+	return r.TranslateRequest(from, to, model, rawJSON, stream)
+}
+
+// HasResponseTransformer indicates whether a response translator exists, directly or
+// through a hub.
 func (r *Registry) HasResponseTransformer(from, to Format) bool {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
@@ -62,44 +111,45 @@ func (r *Registry) HasResponseTransformer(from, to Format) bool {
 			return true
 		}
 	}
-	return false
+	_, chained := r.chainLocked(from, to)
+	return chained
 }
 
 // TranslateStream applies the registered streaming response translator.
 func (r *Registry) TranslateStream(ctx context.Context, from, to Format, model string, originalRequestRawJSON, requestRawJSON, rawJSON []byte, param *any) []string {
-	r.mu.RLock()
-	defer r.mu.RUnlock()
-
-	if byTarget, ok := r.responses[to]; ok {
-		if fn, isOk := byTarget[from]; isOk && fn.Stream != nil {
-			return fn.Stream(ctx, model, originalRequestRawJSON, requestRawJSON, rawJSON, param)
-		}
+	fn, ok, c := r.responseTransform(from, to)
+	if ok && fn.Stream != nil {
+		return fn.Stream(ctx, model, originalRequestRawJSON, requestRawJSON, rawJSON, param)
+	}
+	if c != nil {
+		return c.translateStream(ctx, model, originalRequestRawJSON, requestRawJSON, rawJSON, param)
 	}
 	return []string{string(rawJSON)}
 }
 
 // TranslateNonStream applies the registered non-stream response translator.
 func (r *Registry) TranslateNonStream(ctx context.Context, from, to Format, model string, originalRequestRawJSON, requestRawJSON, rawJSON []byte, param *any) string {
-	r.mu.RLock()
-	defer r.mu.RUnlock()
+	span := startSpan(ctx, "translator.response", from, to, model)
+	defer endSpan(span)
 
-	if byTarget, ok := r.responses[to]; ok {
-		if fn, isOk := byTarget[from]; isOk && fn.NonStream != nil {
-			return fn.NonStream(ctx, model, originalRequestRawJSON, requestRawJSON, rawJSON, param)
-		}
+	fn, ok, c := r.responseTransform(from, to)
+	if ok && fn.NonStream != nil {
+		return fn.NonStream(ctx, model, originalRequestRawJSON, requestRawJSON, rawJSON, param)
+	}
+	if c != nil {
+		return c.translateNonStream(ctx, model, originalRequestRawJSON, requestRawJSON, rawJSON, param)
 	}
 	return string(rawJSON)
 }
 
-// TranslateNonStream applies the registered non-stream response translator.
+// TranslateTokenCount applies the registered token count translator.
 func (r *Registry) TranslateTokenCount(ctx context.Context, from, to Format, count int64, rawJSON []byte) string {
-	r.mu.RLock()
-	defer r.mu.RUnlock()
-
-	if byTarget, ok := r.responses[to]; ok {
-		if fn, isOk := byTarget[from]; isOk && fn.TokenCount != nil {
-			return fn.TokenCount(ctx, count)
-		}
+	fn, ok, c := r.responseTransform(from, to)
+	if ok && fn.TokenCount != nil {
+		return fn.TokenCount(ctx, count)
+	}
+	if c != nil && c.hubToClient.TokenCount != nil {
+		return c.hubToClient.TokenCount(ctx, count)
 	}
 	return string(rawJSON)
 }
@@ -121,6 +171,11 @@ func TranslateRequest(from, to Format, model string, rawJSON []byte, stream bool
 	return defaultRegistry.TranslateRequest(from, to, model, rawJSON, stream)
 }
 
+// TranslateRequestWithContext is a helper on the default registry.
+func TranslateRequestWithContext(ctx context.Context, from, to Format, model string, rawJSON []byte, stream bool) []byte {
+	return defaultRegistry.TranslateRequestWithContext(ctx, from, to, model, rawJSON, stream)
+}
+
 // HasResponseTransformer inspects the default registry.
 func HasResponseTransformer(from, to Format) bool {
 	return defaultRegistry.HasResponseTransformer(from, to)
@@ -136,6 +191,21 @@ func TranslateNonStream(ctx context.Context, from, to Format, model string, orig
 	return defaultRegistry.TranslateNonStream(ctx, from, to, model, originalRequestRawJSON, requestRawJSON, rawJSON, param)
 }
 
+// ResolveRoute is a helper on the default registry.
+func ResolveRoute(from, to Format) (Route, bool) {
+	return defaultRegistry.ResolveRoute(from, to)
+}
+
+// ValidateRequest is a helper on the default registry.
+func ValidateRequest(from, to Format, rawJSON []byte) error {
+	return defaultRegistry.ValidateRequest(from, to, rawJSON)
+}
+
+// SetCapabilities is a helper on the default registry.
+func SetCapabilities(from, to Format, capabilities Capability) {
+	defaultRegistry.SetCapabilities(from, to, capabilities)
+}
+
 // TranslateTokenCount is a helper on the default registry.
 func TranslateTokenCount(ctx context.Context, from, to Format, count int64, rawJSON []byte) string {
 	return defaultRegistry.TranslateTokenCount(ctx, from, to, count, rawJSON)
@@ -0,0 +1,31 @@
+package translator
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+
+// startSpan opens a translation span when ctx already carries a sampled span.
+// Translations outside a traced request do not start new traces.
+func startSpan(ctx context.Context, name string, from, to Format, model string) trace.Span {
+	if ctx == nil || !trace.SpanContextFromContext(ctx).IsValid() {
+		return nil
+	}
+	_, span := otel.Tracer(tracerName).Start(ctx, name, trace.WithAttributes(
+		attribute.String("cliproxy.translator.from", from.String()),
+		attribute.String("cliproxy.translator.to", to.String()),
+		attribute.String("cliproxy.model", model),
+	))
+	return span
+}
+
+func endSpan(span trace.Span) {
+	if span != nil {
+		span.End()
+	}
+}